@@ -28,6 +28,8 @@ import (
 var (
 	// ErrUnsubscribed is an error indicating that the subscriber has been unsubscribed. It is returned by the ISyncSubscriber.Next method when the subscriber has been unsubscribed.
 	ErrUnsubscribed = errors.New("broker: unsubscribed")
+	// ErrUnrecoverable is an error indicating that the event can never be handled successfully, such as a malformed message. Event handlers wrap it to stop the broker from redelivering the event.
+	ErrUnrecoverable = errors.New("broker: unrecoverable event")
 )
 
 // DeliveryReliability Message delivery reliability.
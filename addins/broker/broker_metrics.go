@@ -0,0 +1,43 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package broker
+
+import (
+	"git.golaxy.org/core/service"
+	"git.golaxy.org/framework/addins/metrics"
+)
+
+// ObservePublish records a published message, does nothing if the metrics add-in is not installed.
+func ObservePublish(svcCtx service.Context, err error) {
+	m, ok := metrics.Lookup(svcCtx)
+	if !ok {
+		return
+	}
+	m.ObserveBrokerPublish(err)
+}
+
+// ObserveReceive records a message received by a subscription, does nothing if the metrics add-in is not installed.
+func ObserveReceive(svcCtx service.Context) {
+	m, ok := metrics.Lookup(svcCtx)
+	if !ok {
+		return
+	}
+	m.ObserveBrokerReceive()
+}
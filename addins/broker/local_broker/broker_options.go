@@ -0,0 +1,86 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package local_broker
+
+import (
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/option"
+	"strings"
+)
+
+// BrokerOptions is a struct that holds various configuration options for the local broker.
+type BrokerOptions struct {
+	Hub         *Hub
+	TopicPrefix string
+	QueuePrefix string
+	MaxPayload  int64
+}
+
+var With _Option
+
+type _Option struct{}
+
+// Default sets default values for BrokerOptions.
+func (_Option) Default() option.Setting[BrokerOptions] {
+	return func(options *BrokerOptions) {
+		With.Hub(nil)(options)
+		With.TopicPrefix("")(options)
+		With.QueuePrefix("")(options)
+		With.MaxPayload(1024 * 1024)(options)
+	}
+}
+
+// Hub sets the in-process hub in BrokerOptions. Brokers attached to the same hub can communicate with each other. If nil, the default hub is used.
+func (_Option) Hub(hub *Hub) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		o.Hub = hub
+	}
+}
+
+// TopicPrefix sets the topic prefix in BrokerOptions.
+func (_Option) TopicPrefix(prefix string) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		if prefix != "" && !strings.HasSuffix(prefix, ".") {
+			prefix += "."
+		}
+		o.TopicPrefix = prefix
+	}
+}
+
+// QueuePrefix sets the queue prefix in BrokerOptions.
+func (_Option) QueuePrefix(prefix string) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		if prefix != "" && !strings.HasSuffix(prefix, ".") {
+			prefix += "."
+		}
+		o.QueuePrefix = prefix
+	}
+}
+
+// MaxPayload sets the max payload bytes in BrokerOptions.
+func (_Option) MaxPayload(size int64) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		if size <= 0 {
+			exception.Panicf("%w: option MaxPayload can't be set to a value less equal 0", core.ErrArgs)
+		}
+		o.MaxPayload = size
+	}
+}
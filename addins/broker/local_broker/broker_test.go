@@ -0,0 +1,138 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package local_broker_test
+
+import (
+	"context"
+	"errors"
+	"git.golaxy.org/framework"
+	"git.golaxy.org/framework/addins/broker"
+	"git.golaxy.org/framework/addins/broker/local_broker"
+	"git.golaxy.org/framework/frameworktest"
+	"testing"
+	"time"
+)
+
+type _TestService struct {
+	framework.ServiceInstance
+}
+
+func startBroker(t *testing.T) broker.IBroker {
+	c := frameworktest.NewCluster(t).
+		Setup("test", &_TestService{}, 1).
+		Start()
+	return c.GetService("test", 0).GetBroker()
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	b := startBroker(t)
+	ctx := context.Background()
+
+	sub, err := b.SubscribeSync(ctx, "test.*.msg")
+	if err != nil {
+		t.Fatalf("subscribe failed, %s", err)
+	}
+
+	data := []byte("hello")
+	if err := b.Publish(ctx, "test.a.msg", data); err != nil {
+		t.Fatalf("publish failed, %s", err)
+	}
+
+	// 修改发布的数据不影响已投递的消息
+	data[0] = 'j'
+
+	e, err := sub.Next()
+	if err != nil {
+		t.Fatalf("next failed, %s", err)
+	}
+	if e.Topic() != "test.a.msg" || string(e.Message()) != "hello" {
+		t.Fatalf("received topic %q message %q", e.Topic(), e.Message())
+	}
+
+	<-sub.Unsubscribe()
+
+	if _, err := sub.Next(); !errors.Is(err, broker.ErrUnsubscribed) {
+		t.Fatalf("next after unsubscribe returned %v, want %v", err, broker.ErrUnsubscribed)
+	}
+}
+
+func TestQueueGroup(t *testing.T) {
+	b := startBroker(t)
+	ctx := context.Background()
+
+	const total = 100
+
+	var chans []<-chan broker.IEvent
+
+	for range 2 {
+		sub, err := b.SubscribeChan(ctx, "test.queue", broker.With.Queue("q"), broker.With.EventChanSize(total))
+		if err != nil {
+			t.Fatalf("subscribe failed, %s", err)
+		}
+		ch, _ := sub.EventChan()
+		chans = append(chans, ch)
+	}
+
+	for range total {
+		if err := b.Publish(ctx, "test.queue", []byte("x")); err != nil {
+			t.Fatalf("publish failed, %s", err)
+		}
+	}
+
+	// 队列组中每条消息只投递给一个成员
+	received := 0
+	timeout := time.After(5 * time.Second)
+
+	for received < total {
+		select {
+		case <-chans[0]:
+			received++
+		case <-chans[1]:
+			received++
+		case <-timeout:
+			t.Fatalf("received %d messages, want %d", received, total)
+		}
+	}
+
+	select {
+	case <-chans[0]:
+		t.Fatal("received duplicate message")
+	case <-chans[1]:
+		t.Fatal("received duplicate message")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestPublishInvalid(t *testing.T) {
+	b := startBroker(t)
+	ctx := context.Background()
+
+	if err := b.Publish(ctx, "test.*", nil); !errors.Is(err, local_broker.ErrBadTopic) {
+		t.Fatalf("publish wildcard topic returned %v, want %v", err, local_broker.ErrBadTopic)
+	}
+
+	if err := b.Publish(ctx, "test.big", make([]byte, b.GetMaxPayload()+1)); !errors.Is(err, local_broker.ErrMaxPayload) {
+		t.Fatalf("publish oversize payload returned %v, want %v", err, local_broker.ErrMaxPayload)
+	}
+
+	if _, err := b.SubscribeSync(ctx, "test.>.x"); !errors.Is(err, local_broker.ErrBadTopic) {
+		t.Fatalf("subscribe invalid pattern returned %v, want %v", err, local_broker.ErrBadTopic)
+	}
+}
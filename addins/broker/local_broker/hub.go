@@ -0,0 +1,169 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package local_broker
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	separator      = "."
+	wildcardSingle = "*"
+	wildcardMulti  = ">"
+)
+
+var defaultHub = NewHub()
+
+// DefaultHub returns the process-wide default hub, it is used by brokers that are not given a hub explicitly.
+func DefaultHub() *Hub {
+	return defaultHub
+}
+
+// NewHub creates an in-process message hub.
+func NewHub() *Hub {
+	return &Hub{}
+}
+
+// Hub is an in-process message hub. Brokers attached to the same hub can exchange messages without any external server.
+type Hub struct {
+	mutex sync.RWMutex
+	subs  []*_Subscriber
+}
+
+func (h *Hub) attach(sub *_Subscriber) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.subs = append(h.subs, sub)
+}
+
+func (h *Hub) detach(sub *_Subscriber) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.subs = slices.DeleteFunc(h.subs, func(other *_Subscriber) bool {
+		return other == sub
+	})
+}
+
+func (h *Hub) publish(topic string, data []byte) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	var groups map[string][]*_Subscriber
+
+	for _, sub := range h.subs {
+		if !matchTopic(sub.pattern, topic) {
+			continue
+		}
+
+		if sub.queue == "" {
+			if !sub.tryDeliver(topic, data) {
+				sub.discard(topic)
+			}
+			continue
+		}
+
+		if groups == nil {
+			groups = map[string][]*_Subscriber{}
+		}
+		groups[sub.queue] = append(groups[sub.queue], sub)
+	}
+
+	for _, members := range groups {
+		// 队列组中随机选择一个成员投递，成员接收队列已满时，尝试下一个成员
+		offset := rand.IntN(len(members))
+		delivered := false
+
+		for i := range members {
+			if members[(offset+i)%len(members)].tryDeliver(topic, data) {
+				delivered = true
+				break
+			}
+		}
+
+		if !delivered {
+			members[offset].discard(topic)
+		}
+	}
+}
+
+func validatePattern(pattern string) bool {
+	if pattern == "" {
+		return false
+	}
+
+	tokens := strings.Split(pattern, separator)
+
+	for i, token := range tokens {
+		switch token {
+		case "":
+			return false
+		case wildcardMulti:
+			if i != len(tokens)-1 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func validateTopic(topic string) bool {
+	if topic == "" {
+		return false
+	}
+
+	for _, token := range strings.Split(topic, separator) {
+		switch token {
+		case "", wildcardSingle, wildcardMulti:
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchTopic(pattern, topic string) bool {
+	for {
+		var pToken, tToken string
+		var pMore, tMore bool
+
+		pToken, pattern, pMore = strings.Cut(pattern, separator)
+		tToken, topic, tMore = strings.Cut(topic, separator)
+
+		switch pToken {
+		case wildcardMulti:
+			return true
+		case wildcardSingle:
+		default:
+			if pToken != tToken {
+				return false
+			}
+		}
+
+		if !pMore || !tMore {
+			return pMore == tMore
+		}
+	}
+}
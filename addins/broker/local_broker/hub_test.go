@@ -0,0 +1,74 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package local_broker
+
+import "testing"
+
+func TestMatchTopic(t *testing.T) {
+	cases := []struct {
+		pattern, topic string
+		match          bool
+	}{
+		{"a.b.c", "a.b.c", true},
+		{"a.b.c", "a.b", false},
+		{"a.b", "a.b.c", false},
+		{"a.*.c", "a.b.c", true},
+		{"a.*", "a.b.c", false},
+		{"a.>", "a.b.c", true},
+		{"a.>", "a", false},
+		{">", "a.b", true},
+		{"*.*", "a.b", true},
+		{"a.b.d", "a.b.c", false},
+	}
+
+	for _, c := range cases {
+		if got := matchTopic(c.pattern, c.topic); got != c.match {
+			t.Errorf("matchTopic(%q, %q) = %v, want %v", c.pattern, c.topic, got, c.match)
+		}
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	for _, pattern := range []string{"a", "a.b", "a.*", "a.>", "*.b", ">"} {
+		if !validatePattern(pattern) {
+			t.Errorf("validatePattern(%q) = false, want true", pattern)
+		}
+	}
+
+	for _, pattern := range []string{"", "a..b", ".a", "a.", "a.>.b"} {
+		if validatePattern(pattern) {
+			t.Errorf("validatePattern(%q) = true, want false", pattern)
+		}
+	}
+}
+
+func TestValidateTopic(t *testing.T) {
+	for _, topic := range []string{"a", "a.b.c"} {
+		if !validateTopic(topic) {
+			t.Errorf("validateTopic(%q) = false, want true", topic)
+		}
+	}
+
+	for _, topic := range []string{"", "a..b", "a.*", "a.>", "a."} {
+		if validateTopic(topic) {
+			t.Errorf("validateTopic(%q) = true, want false", topic)
+		}
+	}
+}
@@ -21,6 +21,7 @@ package nats_broker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"git.golaxy.org/core/runtime"
 	"git.golaxy.org/core/service"
@@ -46,6 +47,7 @@ type _Broker struct {
 	wg        sync.WaitGroup
 	options   BrokerOptions
 	client    *nats.Conn
+	js        nats.JetStreamContext
 }
 
 // Init 初始化插件
@@ -68,6 +70,18 @@ func (b *_Broker) Init(svcCtx service.Context, _ runtime.Context) {
 	if _, err := b.client.RTT(); err != nil {
 		log.Panicf(svcCtx, "rtt nats %q failed, %s", b.client.Servers(), err)
 	}
+
+	if b.options.JetStream.Enabled {
+		js, err := b.client.JetStream()
+		if err != nil {
+			log.Panicf(svcCtx, "nats %q jetstream failed, %s", b.client.Servers(), err)
+		}
+		b.js = js
+
+		if err := b.ensureStream(); err != nil {
+			log.Panicf(svcCtx, "nats %q ensure jetstream stream %q failed, %s", b.client.Servers(), b.options.JetStream.Stream, err)
+		}
+	}
 }
 
 // Shut 关闭插件
@@ -87,11 +101,23 @@ func (b *_Broker) Shut(svcCtx service.Context, _ runtime.Context) {
 }
 
 // Publish the data argument to the given topic. The data argument is left untouched and needs to be correctly interpreted on the receiver.
-func (b *_Broker) Publish(ctx context.Context, topic string, data []byte) error {
+func (b *_Broker) Publish(ctx context.Context, topic string, data []byte) (err error) {
+	defer func() { broker.ObservePublish(b.svcCtx, err) }()
+
 	if b.options.TopicPrefix != "" {
 		topic = b.options.TopicPrefix + topic
 	}
 
+	if b.isJetStreamSubject(topic) {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if _, err := b.js.Publish(topic, data, nats.Context(ctx)); err != nil {
+			return fmt.Errorf("broker: %w", err)
+		}
+		return nil
+	}
+
 	if err := b.client.Publish(topic, data); err != nil {
 		return fmt.Errorf("broker: %w", err)
 	}
@@ -122,7 +148,8 @@ func (b *_Broker) Subscribep(ctx context.Context, elems ...string) broker.ISubsc
 	}
 }
 
-// SubscribeSync will express interest in the given topic pattern.
+// SubscribeSync will express interest in the given topic pattern. For JetStream subjects with AutoAck, events are acked as soon as they are queued for the caller,
+// so delivery is at-most-once. Disable AutoAck and call IEvent.Ack after processing for at-least-once delivery.
 func (b *_Broker) SubscribeSync(ctx context.Context, pattern string, settings ...option.Setting[broker.SubscriberOptions]) (broker.ISyncSubscriber, error) {
 	return b.newSubscriber(ctx, _SubscribeMode_Sync, pattern, option.Make(broker.With.Default(), settings...))
 }
@@ -145,7 +172,8 @@ func (b *_Broker) SubscribeSyncp(ctx context.Context, elems ...string) broker.IS
 	}
 }
 
-// SubscribeChan will express interest in the given topic pattern.
+// SubscribeChan will express interest in the given topic pattern. For JetStream subjects with AutoAck, events are acked as soon as they are queued on the channel,
+// so delivery is at-most-once. Disable AutoAck and call IEvent.Ack after processing for at-least-once delivery.
 func (b *_Broker) SubscribeChan(ctx context.Context, pattern string, settings ...option.Setting[broker.SubscriberOptions]) (broker.IChanSubscriber, error) {
 	return b.newSubscriber(ctx, _SubscribeMode_Chan, pattern, option.Make(broker.With.Default(), settings...))
 }
@@ -183,6 +211,9 @@ func (b *_Broker) Flush(ctx context.Context) error {
 
 // GetDeliveryReliability return message delivery reliability.
 func (b *_Broker) GetDeliveryReliability() broker.DeliveryReliability {
+	if b.options.JetStream.Enabled {
+		return broker.AtLeastOnce
+	}
 	return broker.AtMostOnce
 }
 
@@ -195,3 +226,68 @@ func (b *_Broker) GetMaxPayload() int64 {
 func (b *_Broker) GetSeparator() string {
 	return "."
 }
+
+func (b *_Broker) ensureStream() error {
+	subjects := make([]string, 0, len(b.options.JetStream.Subjects))
+	for _, subject := range b.options.JetStream.Subjects {
+		subjects = append(subjects, b.options.TopicPrefix+subject)
+	}
+
+	config := &nats.StreamConfig{
+		Name:      b.options.JetStream.Stream,
+		Subjects:  subjects,
+		Retention: nats.InterestPolicy,
+		Storage:   nats.FileStorage,
+		MaxAge:    b.options.JetStream.MaxAge,
+	}
+
+	_, err := b.js.AddStream(config)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, nats.ErrStreamNameAlreadyInUse) {
+		return err
+	}
+
+	_, err = b.js.UpdateStream(config)
+	return err
+}
+
+// isJetStreamSubject 判断主题是否由JetStream存储，subject需要包含前缀
+func (b *_Broker) isJetStreamSubject(subject string) bool {
+	if b.js == nil {
+		return false
+	}
+
+	for _, pattern := range b.options.JetStream.Subjects {
+		if matchSubject(b.options.TopicPrefix+pattern, subject) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchSubject 判断主题是否被模式覆盖，主题中的通配符只能与模式中相同位置的通配符匹配
+func matchSubject(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+
+	for i, token := range patternTokens {
+		switch token {
+		case ">":
+			return i < len(subjectTokens)
+		case "*":
+			if i >= len(subjectTokens) || subjectTokens[i] == ">" {
+				return false
+			}
+		default:
+			if i >= len(subjectTokens) || subjectTokens[i] != token {
+				return false
+			}
+		}
+	}
+
+	return len(patternTokens) == len(subjectTokens)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/nats-io/nats.go"
 	"net"
 	"strings"
+	"time"
 )
 
 // BrokerOptions is a struct that holds various configuration options for the NATS broker.
@@ -36,6 +37,17 @@ type BrokerOptions struct {
 	CustomAddresses []string
 	CustomUsername  string
 	CustomPassword  string
+	JetStream       JetStreamOptions
+}
+
+// JetStreamOptions is a struct that holds the JetStream configuration options for the NATS broker.
+type JetStreamOptions struct {
+	Enabled    bool          // Enabled indicates whether topics matching the subjects are backed by a JetStream stream.
+	Stream     string        // Stream is the name of the JetStream stream.
+	Subjects   []string      // Subjects are the topic patterns stored in the stream, without the topic prefix.
+	MaxAge     time.Duration // MaxAge is the max age of messages in the stream.
+	AckWait    time.Duration // AckWait is how long the server waits for an acknowledgement before redelivering a message.
+	MaxDeliver int           // MaxDeliver is the max number of deliveries of a message, after which the server stops redelivering it.
 }
 
 var With _Option
@@ -50,6 +62,7 @@ func (_Option) Default() option.Setting[BrokerOptions] {
 		With.QueuePrefix("")(options)
 		With.CustomAuth("", "")(options)
 		With.CustomAddresses("127.0.0.1:4222")(options)
+		With.JetStream(JetStreamOptions{})(options)
 	}
 }
 
@@ -99,3 +112,39 @@ func (_Option) CustomAddresses(addrs ...string) option.Setting[BrokerOptions] {
 		options.CustomAddresses = addrs
 	}
 }
+
+// JetStream sets the JetStream options in BrokerOptions. When enabled, topics matching the subjects are backed by a stream, queue subscriptions use durable consumers,
+// messages must be acknowledged and GetDeliveryReliability reports AtLeastOnce. Subjects default to "svc.lb.>", the default balance domain of dsvc, since only balanced
+// messages need to survive a node restart, storing the other domains would write every RPC, reply and broadcast to disk. Messages whose handler returns
+// broker.ErrUnrecoverable are terminated and never redelivered.
+func (_Option) JetStream(js JetStreamOptions) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		if js.Enabled {
+			if js.Stream == "" {
+				js.Stream = "GOLAXY"
+			}
+			if len(js.Subjects) <= 0 {
+				js.Subjects = []string{"svc.lb.>"}
+			}
+			if js.MaxAge < 0 {
+				exception.Panicf("%w: option JetStream.MaxAge can't be set to a value less than 0", core.ErrArgs)
+			}
+			if js.MaxAge == 0 {
+				js.MaxAge = time.Hour
+			}
+			if js.AckWait < 0 {
+				exception.Panicf("%w: option JetStream.AckWait can't be set to a value less than 0", core.ErrArgs)
+			}
+			if js.AckWait == 0 {
+				js.AckWait = 30 * time.Second
+			}
+			if js.MaxDeliver < 0 {
+				exception.Panicf("%w: option JetStream.MaxDeliver can't be set to a value less than 0", core.ErrArgs)
+			}
+			if js.MaxDeliver == 0 {
+				js.MaxDeliver = 16
+			}
+		}
+		o.JetStream = js
+	}
+}
@@ -0,0 +1,106 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package nats_broker
+
+import (
+	"git.golaxy.org/core/utils/option"
+	"git.golaxy.org/framework/addins/broker"
+	"github.com/nats-io/nats.go"
+	"testing"
+	"time"
+)
+
+type _FakeJetStream struct {
+	nats.JetStreamContext
+}
+
+func TestMatchSubject(t *testing.T) {
+	cases := []struct {
+		pattern, subject string
+		match            bool
+	}{
+		{"svc.>", "svc.ep.node1", true},
+		{"svc.>", "svc", false},
+		{"svc.>", "svc.>", true},
+		{"svc.*", "svc.ep", true},
+		{"svc.*", "svc.ep.node1", false},
+		{"svc.*", "svc.>", false},
+		{"svc.*.node1", "svc.*.node1", true},
+		{"svc.ep", "svc.ep", true},
+		{"svc.ep", "svc.lb", false},
+		{"svc.ep", "svc.*", false},
+	}
+
+	for _, c := range cases {
+		if got := matchSubject(c.pattern, c.subject); got != c.match {
+			t.Errorf("matchSubject(%q, %q) = %v, want %v", c.pattern, c.subject, got, c.match)
+		}
+	}
+}
+
+func TestJetStreamOptions(t *testing.T) {
+	options := option.Make(With.Default())
+	if options.JetStream.Enabled {
+		t.Fatal("jetstream enabled by default")
+	}
+
+	options = option.Make(With.Default(), With.JetStream(JetStreamOptions{Enabled: true}))
+
+	js := options.JetStream
+	if js.Stream != "GOLAXY" || len(js.Subjects) != 1 || js.Subjects[0] != "svc.lb.>" || js.MaxAge != time.Hour || js.AckWait != 30*time.Second || js.MaxDeliver != 16 {
+		t.Fatalf("got jetstream options %+v", js)
+	}
+}
+
+func TestIsJetStreamSubject(t *testing.T) {
+	b := &_Broker{
+		options: option.Make(With.Default(), With.TopicPrefix("app"), With.JetStream(JetStreamOptions{Enabled: true})),
+	}
+
+	// 未连接JetStream时，所有主题使用Core NATS
+	if b.isJetStreamSubject("app.svc.lb.node") {
+		t.Fatal("subject backed by jetstream without jetstream context")
+	}
+	if b.GetDeliveryReliability() != broker.AtLeastOnce {
+		t.Fatal("delivery reliability is not at least once with jetstream enabled")
+	}
+
+	b.js = _FakeJetStream{}
+
+	if !b.isJetStreamSubject("app.svc.lb.node") {
+		t.Fatal("subject with prefix not backed by jetstream")
+	}
+	if b.isJetStreamSubject("svc.lb.node") {
+		t.Fatal("subject without prefix backed by jetstream")
+	}
+	// 默认只存储负载均衡域的消息，单播与广播不写入JetStream
+	if b.isJetStreamSubject("app.svc.ep.node1") || b.isJetStreamSubject("app.svc.bc.node") {
+		t.Fatal("subject outside balance domain backed by jetstream")
+	}
+	if b.isJetStreamSubject("app.other.node1") {
+		t.Fatal("subject not in stream backed by jetstream")
+	}
+}
+
+func TestDurableName(t *testing.T) {
+	if name := durableReplacer.Replace("balance_svc.lb.*"); name != "balance_svc_lb__" {
+		t.Fatalf("durable name %q contains invalid characters", name)
+	}
+}
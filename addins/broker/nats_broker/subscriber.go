@@ -21,6 +21,7 @@ package nats_broker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"git.golaxy.org/core/utils/async"
 	"git.golaxy.org/core/utils/generic"
@@ -104,7 +105,26 @@ func (b *_Broker) newSubscriber(ctx context.Context, mode _SubscribeMode, patter
 
 	var err error
 
-	if opts.Queue != "" {
+	if b.isJetStreamSubject(pattern) {
+		sub.jetStream = true
+		sub.autoAck = opts.AutoAck
+
+		if opts.Queue != "" {
+			queue := opts.Queue
+			if b.options.QueuePrefix != "" {
+				queue = b.options.QueuePrefix + queue
+			}
+			sub.natsSub, err = b.jsQueueSubscribe(pattern, queue, handleMsg)
+		} else {
+			sub.natsSub, err = b.js.Subscribe(pattern, handleMsg,
+				nats.DeliverNew(),
+				nats.AckExplicit(),
+				nats.AckWait(b.options.JetStream.AckWait),
+				nats.MaxDeliver(b.options.JetStream.MaxDeliver),
+				nats.ManualAck(),
+			)
+		}
+	} else if opts.Queue != "" {
 		queue := opts.Queue
 		if b.options.QueuePrefix != "" {
 			queue = b.options.QueuePrefix + queue
@@ -132,6 +152,8 @@ type _Subscriber struct {
 	terminated     chan async.Ret
 	broker         *_Broker
 	natsSub        *nats.Subscription
+	jetStream      bool
+	autoAck        bool
 	eventChan      chan broker.IEvent
 	eventHandler   broker.EventHandler
 	unsubscribedCB broker.UnsubscribedCB
@@ -200,6 +222,8 @@ func (s *_Subscriber) mainLoop() {
 }
 
 func (s *_Subscriber) handleEventChan(msg *nats.Msg) {
+	broker.ObserveReceive(s.broker.svcCtx)
+
 	e := &_Event{
 		msg: msg,
 		ns:  s,
@@ -207,9 +231,15 @@ func (s *_Subscriber) handleEventChan(msg *nats.Msg) {
 
 	select {
 	case s.eventChan <- e:
+		// 无法得知调用方何时处理完毕，自动确认在投递至接收队列后立即确认，只能保证最多一次交付，需要最少一次交付时，应关闭自动确认，由调用方处理完毕后确认
+		if s.jetStream && s.autoAck {
+			if err := e.Ack(context.Background()); err != nil {
+				log.Errorf(s.broker.svcCtx, "ack msg from topic %q queue %q failed, %s", e.Topic(), e.Queue(), err)
+			}
+		}
 	default:
 		var nakErr error
-		if e.Queue() != "" {
+		if e.Queue() != "" || s.jetStream {
 			nakErr = e.Nak(context.Background())
 		}
 		log.Errorf(s.broker.svcCtx, "handle msg from topic %q queue %q failed, receive event chan is full, nak: %v", e.Topic(), e.Queue(), nakErr)
@@ -217,15 +247,65 @@ func (s *_Subscriber) handleEventChan(msg *nats.Msg) {
 }
 
 func (s *_Subscriber) handleEventProcess(msg *nats.Msg) {
+	broker.ObserveReceive(s.broker.svcCtx)
+
 	e := &_Event{
 		msg: msg,
 		ns:  s,
 	}
 
+	var failed, unrecoverable bool
+
 	s.eventHandler.SafeCall(func(err error, panicErr error) bool {
 		if err := generic.FuncError(err, panicErr); err != nil {
 			log.Errorf(s.broker.svcCtx, "handle msg from topic %q queue %q failed, %s", e.Topic(), e.Queue(), err)
+			failed = true
+			unrecoverable = errors.Is(err, broker.ErrUnrecoverable)
 		}
 		return panicErr != nil
 	}, e)
+
+	// 无法处理的JetStream消息，重新投递也不会成功，通知服务器不再重新投递
+	if s.jetStream && unrecoverable {
+		if err := e.term(context.Background()); err != nil {
+			log.Errorf(s.broker.svcCtx, "term msg from topic %q queue %q failed, %s", e.Topic(), e.Queue(), err)
+		}
+		return
+	}
+
+	// JetStream消息处理失败时不确认，等待超时后重新投递，投递次数超过上限后不再重新投递
+	if !s.jetStream || !s.autoAck || failed {
+		return
+	}
+
+	if err := e.Ack(context.Background()); err != nil {
+		log.Errorf(s.broker.svcCtx, "ack msg from topic %q queue %q failed, %s", e.Topic(), e.Queue(), err)
+	}
+}
+
+// jsQueueSubscribe 队列订阅使用持久化消费者，服务节点重启后未确认的消息会重新投递给队列中的其他成员
+func (b *_Broker) jsQueueSubscribe(subject, queue string, handleMsg nats.MsgHandler) (*nats.Subscription, error) {
+	durable := durableReplacer.Replace(queue + "_" + subject)
+
+	_, err := b.js.AddConsumer(b.options.JetStream.Stream, &nats.ConsumerConfig{
+		Durable:        durable,
+		DeliverSubject: "_GOLAXY_DELIVER." + durable,
+		DeliverGroup:   queue,
+		FilterSubject:  subject,
+		DeliverPolicy:  nats.DeliverNewPolicy,
+		AckPolicy:      nats.AckExplicitPolicy,
+		AckWait:        b.options.JetStream.AckWait,
+		MaxDeliver:     b.options.JetStream.MaxDeliver,
+	})
+	if err != nil && !errors.Is(err, nats.ErrConsumerNameAlreadyInUse) {
+		return nil, err
+	}
+
+	// 绑定已创建的消费者，取消订阅时不会删除持久化消费者
+	return b.js.QueueSubscribe(subject, queue, handleMsg,
+		nats.Bind(b.options.JetStream.Stream, durable),
+		nats.ManualAck(),
+	)
 }
+
+var durableReplacer = strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_")
@@ -22,6 +22,7 @@ package nats_broker
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/nats-io/nats.go"
 	"strings"
 )
@@ -53,10 +54,47 @@ func (e *_Event) Message() []byte {
 
 // Ack acknowledges the successful processing of the event. It indicates that the event can be removed from the subscription queue.
 func (e *_Event) Ack(ctx context.Context) error {
-	return errors.New("used not JetStream, unable to acknowledge(ack)")
+	if !e.ns.jetStream {
+		return errors.New("used not JetStream, unable to acknowledge(ack)")
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := e.msg.Ack(nats.Context(ctx)); err != nil {
+		return fmt.Errorf("broker: %w", err)
+	}
+
+	return nil
 }
 
 // Nak negatively acknowledges a message. This tells the server to redeliver the message.
 func (e *_Event) Nak(ctx context.Context) error {
-	return errors.New("used not JetStream, unable to negatively acknowledge(nak)")
+	if !e.ns.jetStream {
+		return errors.New("used not JetStream, unable to negatively acknowledge(nak)")
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := e.msg.Nak(nats.Context(ctx)); err != nil {
+		return fmt.Errorf("broker: %w", err)
+	}
+
+	return nil
+}
+
+// term tells the server to never redeliver the message.
+func (e *_Event) term(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := e.msg.Term(nats.Context(ctx)); err != nil {
+		return fmt.Errorf("broker: %w", err)
+	}
+
+	return nil
 }
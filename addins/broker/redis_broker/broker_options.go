@@ -0,0 +1,192 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package redis_broker
+
+import (
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/option"
+	"github.com/redis/go-redis/v9"
+	"net"
+	"strings"
+	"time"
+)
+
+// BrokerOptions is a struct that holds various configuration options for the Redis Streams broker.
+type BrokerOptions struct {
+	RedisClient    *redis.Client
+	RedisConfig    *redis.Options
+	RedisURL       string
+	TopicPrefix    string
+	QueuePrefix    string
+	MaxPayload     int64
+	StreamMaxLen   int64
+	ReadCount      int64
+	ClaimMinIdle   time.Duration
+	ClaimInterval  time.Duration
+	MaxDeliver     int64
+	CustomUsername string
+	CustomPassword string
+	CustomAddress  string
+	CustomDB       int
+}
+
+var With _Option
+
+type _Option struct{}
+
+// Default sets default values for BrokerOptions.
+func (_Option) Default() option.Setting[BrokerOptions] {
+	return func(options *BrokerOptions) {
+		With.RedisClient(nil)(options)
+		With.RedisConfig(nil)(options)
+		With.RedisURL("")(options)
+		With.TopicPrefix("golaxy:broker:")(options)
+		With.QueuePrefix("")(options)
+		With.MaxPayload(1024 * 1024)(options)
+		With.StreamMaxLen(10000)(options)
+		With.ReadCount(64)(options)
+		With.Claim(30*time.Second, 5*time.Second)(options)
+		With.MaxDeliver(16)(options)
+		With.CustomAuth("", "")(options)
+		With.CustomAddress("127.0.0.1:6379")(options)
+		With.CustomDB(0)(options)
+	}
+}
+
+// RedisClient sets the Redis client in BrokerOptions, it has the highest priority.
+func (_Option) RedisClient(cli *redis.Client) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		o.RedisClient = cli
+	}
+}
+
+// RedisConfig sets the Redis config in BrokerOptions, it is used when RedisClient is nil.
+func (_Option) RedisConfig(conf *redis.Options) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		o.RedisConfig = conf
+	}
+}
+
+// RedisURL sets the Redis connection url in BrokerOptions, it is used when RedisClient and RedisConfig are nil.
+func (_Option) RedisURL(url string) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		o.RedisURL = url
+	}
+}
+
+// TopicPrefix sets the topic prefix in BrokerOptions. Each topic is stored in a stream whose key is the prefix followed by the topic.
+func (_Option) TopicPrefix(prefix string) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		if prefix != "" && !strings.HasSuffix(prefix, ":") {
+			prefix += ":"
+		}
+		o.TopicPrefix = prefix
+	}
+}
+
+// QueuePrefix sets the queue prefix in BrokerOptions. Each queue is mapped to a consumer group whose name is the prefix followed by the queue.
+func (_Option) QueuePrefix(prefix string) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		if prefix != "" && !strings.HasSuffix(prefix, ":") {
+			prefix += ":"
+		}
+		o.QueuePrefix = prefix
+	}
+}
+
+// MaxPayload sets the max payload bytes in BrokerOptions.
+func (_Option) MaxPayload(size int64) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		if size <= 0 {
+			exception.Panicf("%w: option MaxPayload can't be set to a value less equal 0", core.ErrArgs)
+		}
+		o.MaxPayload = size
+	}
+}
+
+// StreamMaxLen sets the approximate max length of each stream in BrokerOptions. Older messages are trimmed when publishing. If 0, streams are never trimmed.
+func (_Option) StreamMaxLen(n int64) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		if n < 0 {
+			exception.Panicf("%w: option StreamMaxLen can't be set to a value less than 0", core.ErrArgs)
+		}
+		o.StreamMaxLen = n
+	}
+}
+
+// ReadCount sets the max number of messages read from a stream at once in BrokerOptions.
+func (_Option) ReadCount(n int64) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		if n <= 0 {
+			exception.Panicf("%w: option ReadCount can't be set to a value less equal 0", core.ErrArgs)
+		}
+		o.ReadCount = n
+	}
+}
+
+// Claim sets how pending messages of queue subscriptions are reclaimed in BrokerOptions. Messages that have been pending for at least minIdle, usually held by dead consumers or negatively acknowledged, are claimed and redelivered every interval.
+func (_Option) Claim(minIdle, interval time.Duration) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		if minIdle <= 0 {
+			exception.Panicf("%w: option ClaimMinIdle can't be set to a value less equal 0", core.ErrArgs)
+		}
+		if interval <= 0 {
+			exception.Panicf("%w: option ClaimInterval can't be set to a value less equal 0", core.ErrArgs)
+		}
+		o.ClaimMinIdle = minIdle
+		o.ClaimInterval = interval
+	}
+}
+
+// MaxDeliver sets the max number of deliveries of a message in queue subscriptions in BrokerOptions. Messages that are still unacknowledged after being delivered this many times, or whose handler returns broker.ErrUnrecoverable, are acknowledged and moved to the dead letter stream, whose key is the stream key followed by ":@dead-letter".
+func (_Option) MaxDeliver(n int64) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		if n <= 0 {
+			exception.Panicf("%w: option MaxDeliver can't be set to a value less equal 0", core.ErrArgs)
+		}
+		o.MaxDeliver = n
+	}
+}
+
+// CustomAuth sets the authentication credentials in BrokerOptions. If RedisClient is nil, these credentials are used for authentication.
+func (_Option) CustomAuth(username, password string) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		o.CustomUsername = username
+		o.CustomPassword = password
+	}
+}
+
+// CustomAddress sets the address in BrokerOptions. If RedisClient is nil, this address is used as the connection address.
+func (_Option) CustomAddress(addr string) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			exception.Panicf("%w: %w", core.ErrArgs, err)
+		}
+		o.CustomAddress = addr
+	}
+}
+
+// CustomDB sets the db in BrokerOptions. If RedisClient is nil, this db is selected.
+func (_Option) CustomDB(db int) option.Setting[BrokerOptions] {
+	return func(o *BrokerOptions) {
+		o.CustomDB = db
+	}
+}
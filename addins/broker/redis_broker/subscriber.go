@@ -0,0 +1,492 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package redis_broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/core/utils/generic"
+	"git.golaxy.org/core/utils/option"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/broker"
+	"git.golaxy.org/framework/addins/log"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"time"
+)
+
+type _SubscribeMode int32
+
+const (
+	_SubscribeMode_Handler _SubscribeMode = iota
+	_SubscribeMode_Sync
+	_SubscribeMode_Chan
+)
+
+const (
+	readBlock        = time.Second     // 阻塞读取stream的超时时间，超时后检查订阅是否已取消
+	deadLetterSuffix = ":@dead-letter" // 死信stream的key后缀
+)
+
+type _SubscriberSettings struct {
+	broker  *_Broker
+	ctx     context.Context
+	pattern string
+}
+
+// With applies additional settings to the subscriber.
+func (s *_SubscriberSettings) With(settings ...option.Setting[broker.SubscriberOptions]) (broker.ISubscriber, error) {
+	return s.broker.Subscribe(s.ctx, s.pattern, settings...)
+}
+
+type _SyncSubscriberSettings struct {
+	broker  *_Broker
+	ctx     context.Context
+	pattern string
+}
+
+// With applies additional settings to the subscriber.
+func (s *_SyncSubscriberSettings) With(settings ...option.Setting[broker.SubscriberOptions]) (broker.ISyncSubscriber, error) {
+	return s.broker.SubscribeSync(s.ctx, s.pattern, settings...)
+}
+
+type _ChanSubscriberSettings struct {
+	broker  *_Broker
+	ctx     context.Context
+	pattern string
+}
+
+// With applies additional settings to the subscriber.
+func (s *_ChanSubscriberSettings) With(settings ...option.Setting[broker.SubscriberOptions]) (broker.IChanSubscriber, error) {
+	return s.broker.SubscribeChan(s.ctx, s.pattern, settings...)
+}
+
+func (b *_Broker) newSubscriber(ctx context.Context, mode _SubscribeMode, pattern string, opts broker.SubscriberOptions) (*_Subscriber, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := validateTopic(pattern); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	sub := &_Subscriber{
+		Context:        ctx,
+		terminate:      cancel,
+		terminated:     async.MakeAsyncRet(),
+		broker:         b,
+		mode:           mode,
+		pattern:        pattern,
+		queue:          opts.Queue,
+		key:            b.getStreamKey(pattern),
+		autoAck:        opts.AutoAck,
+		unsubscribedCB: opts.UnsubscribedCB,
+	}
+
+	switch mode {
+	case _SubscribeMode_Sync, _SubscribeMode_Chan:
+		sub.eventChan = make(chan broker.IEvent, opts.EventChanSize)
+	case _SubscribeMode_Handler:
+		sub.eventHandler = opts.EventHandler
+	}
+
+	if sub.queue != "" {
+		// 队列订阅映射为消费者组，组内每个消费者只接收部分消息
+		sub.group = b.options.QueuePrefix + sub.queue
+		sub.consumer = fmt.Sprintf("%s-%s", b.svcCtx.GetId(), uid.New())
+
+		err := b.client.XGroupCreateMkStream(ctx, sub.key, sub.group, "$").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			cancel()
+			return nil, fmt.Errorf("broker: %w", err)
+		}
+	} else {
+		// 非队列订阅从当前最新消息之后开始读取
+		msgs, err := b.client.XRevRangeN(ctx, sub.key, "+", "-", 1).Result()
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("broker: %w", err)
+		}
+		if len(msgs) > 0 {
+			sub.lastId = msgs[0].ID
+		} else {
+			sub.lastId = "0-0"
+		}
+	}
+
+	log.Debugf(b.svcCtx, "subscribe topic pattern %q queue %q success", sub.Pattern(), sub.Queue())
+
+	b.wg.Add(1)
+	go sub.mainLoop()
+
+	return sub, nil
+}
+
+type _Subscriber struct {
+	context.Context
+	terminate      context.CancelFunc
+	terminated     chan async.Ret
+	broker         *_Broker
+	mode           _SubscribeMode
+	pattern        string
+	queue          string
+	key            string
+	group          string
+	consumer       string
+	lastId         string
+	autoAck        bool
+	eventChan      chan broker.IEvent
+	eventHandler   broker.EventHandler
+	unsubscribedCB broker.UnsubscribedCB
+}
+
+// Pattern returns the subscription pattern used to create the subscriber.
+func (s *_Subscriber) Pattern() string {
+	return s.pattern
+}
+
+// Queue subscribers with the same queue name will create a shared subscription where each receives a subset of messages.
+func (s *_Subscriber) Queue() string {
+	return s.queue
+}
+
+// Unsubscribe unsubscribes the subscriber from the topic.
+func (s *_Subscriber) Unsubscribe() async.AsyncRet {
+	s.terminate()
+	return s.terminated
+}
+
+// Unsubscribed subscriber is unsubscribed.
+func (s *_Subscriber) Unsubscribed() async.AsyncRet {
+	return s.terminated
+}
+
+// Next is a blocking call that waits for the next event to be received from the subscriber.
+func (s *_Subscriber) Next() (broker.IEvent, error) {
+	for event := range s.eventChan {
+		return event, nil
+	}
+	return nil, broker.ErrUnsubscribed
+}
+
+// EventChan returns a channel that can be used to receive events from the subscriber.
+func (s *_Subscriber) EventChan() (<-chan broker.IEvent, error) {
+	return s.eventChan, nil
+}
+
+func (s *_Subscriber) mainLoop() {
+	defer func() {
+		s.terminate()
+		s.broker.wg.Done()
+		async.Return(s.terminated, async.VoidRet)
+	}()
+
+	var lastClaim time.Time
+
+	for {
+		select {
+		case <-s.Done():
+			goto end
+		case <-s.broker.ctx.Done():
+			goto end
+		default:
+		}
+
+		if s.queue != "" {
+			// 定时认领长时间未确认的消息，包括已失效消费者持有的消息与被否定确认的消息
+			if time.Since(lastClaim) >= s.broker.options.ClaimInterval {
+				s.claim()
+				lastClaim = time.Now()
+			}
+			s.readGroup()
+		} else {
+			s.read()
+		}
+	}
+
+end:
+	s.cleanConsumer()
+
+	log.Debugf(s.broker.svcCtx, "unsubscribe topic pattern %q with %q success", s.Pattern(), s.Queue())
+
+	if s.eventChan != nil {
+		close(s.eventChan)
+	}
+
+	s.unsubscribedCB.SafeCall(func(panicErr error) bool {
+		log.Errorf(s.broker.svcCtx, "handle unsubscribed topic pattern %q queue %q failed, %s", s.Pattern(), s.Queue(), panicErr)
+		return false
+	}, s)
+}
+
+func (s *_Subscriber) read() {
+	streams, err := s.broker.client.XRead(s, &redis.XReadArgs{
+		Streams: []string{s.key, s.lastId},
+		Count:   s.broker.options.ReadCount,
+		Block:   readBlock,
+	}).Result()
+	if err != nil {
+		s.readFailed(err)
+		return
+	}
+
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			s.lastId = msg.ID
+			s.deliver(msg)
+		}
+	}
+}
+
+func (s *_Subscriber) readGroup() {
+	streams, err := s.broker.client.XReadGroup(s, &redis.XReadGroupArgs{
+		Group:    s.group,
+		Consumer: s.consumer,
+		Streams:  []string{s.key, ">"},
+		Count:    s.broker.options.ReadCount,
+		Block:    readBlock,
+	}).Result()
+	if err != nil {
+		s.readFailed(err)
+		return
+	}
+
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			s.deliver(msg)
+		}
+	}
+}
+
+func (s *_Subscriber) readFailed(err error) {
+	if errors.Is(err, redis.Nil) || s.Err() != nil || s.broker.ctx.Err() != nil {
+		return
+	}
+
+	log.Errorf(s.broker.svcCtx, "read topic %q queue %q failed, %s", s.Pattern(), s.Queue(), err)
+
+	select {
+	case <-time.After(readBlock):
+	case <-s.Done():
+	case <-s.broker.ctx.Done():
+	}
+}
+
+func (s *_Subscriber) claim() {
+	start := "0-0"
+
+	for {
+		msgs, next, err := s.broker.client.XAutoClaim(s, &redis.XAutoClaimArgs{
+			Stream:   s.key,
+			Group:    s.group,
+			Consumer: s.consumer,
+			MinIdle:  s.broker.options.ClaimMinIdle,
+			Start:    start,
+			Count:    s.broker.options.ReadCount,
+		}).Result()
+		if err != nil {
+			if s.Err() == nil && s.broker.ctx.Err() == nil {
+				log.Errorf(s.broker.svcCtx, "claim pending msgs from topic %q queue %q failed, %s", s.Pattern(), s.Queue(), err)
+			}
+			return
+		}
+
+		deliveries := s.deliveries(msgs)
+
+		for _, msg := range msgs {
+			// 投递次数超过上限的消息转入死信stream，避免处理失败的消息无限重新投递
+			if deliveries[msg.ID] > s.broker.options.MaxDeliver {
+				data, _ := msg.Values[dataField].(string)
+				s.deadLetter(msg.ID, data, "max deliver exceeded")
+				continue
+			}
+			log.Debugf(s.broker.svcCtx, "claim pending msg %q from topic %q queue %q", msg.ID, s.Pattern(), s.Queue())
+			s.deliver(msg)
+		}
+
+		if next == "" || next == "0-0" {
+			return
+		}
+		start = next
+	}
+}
+
+// deliveries 查询认领的消息的投递次数，认领时投递次数已经增加
+func (s *_Subscriber) deliveries(msgs []redis.XMessage) map[string]int64 {
+	cmds := make([]*redis.XPendingExtCmd, len(msgs))
+
+	_, err := s.broker.client.Pipelined(s, func(pipe redis.Pipeliner) error {
+		for i := range msgs {
+			cmds[i] = pipe.XPendingExt(s, &redis.XPendingExtArgs{
+				Stream: s.key,
+				Group:  s.group,
+				Start:  msgs[i].ID,
+				End:    msgs[i].ID,
+				Count:  1,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		if s.Err() == nil && s.broker.ctx.Err() == nil {
+			log.Errorf(s.broker.svcCtx, "get pending msgs of topic %q queue %q failed, %s", s.Pattern(), s.Queue(), err)
+		}
+		return nil
+	}
+
+	deliveries := make(map[string]int64, len(msgs))
+	for _, cmd := range cmds {
+		for _, pending := range cmd.Val() {
+			deliveries[pending.ID] = pending.RetryCount
+		}
+	}
+	return deliveries
+}
+
+// deadLetter 将无法处理的消息转入死信stream后确认
+func (s *_Subscriber) deadLetter(id, data, reason string) {
+	args := &redis.XAddArgs{
+		Stream: s.key + deadLetterSuffix,
+		ID:     "*",
+		Values: []any{dataField, data, "id", id, "group", s.group, "reason", reason},
+	}
+
+	if s.broker.options.StreamMaxLen > 0 {
+		args.MaxLen = s.broker.options.StreamMaxLen
+		args.Approx = true
+	}
+
+	// 转入死信stream失败时保持未确认状态，等待下次认领时重试
+	if err := s.broker.client.XAdd(s.broker.ctx, args).Err(); err != nil {
+		log.Errorf(s.broker.svcCtx, "dead letter msg %q from topic %q queue %q failed, %s", id, s.Pattern(), s.Queue(), err)
+		return
+	}
+
+	if err := s.broker.client.XAck(s.broker.ctx, s.key, s.group, id).Err(); err != nil {
+		log.Errorf(s.broker.svcCtx, "ack msg %q from topic %q queue %q failed, %s", id, s.Pattern(), s.Queue(), err)
+		return
+	}
+
+	log.Warnf(s.broker.svcCtx, "dead letter msg %q from topic %q queue %q, %s", id, s.Pattern(), s.Queue(), reason)
+}
+
+func (s *_Subscriber) cleanConsumer() {
+	if s.queue == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	consumers, err := s.broker.client.XInfoConsumers(ctx, s.key, s.group).Result()
+	if err != nil {
+		log.Errorf(s.broker.svcCtx, "get consumers of topic %q queue %q failed, %s", s.Pattern(), s.Queue(), err)
+		return
+	}
+
+	for _, consumer := range consumers {
+		// 仍有未确认的消息时保留消费者，由其他消费者认领后再清理
+		if consumer.Pending > 0 {
+			continue
+		}
+		if consumer.Name != s.consumer && consumer.Idle < s.broker.options.ClaimMinIdle {
+			continue
+		}
+		if err := s.broker.client.XGroupDelConsumer(ctx, s.key, s.group, consumer.Name).Err(); err != nil {
+			log.Errorf(s.broker.svcCtx, "delete consumer %q of topic %q queue %q failed, %s", consumer.Name, s.Pattern(), s.Queue(), err)
+		}
+	}
+}
+
+func (s *_Subscriber) deliver(msg redis.XMessage) {
+	broker.ObserveReceive(s.broker.svcCtx)
+
+	data, ok := msg.Values[dataField].(string)
+	if !ok {
+		// 消息已被裁剪或格式错误，直接确认
+		log.Warnf(s.broker.svcCtx, "discard invalid msg %q from topic %q queue %q", msg.ID, s.Pattern(), s.Queue())
+		if s.queue != "" {
+			s.broker.client.XAck(s.broker.ctx, s.key, s.group, msg.ID)
+		}
+		return
+	}
+
+	e := &_Event{
+		id:   msg.ID,
+		data: []byte(data),
+		ns:   s,
+	}
+
+	switch s.mode {
+	case _SubscribeMode_Sync, _SubscribeMode_Chan:
+		s.handleEventChan(e)
+	case _SubscribeMode_Handler:
+		s.handleEventProcess(e)
+	}
+}
+
+func (s *_Subscriber) handleEventChan(e *_Event) {
+	select {
+	case s.eventChan <- e:
+		// 无法得知调用方何时处理完毕，自动确认在投递至接收队列后立即确认，只能保证最多一次交付，需要最少一次交付时，应关闭自动确认，由调用方处理完毕后确认
+		if s.autoAck {
+			if err := e.Ack(s.broker.ctx); err != nil {
+				log.Errorf(s.broker.svcCtx, "ack msg %q from topic %q queue %q failed, %s", e.id, e.Topic(), e.Queue(), err)
+			}
+		}
+	default:
+		// 队列订阅时消息保持未确认状态，等待认领后重新投递
+		log.Errorf(s.broker.svcCtx, "handle msg %q from topic %q queue %q failed, receive event chan is full", e.id, e.Topic(), e.Queue())
+	}
+}
+
+func (s *_Subscriber) handleEventProcess(e *_Event) {
+	var failed, unrecoverable bool
+
+	s.eventHandler.SafeCall(func(err error, panicErr error) bool {
+		if err := generic.FuncError(err, panicErr); err != nil {
+			log.Errorf(s.broker.svcCtx, "handle msg %q from topic %q queue %q failed, %s", e.id, e.Topic(), e.Queue(), err)
+			failed = true
+			unrecoverable = errors.Is(err, broker.ErrUnrecoverable)
+		}
+		return panicErr != nil
+	}, e)
+
+	// 无法处理的消息，重新投递也不会成功，队列订阅时直接转入死信stream
+	if unrecoverable {
+		if s.queue != "" {
+			s.deadLetter(e.id, string(e.data), "unrecoverable")
+		}
+		return
+	}
+
+	// 处理失败时，队列订阅的消息保持未确认状态，等待认领后重新投递，投递次数超过上限时转入死信stream
+	if failed || !s.autoAck {
+		return
+	}
+
+	if err := e.Ack(s.broker.ctx); err != nil {
+		log.Errorf(s.broker.svcCtx, "ack msg %q from topic %q queue %q failed, %s", e.id, e.Topic(), e.Queue(), err)
+	}
+}
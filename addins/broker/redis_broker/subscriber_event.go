@@ -0,0 +1,90 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package redis_broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type _Event struct {
+	id   string
+	data []byte
+	ns   *_Subscriber
+}
+
+// Pattern returns the subscription pattern used to create the event.
+func (e *_Event) Pattern() string {
+	return e.ns.Pattern()
+}
+
+// Topic returns the topic the event was received on.
+func (e *_Event) Topic() string {
+	return e.ns.Pattern()
+}
+
+// Queue subscribers with the same queue name will create a shared subscription where each receives a subset of messages.
+func (e *_Event) Queue() string {
+	return e.ns.Queue()
+}
+
+// Message returns the raw message payload of the event.
+func (e *_Event) Message() []byte {
+	return e.data
+}
+
+// Ack acknowledges the successful processing of the event. It indicates that the event can be removed from the subscription queue.
+func (e *_Event) Ack(ctx context.Context) error {
+	// 非队列订阅不使用消费者组，无需确认
+	if e.ns.queue == "" {
+		return nil
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := e.ns.broker.client.XAck(ctx, e.ns.key, e.ns.group, e.id).Err(); err != nil {
+		return fmt.Errorf("broker: %w", err)
+	}
+
+	return nil
+}
+
+// Nak negatively acknowledges a message. This tells the server to redeliver the message.
+func (e *_Event) Nak(ctx context.Context) error {
+	if e.ns.queue == "" {
+		return errors.New("broker: not queue subscription, unable to negatively acknowledge(nak)")
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// 将消息的空闲时间设置为认领阈值，使消费者组内任意消费者在下次认领时重新投递
+	err := e.ns.broker.client.Do(ctx, "XCLAIM", e.ns.key, e.ns.group, e.ns.consumer, 0, e.id,
+		"IDLE", e.ns.broker.options.ClaimMinIdle.Milliseconds(), "JUSTID").Err()
+	if err != nil {
+		return fmt.Errorf("broker: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package redis_broker_test
+
+import (
+	"context"
+	"fmt"
+	"git.golaxy.org/core/utils/generic"
+	"git.golaxy.org/framework"
+	"git.golaxy.org/framework/addins/broker"
+	"git.golaxy.org/framework/addins/broker/redis_broker"
+	"git.golaxy.org/framework/frameworktest"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const maxDeliver = 3
+
+type _RedisService struct {
+	framework.ServiceInstance
+	client *redis.Client
+}
+
+func (s *_RedisService) InstallBroker(inst framework.IServiceInstance) {
+	redis_broker.Install(inst,
+		redis_broker.With.RedisClient(s.client),
+		redis_broker.With.TopicPrefix("test:"),
+		redis_broker.With.Claim(50*time.Millisecond, 20*time.Millisecond),
+		redis_broker.With.MaxDeliver(maxDeliver),
+	)
+}
+
+func startRedisBroker(t *testing.T) (broker.IBroker, *redis.Client) {
+	cli := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { cli.Close() })
+
+	c := frameworktest.NewCluster(t).
+		Setup("test", &_RedisService{client: cli}, 1).
+		Start()
+
+	return c.GetService("test", 0).GetBroker(), cli
+}
+
+// waitDeadLetter 等待消息转入死信stream，并检查没有未确认的消息
+func waitDeadLetter(t *testing.T, cli *redis.Client, topic, queue string) {
+	t.Helper()
+
+	ctx := context.Background()
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		n, err := cli.XLen(ctx, "test:"+topic+":@dead-letter").Result()
+		if err != nil {
+			t.Fatalf("get dead letter length failed, %s", err)
+		}
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d dead letters, want 1", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	pending, err := cli.XPending(ctx, "test:"+topic, queue).Result()
+	if err != nil {
+		t.Fatalf("get pending failed, %s", err)
+	}
+	if pending.Count != 0 {
+		t.Fatalf("got %d pending msgs, want 0", pending.Count)
+	}
+}
+
+func TestMaxDeliver(t *testing.T) {
+	b, cli := startRedisBroker(t)
+	ctx := context.Background()
+
+	var calls atomic.Int64
+
+	_, err := b.Subscribe(ctx, "failing",
+		broker.With.Queue("q"),
+		broker.With.EventHandler(generic.CastDelegate1(func(e broker.IEvent) error {
+			calls.Add(1)
+			return fmt.Errorf("handle failed")
+		})))
+	if err != nil {
+		t.Fatalf("subscribe failed, %s", err)
+	}
+
+	if err := b.Publish(ctx, "failing", []byte("hello")); err != nil {
+		t.Fatalf("publish failed, %s", err)
+	}
+
+	// 处理失败的消息重新投递至上限后转入死信stream，不再重新投递
+	waitDeadLetter(t, cli, "failing", "q")
+	time.Sleep(200 * time.Millisecond)
+
+	if n := calls.Load(); n != maxDeliver {
+		t.Fatalf("got %d deliveries, want %d", n, maxDeliver)
+	}
+}
+
+func TestUnrecoverable(t *testing.T) {
+	b, cli := startRedisBroker(t)
+	ctx := context.Background()
+
+	var calls atomic.Int64
+
+	_, err := b.Subscribe(ctx, "malformed",
+		broker.With.Queue("q"),
+		broker.With.EventHandler(generic.CastDelegate1(func(e broker.IEvent) error {
+			calls.Add(1)
+			return fmt.Errorf("%w: decode failed", broker.ErrUnrecoverable)
+		})))
+	if err != nil {
+		t.Fatalf("subscribe failed, %s", err)
+	}
+
+	if err := b.Publish(ctx, "malformed", []byte("hello")); err != nil {
+		t.Fatalf("publish failed, %s", err)
+	}
+
+	// 无法处理的消息直接转入死信stream，不重新投递
+	waitDeadLetter(t, cli, "malformed", "q")
+	time.Sleep(200 * time.Millisecond)
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("got %d deliveries, want 1", n)
+	}
+}
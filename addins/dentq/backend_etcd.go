@@ -0,0 +1,123 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dentq
+
+import (
+	"context"
+	"errors"
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/dentr"
+	etcdv3 "go.etcd.io/etcd/client/v3"
+	"path"
+	"strings"
+	"time"
+	"unique"
+)
+
+// NewEtcdBackend 创建etcd分布式实体信息查询后端，与dentr.NewEtcdStorage()配套使用
+func NewEtcdBackend(cli *etcdv3.Client, keyPrefix string) IBackend {
+	if cli == nil {
+		exception.Panicf("%w: cli is nil", core.ErrArgs)
+	}
+	if keyPrefix != "" && !strings.HasSuffix(keyPrefix, "/") {
+		keyPrefix += "/"
+	}
+	return &_EtcdBackend{
+		client:    cli,
+		keyPrefix: keyPrefix,
+	}
+}
+
+type _EtcdBackend struct {
+	client    *etcdv3.Client
+	keyPrefix string
+}
+
+// Init 初始化后端，检查连接并完成必要的配置
+func (b *_EtcdBackend) Init(ctx context.Context) error {
+	for _, ep := range b.client.Endpoints() {
+		err := func() error {
+			ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+			defer cancel()
+
+			_, err := b.client.Status(ctx, ep)
+			return err
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get 查询实体的所有节点信息，最新注册的节点在前，同时返回数据版本号
+func (b *_EtcdBackend) Get(ctx context.Context, entityId uid.Id) ([]dentr.EntityNode, int64, error) {
+	rsp, err := b.client.Get(ctx, path.Join(b.keyPrefix, entityId.String()),
+		etcdv3.WithPrefix(),
+		etcdv3.WithSort(etcdv3.SortByModRevision, etcdv3.SortDescend),
+		etcdv3.WithIgnoreValue())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	nodes := make([]dentr.EntityNode, 0, len(rsp.Kvs))
+
+	for _, kv := range rsp.Kvs {
+		subs := strings.Split(strings.TrimPrefix(string(kv.Key), b.keyPrefix), "/")
+		if len(subs) != 3 {
+			continue
+		}
+
+		nodes = append(nodes, dentr.EntityNode{
+			EntityId:  entityId,
+			Service:   unique.Make(subs[1]).Value(),
+			ServiceId: uid.From(unique.Make(subs[2]).Value()),
+		})
+	}
+
+	return nodes, rsp.Header.Revision, nil
+}
+
+// Watch 监听实体信息变化，变化时回调实体Id与数据版本号，阻塞直到ctx结束或发生错误
+func (b *_EtcdBackend) Watch(ctx context.Context, fn func(entityId uid.Id, revision int64)) error {
+	for watchRsp := range b.client.Watch(ctx, b.keyPrefix, etcdv3.WithPrefix(), etcdv3.WithIgnoreValue()) {
+		if watchRsp.Canceled {
+			return errors.New("watch canceled")
+		}
+		if watchRsp.Err() != nil {
+			return watchRsp.Err()
+		}
+
+		for _, event := range watchRsp.Events {
+			subs := strings.Split(strings.TrimPrefix(string(event.Kv.Key), b.keyPrefix), "/")
+			if len(subs) != 3 {
+				continue
+			}
+
+			switch event.Type {
+			case etcdv3.EventTypePut, etcdv3.EventTypeDelete:
+				fn(uid.From(subs[0]), watchRsp.Header.Revision)
+			}
+		}
+	}
+	return ctx.Err()
+}
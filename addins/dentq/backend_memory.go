@@ -0,0 +1,59 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dentq
+
+import (
+	"context"
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/dentr"
+)
+
+// NewMemoryBackend 创建进程内分布式实体信息查询后端，与分布式实体注册插件（dentr）共用同一个进程内存储
+func NewMemoryBackend(storage *dentr.MemoryStorage) IBackend {
+	if storage == nil {
+		exception.Panicf("%w: storage is nil", core.ErrArgs)
+	}
+	return &_MemoryBackend{
+		storage: storage,
+	}
+}
+
+type _MemoryBackend struct {
+	storage *dentr.MemoryStorage
+}
+
+// Init 初始化后端，检查连接并完成必要的配置
+func (b *_MemoryBackend) Init(ctx context.Context) error {
+	return nil
+}
+
+// Get 查询实体的所有节点信息，最新注册的节点在前，同时返回数据版本号
+func (b *_MemoryBackend) Get(ctx context.Context, entityId uid.Id) ([]dentr.EntityNode, int64, error) {
+	nodes, revision := b.storage.Get(entityId)
+	return nodes, revision, nil
+}
+
+// Watch 监听实体信息变化，变化时回调实体Id与数据版本号，阻塞直到ctx结束或发生错误
+func (b *_MemoryBackend) Watch(ctx context.Context, fn func(entityId uid.Id, revision int64)) error {
+	b.storage.Watch(ctx, fn)
+	return ctx.Err()
+}
@@ -0,0 +1,129 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dentq
+
+import (
+	"context"
+	"errors"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/dentr"
+	"testing"
+	"time"
+)
+
+func TestMemoryBackendGet(t *testing.T) {
+	storage := dentr.NewMemoryStorage()
+	b := NewMemoryBackend(storage)
+	ctx := context.Background()
+
+	if err := b.Init(ctx); err != nil {
+		t.Fatalf("init failed, %s", err)
+	}
+
+	if nodes, _, err := b.Get(ctx, "e1"); err != nil || len(nodes) != 0 {
+		t.Fatalf("got nodes %+v, %v, want none", nodes, err)
+	}
+
+	lease, _ := storage.Grant(ctx, time.Minute)
+	node1 := dentr.EntityNode{EntityId: "e1", Service: "svc", ServiceId: "node1"}
+	node2 := dentr.EntityNode{EntityId: "e1", Service: "svc", ServiceId: "node2"}
+	storage.Put(ctx, lease, node1)
+	storage.Put(ctx, lease, node2)
+
+	// 最新注册的节点在前，版本号随写入递增
+	nodes, revision1, err := b.Get(ctx, "e1")
+	if err != nil {
+		t.Fatalf("get failed, %s", err)
+	}
+	if len(nodes) != 2 || nodes[0] != node2 || nodes[1] != node1 {
+		t.Fatalf("got nodes %+v, want [node2 node1]", nodes)
+	}
+
+	storage.Delete(ctx, lease, node2)
+
+	nodes, revision2, _ := b.Get(ctx, "e1")
+	if len(nodes) != 1 || nodes[0] != node1 {
+		t.Fatalf("got nodes %+v, want [node1]", nodes)
+	}
+	if revision2 <= revision1 {
+		t.Fatalf("got revision %d after delete, want greater than %d", revision2, revision1)
+	}
+}
+
+func TestMemoryBackendWatch(t *testing.T) {
+	storage := dentr.NewMemoryStorage()
+	b := NewMemoryBackend(storage)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	changed := make(chan uid.Id, 16)
+	watchErr := make(chan error, 1)
+
+	go func() {
+		watchErr <- b.Watch(ctx, func(entityId uid.Id, revision int64) {
+			changed <- entityId
+		})
+	}()
+
+	// 监听器注册前的变化不会通知，循环写入直到收到通知
+	lease, _ := storage.Grant(ctx, time.Minute)
+	deadline := time.After(time.Second)
+loop:
+	for {
+		storage.Put(ctx, lease, dentr.EntityNode{EntityId: "e1", Service: "svc", ServiceId: "node1"})
+
+		select {
+		case entityId := <-changed:
+			if entityId != "e1" {
+				t.Fatalf("got changed entity %q, want %q", entityId, "e1")
+			}
+			break loop
+		case <-deadline:
+			t.Fatal("watcher not notified")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	cancel()
+
+	select {
+	case err := <-watchErr:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("watch returned %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("watch not returned after ctx canceled")
+	}
+}
+
+func TestEscapeGlob(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"entity:e1:", "entity:e1:"},
+		{"a*b?", `a\*b\?`},
+		{"[x]", `\[x\]`},
+		{`a\b`, `a\\b`},
+	}
+	for _, tt := range tests {
+		if got := escapeGlob(tt.s); got != tt.want {
+			t.Errorf("escapeGlob(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
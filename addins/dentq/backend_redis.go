@@ -0,0 +1,195 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dentq
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/dentr"
+	"github.com/redis/go-redis/v9"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unique"
+)
+
+// NewRedisBackend 创建redis分布式实体信息查询后端，与dentr.NewRedisStorage()配套使用，使用键空间通知监听实体信息变化，需要在redis服务端配置
+// notify-keyspace-events，包含events中的所有类型，events为空时使用dentr.RedisKeyspaceEvents。初始化时只检查配置，不会修改服务端配置
+func NewRedisBackend(cli *redis.Client, keyPrefix, events string) IBackend {
+	if cli == nil {
+		exception.Panicf("%w: cli is nil", core.ErrArgs)
+	}
+	if keyPrefix != "" && !strings.HasSuffix(keyPrefix, ":") {
+		keyPrefix += ":"
+	}
+	if events == "" {
+		events = dentr.RedisKeyspaceEvents
+	}
+	b := &_RedisBackend{
+		client:    cli,
+		keyPrefix: keyPrefix,
+		events:    events,
+	}
+	// redis没有全局数据版本号，使用本地单调递增的版本号，保证缓存更新顺序
+	b.revision.Store(time.Now().UnixNano())
+	return b
+}
+
+// KEYS[1]：实体索引key；返回实体key与注册时间，同时清理租约过期后残留的实体key
+var redisGetScript = redis.NewScript(`
+local ret = {}
+for _, key in ipairs(redis.call('SMEMBERS', KEYS[1])) do
+	local val = redis.call('GET', key)
+	if val then
+		ret[#ret+1] = key
+		ret[#ret+1] = val
+	else
+		redis.call('SREM', KEYS[1], key)
+	end
+end
+return ret
+`)
+
+type _RedisBackend struct {
+	client    *redis.Client
+	keyPrefix string
+	events    string
+	revision  atomic.Int64
+}
+
+// Init 初始化后端，检查连接与键空间通知配置
+func (b *_RedisBackend) Init(ctx context.Context) error {
+	if err := b.client.Ping(ctx).Err(); err != nil {
+		return err
+	}
+	return dentr.CheckRedisKeyspaceEvents(ctx, b.client, b.events)
+}
+
+// Get 查询实体的所有节点信息，最新注册的节点在前，同时返回数据版本号
+func (b *_RedisBackend) Get(ctx context.Context, entityId uid.Id) ([]dentr.EntityNode, int64, error) {
+	revision := b.revision.Add(1)
+
+	// 从实体索引读取实体key，不需要扫描keyspace
+	vals, err := redisGetScript.Run(ctx, b.client, []string{b.keyPrefix + "@entity:" + entityId.String()}).StringSlice()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(vals) <= 0 {
+		return nil, revision, nil
+	}
+
+	type _Node struct {
+		dentr.EntityNode
+		registered int64
+	}
+
+	nodes := make([]_Node, 0, len(vals)/2)
+
+	for i := 0; i+1 < len(vals); i += 2 {
+		key, val := vals[i], vals[i+1]
+
+		subs := strings.Split(strings.TrimPrefix(key, b.keyPrefix), ":")
+		if len(subs) != 3 {
+			continue
+		}
+
+		registered, _ := strconv.ParseInt(val, 10, 64)
+
+		nodes = append(nodes, _Node{
+			EntityNode: dentr.EntityNode{
+				EntityId:  entityId,
+				Service:   unique.Make(subs[1]).Value(),
+				ServiceId: uid.From(unique.Make(subs[2]).Value()),
+			},
+			registered: registered,
+		})
+	}
+
+	slices.SortFunc(nodes, func(a, b _Node) int {
+		return cmp.Compare(b.registered, a.registered)
+	})
+
+	entityNodes := make([]dentr.EntityNode, 0, len(nodes))
+	for i := range nodes {
+		entityNodes = append(entityNodes, nodes[i].EntityNode)
+	}
+
+	return entityNodes, revision, nil
+}
+
+// Watch 监听实体信息变化，变化时回调实体Id与数据版本号，阻塞直到ctx结束或发生错误
+func (b *_RedisBackend) Watch(ctx context.Context, fn func(entityId uid.Id, revision int64)) error {
+	channelPrefix := fmt.Sprintf("__keyspace@%d__:", b.client.Options().DB)
+
+	pubSub := b.client.PSubscribe(ctx, escapeGlob(channelPrefix+b.keyPrefix)+"*")
+	defer pubSub.Close()
+
+	if _, err := pubSub.Receive(ctx); err != nil {
+		return err
+	}
+
+	msgChan := pubSub.Channel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-msgChan:
+			if !ok {
+				return redis.ErrClosed
+			}
+
+			// 跳过租约key
+			key := strings.TrimPrefix(strings.TrimPrefix(msg.Channel, channelPrefix), b.keyPrefix)
+			if strings.HasPrefix(key, "@") {
+				continue
+			}
+
+			subs := strings.Split(key, ":")
+			if len(subs) != 3 {
+				continue
+			}
+
+			switch msg.Payload {
+			case "set", "del", "expired", "evicted":
+				fn(uid.From(subs[0]), b.revision.Add(1))
+			}
+		}
+	}
+}
+
+func escapeGlob(s string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
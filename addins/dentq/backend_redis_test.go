@@ -0,0 +1,90 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dentq
+
+import (
+	"context"
+	"git.golaxy.org/framework/addins/dentr"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+func TestRedisBackendGet(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer cli.Close()
+
+	storage := dentr.NewRedisStorage(cli, "entities", "")
+	b := NewRedisBackend(cli, "entities", "")
+	ctx := context.Background()
+
+	if err := storage.Init(ctx); err != nil {
+		t.Fatalf("init storage failed, %s", err)
+	}
+	if err := b.Init(ctx); err != nil {
+		t.Fatalf("init failed, %s", err)
+	}
+
+	if nodes, _, err := b.Get(ctx, "e1"); err != nil || len(nodes) != 0 {
+		t.Fatalf("got nodes %+v, %v, want none", nodes, err)
+	}
+
+	lease1, _ := storage.Grant(ctx, time.Minute)
+	lease2, _ := storage.Grant(ctx, 3*time.Second)
+	node1 := dentr.EntityNode{EntityId: "e1", Service: "svc", ServiceId: "node1"}
+	node2 := dentr.EntityNode{EntityId: "e1", Service: "svc", ServiceId: "node2"}
+	node3 := dentr.EntityNode{EntityId: "e1", Service: "svc", ServiceId: "node3"}
+	storage.Put(ctx, lease1, node1)
+	storage.Put(ctx, lease1, node2)
+	storage.Put(ctx, lease2, node3)
+
+	// 最新注册的节点在前，版本号随查询递增
+	nodes, revision1, err := b.Get(ctx, "e1")
+	if err != nil {
+		t.Fatalf("get failed, %s", err)
+	}
+	if len(nodes) != 3 || nodes[0] != node3 || nodes[1] != node2 || nodes[2] != node1 {
+		t.Fatalf("got nodes %+v, want [node3 node2 node1]", nodes)
+	}
+
+	storage.Delete(ctx, lease1, node2)
+
+	nodes, revision2, _ := b.Get(ctx, "e1")
+	if len(nodes) != 2 || nodes[0] != node3 || nodes[1] != node1 {
+		t.Fatalf("got nodes %+v, want [node3 node1]", nodes)
+	}
+	if revision2 <= revision1 {
+		t.Fatalf("got revision %d, want greater than %d", revision2, revision1)
+	}
+
+	// 租约过期后，查询时清理实体索引中残留的实体key
+	mr.FastForward(4 * time.Second)
+
+	nodes, _, _ = b.Get(ctx, "e1")
+	if len(nodes) != 1 || nodes[0] != node1 {
+		t.Fatalf("got nodes %+v, want [node1]", nodes)
+	}
+	if members, _ := mr.Members("entities:@entity:e1"); len(members) != 1 {
+		t.Fatalf("got entity index members %q, want only node1", members)
+	}
+}
@@ -21,7 +21,6 @@
 package dentq
 
 import (
-	"context"
 	"crypto/tls"
 	"git.golaxy.org/core/runtime"
 	"git.golaxy.org/core/service"
@@ -31,11 +30,8 @@ import (
 	"git.golaxy.org/framework/addins/log"
 	"git.golaxy.org/framework/utils/concurrent"
 	etcdv3 "go.etcd.io/etcd/client/v3"
-	"path"
-	"strings"
 	"sync"
 	"time"
-	"unique"
 )
 
 // DistEntity 分布式实体信息
@@ -73,6 +69,7 @@ type _DistEntityQuerier struct {
 	options DistEntityQuerierOptions
 	dsvc    dsvc.IDistService
 	client  *etcdv3.Client
+	backend IBackend
 	wg      sync.WaitGroup
 	cache   *concurrent.Cache[uid.Id, *DistEntity]
 }
@@ -84,25 +81,23 @@ func (d *_DistEntityQuerier) Init(svcCtx service.Context, _ runtime.Context) {
 	d.svcCtx = svcCtx
 	d.dsvc = dsvc.Using(d.svcCtx)
 
-	if d.options.EtcdClient == nil {
-		cli, err := etcdv3.New(d.configure())
-		if err != nil {
-			log.Panicf(svcCtx, "new etcd client failed, %s", err)
+	if d.options.Backend == nil {
+		if d.options.EtcdClient == nil {
+			cli, err := etcdv3.New(d.configure())
+			if err != nil {
+				log.Panicf(svcCtx, "new etcd client failed, %s", err)
+			}
+			d.client = cli
+		} else {
+			d.client = d.options.EtcdClient
 		}
-		d.client = cli
+		d.backend = NewEtcdBackend(d.client, d.options.KeyPrefix)
 	} else {
-		d.client = d.options.EtcdClient
+		d.backend = d.options.Backend
 	}
 
-	for _, ep := range d.client.Endpoints() {
-		func() {
-			ctx, cancel := context.WithTimeout(d.svcCtx, 3*time.Second)
-			defer cancel()
-
-			if _, err := d.client.Status(ctx, ep); err != nil {
-				log.Panicf(d.svcCtx, "status etcd %q failed, %s", ep, err)
-			}
-		}()
+	if err := d.backend.Init(d.svcCtx); err != nil {
+		log.Panicf(d.svcCtx, "init backend failed, %s", err)
 	}
 
 	d.cache = concurrent.NewCache[uid.Id, *DistEntity]()
@@ -118,7 +113,7 @@ func (d *_DistEntityQuerier) Shut(svcCtx service.Context, _ runtime.Context) {
 
 	d.wg.Wait()
 
-	if d.options.EtcdClient == nil {
+	if d.options.Backend == nil && d.options.EtcdClient == nil {
 		if d.client != nil {
 			d.client.Close()
 		}
@@ -132,31 +127,23 @@ func (d *_DistEntityQuerier) GetDistEntity(id uid.Id) (*DistEntity, bool) {
 		return entity, true
 	}
 
-	rsp, err := d.client.Get(d.svcCtx, path.Join(d.options.KeyPrefix, id.String()),
-		etcdv3.WithPrefix(),
-		etcdv3.WithSort(etcdv3.SortByModRevision, etcdv3.SortDescend),
-		etcdv3.WithIgnoreValue())
-	if err != nil || len(rsp.Kvs) <= 0 {
+	entityNodes, revision, err := d.backend.Get(d.svcCtx, id)
+	if err != nil || len(entityNodes) <= 0 {
 		return nil, false
 	}
 
 	entity = &DistEntity{
 		Id:       id,
-		Nodes:    make([]Node, 0, len(rsp.Kvs)),
-		Revision: rsp.Header.Revision,
+		Nodes:    make([]Node, 0, len(entityNodes)),
+		Revision: revision,
 	}
 
 	details := d.dsvc.GetNodeDetails()
 
-	for _, kv := range rsp.Kvs {
-		subs := strings.Split(strings.TrimPrefix(string(kv.Key), d.options.KeyPrefix), "/")
-		if len(subs) != 3 {
-			continue
-		}
-
+	for _, entityNode := range entityNodes {
 		node := Node{
-			Service: unique.Make(subs[1]).Value(),
-			Id:      uid.From(unique.Make(subs[2]).Value()),
+			Service: entityNode.Service,
+			Id:      entityNode.ServiceId,
 		}
 		node.BroadcastAddr = details.MakeBroadcastAddr(node.Service)
 		node.BalanceAddr = details.MakeBalanceAddr(node.Service)
@@ -173,43 +160,19 @@ func (d *_DistEntityQuerier) mainLoop() {
 
 	log.Debug(d.svcCtx, "watching distributed entities changes started")
 
-retry:
-	var watchChan etcdv3.WatchChan
-	retryInterval := 3 * time.Second
-
-	select {
-	case <-d.svcCtx.Done():
-		goto end
-	default:
-	}
-
-	watchChan = d.client.Watch(d.svcCtx, d.options.KeyPrefix, etcdv3.WithPrefix(), etcdv3.WithIgnoreValue())
+	for {
+		err := d.backend.Watch(d.svcCtx, func(entityId uid.Id, revision int64) {
+			d.cache.Del(entityId, revision)
+		})
 
-	for watchRsp := range watchChan {
-		if watchRsp.Canceled {
-			log.Debugf(d.svcCtx, "stop watch %q, retry it", d.options.KeyPrefix)
-			time.Sleep(retryInterval)
-			goto retry
-		}
-		if watchRsp.Err() != nil {
-			log.Errorf(d.svcCtx, "interrupt watch %q, %s, retry it", d.options.KeyPrefix, watchRsp.Err())
-			time.Sleep(retryInterval)
-			goto retry
+		select {
+		case <-d.svcCtx.Done():
+			goto end
+		default:
 		}
 
-		for _, event := range watchRsp.Events {
-			subs := strings.Split(strings.TrimPrefix(string(event.Kv.Key), d.options.KeyPrefix), "/")
-			if len(subs) != 3 {
-				continue
-			}
-
-			entityId := uid.From(subs[0])
-
-			switch event.Type {
-			case etcdv3.EventTypePut, etcdv3.EventTypeDelete:
-				d.cache.Del(entityId, watchRsp.Header.Revision)
-			}
-		}
+		log.Errorf(d.svcCtx, "interrupt watch distributed entities changes, %v, retry it", err)
+		time.Sleep(3 * time.Second)
 	}
 
 end:
@@ -32,6 +32,7 @@ import (
 
 // DistEntityQuerierOptions 所有选项
 type DistEntityQuerierOptions struct {
+	Backend         IBackend
 	EtcdClient      *clientv3.Client
 	EtcdConfig      *clientv3.Config
 	KeyPrefix       string
@@ -49,6 +50,7 @@ type _Option struct{}
 // Default 默认值
 func (_Option) Default() option.Setting[DistEntityQuerierOptions] {
 	return func(options *DistEntityQuerierOptions) {
+		With.Backend(nil)(options)
 		With.EtcdClient(nil)(options)
 		With.EtcdConfig(nil)(options)
 		With.KeyPrefix("/golaxy/entities/")(options)
@@ -59,21 +61,28 @@ func (_Option) Default() option.Setting[DistEntityQuerierOptions] {
 	}
 }
 
-// EtcdClient etcd客户端，最优先使用
+// Backend 分布式实体信息查询后端，最优先使用，未设置时使用etcd后端
+func (_Option) Backend(backend IBackend) option.Setting[DistEntityQuerierOptions] {
+	return func(o *DistEntityQuerierOptions) {
+		o.Backend = backend
+	}
+}
+
+// EtcdClient etcd客户端，使用etcd后端时，最优先使用
 func (_Option) EtcdClient(cli *clientv3.Client) option.Setting[DistEntityQuerierOptions] {
 	return func(o *DistEntityQuerierOptions) {
 		o.EtcdClient = cli
 	}
 }
 
-// EtcdConfig etcd配置，次优先使用
+// EtcdConfig etcd配置，使用etcd后端时，次优先使用
 func (_Option) EtcdConfig(config *clientv3.Config) option.Setting[DistEntityQuerierOptions] {
 	return func(o *DistEntityQuerierOptions) {
 		o.EtcdConfig = config
 	}
 }
 
-// KeyPrefix 所有key的前缀
+// KeyPrefix 使用etcd后端时，所有key的前缀
 func (_Option) KeyPrefix(prefix string) option.Setting[DistEntityQuerierOptions] {
 	return func(options *DistEntityQuerierOptions) {
 		if prefix != "" && !strings.HasSuffix(prefix, "/") {
@@ -31,10 +31,7 @@ import (
 	"git.golaxy.org/core/utils/async"
 	"git.golaxy.org/core/utils/option"
 	"git.golaxy.org/framework/addins/log"
-	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	etcdv3 "go.etcd.io/etcd/client/v3"
-	"math"
-	"path"
 	"time"
 )
 
@@ -58,7 +55,8 @@ type _DistEntityRegistry struct {
 	rtCtx   runtime.Context
 	options DistEntityRegistryOptions
 	client  *etcdv3.Client
-	leaseId etcdv3.LeaseID
+	storage IStorage
+	leaseId LeaseId
 }
 
 // Init 初始化插件
@@ -68,25 +66,23 @@ func (d *_DistEntityRegistry) Init(_ service.Context, rtCtx runtime.Context) {
 	d.rtCtx = rtCtx
 	d.rtCtx.ActivateEvent(&d.distEntityRegistryEventTab, event.EventRecursion_Allow)
 
-	if d.options.EtcdClient == nil {
-		cli, err := etcdv3.New(d.configure())
-		if err != nil {
-			log.Panicf(d.rtCtx, "new etcd client failed, %s", err)
+	if d.options.Storage == nil {
+		if d.options.EtcdClient == nil {
+			cli, err := etcdv3.New(d.configure())
+			if err != nil {
+				log.Panicf(d.rtCtx, "new etcd client failed, %s", err)
+			}
+			d.client = cli
+		} else {
+			d.client = d.options.EtcdClient
 		}
-		d.client = cli
+		d.storage = NewEtcdStorage(d.client, d.options.KeyPrefix)
 	} else {
-		d.client = d.options.EtcdClient
+		d.storage = d.options.Storage
 	}
 
-	for _, ep := range d.client.Endpoints() {
-		func() {
-			ctx, cancel := context.WithTimeout(d.rtCtx, 3*time.Second)
-			defer cancel()
-
-			if _, err := d.client.Status(ctx, ep); err != nil {
-				log.Panicf(d.rtCtx, "status etcd %q failed, %s", ep, err)
-			}
-		}()
+	if err := d.storage.Init(d.rtCtx); err != nil {
+		log.Panicf(d.rtCtx, "init storage failed, %s", err)
 	}
 
 	// 申请租约
@@ -118,12 +114,12 @@ func (d *_DistEntityRegistry) Shut(_ service.Context, rtCtx runtime.Context) {
 	d.rtCtx.ManagedCleanTagHooks(tagForDistEntityRegistry)
 
 	// 废除租约
-	_, err := d.client.Revoke(context.Background(), d.leaseId)
+	err := d.storage.Revoke(context.Background(), d.leaseId)
 	if err != nil {
 		log.Errorf(d.rtCtx, "revoke lease %d failed, %s", d.leaseId, err)
 	}
 
-	if d.options.EtcdClient == nil {
+	if d.options.Storage == nil && d.options.EtcdClient == nil {
 		if d.client != nil {
 			d.client.Close()
 		}
@@ -147,14 +143,14 @@ func (d *_DistEntityRegistry) register(entity ec.Entity) bool {
 		return true
 	}
 
-	key := d.getEntityPath(entity)
+	node := d.getEntityNode(entity)
 
-	_, err := d.client.Put(d.rtCtx, key, "", etcdv3.WithLease(d.leaseId))
+	err := d.storage.Put(d.rtCtx, d.leaseId, node)
 	if err != nil {
-		log.Errorf(d.rtCtx, "put %q with lease %d failed, %s", key, d.leaseId, err)
+		log.Errorf(d.rtCtx, "put entity %q with lease %d failed, %s", node.EntityId, d.leaseId, err)
 		return false
 	}
-	log.Debugf(d.rtCtx, "put %q with lease %d ok", key, d.leaseId)
+	log.Debugf(d.rtCtx, "put entity %q with lease %d ok", node.EntityId, d.leaseId)
 
 	// 通知分布式实体上线
 	_EmitEventDistEntityOnline(d, entity)
@@ -170,13 +166,13 @@ func (d *_DistEntityRegistry) deregister(entity ec.Entity) {
 	case <-d.rtCtx.Done():
 		break
 	default:
-		key := d.getEntityPath(entity)
+		node := d.getEntityNode(entity)
 
-		_, err := d.client.Delete(d.rtCtx, key)
+		err := d.storage.Delete(d.rtCtx, d.leaseId, node)
 		if err != nil {
-			log.Warnf(d.rtCtx, "delete %q failed, %s", key, err)
+			log.Warnf(d.rtCtx, "delete entity %q failed, %s", node.EntityId, err)
 		} else {
-			log.Debugf(d.rtCtx, "delete %q ok", key)
+			log.Debugf(d.rtCtx, "delete entity %q ok", node.EntityId)
 		}
 	}
 
@@ -184,20 +180,24 @@ func (d *_DistEntityRegistry) deregister(entity ec.Entity) {
 	_EmitEventDistEntityOffline(d, entity)
 }
 
-func (d *_DistEntityRegistry) getEntityPath(entity ec.Entity) string {
+func (d *_DistEntityRegistry) getEntityNode(entity ec.Entity) EntityNode {
 	svcCtx := service.Current(d.rtCtx)
-	return path.Join(d.options.KeyPrefix, entity.GetId().String(), svcCtx.GetName(), svcCtx.GetId().String())
+	return EntityNode{
+		EntityId:  entity.GetId(),
+		Service:   svcCtx.GetName(),
+		ServiceId: svcCtx.GetId(),
+	}
 }
 
 func (d *_DistEntityRegistry) keepAliveLease(rtCtx runtime.Context, ret async.Ret, args ...any) {
 	// 刷新租约
-	_, err := d.client.KeepAliveOnce(d.rtCtx, d.leaseId)
+	err := d.storage.KeepAlive(d.rtCtx, d.leaseId)
 	if err == nil {
 		log.Debugf(d.rtCtx, "keep alive lease %d ok", d.leaseId)
 		return
 	}
 
-	if !errors.Is(err, rpctypes.ErrLeaseNotFound) {
+	if !errors.Is(err, ErrLeaseNotFound) {
 		log.Errorf(d.rtCtx, "keep alive lease %d failed, %s", d.leaseId, err)
 		return
 	}
@@ -225,12 +225,8 @@ func (d *_DistEntityRegistry) keepAliveLease(rtCtx runtime.Context, ret async.Re
 	d.rtCtx.GetEntityManager().RangeEntities(d.register)
 }
 
-func (d *_DistEntityRegistry) grantLease() (etcdv3.LeaseID, error) {
-	lgr, err := d.client.Grant(d.rtCtx, int64(math.Ceil(d.options.TTL.Seconds())))
-	if err != nil {
-		return etcdv3.NoLease, err
-	}
-	return lgr.ID, nil
+func (d *_DistEntityRegistry) grantLease() (LeaseId, error) {
+	return d.storage.Grant(d.rtCtx, d.options.TTL)
 }
 
 func (d *_DistEntityRegistry) configure() etcdv3.Config {
@@ -32,6 +32,7 @@ import (
 
 // DistEntityRegistryOptions 所有选项
 type DistEntityRegistryOptions struct {
+	Storage         IStorage
 	EtcdClient      *clientv3.Client
 	EtcdConfig      *clientv3.Config
 	KeyPrefix       string
@@ -49,6 +50,7 @@ type _Option struct{}
 // Default 默认值
 func (_Option) Default() option.Setting[DistEntityRegistryOptions] {
 	return func(options *DistEntityRegistryOptions) {
+		With.Storage(nil)(options)
 		With.EtcdClient(nil)(options)
 		With.EtcdConfig(nil)(options)
 		With.KeyPrefix("/golaxy/entities/")(options)
@@ -59,21 +61,28 @@ func (_Option) Default() option.Setting[DistEntityRegistryOptions] {
 	}
 }
 
-// EtcdClient etcd客户端，最优先使用
+// Storage 分布式实体信息存储，最优先使用，未设置时使用etcd存储
+func (_Option) Storage(storage IStorage) option.Setting[DistEntityRegistryOptions] {
+	return func(o *DistEntityRegistryOptions) {
+		o.Storage = storage
+	}
+}
+
+// EtcdClient etcd客户端，使用etcd存储时，最优先使用
 func (_Option) EtcdClient(cli *clientv3.Client) option.Setting[DistEntityRegistryOptions] {
 	return func(o *DistEntityRegistryOptions) {
 		o.EtcdClient = cli
 	}
 }
 
-// EtcdConfig etcd配置，次优先使用
+// EtcdConfig etcd配置，使用etcd存储时，次优先使用
 func (_Option) EtcdConfig(config *clientv3.Config) option.Setting[DistEntityRegistryOptions] {
 	return func(o *DistEntityRegistryOptions) {
 		o.EtcdConfig = config
 	}
 }
 
-// KeyPrefix 所有key的前缀
+// KeyPrefix 使用etcd存储时，所有key的前缀
 func (_Option) KeyPrefix(prefix string) option.Setting[DistEntityRegistryOptions] {
 	return func(options *DistEntityRegistryOptions) {
 		if prefix != "" && !strings.HasSuffix(prefix, "/") {
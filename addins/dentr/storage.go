@@ -0,0 +1,58 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dentr
+
+import (
+	"context"
+	"errors"
+	"git.golaxy.org/core/utils/uid"
+	"time"
+)
+
+var (
+	// ErrLeaseNotFound 租约已丢失
+	ErrLeaseNotFound = errors.New("dentr: requested lease not found")
+)
+
+// LeaseId 租约Id
+type LeaseId int64
+
+// EntityNode 实体节点信息，表示实体在某个服务节点上注册
+type EntityNode struct {
+	EntityId  uid.Id // 实体Id
+	Service   string // 服务名称
+	ServiceId uid.Id // 服务Id
+}
+
+// IStorage 分布式实体信息存储，实体节点信息绑定租约，租约过期后自动删除
+type IStorage interface {
+	// Init 初始化存储，检查连接并完成必要的配置
+	Init(ctx context.Context) error
+	// Grant 申请租约
+	Grant(ctx context.Context, ttl time.Duration) (LeaseId, error)
+	// KeepAlive 刷新租约，租约已丢失时返回ErrLeaseNotFound
+	KeepAlive(ctx context.Context, leaseId LeaseId) error
+	// Revoke 废除租约，同时删除租约下的所有实体节点信息
+	Revoke(ctx context.Context, leaseId LeaseId) error
+	// Put 写入实体节点信息，并绑定租约，租约已丢失时返回ErrLeaseNotFound
+	Put(ctx context.Context, leaseId LeaseId, node EntityNode) error
+	// Delete 删除实体节点信息，并解除与租约的绑定
+	Delete(ctx context.Context, leaseId LeaseId, node EntityNode) error
+}
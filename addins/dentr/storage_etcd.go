@@ -0,0 +1,108 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dentr
+
+import (
+	"context"
+	"errors"
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	etcdv3 "go.etcd.io/etcd/client/v3"
+	"math"
+	"path"
+	"time"
+)
+
+// NewEtcdStorage 创建etcd分布式实体信息存储，使用etcd租约管理实体节点信息
+func NewEtcdStorage(cli *etcdv3.Client, keyPrefix string) IStorage {
+	if cli == nil {
+		exception.Panicf("%w: cli is nil", core.ErrArgs)
+	}
+	return &_EtcdStorage{
+		client:    cli,
+		keyPrefix: keyPrefix,
+	}
+}
+
+type _EtcdStorage struct {
+	client    *etcdv3.Client
+	keyPrefix string
+}
+
+// Init 初始化存储，检查连接并完成必要的配置
+func (s *_EtcdStorage) Init(ctx context.Context) error {
+	for _, ep := range s.client.Endpoints() {
+		err := func() error {
+			ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+			defer cancel()
+
+			_, err := s.client.Status(ctx, ep)
+			return err
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Grant 申请租约
+func (s *_EtcdStorage) Grant(ctx context.Context, ttl time.Duration) (LeaseId, error) {
+	lgr, err := s.client.Grant(ctx, int64(math.Ceil(ttl.Seconds())))
+	if err != nil {
+		return LeaseId(etcdv3.NoLease), err
+	}
+	return LeaseId(lgr.ID), nil
+}
+
+// KeepAlive 刷新租约，租约已丢失时返回ErrLeaseNotFound
+func (s *_EtcdStorage) KeepAlive(ctx context.Context, leaseId LeaseId) error {
+	_, err := s.client.KeepAliveOnce(ctx, etcdv3.LeaseID(leaseId))
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		return ErrLeaseNotFound
+	}
+	return err
+}
+
+// Revoke 废除租约，同时删除租约下的所有实体节点信息
+func (s *_EtcdStorage) Revoke(ctx context.Context, leaseId LeaseId) error {
+	_, err := s.client.Revoke(ctx, etcdv3.LeaseID(leaseId))
+	return err
+}
+
+// Put 写入实体节点信息，并绑定租约，租约已丢失时返回ErrLeaseNotFound
+func (s *_EtcdStorage) Put(ctx context.Context, leaseId LeaseId, node EntityNode) error {
+	_, err := s.client.Put(ctx, s.getNodePath(node), "", etcdv3.WithLease(etcdv3.LeaseID(leaseId)))
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		return ErrLeaseNotFound
+	}
+	return err
+}
+
+// Delete 删除实体节点信息，并解除与租约的绑定
+func (s *_EtcdStorage) Delete(ctx context.Context, _ LeaseId, node EntityNode) error {
+	_, err := s.client.Delete(ctx, s.getNodePath(node))
+	return err
+}
+
+func (s *_EtcdStorage) getNodePath(node EntityNode) string {
+	return path.Join(s.keyPrefix, node.EntityId.String(), node.Service, node.ServiceId.String())
+}
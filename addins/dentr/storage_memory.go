@@ -0,0 +1,323 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dentr
+
+import (
+	"context"
+	"git.golaxy.org/core/utils/uid"
+	"slices"
+	"sync"
+	"time"
+)
+
+// NewMemoryStorage 创建进程内分布式实体信息存储，可以同时提供给分布式实体注册与查询插件使用，适用于单进程部署或测试
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		leases:   map[LeaseId]*_MemoryLease{},
+		entities: map[uid.Id][]_MemoryEntityNode{},
+		watchers: map[*_MemoryWatcher]struct{}{},
+	}
+}
+
+type _MemoryLease struct {
+	ttl      time.Duration
+	deadline time.Time
+	nodes    map[EntityNode]struct{}
+}
+
+type _MemoryEntityNode struct {
+	EntityNode
+	leaseId LeaseId
+}
+
+type _MemoryWatcher struct {
+	fn func(entityId uid.Id, revision int64)
+}
+
+type _MemoryEvent struct {
+	entityId uid.Id
+	revision int64
+}
+
+// MemoryStorage 进程内分布式实体信息存储
+type MemoryStorage struct {
+	mutex       sync.Mutex
+	revision    int64
+	leaseId     LeaseId
+	leases      map[LeaseId]*_MemoryLease
+	entities    map[uid.Id][]_MemoryEntityNode
+	watchers    map[*_MemoryWatcher]struct{}
+	watchersMtx sync.RWMutex
+}
+
+// Init 初始化存储，检查连接并完成必要的配置
+func (s *MemoryStorage) Init(ctx context.Context) error {
+	return nil
+}
+
+// Grant 申请租约
+func (s *MemoryStorage) Grant(ctx context.Context, ttl time.Duration) (LeaseId, error) {
+	var leaseId LeaseId
+
+	events := func() []_MemoryEvent {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		events := s.sweep()
+
+		s.leaseId++
+		leaseId = s.leaseId
+
+		s.leases[leaseId] = &_MemoryLease{
+			ttl:      ttl,
+			deadline: time.Now().Add(ttl),
+			nodes:    map[EntityNode]struct{}{},
+		}
+
+		return events
+	}()
+	s.notify(events)
+
+	return leaseId, nil
+}
+
+// KeepAlive 刷新租约，租约已丢失时返回ErrLeaseNotFound
+func (s *MemoryStorage) KeepAlive(ctx context.Context, leaseId LeaseId) error {
+	var err error
+
+	events := func() []_MemoryEvent {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		events := s.sweep()
+
+		lease, ok := s.leases[leaseId]
+		if !ok {
+			err = ErrLeaseNotFound
+			return events
+		}
+		lease.deadline = time.Now().Add(lease.ttl)
+
+		return events
+	}()
+	s.notify(events)
+
+	return err
+}
+
+// Revoke 废除租约，同时删除租约下的所有实体节点信息
+func (s *MemoryStorage) Revoke(ctx context.Context, leaseId LeaseId) error {
+	events := func() []_MemoryEvent {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		events := s.sweep()
+
+		lease, ok := s.leases[leaseId]
+		if !ok {
+			return events
+		}
+
+		return append(events, s.revoke(leaseId, lease)...)
+	}()
+	s.notify(events)
+
+	return nil
+}
+
+// Put 写入实体节点信息，并绑定租约，租约已丢失时返回ErrLeaseNotFound
+func (s *MemoryStorage) Put(ctx context.Context, leaseId LeaseId, node EntityNode) error {
+	var err error
+
+	events := func() []_MemoryEvent {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		events := s.sweep()
+
+		lease, ok := s.leases[leaseId]
+		if !ok {
+			err = ErrLeaseNotFound
+			return events
+		}
+
+		if event, ok := s.delete(node); ok {
+			events = append(events, event)
+		}
+
+		s.revision++
+		s.entities[node.EntityId] = append(s.entities[node.EntityId], _MemoryEntityNode{
+			EntityNode: node,
+			leaseId:    leaseId,
+		})
+		lease.nodes[node] = struct{}{}
+
+		return append(events, _MemoryEvent{entityId: node.EntityId, revision: s.revision})
+	}()
+	s.notify(events)
+
+	return err
+}
+
+// Delete 删除实体节点信息，并解除与租约的绑定
+func (s *MemoryStorage) Delete(ctx context.Context, _ LeaseId, node EntityNode) error {
+	events := func() []_MemoryEvent {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		events := s.sweep()
+
+		if event, ok := s.delete(node); ok {
+			events = append(events, event)
+		}
+
+		return events
+	}()
+	s.notify(events)
+
+	return nil
+}
+
+// Get 查询实体的所有节点信息，最新注册的节点在前，同时返回数据版本号
+func (s *MemoryStorage) Get(entityId uid.Id) ([]EntityNode, int64) {
+	var nodes []EntityNode
+	var revision int64
+
+	events := func() []_MemoryEvent {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		events := s.sweep()
+
+		entityNodes := s.entities[entityId]
+		nodes = make([]EntityNode, 0, len(entityNodes))
+		for i := len(entityNodes) - 1; i >= 0; i-- {
+			nodes = append(nodes, entityNodes[i].EntityNode)
+		}
+		revision = s.revision
+
+		return events
+	}()
+	s.notify(events)
+
+	return nodes, revision
+}
+
+// Watch 监听实体信息变化，阻塞直到ctx结束，监听期间定期清理过期的租约
+func (s *MemoryStorage) Watch(ctx context.Context, fn func(entityId uid.Id, revision int64)) {
+	if fn == nil {
+		return
+	}
+
+	watcher := &_MemoryWatcher{fn: fn}
+
+	s.watchersMtx.Lock()
+	s.watchers[watcher] = struct{}{}
+	s.watchersMtx.Unlock()
+
+	defer func() {
+		s.watchersMtx.Lock()
+		delete(s.watchers, watcher)
+		s.watchersMtx.Unlock()
+	}()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mutex.Lock()
+			events := s.sweep()
+			s.mutex.Unlock()
+
+			s.notify(events)
+		}
+	}
+}
+
+func (s *MemoryStorage) sweep() []_MemoryEvent {
+	var events []_MemoryEvent
+	now := time.Now()
+
+	for leaseId, lease := range s.leases {
+		if now.Before(lease.deadline) {
+			continue
+		}
+		events = append(events, s.revoke(leaseId, lease)...)
+	}
+
+	return events
+}
+
+func (s *MemoryStorage) revoke(leaseId LeaseId, lease *_MemoryLease) []_MemoryEvent {
+	events := make([]_MemoryEvent, 0, len(lease.nodes))
+
+	for node := range lease.nodes {
+		if event, ok := s.delete(node); ok {
+			events = append(events, event)
+		}
+	}
+	delete(s.leases, leaseId)
+
+	return events
+}
+
+func (s *MemoryStorage) delete(node EntityNode) (_MemoryEvent, bool) {
+	entityNodes := s.entities[node.EntityId]
+
+	idx := slices.IndexFunc(entityNodes, func(other _MemoryEntityNode) bool {
+		return other.EntityNode == node
+	})
+	if idx < 0 {
+		return _MemoryEvent{}, false
+	}
+
+	if lease, ok := s.leases[entityNodes[idx].leaseId]; ok {
+		delete(lease.nodes, node)
+	}
+
+	entityNodes = slices.Delete(entityNodes, idx, idx+1)
+	if len(entityNodes) > 0 {
+		s.entities[node.EntityId] = entityNodes
+	} else {
+		delete(s.entities, node.EntityId)
+	}
+
+	s.revision++
+	return _MemoryEvent{entityId: node.EntityId, revision: s.revision}, true
+}
+
+func (s *MemoryStorage) notify(events []_MemoryEvent) {
+	if len(events) <= 0 {
+		return
+	}
+
+	s.watchersMtx.RLock()
+	defer s.watchersMtx.RUnlock()
+
+	for watcher := range s.watchers {
+		for _, event := range events {
+			watcher.fn(event.entityId, event.revision)
+		}
+	}
+}
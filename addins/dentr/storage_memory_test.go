@@ -0,0 +1,166 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dentr
+
+import (
+	"context"
+	"errors"
+	"git.golaxy.org/core/utils/uid"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoragePut(t *testing.T) {
+	s := NewMemoryStorage()
+	ctx := context.Background()
+
+	lease1, _ := s.Grant(ctx, time.Minute)
+	lease2, _ := s.Grant(ctx, time.Minute)
+
+	node1 := EntityNode{EntityId: "e1", Service: "svc", ServiceId: "node1"}
+	node2 := EntityNode{EntityId: "e1", Service: "svc", ServiceId: "node2"}
+
+	if err := s.Put(ctx, lease1, node1); err != nil {
+		t.Fatalf("put failed, %s", err)
+	}
+	if err := s.Put(ctx, lease2, node2); err != nil {
+		t.Fatalf("put failed, %s", err)
+	}
+
+	// 最新注册的节点在前
+	nodes, revision := s.Get("e1")
+	if len(nodes) != 2 || nodes[0] != node2 || nodes[1] != node1 {
+		t.Fatalf("got nodes %+v, want [node2 node1]", nodes)
+	}
+	if revision != 2 {
+		t.Fatalf("got revision %d, want 2", revision)
+	}
+
+	// 重复写入时移至最前
+	if err := s.Put(ctx, lease1, node1); err != nil {
+		t.Fatalf("put failed, %s", err)
+	}
+	if nodes, _ := s.Get("e1"); len(nodes) != 2 || nodes[0] != node1 {
+		t.Fatalf("got nodes %+v, want node1 first", nodes)
+	}
+
+	if err := s.Delete(ctx, lease1, node1); err != nil {
+		t.Fatalf("delete failed, %s", err)
+	}
+	if nodes, _ := s.Get("e1"); len(nodes) != 1 || nodes[0] != node2 {
+		t.Fatalf("got nodes %+v, want [node2]", nodes)
+	}
+
+	// 废除租约时删除租约下的节点
+	if err := s.Revoke(ctx, lease2); err != nil {
+		t.Fatalf("revoke failed, %s", err)
+	}
+	if nodes, _ := s.Get("e1"); len(nodes) != 0 {
+		t.Fatalf("got nodes %+v after revoke, want none", nodes)
+	}
+
+	if err := s.Put(ctx, lease2, node2); !errors.Is(err, ErrLeaseNotFound) {
+		t.Fatalf("put with revoked lease returned %v, want %v", err, ErrLeaseNotFound)
+	}
+	if err := s.KeepAlive(ctx, lease2); !errors.Is(err, ErrLeaseNotFound) {
+		t.Fatalf("keep alive revoked lease returned %v, want %v", err, ErrLeaseNotFound)
+	}
+}
+
+func TestMemoryStorageExpire(t *testing.T) {
+	s := NewMemoryStorage()
+	ctx := context.Background()
+
+	lease, _ := s.Grant(ctx, 100*time.Millisecond)
+	node := EntityNode{EntityId: "e1", Service: "svc", ServiceId: "node1"}
+
+	if err := s.Put(ctx, lease, node); err != nil {
+		t.Fatalf("put failed, %s", err)
+	}
+
+	// 续约后不过期
+	time.Sleep(60 * time.Millisecond)
+	if err := s.KeepAlive(ctx, lease); err != nil {
+		t.Fatalf("keep alive failed, %s", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if nodes, _ := s.Get("e1"); len(nodes) != 1 {
+		t.Fatalf("got nodes %+v, want node alive after keep alive", nodes)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if nodes, _ := s.Get("e1"); len(nodes) != 0 {
+		t.Fatalf("got nodes %+v, want none after lease expired", nodes)
+	}
+	if err := s.KeepAlive(ctx, lease); !errors.Is(err, ErrLeaseNotFound) {
+		t.Fatalf("keep alive expired lease returned %v, want %v", err, ErrLeaseNotFound)
+	}
+}
+
+func TestMemoryStorageWatch(t *testing.T) {
+	s := NewMemoryStorage()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan uid.Id, 16)
+	watching := make(chan struct{})
+
+	go func() {
+		close(watching)
+		s.Watch(ctx, func(entityId uid.Id, revision int64) {
+			changed <- entityId
+		})
+	}()
+	<-watching
+
+	// 等待监听器注册
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.watchersMtx.RLock()
+		n := len(s.watchers)
+		s.watchersMtx.RUnlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("watcher not registered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	lease, _ := s.Grant(ctx, time.Minute)
+	if err := s.Put(ctx, lease, EntityNode{EntityId: "e1", Service: "svc", ServiceId: "node1"}); err != nil {
+		t.Fatalf("put failed, %s", err)
+	}
+	if err := s.Revoke(ctx, lease); err != nil {
+		t.Fatalf("revoke failed, %s", err)
+	}
+
+	for range 2 {
+		select {
+		case entityId := <-changed:
+			if entityId != "e1" {
+				t.Fatalf("got changed entity %q, want %q", entityId, "e1")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("watcher not notified")
+		}
+	}
+}
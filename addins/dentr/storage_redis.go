@@ -0,0 +1,225 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dentr
+
+import (
+	"context"
+	"fmt"
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
+	"github.com/redis/go-redis/v9"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RedisKeyspaceEvents 查询方监听实体信息变化需要redis开启的键空间通知类型，K：键空间通知，g：DEL等通用命令，$：字符串命令，x：过期，e：驱逐
+const RedisKeyspaceEvents = "Kg$xe"
+
+// NewRedisStorage 创建redis分布式实体信息存储，使用key的过期时间模拟租约。查询方使用键空间通知监听实体信息变化，需要在redis服务端配置notify-keyspace-events，
+// 包含events中的所有类型，events为空时使用RedisKeyspaceEvents。初始化时只检查配置，不会修改服务端配置
+func NewRedisStorage(cli *redis.Client, keyPrefix, events string) IStorage {
+	if cli == nil {
+		exception.Panicf("%w: cli is nil", core.ErrArgs)
+	}
+	if keyPrefix != "" && !strings.HasSuffix(keyPrefix, ":") {
+		keyPrefix += ":"
+	}
+	if events == "" {
+		events = RedisKeyspaceEvents
+	}
+	return &_RedisStorage{
+		client:    cli,
+		keyPrefix: keyPrefix,
+		events:    events,
+	}
+}
+
+// CheckRedisKeyspaceEvents 检查redis服务端配置的notify-keyspace-events是否包含events中的所有类型。托管redis通常禁用CONFIG命令，此时无法检查，
+// 由使用方保证配置正确
+func CheckRedisKeyspaceEvents(ctx context.Context, cli *redis.Client, events string) error {
+	vals, err := cli.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		return nil
+	}
+
+	configured := vals["notify-keyspace-events"]
+
+	if missing := missingKeyspaceEvents(configured, events); missing != "" {
+		return fmt.Errorf("redis notify-keyspace-events %q missing %q, requires %q", configured, missing, events)
+	}
+
+	return nil
+}
+
+// missingKeyspaceEvents 返回events中未配置的类型
+func missingKeyspaceEvents(configured, events string) string {
+	// A是g$lshzxetd的别名
+	if strings.ContainsRune(configured, 'A') {
+		configured += "g$lshzxetd"
+	}
+
+	var missing strings.Builder
+	for _, c := range events {
+		if !strings.ContainsRune(configured, c) {
+			missing.WriteRune(c)
+		}
+	}
+
+	return missing.String()
+}
+
+var (
+	// KEYS[1]：租约key，KEYS[2]：租约下的实体key集合；ARGV[1]：租约TTL（毫秒）
+	redisGrantScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[1], 'NX') then
+	return 1
+end
+return 0
+`)
+	// KEYS[1]：租约key，KEYS[2]：租约下的实体key与实体索引key映射；实体索引可能被多个租约引用，只延长不缩短过期时间
+	redisKeepAliveScript = redis.NewScript(`
+local ttl = redis.call('GET', KEYS[1])
+if not ttl then
+	return 0
+end
+redis.call('PEXPIRE', KEYS[1], ttl)
+local keys = redis.call('HGETALL', KEYS[2])
+for i = 1, #keys, 2 do
+	redis.call('PEXPIRE', keys[i], ttl)
+	if redis.call('PTTL', keys[i+1]) < tonumber(ttl) then
+		redis.call('PEXPIRE', keys[i+1], ttl)
+	end
+end
+redis.call('PEXPIRE', KEYS[2], ttl)
+return 1
+`)
+	// KEYS[1]：租约key，KEYS[2]：租约下的实体key与实体索引key映射
+	redisRevokeScript = redis.NewScript(`
+local keys = redis.call('HGETALL', KEYS[2])
+for i = 1, #keys, 2 do
+	redis.call('DEL', keys[i])
+	redis.call('SREM', keys[i+1], keys[i])
+end
+redis.call('DEL', KEYS[1], KEYS[2])
+return 1
+`)
+	// KEYS[1]：实体key，KEYS[2]：租约下的实体key与实体索引key映射，KEYS[3]：实体索引key
+	redisDeleteScript = redis.NewScript(`
+redis.call('DEL', KEYS[1])
+redis.call('HDEL', KEYS[2], KEYS[1])
+redis.call('SREM', KEYS[3], KEYS[1])
+return 1
+`)
+	// KEYS[1]：租约key，KEYS[2]：租约下的实体key与实体索引key映射，KEYS[3]：实体key，KEYS[4]：实体索引key；ARGV[1]：注册时间（纳秒），用于查询时排序
+	redisPutScript = redis.NewScript(`
+local ttl = redis.call('GET', KEYS[1])
+if not ttl then
+	return 0
+end
+redis.call('SET', KEYS[3], ARGV[1], 'PX', ttl)
+redis.call('HSET', KEYS[2], KEYS[3], KEYS[4])
+redis.call('PEXPIRE', KEYS[2], ttl)
+redis.call('SADD', KEYS[4], KEYS[3])
+if redis.call('PTTL', KEYS[4]) < tonumber(ttl) then
+	redis.call('PEXPIRE', KEYS[4], ttl)
+end
+return 1
+`)
+)
+
+type _RedisStorage struct {
+	client    *redis.Client
+	keyPrefix string
+	events    string
+}
+
+// Init 初始化存储，检查连接与键空间通知配置
+func (s *_RedisStorage) Init(ctx context.Context) error {
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		return err
+	}
+	return CheckRedisKeyspaceEvents(ctx, s.client, s.events)
+}
+
+// Grant 申请租约
+func (s *_RedisStorage) Grant(ctx context.Context, ttl time.Duration) (LeaseId, error) {
+	for {
+		leaseId := LeaseId(rand.Int64())
+
+		ok, err := redisGrantScript.Run(ctx, s.client, s.getLeaseKeys(leaseId), ttl.Milliseconds()).Bool()
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return leaseId, nil
+		}
+	}
+}
+
+// KeepAlive 刷新租约，租约已丢失时返回ErrLeaseNotFound
+func (s *_RedisStorage) KeepAlive(ctx context.Context, leaseId LeaseId) error {
+	ok, err := redisKeepAliveScript.Run(ctx, s.client, s.getLeaseKeys(leaseId)).Bool()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLeaseNotFound
+	}
+	return nil
+}
+
+// Revoke 废除租约，同时删除租约下的所有实体节点信息
+func (s *_RedisStorage) Revoke(ctx context.Context, leaseId LeaseId) error {
+	return redisRevokeScript.Run(ctx, s.client, s.getLeaseKeys(leaseId)).Err()
+}
+
+// Put 写入实体节点信息，并绑定租约，租约已丢失时返回ErrLeaseNotFound
+func (s *_RedisStorage) Put(ctx context.Context, leaseId LeaseId, node EntityNode) error {
+	ok, err := redisPutScript.Run(ctx, s.client, append(s.getLeaseKeys(leaseId), s.getNodeKey(node), s.getIndexKey(node)), time.Now().UnixNano()).Bool()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLeaseNotFound
+	}
+	return nil
+}
+
+// Delete 删除实体节点信息，并解除与租约的绑定
+func (s *_RedisStorage) Delete(ctx context.Context, leaseId LeaseId, node EntityNode) error {
+	return redisDeleteScript.Run(ctx, s.client, []string{s.getNodeKey(node), s.getLeaseKeys(leaseId)[1], s.getIndexKey(node)}).Err()
+}
+
+func (s *_RedisStorage) getNodeKey(node EntityNode) string {
+	return s.keyPrefix + node.EntityId.String() + ":" + node.Service + ":" + node.ServiceId.String()
+}
+
+// getIndexKey 实体索引key，集合中保存实体的所有实体key，查询方不需要扫描keyspace，租约过期后残留的实体key由查询方清理
+func (s *_RedisStorage) getIndexKey(node EntityNode) string {
+	return s.keyPrefix + "@entity:" + node.EntityId.String()
+}
+
+// getLeaseKeys 租约key，使用实体Id中不会出现的@开头，避免与实体key冲突
+func (s *_RedisStorage) getLeaseKeys(leaseId LeaseId) []string {
+	leaseKey := s.keyPrefix + "@lease:" + strconv.FormatInt(int64(leaseId), 10)
+	return []string{leaseKey, leaseKey + ":keys"}
+}
@@ -0,0 +1,204 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dentr
+
+import (
+	"context"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+func newTestRedisStorage(t *testing.T) (*_RedisStorage, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { cli.Close() })
+
+	s := NewRedisStorage(cli, "entities", "").(*_RedisStorage)
+	if err := s.Init(context.Background()); err != nil {
+		t.Fatalf("init failed, %s", err)
+	}
+
+	return s, mr
+}
+
+func TestRedisStoragePut(t *testing.T) {
+	s, mr := newTestRedisStorage(t)
+	ctx := context.Background()
+
+	lease, err := s.Grant(ctx, time.Minute)
+	if err != nil {
+		t.Fatalf("grant failed, %s", err)
+	}
+
+	node := EntityNode{EntityId: "e1", Service: "svc", ServiceId: "node1"}
+
+	if err := s.Put(ctx, lease, node); err != nil {
+		t.Fatalf("put failed, %s", err)
+	}
+
+	// 实体key与租约绑定，过期时间与租约一致
+	key := s.getNodeKey(node)
+	if !mr.Exists(key) {
+		t.Fatalf("node key %q not found", key)
+	}
+	if ttl := mr.TTL(key); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("got node key ttl %s, want bound to lease", ttl)
+	}
+	if idx := mr.HGet(s.getLeaseKeys(lease)[1], key); idx != s.getIndexKey(node) {
+		t.Fatalf("node key %q bound to index %q in lease keys, want %q", key, idx, s.getIndexKey(node))
+	}
+
+	// 实体索引中保存实体key
+	if ok, _ := mr.SIsMember(s.getIndexKey(node), key); !ok {
+		t.Fatalf("node key %q not in entity index", key)
+	}
+
+	// 租约不存在时写入失败
+	if err := s.Put(ctx, lease+1, node); !errors.Is(err, ErrLeaseNotFound) {
+		t.Fatalf("put with unknown lease returned %v, want %v", err, ErrLeaseNotFound)
+	}
+}
+
+func TestRedisStorageDelete(t *testing.T) {
+	s, mr := newTestRedisStorage(t)
+	ctx := context.Background()
+
+	lease, _ := s.Grant(ctx, time.Minute)
+	node := EntityNode{EntityId: "e1", Service: "svc", ServiceId: "node1"}
+
+	if err := s.Put(ctx, lease, node); err != nil {
+		t.Fatalf("put failed, %s", err)
+	}
+	if err := s.Delete(ctx, lease, node); err != nil {
+		t.Fatalf("delete failed, %s", err)
+	}
+
+	// 删除实体key时同时解除与租约的绑定
+	key := s.getNodeKey(node)
+	if mr.Exists(key) {
+		t.Fatalf("node key %q not deleted", key)
+	}
+	if idx := mr.HGet(s.getLeaseKeys(lease)[1], key); idx != "" {
+		t.Fatalf("node key %q still in lease keys", key)
+	}
+	if mr.Exists(s.getIndexKey(node)) {
+		t.Fatalf("entity index %q not deleted", s.getIndexKey(node))
+	}
+}
+
+func TestRedisStorageRevoke(t *testing.T) {
+	s, mr := newTestRedisStorage(t)
+	ctx := context.Background()
+
+	lease, _ := s.Grant(ctx, time.Minute)
+	node1 := EntityNode{EntityId: "e1", Service: "svc", ServiceId: "node1"}
+	node2 := EntityNode{EntityId: "e2", Service: "svc", ServiceId: "node1"}
+
+	for _, node := range []EntityNode{node1, node2} {
+		if err := s.Put(ctx, lease, node); err != nil {
+			t.Fatalf("put failed, %s", err)
+		}
+	}
+
+	if err := s.Revoke(ctx, lease); err != nil {
+		t.Fatalf("revoke failed, %s", err)
+	}
+
+	// 废除租约时删除租约下的所有实体key与实体索引
+	for _, node := range []EntityNode{node1, node2} {
+		if key := s.getNodeKey(node); mr.Exists(key) {
+			t.Fatalf("node key %q not deleted after revoke", key)
+		}
+		if key := s.getIndexKey(node); mr.Exists(key) {
+			t.Fatalf("entity index %q not deleted after revoke", key)
+		}
+	}
+	for _, key := range s.getLeaseKeys(lease) {
+		if mr.Exists(key) {
+			t.Fatalf("lease key %q not deleted after revoke", key)
+		}
+	}
+
+	if err := s.Put(ctx, lease, node1); !errors.Is(err, ErrLeaseNotFound) {
+		t.Fatalf("put with revoked lease returned %v, want %v", err, ErrLeaseNotFound)
+	}
+	if err := s.KeepAlive(ctx, lease); !errors.Is(err, ErrLeaseNotFound) {
+		t.Fatalf("keep alive revoked lease returned %v, want %v", err, ErrLeaseNotFound)
+	}
+}
+
+func TestRedisStorageExpire(t *testing.T) {
+	s, mr := newTestRedisStorage(t)
+	ctx := context.Background()
+
+	lease, _ := s.Grant(ctx, 3*time.Second)
+	node := EntityNode{EntityId: "e1", Service: "svc", ServiceId: "node1"}
+
+	if err := s.Put(ctx, lease, node); err != nil {
+		t.Fatalf("put failed, %s", err)
+	}
+
+	// 续约后不过期
+	mr.FastForward(2 * time.Second)
+	if err := s.KeepAlive(ctx, lease); err != nil {
+		t.Fatalf("keep alive failed, %s", err)
+	}
+	mr.FastForward(2 * time.Second)
+	if key := s.getNodeKey(node); !mr.Exists(key) {
+		t.Fatalf("node key %q expired after keep alive", key)
+	}
+
+	// 租约过期后，实体key与租约key一并过期
+	mr.FastForward(2 * time.Second)
+	if key := s.getNodeKey(node); mr.Exists(key) {
+		t.Fatalf("node key %q not expired with lease", key)
+	}
+	for _, key := range s.getLeaseKeys(lease) {
+		if mr.Exists(key) {
+			t.Fatalf("lease key %q not expired", key)
+		}
+	}
+
+	if err := s.KeepAlive(ctx, lease); !errors.Is(err, ErrLeaseNotFound) {
+		t.Fatalf("keep alive expired lease returned %v, want %v", err, ErrLeaseNotFound)
+	}
+}
+
+func TestMissingKeyspaceEvents(t *testing.T) {
+	tests := []struct {
+		configured, events, missing string
+	}{
+		{"KEA", RedisKeyspaceEvents, ""},
+		{"Kg$xe", RedisKeyspaceEvents, ""},
+		{"AK", RedisKeyspaceEvents, ""},
+		{"", RedisKeyspaceEvents, "Kg$xe"},
+		{"Ex", RedisKeyspaceEvents, "Kg$e"},
+		{"Kx", "Kx", ""},
+	}
+	for _, tt := range tests {
+		if missing := missingKeyspaceEvents(tt.configured, tt.events); missing != tt.missing {
+			t.Errorf("missingKeyspaceEvents(%q, %q) = %q, want %q", tt.configured, tt.events, missing, tt.missing)
+		}
+	}
+}
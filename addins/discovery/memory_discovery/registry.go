@@ -0,0 +1,238 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package memory_discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/runtime"
+	"git.golaxy.org/core/service"
+	"git.golaxy.org/core/utils/option"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/discovery"
+	"git.golaxy.org/framework/addins/log"
+	"git.golaxy.org/framework/utils/concurrent"
+	hash "github.com/mitchellh/hashstructure/v2"
+	"sync"
+	"time"
+)
+
+// NewRegistry 创建进程内registry插件，同一进程内的所有服务可以共享服务信息，适用于单进程部署与测试
+func NewRegistry(settings ...option.Setting[RegistryOptions]) discovery.IRegistry {
+	return &_Registry{
+		options: option.Make(With.Default(), settings...),
+	}
+}
+
+type _Registry struct {
+	svcCtx    service.Context
+	ctx       context.Context
+	terminate context.CancelFunc
+	wg        sync.WaitGroup
+	options   RegistryOptions
+	store     *Store
+	registers concurrent.LockedMap[string, struct{}]
+}
+
+// Init 初始化插件
+func (r *_Registry) Init(svcCtx service.Context, _ runtime.Context) {
+	log.Infof(svcCtx, "init addin %q", self.Name)
+
+	r.svcCtx = svcCtx
+	r.ctx, r.terminate = context.WithCancel(context.Background())
+
+	if r.options.Store == nil {
+		r.store = DefaultStore()
+	} else {
+		r.store = r.options.Store
+	}
+
+	r.registers = concurrent.MakeLockedMap[string, struct{}](0)
+
+	r.wg.Add(1)
+	go r.mainLoop()
+}
+
+// Shut 关闭插件
+func (r *_Registry) Shut(svcCtx service.Context, _ runtime.Context) {
+	log.Infof(svcCtx, "shut addin %q", self.Name)
+
+	r.terminate()
+	r.wg.Wait()
+}
+
+// Register 注册服务
+func (r *_Registry) Register(ctx context.Context, service *discovery.Service, ttl time.Duration) error {
+	if service == nil {
+		return fmt.Errorf("registry: %w: serivce is nil", core.ErrArgs)
+	}
+
+	if len(service.Nodes) <= 0 {
+		return errors.New("registry: require at least one node")
+	}
+
+	var errs []error
+
+	for i := range service.Nodes {
+		node := &service.Nodes[i]
+
+		if err := r.registerNode(service.Name, node, ttl); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", node.Id, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("registry: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// Deregister 取消注册服务
+func (r *_Registry) Deregister(ctx context.Context, service *discovery.Service) error {
+	if service == nil {
+		return fmt.Errorf("registry: %w: serivce is nil", core.ErrArgs)
+	}
+
+	if len(service.Nodes) <= 0 {
+		return errors.New("registry: require at least one node")
+	}
+
+	for i := range service.Nodes {
+		r.deregisterNode(service.Name, &service.Nodes[i])
+	}
+
+	return nil
+}
+
+// RefreshTTL 刷新所有服务TTL
+func (r *_Registry) RefreshTTL(ctx context.Context) error {
+	var errs []error
+
+	r.registers.Each(func(nodePath string, _ struct{}) {
+		if err := r.store.keepAlive(r, nodePath); err != nil {
+			errs = append(errs, fmt.Errorf("keeplive %q failed, %w", nodePath, err))
+		}
+	})
+
+	if len(errs) > 0 {
+		return fmt.Errorf("registry: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// GetServiceNode 查询服务节点
+func (r *_Registry) GetServiceNode(ctx context.Context, serviceName string, nodeId uid.Id) (*discovery.Service, error) {
+	if serviceName == "" || nodeId == "" {
+		return nil, discovery.ErrNotFound
+	}
+	return r.store.GetServiceNode(serviceName, nodeId)
+}
+
+// GetService 查询服务
+func (r *_Registry) GetService(ctx context.Context, serviceName string) (*discovery.Service, error) {
+	if serviceName == "" {
+		return nil, discovery.ErrNotFound
+	}
+	return r.store.GetService(serviceName)
+}
+
+// ListServices 查询所有服务
+func (r *_Registry) ListServices(ctx context.Context) ([]discovery.Service, error) {
+	return r.store.ListServices(), nil
+}
+
+// Watch 监听服务变化
+func (r *_Registry) Watch(ctx context.Context, pattern string, revision ...int64) (discovery.IWatcher, error) {
+	return r.newWatcher(ctx, pattern, revision...)
+}
+
+func (r *_Registry) registerNode(serviceName string, node *discovery.Node, ttl time.Duration) error {
+	if serviceName == "" {
+		return errors.New("service name can't empty")
+	}
+
+	if node.Id == "" {
+		return errors.New("service node id can't empty")
+	}
+
+	ttl = max(ttl, r.options.TTL)
+
+	hv, err := hash.Hash(node, hash.FormatV2, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := r.store.put(r, serviceName, node, hv, ttl); err != nil {
+		return err
+	}
+
+	r.registers.Add(getNodePath(serviceName, node.Id), struct{}{})
+
+	log.Debugf(r.svcCtx, "register service %q node %q success", serviceName, node.Id)
+	return nil
+}
+
+func (r *_Registry) deregisterNode(serviceName string, node *discovery.Node) {
+	nodePath := getNodePath(serviceName, node.Id)
+
+	if !r.registers.Exist(nodePath) {
+		return
+	}
+	r.registers.Delete(nodePath)
+
+	if r.store.del(r, serviceName, node.Id) {
+		log.Debugf(r.svcCtx, "deregister service %q node %q success", serviceName, node.Id)
+	}
+}
+
+func (r *_Registry) mainLoop() {
+	defer r.wg.Done()
+
+	// 定时清理过期服务节点
+	expireTicker := time.NewTicker(time.Second)
+	defer expireTicker.Stop()
+
+	// 定时刷新服务节点TTL
+	var refreshTick <-chan time.Time
+	if r.options.AutoRefreshTTL {
+		refreshTicker := time.NewTicker(r.options.TTL / 2)
+		defer refreshTicker.Stop()
+		refreshTick = refreshTicker.C
+	}
+
+	for {
+		select {
+		case now := <-expireTicker.C:
+			r.store.expire(now)
+		case <-refreshTick:
+			if err := r.RefreshTTL(r.ctx); err != nil {
+				log.Errorf(r.svcCtx, "refresh ttl failed, %s", err)
+				continue
+			}
+			log.Debugf(r.svcCtx, "refresh ttl success")
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
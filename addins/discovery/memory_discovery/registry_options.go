@@ -0,0 +1,76 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package memory_discovery
+
+import (
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/option"
+	"time"
+)
+
+// RegistryOptions 所有选项
+type RegistryOptions struct {
+	Store          *Store
+	WatchChanSize  int
+	TTL            time.Duration
+	AutoRefreshTTL bool
+}
+
+var With _Option
+
+type _Option struct{}
+
+// Default 默认值
+func (_Option) Default() option.Setting[RegistryOptions] {
+	return func(options *RegistryOptions) {
+		With.Store(nil)(options)
+		With.WatchChanSize(128)(options)
+		With.TTL(10*time.Second, true)(options)
+	}
+}
+
+// Store 进程内服务信息存储，使用相同存储的registry插件共享服务信息，为nil时使用默认存储
+func (_Option) Store(store *Store) option.Setting[RegistryOptions] {
+	return func(options *RegistryOptions) {
+		options.Store = store
+	}
+}
+
+// WatchChanSize 监控服务变化的channel大小
+func (_Option) WatchChanSize(size int) option.Setting[RegistryOptions] {
+	return func(options *RegistryOptions) {
+		if size < 0 {
+			exception.Panicf("%w: option WatchChanSize can't be set to a value less than 0", core.ErrArgs)
+		}
+		options.WatchChanSize = size
+	}
+}
+
+// TTL 默认TTL
+func (_Option) TTL(ttl time.Duration, auto bool) option.Setting[RegistryOptions] {
+	return func(options *RegistryOptions) {
+		if ttl < time.Second {
+			exception.Panicf("%w: option TTL can't be set to a value less than 1 second", core.ErrArgs)
+		}
+		options.TTL = ttl
+		options.AutoRefreshTTL = auto
+	}
+}
@@ -0,0 +1,331 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package memory_discovery
+
+import (
+	"errors"
+	"fmt"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/discovery"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCompacted Watching revision has been compacted error
+	ErrCompacted = errors.New("registry: required revision has been compacted")
+	// ErrLeaseNotFound Node lease expired or not found error
+	ErrLeaseNotFound = errors.New("registry: requested lease not found")
+)
+
+const historyLimit = 4096 // 保留的历史事件数量
+
+var defaultStore = NewStore()
+
+// DefaultStore 默认进程内服务信息存储
+func DefaultStore() *Store {
+	return defaultStore
+}
+
+// NewStore 创建进程内服务信息存储
+func NewStore() *Store {
+	return &Store{
+		entries: map[string]*_Entry{},
+		changed: make(chan struct{}),
+	}
+}
+
+type _Entry struct {
+	owner    *_Registry
+	service  string
+	node     discovery.Node
+	hash     uint64
+	ttl      time.Duration
+	expireAt time.Time
+	revision int64
+}
+
+type _Record struct {
+	revision int64
+	event    *discovery.Event
+}
+
+// Store 进程内服务信息存储，可以被同一进程内的所有服务共享
+type Store struct {
+	mutex     sync.RWMutex
+	revision  int64
+	compacted int64
+	entries   map[string]*_Entry
+	history   []_Record
+	changed   chan struct{}
+}
+
+func (s *Store) put(owner *_Registry, serviceName string, node *discovery.Node, hash uint64, ttl time.Duration) error {
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.expireLocked(now)
+
+	key := getNodePath(serviceName, node.Id)
+
+	entry, ok := s.entries[key]
+	if ok {
+		if entry.owner != owner {
+			return fmt.Errorf("service %q node %q already existed", serviceName, node.Id)
+		}
+
+		entry.ttl = ttl
+		entry.expireAt = now.Add(ttl)
+
+		if entry.hash == hash {
+			return nil
+		}
+
+		entry.node = *node.DeepCopy()
+		entry.hash = hash
+		entry.revision = s.recordLocked(discovery.Update, entry)
+		return nil
+	}
+
+	entry = &_Entry{
+		owner:    owner,
+		service:  serviceName,
+		node:     *node.DeepCopy(),
+		hash:     hash,
+		ttl:      ttl,
+		expireAt: now.Add(ttl),
+	}
+	s.entries[key] = entry
+	entry.revision = s.recordLocked(discovery.Create, entry)
+
+	return nil
+}
+
+func (s *Store) del(owner *_Registry, serviceName string, nodeId uid.Id) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.expireLocked(time.Now())
+
+	key := getNodePath(serviceName, nodeId)
+
+	entry, ok := s.entries[key]
+	if !ok || entry.owner != owner {
+		return false
+	}
+
+	delete(s.entries, key)
+	s.recordLocked(discovery.Delete, entry)
+
+	return true
+}
+
+func (s *Store) keepAlive(owner *_Registry, key string) error {
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.expireLocked(now)
+
+	entry, ok := s.entries[key]
+	if !ok || entry.owner != owner {
+		return ErrLeaseNotFound
+	}
+
+	entry.expireAt = now.Add(entry.ttl)
+	return nil
+}
+
+func (s *Store) expire(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.expireLocked(now)
+}
+
+// GetServiceNode 查询服务节点，可以在服务外部直接查询存储
+func (s *Store) GetServiceNode(serviceName string, nodeId uid.Id) (*discovery.Service, error) {
+	now := time.Now()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entry, ok := s.entries[getNodePath(serviceName, nodeId)]
+	if !ok || !now.Before(entry.expireAt) {
+		return nil, discovery.ErrNotFound
+	}
+
+	return &discovery.Service{
+		Name:     entry.service,
+		Nodes:    []discovery.Node{*entry.node.DeepCopy()},
+		Revision: entry.revision,
+	}, nil
+}
+
+// GetService 查询服务
+func (s *Store) GetService(serviceName string) (*discovery.Service, error) {
+	now := time.Now()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	service := &discovery.Service{
+		Name:     serviceName,
+		Revision: s.revision,
+	}
+
+	for _, entry := range s.entries {
+		if entry.service != serviceName || !now.Before(entry.expireAt) {
+			continue
+		}
+		service.Nodes = append(service.Nodes, *entry.node.DeepCopy())
+	}
+
+	if len(service.Nodes) <= 0 {
+		return nil, discovery.ErrNotFound
+	}
+
+	sortNodes(service.Nodes)
+
+	return service, nil
+}
+
+// ListServices 查询所有服务
+func (s *Store) ListServices() []discovery.Service {
+	now := time.Now()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var services []discovery.Service
+
+	for _, entry := range s.entries {
+		if !now.Before(entry.expireAt) {
+			continue
+		}
+
+		idx := slices.IndexFunc(services, func(service discovery.Service) bool {
+			return service.Name == entry.service
+		})
+		if idx < 0 {
+			services = append(services, discovery.Service{
+				Name:     entry.service,
+				Revision: s.revision,
+			})
+			idx = len(services) - 1
+		}
+
+		services[idx].Nodes = append(services[idx].Nodes, *entry.node.DeepCopy())
+	}
+
+	for i := range services {
+		sortNodes(services[i].Nodes)
+	}
+
+	return services
+}
+
+func (s *Store) currentRevision() int64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.revision
+}
+
+func (s *Store) since(revision int64) ([]_Record, <-chan struct{}, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if revision <= s.compacted {
+		return nil, nil, ErrCompacted
+	}
+
+	idx, _ := slices.BinarySearchFunc(s.history, revision, func(record _Record, revision int64) int {
+		switch {
+		case record.revision < revision:
+			return -1
+		case record.revision > revision:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return slices.Clone(s.history[idx:]), s.changed, nil
+}
+
+func (s *Store) expireLocked(now time.Time) {
+	for key, entry := range s.entries {
+		if now.Before(entry.expireAt) {
+			continue
+		}
+		delete(s.entries, key)
+		s.recordLocked(discovery.Delete, entry)
+	}
+}
+
+func (s *Store) recordLocked(eventType discovery.EventType, entry *_Entry) int64 {
+	s.revision++
+
+	s.history = append(s.history, _Record{
+		revision: s.revision,
+		event: &discovery.Event{
+			Type: eventType,
+			Service: &discovery.Service{
+				Name:     entry.service,
+				Nodes:    []discovery.Node{*entry.node.DeepCopy()},
+				Revision: s.revision,
+			},
+		},
+	})
+
+	if len(s.history) > historyLimit {
+		n := len(s.history) - historyLimit
+		s.compacted = s.history[n-1].revision
+		s.history = slices.Delete(s.history, 0, n)
+	}
+
+	// 通知所有监听器
+	close(s.changed)
+	s.changed = make(chan struct{})
+
+	return s.revision
+}
+
+func sortNodes(nodes []discovery.Node) {
+	slices.SortFunc(nodes, func(a, b discovery.Node) int {
+		switch {
+		case a.Id < b.Id:
+			return -1
+		case a.Id > b.Id:
+			return 1
+		default:
+			return 0
+		}
+	})
+}
+
+func getNodePath(s string, id uid.Id) string {
+	return s + "/" + id.String()
+}
@@ -0,0 +1,176 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package memory_discovery
+
+import (
+	"errors"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/discovery"
+	"testing"
+	"time"
+)
+
+func TestStorePut(t *testing.T) {
+	s := NewStore()
+	owner, other := &_Registry{}, &_Registry{}
+	node := &discovery.Node{Id: uid.Id("node1"), Address: "addr1"}
+
+	if err := s.put(owner, "svc", node, 1, time.Minute); err != nil {
+		t.Fatalf("put failed, %s", err)
+	}
+
+	service, err := s.GetServiceNode("svc", node.Id)
+	if err != nil {
+		t.Fatalf("get service node failed, %s", err)
+	}
+	if service.Nodes[0].Address != "addr1" || service.Revision != 1 {
+		t.Fatalf("got node %+v revision %d", service.Nodes[0], service.Revision)
+	}
+
+	// 内容未变化时，只刷新TTL，不产生新版本
+	if err := s.put(owner, "svc", node, 1, time.Minute); err != nil {
+		t.Fatalf("put failed, %s", err)
+	}
+	if rev := s.currentRevision(); rev != 1 {
+		t.Fatalf("revision %d after put unchanged node, want 1", rev)
+	}
+
+	// 内容变化时，产生更新事件
+	node.Address = "addr2"
+	if err := s.put(owner, "svc", node, 2, time.Minute); err != nil {
+		t.Fatalf("put failed, %s", err)
+	}
+	records, _, err := s.since(2)
+	if err != nil {
+		t.Fatalf("since failed, %s", err)
+	}
+	if len(records) != 1 || records[0].event.Type != discovery.Update || records[0].event.Service.Nodes[0].Address != "addr2" {
+		t.Fatalf("got records %+v, want one update event", records)
+	}
+
+	// 其他注册器不能覆盖或删除
+	if err := s.put(other, "svc", node, 3, time.Minute); err == nil {
+		t.Fatal("put node owned by other registry succeeded")
+	}
+	if s.del(other, "svc", node.Id) {
+		t.Fatal("delete node owned by other registry succeeded")
+	}
+	if err := s.keepAlive(other, getNodePath("svc", node.Id)); !errors.Is(err, ErrLeaseNotFound) {
+		t.Fatalf("keep alive node owned by other registry returned %v, want %v", err, ErrLeaseNotFound)
+	}
+
+	if !s.del(owner, "svc", node.Id) {
+		t.Fatal("delete node failed")
+	}
+	if _, err := s.GetServiceNode("svc", node.Id); !errors.Is(err, discovery.ErrNotFound) {
+		t.Fatalf("get deleted node returned %v, want %v", err, discovery.ErrNotFound)
+	}
+}
+
+func TestStoreGetService(t *testing.T) {
+	s := NewStore()
+	owner := &_Registry{}
+
+	for _, id := range []uid.Id{"node3", "node1", "node2"} {
+		if err := s.put(owner, "svc", &discovery.Node{Id: id}, 0, time.Minute); err != nil {
+			t.Fatalf("put failed, %s", err)
+		}
+	}
+	if err := s.put(owner, "other", &discovery.Node{Id: "node4"}, 0, time.Minute); err != nil {
+		t.Fatalf("put failed, %s", err)
+	}
+
+	service, err := s.GetService("svc")
+	if err != nil {
+		t.Fatalf("get service failed, %s", err)
+	}
+	if len(service.Nodes) != 3 || service.Nodes[0].Id != "node1" || service.Nodes[2].Id != "node3" {
+		t.Fatalf("got nodes %+v, want sorted node1..node3", service.Nodes)
+	}
+
+	if services := s.ListServices(); len(services) != 2 {
+		t.Fatalf("list services returned %d services, want 2", len(services))
+	}
+
+	if _, err := s.GetService("none"); !errors.Is(err, discovery.ErrNotFound) {
+		t.Fatalf("get not existed service returned %v, want %v", err, discovery.ErrNotFound)
+	}
+}
+
+func TestStoreExpire(t *testing.T) {
+	s := NewStore()
+	owner := &_Registry{}
+	node := &discovery.Node{Id: uid.Id("node1")}
+
+	if err := s.put(owner, "svc", node, 0, 20*time.Millisecond); err != nil {
+		t.Fatalf("put failed, %s", err)
+	}
+
+	_, changed, _ := s.since(s.currentRevision() + 1)
+
+	time.Sleep(30 * time.Millisecond)
+
+	// 过期的节点查询不到，续约失败
+	if _, err := s.GetServiceNode("svc", node.Id); !errors.Is(err, discovery.ErrNotFound) {
+		t.Fatalf("get expired node returned %v, want %v", err, discovery.ErrNotFound)
+	}
+	if err := s.keepAlive(owner, getNodePath("svc", node.Id)); !errors.Is(err, ErrLeaseNotFound) {
+		t.Fatalf("keep alive expired node returned %v, want %v", err, ErrLeaseNotFound)
+	}
+
+	// 过期时产生删除事件，并通知监听器
+	select {
+	case <-changed:
+	default:
+		t.Fatal("watchers not notified")
+	}
+
+	records, _, err := s.since(2)
+	if err != nil {
+		t.Fatalf("since failed, %s", err)
+	}
+	if len(records) != 1 || records[0].event.Type != discovery.Delete {
+		t.Fatalf("got records %+v, want one delete event", records)
+	}
+}
+
+func TestStoreCompacted(t *testing.T) {
+	s := NewStore()
+	owner := &_Registry{}
+	node := &discovery.Node{Id: uid.Id("node1")}
+
+	for i := range historyLimit + 10 {
+		if err := s.put(owner, "svc", node, uint64(i), time.Minute); err != nil {
+			t.Fatalf("put failed, %s", err)
+		}
+	}
+
+	if _, _, err := s.since(1); !errors.Is(err, ErrCompacted) {
+		t.Fatalf("since compacted revision returned %v, want %v", err, ErrCompacted)
+	}
+
+	records, _, err := s.since(s.currentRevision())
+	if err != nil {
+		t.Fatalf("since failed, %s", err)
+	}
+	if len(records) != 1 || records[0].revision != s.currentRevision() {
+		t.Fatalf("got %d records, want the latest one", len(records))
+	}
+}
@@ -0,0 +1,137 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package memory_discovery
+
+import (
+	"context"
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/framework/addins/discovery"
+	"git.golaxy.org/framework/addins/log"
+)
+
+func (r *_Registry) newWatcher(ctx context.Context, pattern string, revision ...int64) (*_Watcher, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	// 未指定版本号时，只监听新产生的事件
+	var next int64
+	if len(revision) > 0 && revision[0] > 0 {
+		next = revision[0]
+	} else {
+		next = r.store.currentRevision() + 1
+	}
+
+	watcher := &_Watcher{
+		registry:   r,
+		ctx:        ctx,
+		terminate:  cancel,
+		terminated: async.MakeAsyncRet(),
+		pattern:    pattern,
+		revision:   next,
+		eventChan:  make(chan *discovery.Event, r.options.WatchChanSize),
+	}
+
+	go watcher.mainLoop()
+
+	return watcher, nil
+}
+
+type _Watcher struct {
+	registry   *_Registry
+	ctx        context.Context
+	terminate  context.CancelFunc
+	terminated chan async.Ret
+	pattern    string
+	revision   int64
+	eventChan  chan *discovery.Event
+}
+
+// Pattern watching pattern
+func (w *_Watcher) Pattern() string {
+	return w.pattern
+}
+
+// Next is a blocking call
+func (w *_Watcher) Next() (*discovery.Event, error) {
+	for event := range w.eventChan {
+		return event, nil
+	}
+	return nil, discovery.ErrTerminated
+}
+
+// Terminate stop watching
+func (w *_Watcher) Terminate() async.AsyncRet {
+	w.terminate()
+	return w.terminated
+}
+
+// Terminated stopped notify
+func (w *_Watcher) Terminated() async.AsyncRet {
+	return w.terminated
+}
+
+func (w *_Watcher) mainLoop() {
+	defer func() {
+		w.terminate()
+		close(w.eventChan)
+		async.Return(w.terminated, async.VoidRet)
+	}()
+
+	log.Debugf(w.registry.svcCtx, "start watch %q", w.pattern)
+
+	for {
+		records, changed, err := w.registry.store.since(w.revision)
+		if err != nil {
+			log.Errorf(w.registry.svcCtx, "interrupt watch %q, %s", w.pattern, err)
+			return
+		}
+
+		for _, record := range records {
+			w.revision = record.revision + 1
+
+			if w.pattern != "" && record.event.Service.Name != w.pattern {
+				continue
+			}
+
+			select {
+			case w.eventChan <- record.event.DeepCopy():
+			case <-w.ctx.Done():
+				log.Debugf(w.registry.svcCtx, "stop watch %q", w.pattern)
+				return
+			case <-w.registry.ctx.Done():
+				log.Debugf(w.registry.svcCtx, "stop watch %q", w.pattern)
+				return
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-w.ctx.Done():
+			log.Debugf(w.registry.svcCtx, "stop watch %q", w.pattern)
+			return
+		case <-w.registry.ctx.Done():
+			log.Debugf(w.registry.svcCtx, "stop watch %q", w.pattern)
+			return
+		}
+	}
+}
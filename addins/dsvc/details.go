@@ -24,9 +24,13 @@ import (
 	"git.golaxy.org/core"
 	"git.golaxy.org/core/utils/uid"
 	"git.golaxy.org/framework/net/netpath"
+	"strings"
 	"unique"
 )
 
+// BalanceKeySep 负载均衡地址中哈希key的分隔符
+const BalanceKeySep = "#"
+
 // NodeDetails 服务节点地址信息
 type NodeDetails struct {
 	netpath.NodeDetails
@@ -47,6 +51,24 @@ func (d *NodeDetails) MakeBalanceAddr(service string) string {
 	return unique.Make(d.DomainBalance.Join(service)).Value()
 }
 
+// MakeHashBalanceAddr 创建服务一致性哈希负载均衡地址，RPC投递器使用一致性哈希负载均衡器时，相同key的请求投递至相同的服务节点
+func (d *NodeDetails) MakeHashBalanceAddr(service, key string) string {
+	if key == "" {
+		return d.MakeBalanceAddr(service)
+	}
+	return d.DomainBalance.Join(service) + BalanceKeySep + key
+}
+
+// ParseBalanceAddr 解析服务负载均衡地址，返回服务名称与哈希key
+func (d *NodeDetails) ParseBalanceAddr(addr string) (service, key string, ok bool) {
+	service, ok = d.DomainBalance.Relative(addr)
+	if !ok {
+		return "", "", false
+	}
+	service, key, _ = strings.Cut(service, BalanceKeySep)
+	return service, key, true
+}
+
 // MakeNodeAddr 创建服务节点地址
 func (d *NodeDetails) MakeNodeAddr(nodeId uid.Id) (string, error) {
 	if nodeId.IsNil() {
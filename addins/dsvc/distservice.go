@@ -33,11 +33,13 @@ import (
 	"git.golaxy.org/framework/addins/discovery"
 	"git.golaxy.org/framework/addins/dsync"
 	"git.golaxy.org/framework/addins/log"
+	"git.golaxy.org/framework/addins/metrics"
 	"git.golaxy.org/framework/net/gap"
 	"git.golaxy.org/framework/net/gap/codec"
 	"git.golaxy.org/framework/net/netpath"
 	"git.golaxy.org/framework/utils/concurrent"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unique"
 )
@@ -68,21 +70,24 @@ func newDistService(setting ...option.Setting[DistServiceOptions]) IDistService
 }
 
 type _DistService struct {
-	svcCtx       service.Context
-	ctx          context.Context
-	terminate    context.CancelFunc
-	wg           sync.WaitGroup
-	options      DistServiceOptions
-	registry     discovery.IRegistry
-	broker       broker.IBroker
-	dsync        dsync.IDistSync
-	details      *NodeDetails
-	encoder      codec.Encoder
-	decoder      codec.Decoder
-	futures      *concurrent.Futures
-	deduplicator *concurrent.Deduplicator
-	msgWatchers  concurrent.LockedSlice[*_MsgWatcher]
-	sendMutex    sync.Mutex
+	svcCtx          service.Context
+	ctx             context.Context
+	terminate       context.CancelFunc
+	wg              sync.WaitGroup
+	options         DistServiceOptions
+	registry        discovery.IRegistry
+	broker          broker.IBroker
+	dsync           dsync.IDistSync
+	details         *NodeDetails
+	encoder         codec.Encoder
+	decoder         codec.Decoder
+	futures         *concurrent.Futures
+	deduplicator    *concurrent.Deduplicator
+	msgWatchers     concurrent.LockedSlice[*_MsgWatcher]
+	sendMutex       sync.Mutex
+	chunkAssembler  *_ChunkAssembler
+	chunkTransferId atomic.Int64
+	cancelMetric    func()
 }
 
 // Init 初始化插件
@@ -97,19 +102,31 @@ func (d *_DistService) Init(svcCtx service.Context, _ runtime.Context) {
 	d.broker = broker.Using(d.svcCtx)
 	d.dsync = dsync.Using(d.svcCtx)
 
-	// 初始化消息包编解码器
-	d.decoder = codec.MakeDecoder(d.options.DecoderMsgCreator)
-	d.encoder = codec.MakeEncoder()
+	// 初始化消息包编解码器，解压缩后的消息长度不能超过单个消息包或分块重组的上限
+	d.decoder = codec.MakeLimitedDecoder(d.options.DecoderMsgCreator, max(int(d.broker.GetMaxPayload()), d.options.ChunkMemoryLimit))
+	d.encoder = codec.MakeCompressionEncoder(d.options.Compression, d.options.CompressedSize)
 
 	// 初始化异步模型Future
 	d.futures = concurrent.NewFutures(d.ctx, d.options.FutureTimeout)
 
+	// 统计未完成的Future数量
+	if m, ok := metrics.Lookup(d.svcCtx); ok {
+		d.cancelMetric = m.OnCollect(func() {
+			m.SetFuturesOutstanding(d.futures.Count())
+		})
+	}
+
 	// 初始化消息去重器
 	d.deduplicator = concurrent.NewDeduplicator()
 
 	// 初始化监听器
 	d.msgWatchers = concurrent.MakeLockedSlice[*_MsgWatcher](0, 0)
 
+	// 初始化分块重组器
+	d.chunkAssembler = newChunkAssembler(d.options.ChunkTimeout, d.options.ChunkMemoryLimit, int(d.broker.GetMaxPayload()), func(src string, transferId int64) {
+		log.Warnf(d.svcCtx, "chunk transfer timeout, src:%q, transferId:%d", src, transferId)
+	})
+
 	// 初始化地址信息
 	details := &NodeDetails{}
 	sep := d.broker.GetSeparator()
@@ -206,6 +223,10 @@ func (d *_DistService) Shut(svcCtx service.Context, _ runtime.Context) {
 
 	d.terminate()
 	d.wg.Wait()
+
+	if d.cancelMetric != nil {
+		d.cancelMetric()
+	}
 }
 
 // GetNodeDetails 获取节点地址信息
@@ -233,16 +254,19 @@ func (d *_DistService) SendMsg(dst string, msg gap.Msg) error {
 		seq = d.deduplicator.Make()
 	}
 
-	mpBuf, err := d.encoder.Encode(
-		gap.Origin{Svc: d.svcCtx.GetName(), Addr: d.details.LocalAddr, Timestamp: time.Now().UnixMilli()},
-		seq,
-		msg,
-	)
+	src := gap.Origin{Svc: d.svcCtx.GetName(), Addr: d.details.LocalAddr, Timestamp: time.Now().UnixMilli()}
+
+	mpBuf, err := d.encoder.Encode(src, seq, msg)
 	if err != nil {
 		return err
 	}
 	defer mpBuf.Release()
 
+	// 消息包超过传输上限，分块发送
+	if maxPayload := d.broker.GetMaxPayload(); maxPayload > 0 && int64(len(mpBuf.Data())) > maxPayload {
+		return d.publishChunks(dst, src, mpBuf.Data(), int(maxPayload))
+	}
+
 	return d.broker.Publish(d.ctx, dst, mpBuf.Data())
 }
 
@@ -0,0 +1,228 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dsvc
+
+import (
+	"errors"
+	"fmt"
+	"git.golaxy.org/framework/net/gap"
+	"git.golaxy.org/framework/net/gap/codec"
+	"git.golaxy.org/framework/utils/binaryutil"
+	"math"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+var (
+	ErrChunkInvalid     = errors.New("dsvc: invalid chunk")                         // 分块无效
+	ErrChunkMemoryLimit = errors.New("dsvc: chunk reassembly memory limit reached") // 分块重组内存达到上限
+	ErrPayloadTooLarge  = errors.New("dsvc: payload too large")                     // 消息包超过传输上限且无法分块
+)
+
+type _ChunkKey struct {
+	src        string
+	transferId int64
+}
+
+// chunkRefSize 分块引用占用的内存，分块总数由发送方指定，需要计入重组内存
+const chunkRefSize = int(unsafe.Sizeof([]byte(nil)))
+
+type _ChunkTransfer struct {
+	chunks   [][]byte
+	received int64
+	size     int
+	timer    *time.Timer
+}
+
+// _ChunkAssembler 分块重组器
+type _ChunkAssembler struct {
+	timeout     time.Duration
+	memoryLimit int
+	maxPayload  int
+	expired     func(src string, transferId int64)
+	mutex       sync.Mutex
+	transfers   map[_ChunkKey]*_ChunkTransfer
+	size        int
+}
+
+// newChunkAssembler 创建分块重组器，分块传输超时未到齐时丢弃已接收的分块并回调expired
+func newChunkAssembler(timeout time.Duration, memoryLimit, maxPayload int, expired func(src string, transferId int64)) *_ChunkAssembler {
+	return &_ChunkAssembler{
+		timeout:     timeout,
+		memoryLimit: memoryLimit,
+		maxPayload:  maxPayload,
+		expired:     expired,
+		transfers:   map[_ChunkKey]*_ChunkTransfer{},
+	}
+}
+
+// Push 添加分块，全部分块到齐后返回重组的消息包数据
+func (a *_ChunkAssembler) Push(src string, chunk *gap.MsgChunk) ([]byte, error) {
+	if chunk.Total <= 0 || chunk.Index < 0 || chunk.Index >= chunk.Total || chunk.Total > a.maxTotal() ||
+		(a.maxPayload > 0 && len(chunk.Data) > a.maxPayload) {
+		return nil, fmt.Errorf("%w: src:%q, transferId:%d, index:%d, total:%d, size:%d", ErrChunkInvalid, src, chunk.TransferId, chunk.Index, chunk.Total, len(chunk.Data))
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	key := _ChunkKey{src: src, transferId: chunk.TransferId}
+
+	transfer, ok := a.transfers[key]
+	if !ok {
+		// 分块引用计入重组内存，避免伪造的分块总数占用过多内存
+		refSize := int(chunk.Total) * chunkRefSize
+		if a.size+refSize > a.memoryLimit {
+			return nil, fmt.Errorf("%w: src:%q, transferId:%d", ErrChunkMemoryLimit, src, chunk.TransferId)
+		}
+
+		transfer = &_ChunkTransfer{
+			chunks: make([][]byte, chunk.Total),
+			size:   refSize,
+		}
+		a.size += refSize
+
+		transfer.timer = time.AfterFunc(a.timeout, func() {
+			if a.remove(key, transfer) && a.expired != nil {
+				a.expired(key.src, key.transferId)
+			}
+		})
+		a.transfers[key] = transfer
+	}
+
+	if int64(len(transfer.chunks)) != chunk.Total {
+		a.removeLocked(key, transfer)
+		return nil, fmt.Errorf("%w: src:%q, transferId:%d, total mismatch", ErrChunkInvalid, src, chunk.TransferId)
+	}
+
+	// 重复的分块直接丢弃
+	if transfer.chunks[chunk.Index] != nil {
+		return nil, nil
+	}
+
+	if a.size+len(chunk.Data) > a.memoryLimit {
+		a.removeLocked(key, transfer)
+		return nil, fmt.Errorf("%w: src:%q, transferId:%d", ErrChunkMemoryLimit, src, chunk.TransferId)
+	}
+
+	// 分块数据引用接收缓存，需要拷贝
+	data := make([]byte, len(chunk.Data))
+	copy(data, chunk.Data)
+
+	transfer.chunks[chunk.Index] = data
+	transfer.received++
+	transfer.size += len(data)
+	a.size += len(data)
+
+	if transfer.received < chunk.Total {
+		return nil, nil
+	}
+
+	a.removeLocked(key, transfer)
+
+	mpData := make([]byte, 0, transfer.size-len(transfer.chunks)*chunkRefSize)
+	for _, data := range transfer.chunks {
+		mpData = append(mpData, data...)
+	}
+
+	return mpData, nil
+}
+
+// maxTotal 分块总数上限，重组内存上限可容纳的最大长度分块的数量
+func (a *_ChunkAssembler) maxTotal() int64 {
+	if a.maxPayload <= 0 {
+		return int64(a.memoryLimit / chunkRefSize)
+	}
+	return int64((a.memoryLimit + a.maxPayload - 1) / a.maxPayload)
+}
+
+func (a *_ChunkAssembler) remove(key _ChunkKey, transfer *_ChunkTransfer) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.removeLocked(key, transfer)
+}
+
+func (a *_ChunkAssembler) removeLocked(key _ChunkKey, transfer *_ChunkTransfer) bool {
+	if a.transfers[key] != transfer {
+		return false
+	}
+	transfer.timer.Stop()
+	delete(a.transfers, key)
+	a.size -= transfer.size
+	return true
+}
+
+// publishChunks 拆分消息包为多个分块发送
+func (d *_DistService) publishChunks(dst string, src gap.Origin, mpData []byte, maxPayload int) error {
+	// 负载均衡地址的各个分块可能投递至不同的服务节点，无法重组
+	if d.details.DomainBalance.Contains(dst) {
+		return fmt.Errorf("%w: dst:%q is a balance address, size:%d, max-payload:%d", ErrPayloadTooLarge, dst, len(mpData), maxPayload)
+	}
+
+	// 超过重组内存上限的消息包，接收方无法重组
+	if len(mpData) > d.options.ChunkMemoryLimit {
+		return fmt.Errorf("%w: size:%d, chunk-memory-limit:%d", ErrPayloadTooLarge, len(mpData), d.options.ChunkMemoryLimit)
+	}
+
+	return encodeChunks(src, d.chunkTransferId.Add(1), mpData, maxPayload, func(chunkData []byte) error {
+		return d.broker.Publish(d.ctx, dst, chunkData)
+	})
+}
+
+// encodeChunks 拆分消息包为多个分块，逐个编码后回调，编码的分块数据在回调结束后回收
+func encodeChunks(src gap.Origin, transferId int64, mpData []byte, maxPayload int, fun func(chunkData []byte) error) error {
+	// 计算分块数据最大长度，分块序号与总数按最大值估算
+	overhead := gap.MsgPacket{
+		Head: gap.MsgHead{MsgId: gap.MsgId_Chunk, Src: src},
+		Msg:  &gap.MsgChunk{TransferId: transferId, Index: math.MaxInt32, Total: math.MaxInt32},
+	}.Size() + binaryutil.SizeofUvarint(uint64(maxPayload))
+
+	chunkSize := maxPayload - overhead
+	if chunkSize <= 0 {
+		return fmt.Errorf("%w: size:%d, max-payload:%d", ErrPayloadTooLarge, len(mpData), maxPayload)
+	}
+
+	total := int64((len(mpData) + chunkSize - 1) / chunkSize)
+	encoder := codec.MakeEncoder()
+
+	for i := int64(0); i < total; i++ {
+		chunk := &gap.MsgChunk{
+			TransferId: transferId,
+			Index:      i,
+			Total:      total,
+			Data:       mpData[i*int64(chunkSize) : min((i+1)*int64(chunkSize), int64(len(mpData)))],
+		}
+
+		if err := func() error {
+			chunkBuf, err := encoder.Encode(src, 0, chunk)
+			if err != nil {
+				return err
+			}
+			defer chunkBuf.Release()
+
+			return fun(chunkBuf.Data())
+		}(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,257 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dsvc
+
+import (
+	"bytes"
+	"errors"
+	"git.golaxy.org/framework/net/gap"
+	"git.golaxy.org/framework/net/gap/codec"
+	"git.golaxy.org/framework/net/netpath"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// splitChunks 编码消息包并拆分为分块
+func splitChunks(t *testing.T, payload []byte, maxPayload int) ([]byte, []*gap.MsgChunk) {
+	t.Helper()
+
+	src := gap.Origin{Svc: "svc", Addr: "svc.node1", Timestamp: time.Now().UnixMilli()}
+
+	mpBuf, err := codec.MakeEncoder().Encode(src, 5, &gap.MsgForward{Dst: "dst", TransData: payload})
+	if err != nil {
+		t.Fatalf("encode failed, %s", err)
+	}
+	mpData := bytes.Clone(mpBuf.Data())
+	mpBuf.Release()
+
+	var chunks []*gap.MsgChunk
+	err = encodeChunks(src, 1, mpData, maxPayload, func(chunkData []byte) error {
+		if len(chunkData) > maxPayload {
+			t.Fatalf("got chunk size %d, want at most %d", len(chunkData), maxPayload)
+		}
+
+		// 分块数据在回调结束后回收，需要拷贝
+		mp, err := codec.DefaultDecoder().Decode(bytes.Clone(chunkData))
+		if err != nil {
+			t.Fatalf("decode chunk failed, %s", err)
+		}
+		if mp.Head.MsgId != gap.MsgId_Chunk || mp.Head.Src != src {
+			t.Fatalf("got chunk head %+v, want chunk from %+v", mp.Head, src)
+		}
+
+		chunks = append(chunks, mp.Msg.(*gap.MsgChunk))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("split failed, %s", err)
+	}
+
+	return mpData, chunks
+}
+
+func TestChunkRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for _, maxPayload := range []int{256, 4096} {
+		for _, size := range []int{5000, 100000} {
+			payload := make([]byte, size)
+			rnd.Read(payload)
+
+			mpData, chunks := splitChunks(t, payload, maxPayload)
+			if len(chunks) < 2 {
+				t.Fatalf("got %d chunks, want at least 2", len(chunks))
+			}
+
+			// 乱序到达，并且重复投递第一个分块
+			rnd.Shuffle(len(chunks), func(i, j int) { chunks[i], chunks[j] = chunks[j], chunks[i] })
+			chunks = append(chunks[:1], chunks...)
+
+			assembler := newChunkAssembler(time.Minute, 1<<20, maxPayload, nil)
+
+			var assembled []byte
+			for i, chunk := range chunks {
+				data, err := assembler.Push("svc.node1", chunk)
+				if err != nil {
+					t.Fatalf("push chunk failed, %s", err)
+				}
+				if data != nil {
+					if i != len(chunks)-1 {
+						t.Fatalf("got assembled data at chunk %d, want at last chunk %d", i, len(chunks)-1)
+					}
+					assembled = data
+				}
+			}
+
+			if !bytes.Equal(assembled, mpData) {
+				t.Fatalf("got assembled %d bytes, want %d bytes", len(assembled), len(mpData))
+			}
+
+			mp, err := codec.DefaultDecoder().Decode(assembled)
+			if err != nil {
+				t.Fatalf("decode assembled failed, %s", err)
+			}
+			if mp.Head.Seq != 5 || !bytes.Equal(mp.Msg.(*gap.MsgForward).TransData, payload) {
+				t.Fatalf("got assembled msg-packet mismatch, head:%+v", mp.Head)
+			}
+
+			if assembler.size != 0 || len(assembler.transfers) != 0 {
+				t.Fatalf("got size %d, transfers %d after assembled, want 0", assembler.size, len(assembler.transfers))
+			}
+		}
+	}
+}
+
+func TestChunkTransfersIsolated(t *testing.T) {
+	assembler := newChunkAssembler(time.Minute, 1<<20, 256, nil)
+
+	// 不同源地址的相同传输Id，不会互相干扰
+	for _, src := range []string{"svc.node1", "svc.node2"} {
+		if data, err := assembler.Push(src, &gap.MsgChunk{TransferId: 1, Index: 0, Total: 2, Data: []byte(src)}); err != nil || data != nil {
+			t.Fatalf("got %q, %v, want pending", data, err)
+		}
+	}
+	for _, src := range []string{"svc.node1", "svc.node2"} {
+		data, err := assembler.Push(src, &gap.MsgChunk{TransferId: 1, Index: 1, Total: 2, Data: []byte("!")})
+		if err != nil || string(data) != src+"!" {
+			t.Fatalf("got %q, %v, want %q", data, err, src+"!")
+		}
+	}
+}
+
+func TestChunkInvalid(t *testing.T) {
+	assembler := newChunkAssembler(time.Minute, 4096, 256, nil)
+
+	invalid := []*gap.MsgChunk{
+		{Index: 0, Total: 0},
+		{Index: -1, Total: 2},
+		{Index: 2, Total: 2},
+		{Index: 0, Total: 4096/256 + 1},
+		{Index: 0, Total: 2, Data: make([]byte, 257)},
+	}
+
+	for _, chunk := range invalid {
+		if _, err := assembler.Push("svc.node1", chunk); !errors.Is(err, ErrChunkInvalid) {
+			t.Fatalf("got push %+v error %v, want %v", chunk, err, ErrChunkInvalid)
+		}
+	}
+
+	// 相同传输的分块总数不一致时，丢弃整个传输
+	if _, err := assembler.Push("svc.node1", &gap.MsgChunk{TransferId: 1, Index: 0, Total: 2, Data: []byte("a")}); err != nil {
+		t.Fatalf("push chunk failed, %s", err)
+	}
+	if _, err := assembler.Push("svc.node1", &gap.MsgChunk{TransferId: 1, Index: 1, Total: 3, Data: []byte("b")}); !errors.Is(err, ErrChunkInvalid) {
+		t.Fatalf("got push error %v, want %v", err, ErrChunkInvalid)
+	}
+	if assembler.size != 0 || len(assembler.transfers) != 0 {
+		t.Fatalf("got size %d, transfers %d, want 0", assembler.size, len(assembler.transfers))
+	}
+}
+
+func TestChunkMemoryLimit(t *testing.T) {
+	assembler := newChunkAssembler(time.Minute, 1024, 512, nil)
+
+	if _, err := assembler.Push("svc.node1", &gap.MsgChunk{TransferId: 1, Index: 0, Total: 2, Data: make([]byte, 512)}); err != nil {
+		t.Fatalf("push chunk failed, %s", err)
+	}
+
+	// 超过重组内存上限时，丢弃整个传输
+	if _, err := assembler.Push("svc.node2", &gap.MsgChunk{TransferId: 1, Index: 0, Total: 2, Data: make([]byte, 512)}); !errors.Is(err, ErrChunkMemoryLimit) {
+		t.Fatalf("got push error %v, want %v", err, ErrChunkMemoryLimit)
+	}
+	if len(assembler.transfers) != 1 || assembler.size != 512+2*chunkRefSize {
+		t.Fatalf("got size %d, transfers %d, want %d, 1", assembler.size, len(assembler.transfers), 512+2*chunkRefSize)
+	}
+
+	// 未限制传输上限时，分块引用占用的内存计入重组内存
+	assembler = newChunkAssembler(time.Minute, 1024, 0, nil)
+
+	maxTotal := int64(1024 / chunkRefSize)
+	if _, err := assembler.Push("svc.node1", &gap.MsgChunk{TransferId: 1, Index: 0, Total: maxTotal + 1}); !errors.Is(err, ErrChunkInvalid) {
+		t.Fatalf("got push error %v, want %v", err, ErrChunkInvalid)
+	}
+	if _, err := assembler.Push("svc.node1", &gap.MsgChunk{TransferId: 1, Index: 0, Total: maxTotal}); err != nil {
+		t.Fatalf("push chunk failed, %s", err)
+	}
+	if _, err := assembler.Push("svc.node2", &gap.MsgChunk{TransferId: 1, Index: 0, Total: 2}); !errors.Is(err, ErrChunkMemoryLimit) {
+		t.Fatalf("got push error %v, want %v", err, ErrChunkMemoryLimit)
+	}
+}
+
+func TestChunkTimeout(t *testing.T) {
+	expired := make(chan _ChunkKey, 1)
+
+	assembler := newChunkAssembler(50*time.Millisecond, 1<<20, 256, func(src string, transferId int64) {
+		expired <- _ChunkKey{src: src, transferId: transferId}
+	})
+
+	if _, err := assembler.Push("svc.node1", &gap.MsgChunk{TransferId: 7, Index: 0, Total: 2, Data: []byte("a")}); err != nil {
+		t.Fatalf("push chunk failed, %s", err)
+	}
+
+	select {
+	case key := <-expired:
+		if key.src != "svc.node1" || key.transferId != 7 {
+			t.Fatalf("got expired %+v, want svc.node1 7", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("chunk transfer not expired")
+	}
+
+	assembler.mutex.Lock()
+	size, transfers := assembler.size, len(assembler.transfers)
+	assembler.mutex.Unlock()
+
+	if size != 0 || transfers != 0 {
+		t.Fatalf("got size %d, transfers %d after expired, want 0", size, transfers)
+	}
+
+	// 超时后收到的分块开始新的传输
+	data, err := assembler.Push("svc.node1", &gap.MsgChunk{TransferId: 7, Index: 1, Total: 2, Data: []byte("b")})
+	if err != nil || data != nil {
+		t.Fatalf("got %q, %v, want pending", data, err)
+	}
+}
+
+func TestPublishChunksRejected(t *testing.T) {
+	details := &NodeDetails{}
+	details.DomainBalance = netpath.Domain{Path: "svc.balance", Sep: "."}
+
+	d := &_DistService{
+		details: details,
+		options: DistServiceOptions{ChunkMemoryLimit: 1024},
+	}
+
+	// 负载均衡地址无法重组分块
+	if err := d.publishChunks("svc.balance.echo", gap.Origin{}, make([]byte, 512), 256); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("got publish error %v, want %v", err, ErrPayloadTooLarge)
+	}
+
+	// 超过重组内存上限
+	if err := d.publishChunks("svc.node.1", gap.Origin{}, make([]byte, 2048), 256); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("got publish error %v, want %v", err, ErrPayloadTooLarge)
+	}
+
+	// 传输上限无法容纳分块
+	if err := encodeChunks(gap.Origin{}, 1, make([]byte, 512), 8, func([]byte) error { return nil }); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("got encode error %v, want %v", err, ErrPayloadTooLarge)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"git.golaxy.org/core/utils/generic"
 	"git.golaxy.org/core/utils/option"
 	"git.golaxy.org/framework/net/gap"
+	"git.golaxy.org/framework/net/gtp"
 	"time"
 )
 
@@ -41,6 +42,10 @@ type DistServiceOptions struct {
 	RefreshTTL        bool              // 主动刷新服务信息TTL
 	FutureTimeout     time.Duration     // 异步模型Future超时时间
 	DecoderMsgCreator gap.IMsgCreator   // 消息包解码器的消息构建器
+	Compression       gtp.Compression   // 消息包压缩算法
+	CompressedSize    int               // 消息包启用压缩阀值（字节），<=0表示不开启
+	ChunkTimeout      time.Duration     // 分块传输重组超时时间
+	ChunkMemoryLimit  int               // 分块传输重组占用内存上限（字节）
 	RecvMsgHandler    RecvMsgHandler    // 接收消息的处理器（优先级低于监控器）
 }
 
@@ -57,6 +62,9 @@ func (_Option) Default() option.Setting[DistServiceOptions] {
 		With.TTL(0, false)(options)
 		With.FutureTimeout(5 * time.Second)(options)
 		With.DecoderMsgCreator(gap.DefaultMsgCreator())(options)
+		With.Compression(gtp.Compression_None, 0)(options)
+		With.ChunkTimeout(30 * time.Second)(options)
+		With.ChunkMemoryLimit(64 * 1024 * 1024)(options)
 		With.RecvMsgHandler(nil)(options)
 	}
 }
@@ -110,6 +118,34 @@ func (_Option) DecoderMsgCreator(mc gap.IMsgCreator) option.Setting[DistServiceO
 	}
 }
 
+// Compression 消息包压缩算法与启用压缩阀值（字节），<=0表示不开启
+func (_Option) Compression(compression gtp.Compression, compressedSize int) option.Setting[DistServiceOptions] {
+	return func(options *DistServiceOptions) {
+		options.Compression = compression
+		options.CompressedSize = compressedSize
+	}
+}
+
+// ChunkTimeout 分块传输重组超时时间
+func (_Option) ChunkTimeout(d time.Duration) option.Setting[DistServiceOptions] {
+	return func(options *DistServiceOptions) {
+		if d <= 0 {
+			exception.Panicf("%w: option ChunkTimeout can't be set to a value less equal 0", core.ErrArgs)
+		}
+		options.ChunkTimeout = d
+	}
+}
+
+// ChunkMemoryLimit 分块传输重组占用内存上限（字节）
+func (_Option) ChunkMemoryLimit(limit int) option.Setting[DistServiceOptions] {
+	return func(options *DistServiceOptions) {
+		if limit <= 0 {
+			exception.Panicf("%w: option ChunkMemoryLimit can't be set to a value less equal 0", core.ErrArgs)
+		}
+		options.ChunkMemoryLimit = limit
+	}
+}
+
 // RecvMsgHandler 接收消息的处理器
 func (_Option) RecvMsgHandler(handler RecvMsgHandler) option.Setting[DistServiceOptions] {
 	return func(options *DistServiceOptions) {
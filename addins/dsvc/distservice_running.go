@@ -26,6 +26,7 @@ import (
 	"git.golaxy.org/framework/addins/broker"
 	"git.golaxy.org/framework/addins/discovery"
 	"git.golaxy.org/framework/addins/log"
+	"git.golaxy.org/framework/net/gap"
 	"time"
 )
 
@@ -128,15 +129,34 @@ end:
 }
 
 func (d *_DistService) handleEvent(e broker.IEvent) error {
+	// 无法解码的消息包重新投递也无法解码，通知消息队列不再重新投递
 	mp, err := d.decoder.Decode(e.Message())
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", broker.ErrUnrecoverable, err)
+	}
+
+	// 分块传输，全部分块到齐后重组消息包
+	if mp.Head.MsgId == gap.MsgId_Chunk {
+		mpData, err := d.chunkAssembler.Push(mp.Head.Src.Addr, mp.Msg.(*gap.MsgChunk))
+		if err != nil {
+			return fmt.Errorf("%w: %w", broker.ErrUnrecoverable, err)
+		}
+		if mpData == nil {
+			return nil
+		}
+
+		mp, err = d.decoder.Decode(mpData)
+		if err != nil {
+			return fmt.Errorf("%w: %w", broker.ErrUnrecoverable, err)
+		}
 	}
 
 	// 最少一次交付模式，需要消息去重
 	if d.broker.GetDeliveryReliability() == broker.AtLeastOnce {
+		// 重复的消息包已经处理过，直接丢弃，不能返回错误，否则消息队列会一直重新投递
 		if !d.deduplicator.Validate(mp.Head.Src.Addr, mp.Head.Seq) {
-			return fmt.Errorf("gap: discard duplicate msg-packet, head:%+v", mp.Head)
+			log.Debugf(d.svcCtx, "discard duplicate msg-packet, head:%+v", mp.Head)
+			return nil
 		}
 	}
 
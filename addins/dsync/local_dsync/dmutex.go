@@ -0,0 +1,157 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package local_dsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"git.golaxy.org/core/utils/option"
+	"git.golaxy.org/framework/addins/dsync"
+	"git.golaxy.org/framework/addins/log"
+	"time"
+)
+
+var (
+	// ErrFailed is an error indicating that the lock could not be acquired after all tries.
+	ErrFailed = errors.New("failed to acquire lock")
+)
+
+type _DistMutexSettings struct {
+	dsync *_DistSync
+	name  string
+}
+
+// With applies additional settings to the distributed mutex.
+func (s *_DistMutexSettings) With(settings ...option.Setting[dsync.DistMutexOptions]) dsync.IDistMutex {
+	return s.dsync.NewMutex(s.name, settings...)
+}
+
+func (s *_DistSync) newMutex(name string, options dsync.DistMutexOptions) *_DistMutex {
+	log.Debugf(s.svcCtx, "new dist mutex %q", name)
+
+	return &_DistMutex{
+		dsync:   s,
+		name:    name,
+		options: options,
+		value:   options.Value,
+	}
+}
+
+type _DistMutex struct {
+	dsync   *_DistSync
+	name    string
+	options dsync.DistMutexOptions
+	value   string
+	until   time.Time
+}
+
+// Name returns mutex name.
+func (m *_DistMutex) Name() string {
+	return m.name
+}
+
+// Value returns the current random value. The value will be empty until a lock is acquired (or Value option is used).
+func (m *_DistMutex) Value() string {
+	return m.value
+}
+
+// Until returns the time of validity of acquired lock. The value will be zero value until a lock is acquired.
+func (m *_DistMutex) Until() time.Time {
+	return m.until
+}
+
+// Lock locks m. In case it returns an error on failure, you may retry to acquire the lock by calling this method again.
+func (m *_DistMutex) Lock(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	value, err := m.options.GenValueFunc()
+	if err != nil {
+		return fmt.Errorf("dsync: %w", err)
+	}
+
+	tries := max(m.options.Tries, 1)
+
+	for i := 0; i < tries; i++ {
+		if i != 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("dsync: %w", context.Cause(ctx))
+			case <-time.After(m.options.DelayFunc(i)):
+			}
+		}
+
+		start := time.Now()
+
+		if m.dsync.store.acquire(m.name, value, m.options.Expiry) {
+			now := time.Now()
+
+			m.value = value
+			m.until = now.Add(m.options.Expiry - now.Sub(start) - time.Duration(int64(float64(m.options.Expiry)*m.options.DriftFactor)))
+
+			log.Debugf(m.dsync.svcCtx, "dist mutex %q is locked", m.name)
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("dsync: %w", ErrFailed)
+}
+
+// Unlock unlocks m and returns the status of unlock.
+func (m *_DistMutex) Unlock(ctx context.Context) error {
+	if m.value == "" || !m.dsync.store.release(m.name, m.value) {
+		return dsync.ErrNotAcquired
+	}
+
+	m.until = time.Time{}
+
+	log.Debugf(m.dsync.svcCtx, "dist mutex %q is unlocked", m.name)
+
+	return nil
+}
+
+// Extend resets the mutex's expiry and returns the status of expiry extension.
+func (m *_DistMutex) Extend(ctx context.Context) error {
+	start := time.Now()
+
+	if m.value == "" || !m.dsync.store.extend(m.name, m.value, m.options.Expiry) {
+		return dsync.ErrNotAcquired
+	}
+
+	now := time.Now()
+	m.until = now.Add(m.options.Expiry - now.Sub(start) - time.Duration(int64(float64(m.options.Expiry)*m.options.DriftFactor)))
+
+	log.Debugf(m.dsync.svcCtx, "dist mutex %q is extended", m.name)
+
+	return nil
+}
+
+// Valid returns true if the lock acquired through m is still valid. It may
+// also return true erroneously if quorum is achieved during the call and at
+// least one node then takes long enough to respond for the lock to expire.
+func (m *_DistMutex) Valid(ctx context.Context) (bool, error) {
+	if m.value == "" {
+		return false, nil
+	}
+	return m.dsync.store.valid(m.name, m.value), nil
+}
@@ -0,0 +1,86 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package local_dsync
+
+import (
+	"fmt"
+	"git.golaxy.org/core/runtime"
+	"git.golaxy.org/core/service"
+	"git.golaxy.org/core/utils/option"
+	"git.golaxy.org/framework/addins/dsync"
+	"git.golaxy.org/framework/addins/log"
+	"git.golaxy.org/framework/net/netpath"
+)
+
+func newDSync(settings ...option.Setting[DSyncOptions]) dsync.IDistSync {
+	return &_DistSync{
+		options: option.Make(With.Default(), settings...),
+	}
+}
+
+type _DistSync struct {
+	svcCtx  service.Context
+	options DSyncOptions
+	store   *Store
+}
+
+// Init 初始化插件
+func (s *_DistSync) Init(svcCtx service.Context, _ runtime.Context) {
+	log.Infof(svcCtx, "init addin %q", self.Name)
+
+	s.svcCtx = svcCtx
+
+	if s.options.Store == nil {
+		s.store = DefaultStore()
+	} else {
+		s.store = s.options.Store
+	}
+}
+
+// Shut 关闭插件
+func (s *_DistSync) Shut(svcCtx service.Context, _ runtime.Context) {
+	log.Infof(svcCtx, "shut addin %q", self.Name)
+}
+
+// NewMutex returns a new distributed mutex with given name.
+func (s *_DistSync) NewMutex(name string, settings ...option.Setting[dsync.DistMutexOptions]) dsync.IDistMutex {
+	return s.newMutex(name, option.Make(dsync.With.Default(), settings...))
+}
+
+// NewMutexf returns a new distributed mutex using a formatted string.
+func (s *_DistSync) NewMutexf(format string, args ...any) dsync.IDistMutexSettings {
+	return &_DistMutexSettings{
+		dsync: s,
+		name:  fmt.Sprintf(format, args...),
+	}
+}
+
+// NewMutexp returns a new distributed mutex using elements.
+func (s *_DistSync) NewMutexp(elems ...string) dsync.IDistMutexSettings {
+	return &_DistMutexSettings{
+		dsync: s,
+		name:  netpath.Join(s.GetSeparator(), elems...),
+	}
+}
+
+// GetSeparator return name path separator.
+func (s *_DistSync) GetSeparator() string {
+	return ":"
+}
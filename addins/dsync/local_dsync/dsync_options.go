@@ -0,0 +1,47 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package local_dsync
+
+import (
+	"git.golaxy.org/core/utils/option"
+)
+
+// DSyncOptions contains various options for configuring in-process distributed locking.
+type DSyncOptions struct {
+	Store *Store
+}
+
+var With _Option
+
+type _Option struct{}
+
+// Default sets default values for DSyncOptions.
+func (_Option) Default() option.Setting[DSyncOptions] {
+	return func(options *DSyncOptions) {
+		With.Store(nil)(options)
+	}
+}
+
+// Store sets the in-process lock store for DSyncOptions. Mutexes with the same name in the same store exclude each other. If nil, the default store is used.
+func (_Option) Store(store *Store) option.Setting[DSyncOptions] {
+	return func(o *DSyncOptions) {
+		o.Store = store
+	}
+}
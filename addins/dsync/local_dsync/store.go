@@ -0,0 +1,131 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package local_dsync
+
+import (
+	"sync"
+	"time"
+)
+
+var defaultStore = NewStore()
+
+// DefaultStore returns the process-wide default lock store, it is used by dsync add-ins that are not given a store explicitly.
+func DefaultStore() *Store {
+	return defaultStore
+}
+
+// NewStore creates an in-process lock store.
+func NewStore() *Store {
+	return &Store{
+		locks: map[string]*_Lock{},
+	}
+}
+
+type _Lock struct {
+	value    string
+	expireAt time.Time
+}
+
+// Store is an in-process lock store. It can be shared by all services in one process.
+type Store struct {
+	mutex     sync.Mutex
+	locks     map[string]*_Lock
+	lastSweep time.Time
+}
+
+func (s *Store) acquire(name, value string, expiry time.Duration) bool {
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sweepLocked(now)
+
+	lock, ok := s.locks[name]
+	if ok && now.Before(lock.expireAt) {
+		return false
+	}
+
+	s.locks[name] = &_Lock{
+		value:    value,
+		expireAt: now.Add(expiry),
+	}
+
+	return true
+}
+
+func (s *Store) release(name, value string) bool {
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	lock, ok := s.locks[name]
+	if !ok || lock.value != value {
+		return false
+	}
+
+	delete(s.locks, name)
+
+	return now.Before(lock.expireAt)
+}
+
+func (s *Store) extend(name, value string, expiry time.Duration) bool {
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	lock, ok := s.locks[name]
+	if !ok || lock.value != value || !now.Before(lock.expireAt) {
+		return false
+	}
+
+	lock.expireAt = now.Add(expiry)
+
+	return true
+}
+
+func (s *Store) valid(name, value string) bool {
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	lock, ok := s.locks[name]
+	if !ok || lock.value != value {
+		return false
+	}
+
+	return now.Before(lock.expireAt)
+}
+
+func (s *Store) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Second {
+		return
+	}
+	s.lastSweep = now
+
+	for name, lock := range s.locks {
+		if !now.Before(lock.expireAt) {
+			delete(s.locks, name)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package local_dsync
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStoreAcquire(t *testing.T) {
+	s := NewStore()
+
+	if !s.acquire("lock", "a", time.Minute) {
+		t.Fatal("acquire free lock failed")
+	}
+	if s.acquire("lock", "b", time.Minute) {
+		t.Fatal("acquire held lock succeeded")
+	}
+	if !s.valid("lock", "a") || s.valid("lock", "b") {
+		t.Fatal("lock owner mismatch")
+	}
+
+	// only the owner can extend or release
+	if s.extend("lock", "b", time.Minute) {
+		t.Fatal("extend lock held by other succeeded")
+	}
+	if !s.extend("lock", "a", time.Minute) {
+		t.Fatal("extend lock failed")
+	}
+	if s.release("lock", "b") {
+		t.Fatal("release lock held by other succeeded")
+	}
+	if !s.release("lock", "a") {
+		t.Fatal("release lock failed")
+	}
+	if s.valid("lock", "a") {
+		t.Fatal("released lock still valid")
+	}
+
+	if !s.acquire("lock", "b", time.Minute) {
+		t.Fatal("acquire released lock failed")
+	}
+}
+
+func TestStoreExpiry(t *testing.T) {
+	s := NewStore()
+
+	if !s.acquire("lock", "a", 20*time.Millisecond) {
+		t.Fatal("acquire free lock failed")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if s.valid("lock", "a") {
+		t.Fatal("expired lock still valid")
+	}
+	if s.extend("lock", "a", time.Minute) {
+		t.Fatal("extend expired lock succeeded")
+	}
+	if !s.acquire("lock", "b", time.Minute) {
+		t.Fatal("acquire expired lock failed")
+	}
+	if s.release("lock", "a") {
+		t.Fatal("release lock taken over by other succeeded")
+	}
+}
+
+func TestStoreMutualExclusion(t *testing.T) {
+	s := NewStore()
+
+	var holders, violations atomic.Int32
+	var wg sync.WaitGroup
+
+	for i := range 8 {
+		wg.Add(1)
+		go func(value string) {
+			defer wg.Done()
+
+			for range 100 {
+				for !s.acquire("lock", value, time.Minute) {
+					time.Sleep(time.Microsecond)
+				}
+
+				if holders.Add(1) != 1 {
+					violations.Add(1)
+				}
+				holders.Add(-1)
+
+				if !s.release("lock", value) {
+					violations.Add(1)
+				}
+			}
+		}(string(rune('a' + i)))
+	}
+
+	wg.Wait()
+
+	if n := violations.Load(); n > 0 {
+		t.Fatalf("%d mutual exclusion violations", n)
+	}
+}
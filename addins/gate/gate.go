@@ -30,6 +30,7 @@ import (
 	"git.golaxy.org/core/utils/option"
 	"git.golaxy.org/core/utils/uid"
 	"git.golaxy.org/framework/addins/log"
+	"git.golaxy.org/framework/addins/metrics"
 	"git.golaxy.org/framework/net/gtp"
 	"git.golaxy.org/framework/net/gtp/transport"
 	"git.golaxy.org/framework/utils/concurrent"
@@ -79,6 +80,8 @@ type _Gate struct {
 	sessionMap      sync.Map
 	sessionCount    int64
 	sessionWatchers concurrent.LockedSlice[*_SessionWatcher]
+	metrics         metrics.IMetrics
+	cancelMetrics   func()
 }
 
 // Init 初始化插件
@@ -88,6 +91,8 @@ func (g *_Gate) Init(svcCtx service.Context, _ runtime.Context) {
 	g.svcCtx = svcCtx
 	g.ctx, g.terminate = context.WithCancelCause(context.Background())
 
+	g.initMetrics()
+
 	if g.options.TCPAddress != "" {
 		listener, err := newListenConfig(&g.options).Listen(context.Background(), "tcp", g.options.TCPAddress)
 		if err != nil {
@@ -177,6 +182,8 @@ func (g *_Gate) Shut(svcCtx service.Context, _ runtime.Context) {
 	if g.wsListener != nil {
 		g.wsListener.Close()
 	}
+
+	g.shutMetrics()
 }
 
 // GetSession 查询会话
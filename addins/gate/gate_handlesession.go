@@ -43,6 +43,11 @@ func (g *_Gate) handleSession(conn net.Conn) (*_Session, bool) {
 		}
 	}()
 
+	// 统计网络收发字节数
+	if g.metrics != nil {
+		conn = &_MetricsConn{Conn: conn, metrics: g.metrics}
+	}
+
 	// 网络连接接受器
 	acceptor := _Acceptor{
 		gate: g,
@@ -0,0 +1,79 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package gate
+
+import (
+	"git.golaxy.org/framework/addins/metrics"
+	"net"
+	"strings"
+)
+
+// initMetrics 初始化指标统计，未安装指标插件时不统计
+func (g *_Gate) initMetrics() {
+	m, ok := metrics.Lookup(g.svcCtx)
+	if !ok {
+		return
+	}
+	g.metrics = m
+	g.cancelMetrics = m.OnCollect(g.collectMetrics)
+}
+
+// shutMetrics 停止指标统计
+func (g *_Gate) shutMetrics() {
+	if g.cancelMetrics != nil {
+		g.cancelMetrics()
+	}
+}
+
+// collectMetrics 统计各状态的会话数量
+func (g *_Gate) collectMetrics() {
+	var counts [SessionState_Death + 1]int
+
+	g.sessionMap.Range(func(_, v any) bool {
+		if state := v.(*_Session).GetState(); state >= SessionState_Birth && state <= SessionState_Death {
+			counts[state]++
+		}
+		return true
+	})
+
+	for state := range counts {
+		g.metrics.SetGateSessions(strings.TrimPrefix(SessionState(state).String(), "SessionState_"), counts[state])
+	}
+}
+
+// _MetricsConn 统计收发字节数的网络连接
+type _MetricsConn struct {
+	net.Conn
+	metrics metrics.IMetrics
+}
+
+// Read implements net.Conn
+func (c *_MetricsConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.metrics.AddGTPBytes(n, 0)
+	return n, err
+}
+
+// Write implements net.Conn
+func (c *_MetricsConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.metrics.AddGTPBytes(0, n)
+	return n, err
+}
@@ -0,0 +1,195 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package metrics
+
+import (
+	"git.golaxy.org/core/extension"
+	"git.golaxy.org/core/runtime"
+	"git.golaxy.org/core/service"
+	"git.golaxy.org/core/utils/option"
+	"git.golaxy.org/framework/addins/log"
+	"git.golaxy.org/framework/addins/rpc/callpath"
+	"git.golaxy.org/framework/utils/metricutil"
+	"time"
+)
+
+// RPCRole RPC调用角色
+type RPCRole string
+
+const (
+	RPCRole_Client RPCRole = "client" // 调用方
+	RPCRole_Server RPCRole = "server" // 被调用方
+)
+
+// IMetrics 指标支持
+type IMetrics interface {
+	// Registry 指标注册表
+	Registry() *metricutil.Registry
+	// OnCollect 添加采集回调，导出指标前调用，用于更新瞬时值，返回取消回调的函数
+	OnCollect(fun func()) (cancel func())
+	// ObserveRPC 统计RPC调用次数与耗时
+	ObserveRPC(role RPCRole, cp callpath.CallPath, elapsed time.Duration, err error)
+	// ObserveBrokerPublish 统计消息队列发布的消息
+	ObserveBrokerPublish(err error)
+	// ObserveBrokerReceive 统计消息队列订阅收到的消息
+	ObserveBrokerReceive()
+	// SetFuturesOutstanding 设置未完成的Future数量
+	SetFuturesOutstanding(count int64)
+	// SetGateSessions 设置网关处于指定状态的会话数量
+	SetGateSessions(state string, count int)
+	// AddGTPBytes 统计gtp网络收发的字节数
+	AddGTPBytes(in, out int)
+	// ObserveFrame 统计运行时帧更新耗时
+	ObserveFrame(rtName string, elapsed time.Duration)
+}
+
+// Lookup 查找指标插件，未安装或未激活时返回false
+func Lookup(svcCtx service.Context) (IMetrics, bool) {
+	if svcCtx == nil {
+		return nil, false
+	}
+	addIn, ok := svcCtx.GetAddInManager().Get(Name)
+	if !ok || addIn.State() != extension.AddInState_Active {
+		return nil, false
+	}
+	return Using(svcCtx), true
+}
+
+func newMetrics(settings ...option.Setting[MetricsOptions]) IMetrics {
+	return &_Metrics{
+		options: option.Make(With.Default(), settings...),
+	}
+}
+
+type _Metrics struct {
+	options         MetricsOptions
+	svcCtx          service.Context
+	service, node   string
+	rpcCalls        *metricutil.CounterVec
+	rpcDuration     *metricutil.HistogramVec
+	brokerPublished *metricutil.CounterVec
+	brokerReceived  *metricutil.CounterVec
+	futures         *metricutil.GaugeVec
+	gateSessions    *metricutil.GaugeVec
+	gtpBytes        *metricutil.CounterVec
+	frameDuration   *metricutil.HistogramVec
+}
+
+// Init 初始化插件
+func (m *_Metrics) Init(svcCtx service.Context, _ runtime.Context) {
+	log.Infof(svcCtx, "init addin %q", self.Name)
+
+	m.svcCtx = svcCtx
+	m.service = svcCtx.GetName()
+	m.node = svcCtx.GetId().String()
+
+	reg := m.options.Registry
+
+	m.rpcCalls = reg.Counter("golaxy_rpc_calls_total", "Total number of finished RPC calls.", "service", "node", "role", "category", "script", "method", "result")
+	m.rpcDuration = reg.Histogram("golaxy_rpc_duration_seconds", "Latency of finished RPC calls in seconds.", m.options.LatencyBuckets, "service", "node", "role", "category", "script", "method")
+	m.brokerPublished = reg.Counter("golaxy_broker_published_total", "Total number of messages published to the broker.", "service", "node", "result")
+	m.brokerReceived = reg.Counter("golaxy_broker_received_total", "Total number of messages received from broker subscriptions.", "service", "node")
+	m.futures = reg.Gauge("golaxy_dsvc_futures_outstanding", "Number of distributed service futures waiting for responses.", "service", "node")
+	m.gateSessions = reg.Gauge("golaxy_gate_sessions", "Number of gate sessions by state.", "service", "node", "state")
+	m.gtpBytes = reg.Counter("golaxy_gtp_bytes_total", "Total bytes transferred over gtp connections.", "service", "node", "direction")
+	m.frameDuration = reg.Histogram("golaxy_runtime_frame_duration_seconds", "Duration of runtime frame updates in seconds.", m.options.LatencyBuckets, "service", "node", "runtime")
+}
+
+// Shut 关闭插件
+func (m *_Metrics) Shut(svcCtx service.Context, _ runtime.Context) {
+	log.Infof(svcCtx, "shut addin %q", self.Name)
+
+	// 清理服务节点的指标序列
+	m.options.Registry.DeleteMatching(map[string]string{"service": m.service, "node": m.node})
+}
+
+// Registry 指标注册表
+func (m *_Metrics) Registry() *metricutil.Registry {
+	return m.options.Registry
+}
+
+// OnCollect 添加采集回调，导出指标前调用，用于更新瞬时值，返回取消回调的函数
+func (m *_Metrics) OnCollect(fun func()) (cancel func()) {
+	return m.options.Registry.OnCollect(fun)
+}
+
+// ObserveRPC 统计RPC调用次数与耗时
+func (m *_Metrics) ObserveRPC(role RPCRole, cp callpath.CallPath, elapsed time.Duration, err error) {
+	category := categoryName(cp.Category)
+	m.rpcCalls.With(m.service, m.node, string(role), category, cp.Script, cp.Method, resultName(err)).Inc()
+	m.rpcDuration.With(m.service, m.node, string(role), category, cp.Script, cp.Method).ObserveDuration(elapsed)
+}
+
+// ObserveBrokerPublish 统计消息队列发布的消息
+func (m *_Metrics) ObserveBrokerPublish(err error) {
+	m.brokerPublished.With(m.service, m.node, resultName(err)).Inc()
+}
+
+// ObserveBrokerReceive 统计消息队列订阅收到的消息
+func (m *_Metrics) ObserveBrokerReceive() {
+	m.brokerReceived.With(m.service, m.node).Inc()
+}
+
+// SetFuturesOutstanding 设置未完成的Future数量
+func (m *_Metrics) SetFuturesOutstanding(count int64) {
+	m.futures.With(m.service, m.node).Set(float64(count))
+}
+
+// SetGateSessions 设置网关处于指定状态的会话数量
+func (m *_Metrics) SetGateSessions(state string, count int) {
+	m.gateSessions.With(m.service, m.node, state).Set(float64(count))
+}
+
+// AddGTPBytes 统计gtp网络收发的字节数
+func (m *_Metrics) AddGTPBytes(in, out int) {
+	if in > 0 {
+		m.gtpBytes.With(m.service, m.node, "in").Add(float64(in))
+	}
+	if out > 0 {
+		m.gtpBytes.With(m.service, m.node, "out").Add(float64(out))
+	}
+}
+
+// ObserveFrame 统计运行时帧更新耗时
+func (m *_Metrics) ObserveFrame(rtName string, elapsed time.Duration) {
+	m.frameDuration.With(m.service, m.node, rtName).ObserveDuration(elapsed)
+}
+
+func categoryName(category callpath.Category) string {
+	switch category {
+	case callpath.Service:
+		return "service"
+	case callpath.Runtime:
+		return "runtime"
+	case callpath.Entity:
+		return "entity"
+	case callpath.Client:
+		return "client"
+	default:
+		return "unknown"
+	}
+}
+
+func resultName(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,60 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package metrics
+
+import (
+	"git.golaxy.org/core/utils/option"
+	"git.golaxy.org/framework/utils/metricutil"
+)
+
+// MetricsOptions 所有选项
+type MetricsOptions struct {
+	Registry       *metricutil.Registry // 指标注册表，同一进程的多个服务可以共用
+	LatencyBuckets []float64            // 统计耗时的直方图桶上界，单位秒
+}
+
+var With _Option
+
+type _Option struct{}
+
+// Default 默认值
+func (_Option) Default() option.Setting[MetricsOptions] {
+	return func(options *MetricsOptions) {
+		With.Registry(metricutil.DefaultRegistry).Apply(options)
+		With.LatencyBuckets(metricutil.DefaultBuckets...).Apply(options)
+	}
+}
+
+// Registry 指标注册表，同一进程的多个服务可以共用，为nil时使用默认指标注册表
+func (_Option) Registry(registry *metricutil.Registry) option.Setting[MetricsOptions] {
+	return func(options *MetricsOptions) {
+		if registry == nil {
+			registry = metricutil.DefaultRegistry
+		}
+		options.Registry = registry
+	}
+}
+
+// LatencyBuckets 统计耗时的直方图桶上界，单位秒
+func (_Option) LatencyBuckets(buckets ...float64) option.Setting[MetricsOptions] {
+	return func(options *MetricsOptions) {
+		options.LatencyBuckets = buckets
+	}
+}
@@ -29,11 +29,9 @@ import (
 	"git.golaxy.org/framework/addins/log"
 	"git.golaxy.org/framework/net/gtp/transport"
 	"git.golaxy.org/framework/utils/binaryutil"
-	etcdv3 "go.etcd.io/etcd/client/v3"
-	"path"
 	"slices"
-	"strings"
 	"sync"
+	"time"
 )
 
 // IGroup 分组接口
@@ -70,8 +68,8 @@ type _Group struct {
 	sync.RWMutex
 	terminate     context.CancelFunc
 	router        *_Router
-	groupKey      string
-	leaseId       etcdv3.LeaseID
+	addr          string
+	leaseId       int64
 	revision      int64
 	entities      []uid.Id
 	sendDataChan  chan binaryutil.RecycleBytes
@@ -86,7 +84,7 @@ func (g *_Group) GetName() string {
 
 // GetAddr 获取分组地址
 func (g *_Group) GetAddr() string {
-	return strings.TrimPrefix(g.groupKey, g.router.options.GroupKeyPrefix)
+	return g.addr
 }
 
 // Add 添加实体
@@ -106,19 +104,7 @@ func (g *_Group) Add(ctx context.Context, entIds ...uid.Id) error {
 		return nil
 	}
 
-	opsPut := make([]etcdv3.Op, 0, len(entIds)*2)
-	for _, entId := range entIds {
-		opsPut = append(opsPut,
-			etcdv3.OpPut(path.Join(g.groupKey, entId.String()), "", etcdv3.WithLease(g.leaseId)),
-			etcdv3.OpPut(path.Join(g.router.options.EntityGroupsKeyPrefix, entId.String(), g.GetAddr()), "", etcdv3.WithLease(g.leaseId)),
-		)
-	}
-
-	_, err := g.router.client.Txn(ctx).
-		Then(opsPut...).
-		Commit()
-
-	return err
+	return g.router.storage.Add(ctx, g.snapshot(), entIds...)
 }
 
 // Remove 删除实体
@@ -138,19 +124,7 @@ func (g *_Group) Remove(ctx context.Context, entIds ...uid.Id) error {
 		return nil
 	}
 
-	opsDel := make([]etcdv3.Op, 0, len(entIds)*2)
-	for _, entId := range entIds {
-		opsDel = append(opsDel,
-			etcdv3.OpDelete(path.Join(g.groupKey, entId.String())),
-			etcdv3.OpDelete(path.Join(g.router.options.EntityGroupsKeyPrefix, entId.String(), g.GetAddr())),
-		)
-	}
-
-	_, err := g.router.client.Txn(ctx).
-		Then(opsDel...).
-		Commit()
-
-	return err
+	return g.router.storage.Remove(ctx, g.snapshot(), entIds...)
 }
 
 // Range 遍历所有实体
@@ -180,7 +154,7 @@ func (g *_Group) Each(fun generic.Action1[uid.Id]) {
 // Count 获取实体数量
 func (g *_Group) Count() int {
 	g.RLock()
-	defer g.Unlock()
+	defer g.RUnlock()
 	return len(g.entities)
 }
 
@@ -197,8 +171,7 @@ func (g *_Group) RefreshTTL(ctx context.Context) error {
 		break
 	}
 
-	_, err := g.router.client.KeepAliveOnce(ctx, g.leaseId)
-	return err
+	return g.router.storage.RefreshTTL(ctx, g.snapshot())
 }
 
 // SendData 发送数据
@@ -277,17 +250,24 @@ func (g *_Group) SendEventChan() chan<- transport.IEvent {
 
 func (g *_Group) mainLoop() {
 	ctx, cancel := context.WithCancel(g)
+	defer cancel()
 
 	if g.router.options.GroupAutoRefreshTTL {
-		rspChan, err := g.router.client.KeepAlive(ctx, g.leaseId)
-		if err != nil {
-			log.Errorf(g.router.svcCtx, "keep alive groupKey %q lease %q failed, %s", g.groupKey, g.leaseId, err)
-			goto watch
-		}
-
 		go func() {
-			for range rspChan {
-				log.Debugf(g.router.svcCtx, "refresh groupKey %q ttl success", g.groupKey)
+			ticker := time.NewTicker(g.router.options.GroupTTL / 3)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					if err := g.RefreshTTL(ctx); err != nil {
+						log.Errorf(g.router.svcCtx, "refresh group %q ttl failed, %s", g.addr, err)
+						continue
+					}
+					log.Debugf(g.router.svcCtx, "refresh group %q ttl success", g.addr)
+				case <-ctx.Done():
+					return
+				}
 			}
 		}()
 	}
@@ -324,67 +304,53 @@ func (g *_Group) mainLoop() {
 		}()
 	}
 
-watch:
-	watchChan := g.router.client.Watch(ctx, g.groupKey, etcdv3.WithRev(g.revision), etcdv3.WithPrefix(), etcdv3.WithIgnoreValue())
-
-	log.Debugf(g.router.svcCtx, "start watch groupKey %q", g.groupKey)
-
-	for watchRsp := range watchChan {
-		if watchRsp.Canceled {
-			log.Debugf(g.router.svcCtx, "stop watch groupKey %q", g.groupKey)
-			goto end
-		}
-		if watchRsp.Err() != nil {
-			log.Errorf(g.router.svcCtx, "interrupt watch groupKey %q, %s", g.groupKey, watchRsp.Err())
-			goto end
-		}
+	log.Debugf(g.router.svcCtx, "start watch group %q", g.addr)
 
+	err := g.router.storage.Watch(ctx, g.snapshot(), func(event GroupEvent) {
 		g.Lock()
-		for _, event := range watchRsp.Events {
-			switch event.Type {
-			case etcdv3.EventTypePut:
-				key := string(event.Kv.Key)
-				if key == g.groupKey {
-					continue
-				}
+		defer g.Unlock()
 
-				entId := uid.From(path.Base(key))
-
-				if !slices.Contains(g.entities, entId) {
-					g.entities = append(g.entities, entId)
-				}
-
-				g.router.entityGroupsCache.Del(entId, watchRsp.Header.Revision)
-
-			case etcdv3.EventTypeDelete:
-				key := string(event.Kv.Key)
-				if key == g.groupKey {
-					cancel()
-					continue
-				}
-
-				entId := uid.From(path.Base(key))
+		switch event.Type {
+		case GroupEventType_Add:
+			if !slices.Contains(g.entities, event.EntityId) {
+				g.entities = append(g.entities, event.EntityId)
+			}
+			g.router.entityGroupsCache.Del(event.EntityId, event.Revision)
 
-				idx := slices.Index(g.entities, entId)
-				if idx >= 0 {
-					g.entities = slices.Delete(g.entities, idx, idx+1)
-				}
+		case GroupEventType_Remove:
+			idx := slices.Index(g.entities, event.EntityId)
+			if idx >= 0 {
+				g.entities = slices.Delete(g.entities, idx, idx+1)
+			}
+			g.router.entityGroupsCache.Del(event.EntityId, event.Revision)
 
-				g.router.entityGroupsCache.Del(entId, watchRsp.Header.Revision)
+		case GroupEventType_Delete:
+			cancel()
 
-			default:
-				log.Errorf(g.router.svcCtx, "unknown event type %q", event.Type)
-				continue
+		case GroupEventType_Sync:
+			for _, entId := range g.entities {
+				g.router.entityGroupsCache.Del(entId, event.Revision)
 			}
+			for _, entId := range event.Entities {
+				g.router.entityGroupsCache.Del(entId, event.Revision)
+			}
+			g.entities = slices.Clone(event.Entities)
+
+		default:
+			log.Errorf(g.router.svcCtx, "unknown group event type %d", event.Type)
+			return
 		}
 
-		if g.revision < watchRsp.Header.Revision {
-			g.revision = watchRsp.Header.Revision
+		if g.revision < event.Revision {
+			g.revision = event.Revision
 		}
-		g.Unlock()
+	})
+	if err != nil && ctx.Err() == nil {
+		log.Errorf(g.router.svcCtx, "interrupt watch group %q, %s", g.addr, err)
+	} else {
+		log.Debugf(g.router.svcCtx, "stop watch group %q", g.addr)
 	}
 
-end:
 	g.RLock()
 	for _, entId := range g.entities {
 		g.router.entityGroupsCache.Del(entId, g.revision)
@@ -392,3 +358,14 @@ end:
 	g.router.groupCache.Del(g.GetName(), g.revision)
 	g.RUnlock()
 }
+
+func (g *_Group) snapshot() *GroupSnapshot {
+	g.RLock()
+	defer g.RUnlock()
+
+	return &GroupSnapshot{
+		Addr:     g.addr,
+		LeaseId:  g.leaseId,
+		Revision: g.revision,
+	}
+}
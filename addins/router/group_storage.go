@@ -0,0 +1,79 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package router
+
+import (
+	"context"
+	"errors"
+	"git.golaxy.org/core/utils/uid"
+	"time"
+)
+
+var (
+	ErrGroupNotFound = errors.New("router: group not found")
+)
+
+// GroupSnapshot 分组快照
+type GroupSnapshot struct {
+	Addr     string   // 分组地址
+	LeaseId  int64    // 租约Id，由存储自行解释
+	Entities []uid.Id // 所有实体，最新加入的实体在前
+	Revision int64    // 数据版本号
+}
+
+// GroupEventType 分组变化事件类型
+type GroupEventType int32
+
+const (
+	GroupEventType_Add    GroupEventType = iota // 实体加入分组
+	GroupEventType_Remove                       // 实体离开分组
+	GroupEventType_Delete                       // 分组删除或过期
+	GroupEventType_Sync                         // 全量同步分组实体
+)
+
+// GroupEvent 分组变化事件
+type GroupEvent struct {
+	Type     GroupEventType // 事件类型
+	EntityId uid.Id         // 实体Id，Add与Remove事件有效
+	Entities []uid.Id       // 所有实体，Sync事件有效
+	Revision int64          // 数据版本号
+}
+
+// IGroupStorage 分组存储，分组与实体的关系绑定分组TTL，分组过期后自动删除
+type IGroupStorage interface {
+	// Init 初始化存储，检查连接并完成必要的配置
+	Init(ctx context.Context) error
+	// Create 创建分组，分组已存在时，返回已存在的分组快照
+	Create(ctx context.Context, addr string, ttl time.Duration) (*GroupSnapshot, error)
+	// Delete 删除分组
+	Delete(ctx context.Context, addr string) error
+	// Get 查询分组快照，分组不存在时返回ErrGroupNotFound
+	Get(ctx context.Context, addr string) (*GroupSnapshot, error)
+	// Add 分组添加实体
+	Add(ctx context.Context, group *GroupSnapshot, entIds ...uid.Id) error
+	// Remove 分组删除实体
+	Remove(ctx context.Context, group *GroupSnapshot, entIds ...uid.Id) error
+	// RefreshTTL 刷新分组TTL，分组不存在时返回ErrGroupNotFound
+	RefreshTTL(ctx context.Context, group *GroupSnapshot) error
+	// GetEntityGroups 查询实体加入的所有分组地址，同时返回数据版本号
+	GetEntityGroups(ctx context.Context, entityId uid.Id) ([]string, int64, error)
+	// Watch 从指定数据版本号开始监听分组变化，阻塞直到ctx结束、分组删除或发生错误
+	Watch(ctx context.Context, group *GroupSnapshot, fn func(event GroupEvent)) error
+}
@@ -0,0 +1,319 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package router
+
+import (
+	"context"
+	"errors"
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/uid"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	etcdv3 "go.etcd.io/etcd/client/v3"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewEtcdGroupStorage 创建etcd分组存储，使用etcd事务、租约与前缀监听实现分组
+func NewEtcdGroupStorage(cli *etcdv3.Client, groupKeyPrefix, entityGroupsKeyPrefix string) IGroupStorage {
+	if cli == nil {
+		exception.Panicf("%w: cli is nil", core.ErrArgs)
+	}
+	if groupKeyPrefix != "" && !strings.HasSuffix(groupKeyPrefix, "/") {
+		groupKeyPrefix += "/"
+	}
+	if entityGroupsKeyPrefix != "" && !strings.HasSuffix(entityGroupsKeyPrefix, "/") {
+		entityGroupsKeyPrefix += "/"
+	}
+	return &_EtcdGroupStorage{
+		client:                cli,
+		groupKeyPrefix:        groupKeyPrefix,
+		entityGroupsKeyPrefix: entityGroupsKeyPrefix,
+	}
+}
+
+type _EtcdGroupStorage struct {
+	client                *etcdv3.Client
+	groupKeyPrefix        string
+	entityGroupsKeyPrefix string
+}
+
+// Init 初始化存储，检查连接并完成必要的配置
+func (s *_EtcdGroupStorage) Init(ctx context.Context) error {
+	for _, ep := range s.client.Endpoints() {
+		err := func() error {
+			ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+			defer cancel()
+
+			_, err := s.client.Status(ctx, ep)
+			return err
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Create 创建分组，分组已存在时，返回已存在的分组快照
+func (s *_EtcdGroupStorage) Create(ctx context.Context, addr string, ttl time.Duration) (*GroupSnapshot, error) {
+	lgr, err := s.client.Grant(ctx, int64(math.Ceil(ttl.Seconds())))
+	if err != nil {
+		return nil, err
+	}
+	leaseId := lgr.ID
+
+	groupKey := s.getGroupKey(addr)
+
+	tr, err := s.client.Txn(ctx).
+		If(etcdv3.Compare(etcdv3.Version(groupKey), "=", 0)).
+		Then(
+			etcdv3.OpPut(groupKey, strconv.Itoa(int(leaseId)), etcdv3.WithLease(leaseId)),
+		).
+		Else(
+			etcdv3.OpGet(groupKey),
+			etcdv3.OpGet(groupKey+"/",
+				etcdv3.WithPrefix(),
+				etcdv3.WithSort(etcdv3.SortByModRevision, etcdv3.SortDescend),
+				etcdv3.WithIgnoreValue(),
+			),
+		).
+		Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	group := &GroupSnapshot{
+		Addr:     addr,
+		LeaseId:  int64(leaseId),
+		Revision: tr.Header.Revision,
+	}
+
+	if !tr.Succeeded {
+		// 分组已存在，废弃新申请的租约
+		s.client.Revoke(ctx, leaseId)
+
+		if len(tr.Responses[0].GetResponseRange().Kvs) <= 0 {
+			return nil, errors.New("missing groupKey")
+		}
+
+		l, err := strconv.Atoi(string(tr.Responses[0].GetResponseRange().Kvs[0].Value))
+		if err != nil {
+			return nil, errors.New("missing groupKey leaseId")
+		}
+		group.LeaseId = int64(l)
+
+		group.Entities = make([]uid.Id, 0, len(tr.Responses[1].GetResponseRange().Kvs))
+		for _, kv := range tr.Responses[1].GetResponseRange().Kvs {
+			group.Entities = append(group.Entities, uid.From(path.Base(string(kv.Key))))
+		}
+	}
+
+	return group, nil
+}
+
+// Delete 删除分组
+func (s *_EtcdGroupStorage) Delete(ctx context.Context, addr string) error {
+	gr, err := s.client.Get(ctx, s.getGroupKey(addr))
+	if err != nil {
+		return err
+	}
+
+	if len(gr.Kvs) <= 0 {
+		return nil
+	}
+
+	l, err := strconv.Atoi(string(gr.Kvs[0].Value))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.Revoke(ctx, etcdv3.LeaseID(l))
+	return err
+}
+
+// Get 查询分组快照，分组不存在时返回ErrGroupNotFound
+func (s *_EtcdGroupStorage) Get(ctx context.Context, addr string) (*GroupSnapshot, error) {
+	groupKey := s.getGroupKey(addr)
+
+	tr, err := s.client.Txn(ctx).
+		If(etcdv3.Compare(etcdv3.Version(groupKey), "!=", 0)).
+		Then(
+			etcdv3.OpGet(groupKey),
+			etcdv3.OpGet(groupKey+"/",
+				etcdv3.WithPrefix(),
+				etcdv3.WithSort(etcdv3.SortByModRevision, etcdv3.SortDescend),
+				etcdv3.WithIgnoreValue(),
+			),
+		).
+		Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	if !tr.Succeeded || len(tr.Responses[0].GetResponseRange().Kvs) <= 0 {
+		return nil, ErrGroupNotFound
+	}
+
+	l, err := strconv.Atoi(string(tr.Responses[0].GetResponseRange().Kvs[0].Value))
+	if err != nil {
+		return nil, errors.New("missing groupKey leaseId")
+	}
+
+	group := &GroupSnapshot{
+		Addr:     addr,
+		LeaseId:  int64(l),
+		Entities: make([]uid.Id, 0, len(tr.Responses[1].GetResponseRange().Kvs)),
+		Revision: tr.Header.Revision,
+	}
+
+	for _, kv := range tr.Responses[1].GetResponseRange().Kvs {
+		group.Entities = append(group.Entities, uid.From(path.Base(string(kv.Key))))
+	}
+
+	return group, nil
+}
+
+// Add 分组添加实体
+func (s *_EtcdGroupStorage) Add(ctx context.Context, group *GroupSnapshot, entIds ...uid.Id) error {
+	groupKey := s.getGroupKey(group.Addr)
+	leaseId := etcdv3.LeaseID(group.LeaseId)
+
+	opsPut := make([]etcdv3.Op, 0, len(entIds)*2)
+	for _, entId := range entIds {
+		opsPut = append(opsPut,
+			etcdv3.OpPut(path.Join(groupKey, entId.String()), "", etcdv3.WithLease(leaseId)),
+			etcdv3.OpPut(path.Join(s.entityGroupsKeyPrefix, entId.String(), group.Addr), "", etcdv3.WithLease(leaseId)),
+		)
+	}
+
+	_, err := s.client.Txn(ctx).
+		Then(opsPut...).
+		Commit()
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		return ErrGroupNotFound
+	}
+
+	return err
+}
+
+// Remove 分组删除实体
+func (s *_EtcdGroupStorage) Remove(ctx context.Context, group *GroupSnapshot, entIds ...uid.Id) error {
+	groupKey := s.getGroupKey(group.Addr)
+
+	opsDel := make([]etcdv3.Op, 0, len(entIds)*2)
+	for _, entId := range entIds {
+		opsDel = append(opsDel,
+			etcdv3.OpDelete(path.Join(groupKey, entId.String())),
+			etcdv3.OpDelete(path.Join(s.entityGroupsKeyPrefix, entId.String(), group.Addr)),
+		)
+	}
+
+	_, err := s.client.Txn(ctx).
+		Then(opsDel...).
+		Commit()
+
+	return err
+}
+
+// RefreshTTL 刷新分组TTL，分组不存在时返回ErrGroupNotFound
+func (s *_EtcdGroupStorage) RefreshTTL(ctx context.Context, group *GroupSnapshot) error {
+	_, err := s.client.KeepAliveOnce(ctx, etcdv3.LeaseID(group.LeaseId))
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		return ErrGroupNotFound
+	}
+	return err
+}
+
+// GetEntityGroups 查询实体加入的所有分组地址，同时返回数据版本号
+func (s *_EtcdGroupStorage) GetEntityGroups(ctx context.Context, entityId uid.Id) ([]string, int64, error) {
+	entityKey := path.Join(s.entityGroupsKeyPrefix, entityId.String()) + "/"
+
+	gr, err := s.client.Get(ctx, entityKey,
+		etcdv3.WithPrefix(),
+		etcdv3.WithSort(etcdv3.SortByModRevision, etcdv3.SortDescend),
+		etcdv3.WithIgnoreValue())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	groupAddrs := make([]string, 0, len(gr.Kvs))
+
+	for _, kv := range gr.Kvs {
+		groupAddrs = append(groupAddrs, strings.TrimPrefix(string(kv.Key), entityKey))
+	}
+
+	return groupAddrs, gr.Header.Revision, nil
+}
+
+// Watch 从指定数据版本号开始监听分组变化，阻塞直到ctx结束、分组删除或发生错误
+func (s *_EtcdGroupStorage) Watch(ctx context.Context, group *GroupSnapshot, fn func(event GroupEvent)) error {
+	groupKey := s.getGroupKey(group.Addr)
+
+	watchChan := s.client.Watch(ctx, groupKey, etcdv3.WithRev(group.Revision), etcdv3.WithPrefix(), etcdv3.WithIgnoreValue())
+
+	for watchRsp := range watchChan {
+		if watchRsp.Canceled {
+			return ctx.Err()
+		}
+		if watchRsp.Err() != nil {
+			return watchRsp.Err()
+		}
+
+		for _, event := range watchRsp.Events {
+			key := string(event.Kv.Key)
+
+			switch event.Type {
+			case etcdv3.EventTypePut:
+				if key == groupKey {
+					continue
+				}
+				fn(GroupEvent{
+					Type:     GroupEventType_Add,
+					EntityId: uid.From(path.Base(key)),
+					Revision: watchRsp.Header.Revision,
+				})
+
+			case etcdv3.EventTypeDelete:
+				if key == groupKey {
+					fn(GroupEvent{
+						Type:     GroupEventType_Delete,
+						Revision: watchRsp.Header.Revision,
+					})
+					return nil
+				}
+				fn(GroupEvent{
+					Type:     GroupEventType_Remove,
+					EntityId: uid.From(path.Base(key)),
+					Revision: watchRsp.Header.Revision,
+				})
+			}
+		}
+	}
+
+	return ctx.Err()
+}
+
+func (s *_EtcdGroupStorage) getGroupKey(addr string) string {
+	return path.Join(s.groupKeyPrefix, addr)
+}
@@ -0,0 +1,392 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package router
+
+import (
+	"context"
+	"fmt"
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/dentr"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RedisGroupKeyspaceEvents 感知分组过期需要redis开启的键空间通知类型，K：键空间通知，x：过期
+const RedisGroupKeyspaceEvents = "Kx"
+
+// NewRedisGroupStorage 创建redis分组存储，使用有序集合保存分组实体，使用发布订阅传播分组变化，使用键空间通知感知分组过期。
+// 脚本会访问由分组地址与实体Id拼接的key，所有key使用groupKeyPrefix作为hash tag，实体的分组key也位于groupKeyPrefix之下，redis集群模式下所有key位于同一slot。
+// 需要在redis服务端配置notify-keyspace-events，包含events中的所有类型，events为空时使用RedisGroupKeyspaceEvents，初始化时只检查配置，不会修改服务端配置
+func NewRedisGroupStorage(cli *redis.Client, groupKeyPrefix, entityGroupsKeyPrefix, events string) IGroupStorage {
+	if cli == nil {
+		exception.Panicf("%w: cli is nil", core.ErrArgs)
+	}
+	hashTag := strings.TrimSuffix(groupKeyPrefix, ":")
+	if hashTag == "" {
+		exception.Panicf("%w: groupKeyPrefix is empty", core.ErrArgs)
+	}
+	groupKeyPrefix = "{" + hashTag + "}:"
+	if entityGroupsKeyPrefix != "" && !strings.HasSuffix(entityGroupsKeyPrefix, ":") {
+		entityGroupsKeyPrefix += ":"
+	}
+	if events == "" {
+		events = RedisGroupKeyspaceEvents
+	}
+	return &_RedisGroupStorage{
+		client:                cli,
+		groupKeyPrefix:        groupKeyPrefix,
+		entityGroupsKeyPrefix: groupKeyPrefix + entityGroupsKeyPrefix,
+		revisionKey:           groupKeyPrefix + "@revision",
+		events:                events,
+	}
+}
+
+// 分组key保存分组TTL（毫秒），分组实体有序集合以数据版本号排序，实体的分组有序集合不设置过期时间，查询时清理已过期的分组。
+// 脚本中拼接的key与KEYS使用相同的hash tag，位于同一slot
+var (
+	// KEYS[1]：分组key，KEYS[2]：分组实体key，KEYS[3]：数据版本号key；ARGV[1]：分组TTL（毫秒）
+	redisGroupCreateScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[1], 'NX') then
+	return {redis.call('INCR', KEYS[3])}
+end
+local rev = tonumber(redis.call('GET', KEYS[3]) or '0')
+local ret = redis.call('ZREVRANGE', KEYS[2], 0, -1)
+table.insert(ret, 1, rev)
+return ret
+`)
+	// KEYS[1]：分组key，KEYS[2]：分组实体key，KEYS[3]：数据版本号key
+	redisGroupGetScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return false
+end
+local rev = tonumber(redis.call('GET', KEYS[3]) or '0')
+local ret = redis.call('ZREVRANGE', KEYS[2], 0, -1)
+table.insert(ret, 1, rev)
+return ret
+`)
+	// KEYS[1]：分组key，KEYS[2]：分组实体key，KEYS[3]：数据版本号key；ARGV[1]：变化通知频道，ARGV[2]：分组地址，ARGV[3]：实体的分组key前缀
+	redisGroupDeleteScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+for _, entId in ipairs(redis.call('ZRANGE', KEYS[2], 0, -1)) do
+	redis.call('ZREM', ARGV[3] .. entId, ARGV[2])
+end
+redis.call('DEL', KEYS[1], KEYS[2])
+local rev = redis.call('INCR', KEYS[3])
+redis.call('PUBLISH', ARGV[1], 'd::' .. rev)
+return rev
+`)
+	// KEYS[1]：分组key，KEYS[2]：分组实体key，KEYS[3]：数据版本号key；ARGV[1]：变化通知频道，ARGV[2]：分组地址，ARGV[3]：实体的分组key前缀，ARGV[4:]：实体Id
+	redisGroupAddScript = redis.NewScript(`
+local ttl = redis.call('GET', KEYS[1])
+if not ttl then
+	return -1
+end
+local rev = redis.call('INCR', KEYS[3])
+for i = 4, #ARGV do
+	redis.call('ZADD', KEYS[2], rev, ARGV[i])
+	redis.call('ZADD', ARGV[3] .. ARGV[i], rev, ARGV[2])
+	redis.call('PUBLISH', ARGV[1], 'a:' .. ARGV[i] .. ':' .. rev)
+end
+redis.call('PEXPIRE', KEYS[2], ttl)
+return rev
+`)
+	// KEYS[1]：分组key，KEYS[2]：分组实体key，KEYS[3]：数据版本号key；ARGV[1]：变化通知频道，ARGV[2]：分组地址，ARGV[3]：实体的分组key前缀，ARGV[4:]：实体Id
+	redisGroupRemoveScript = redis.NewScript(`
+local rev = redis.call('INCR', KEYS[3])
+for i = 4, #ARGV do
+	if redis.call('ZREM', KEYS[2], ARGV[i]) > 0 then
+		redis.call('PUBLISH', ARGV[1], 'r:' .. ARGV[i] .. ':' .. rev)
+	end
+	redis.call('ZREM', ARGV[3] .. ARGV[i], ARGV[2])
+end
+return rev
+`)
+	// KEYS[1]：分组key，KEYS[2]：分组实体key
+	redisGroupRefreshScript = redis.NewScript(`
+local ttl = redis.call('GET', KEYS[1])
+if not ttl then
+	return 0
+end
+redis.call('PEXPIRE', KEYS[1], ttl)
+redis.call('PEXPIRE', KEYS[2], ttl)
+return 1
+`)
+	// KEYS[1]：实体的分组key，KEYS[2]：数据版本号key；ARGV[1]：分组key前缀
+	redisEntityGroupsScript = redis.NewScript(`
+local ret = {tonumber(redis.call('GET', KEYS[2]) or '0')}
+for _, addr in ipairs(redis.call('ZREVRANGE', KEYS[1], 0, -1)) do
+	if redis.call('EXISTS', ARGV[1] .. addr) == 1 then
+		table.insert(ret, addr)
+	else
+		redis.call('ZREM', KEYS[1], addr)
+	end
+end
+return ret
+`)
+)
+
+type _RedisGroupStorage struct {
+	client                *redis.Client
+	groupKeyPrefix        string
+	entityGroupsKeyPrefix string
+	revisionKey           string
+	events                string
+}
+
+// Init 初始化存储，检查连接与键空间通知配置
+func (s *_RedisGroupStorage) Init(ctx context.Context) error {
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		return err
+	}
+	return dentr.CheckRedisKeyspaceEvents(ctx, s.client, s.events)
+}
+
+// Create 创建分组，分组已存在时，返回已存在的分组快照
+func (s *_RedisGroupStorage) Create(ctx context.Context, addr string, ttl time.Duration) (*GroupSnapshot, error) {
+	ret, err := redisGroupCreateScript.Run(ctx, s.client, s.getKeys(addr), ttl.Milliseconds()).Slice()
+	if err != nil {
+		return nil, err
+	}
+	return s.parseSnapshot(addr, ret)
+}
+
+// Delete 删除分组
+func (s *_RedisGroupStorage) Delete(ctx context.Context, addr string) error {
+	return redisGroupDeleteScript.Run(ctx, s.client, s.getKeys(addr), s.getChannel(addr), addr, s.entityGroupsKeyPrefix).Err()
+}
+
+// Get 查询分组快照，分组不存在时返回ErrGroupNotFound
+func (s *_RedisGroupStorage) Get(ctx context.Context, addr string) (*GroupSnapshot, error) {
+	ret, err := redisGroupGetScript.Run(ctx, s.client, s.getKeys(addr)).Slice()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrGroupNotFound
+		}
+		return nil, err
+	}
+	return s.parseSnapshot(addr, ret)
+}
+
+// Add 分组添加实体
+func (s *_RedisGroupStorage) Add(ctx context.Context, group *GroupSnapshot, entIds ...uid.Id) error {
+	rev, err := redisGroupAddScript.Run(ctx, s.client, s.getKeys(group.Addr), s.makeArgs(group.Addr, entIds)...).Int64()
+	if err != nil {
+		return err
+	}
+	if rev < 0 {
+		return ErrGroupNotFound
+	}
+	return nil
+}
+
+// Remove 分组删除实体
+func (s *_RedisGroupStorage) Remove(ctx context.Context, group *GroupSnapshot, entIds ...uid.Id) error {
+	return redisGroupRemoveScript.Run(ctx, s.client, s.getKeys(group.Addr), s.makeArgs(group.Addr, entIds)...).Err()
+}
+
+// RefreshTTL 刷新分组TTL，分组不存在时返回ErrGroupNotFound
+func (s *_RedisGroupStorage) RefreshTTL(ctx context.Context, group *GroupSnapshot) error {
+	ok, err := redisGroupRefreshScript.Run(ctx, s.client, s.getKeys(group.Addr)[:2]).Bool()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrGroupNotFound
+	}
+	return nil
+}
+
+// GetEntityGroups 查询实体加入的所有分组地址，同时返回数据版本号
+func (s *_RedisGroupStorage) GetEntityGroups(ctx context.Context, entityId uid.Id) ([]string, int64, error) {
+	ret, err := redisEntityGroupsScript.Run(ctx, s.client, []string{s.entityGroupsKeyPrefix + entityId.String(), s.revisionKey}, s.groupKeyPrefix).Slice()
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(ret) <= 0 {
+		return nil, 0, fmt.Errorf("invalid entity groups result")
+	}
+
+	revision, _ := ret[0].(int64)
+
+	groupAddrs := make([]string, 0, len(ret)-1)
+	for _, v := range ret[1:] {
+		if addr, ok := v.(string); ok {
+			groupAddrs = append(groupAddrs, addr)
+		}
+	}
+
+	return groupAddrs, revision, nil
+}
+
+// Watch 从指定数据版本号开始监听分组变化，阻塞直到ctx结束、分组删除或发生错误
+func (s *_RedisGroupStorage) Watch(ctx context.Context, group *GroupSnapshot, fn func(event GroupEvent)) error {
+	keyspaceChannel := fmt.Sprintf("__keyspace@%d__:%s", s.client.Options().DB, s.getKeys(group.Addr)[0])
+
+	pubSub := s.client.Subscribe(ctx, s.getChannel(group.Addr), keyspaceChannel)
+	defer pubSub.Close()
+
+	if _, err := pubSub.Receive(ctx); err != nil {
+		return err
+	}
+
+	// 订阅完成后，同步订阅前遗漏的变化
+	snapshot, err := s.Get(ctx, group.Addr)
+	if err != nil {
+		if err == ErrGroupNotFound {
+			fn(GroupEvent{
+				Type:     GroupEventType_Delete,
+				Revision: group.Revision,
+			})
+			return nil
+		}
+		return err
+	}
+
+	if snapshot.Revision > group.Revision {
+		fn(GroupEvent{
+			Type:     GroupEventType_Sync,
+			Entities: snapshot.Entities,
+			Revision: snapshot.Revision,
+		})
+	}
+
+	msgChan := pubSub.Channel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-msgChan:
+			if !ok {
+				return redis.ErrClosed
+			}
+
+			// 分组过期
+			if msg.Channel == keyspaceChannel {
+				if msg.Payload != "expired" {
+					continue
+				}
+
+				revision, err := s.client.Incr(ctx, s.revisionKey).Result()
+				if err != nil {
+					return err
+				}
+
+				fn(GroupEvent{
+					Type:     GroupEventType_Delete,
+					Revision: revision,
+				})
+				return nil
+			}
+
+			event, ok := parseGroupEvent(msg.Payload)
+			if !ok {
+				continue
+			}
+
+			fn(event)
+
+			if event.Type == GroupEventType_Delete {
+				return nil
+			}
+		}
+	}
+}
+
+// parseGroupEvent 解析分组变化通知，格式为“类型:实体Id:数据版本号”
+func parseGroupEvent(payload string) (GroupEvent, bool) {
+	subs := strings.Split(payload, ":")
+	if len(subs) != 3 {
+		return GroupEvent{}, false
+	}
+
+	revision, err := strconv.ParseInt(subs[2], 10, 64)
+	if err != nil {
+		return GroupEvent{}, false
+	}
+
+	switch subs[0] {
+	case "a":
+		return GroupEvent{
+			Type:     GroupEventType_Add,
+			EntityId: uid.From(subs[1]),
+			Revision: revision,
+		}, true
+	case "r":
+		return GroupEvent{
+			Type:     GroupEventType_Remove,
+			EntityId: uid.From(subs[1]),
+			Revision: revision,
+		}, true
+	case "d":
+		return GroupEvent{
+			Type:     GroupEventType_Delete,
+			Revision: revision,
+		}, true
+	default:
+		return GroupEvent{}, false
+	}
+}
+
+func (s *_RedisGroupStorage) getKeys(addr string) []string {
+	groupKey := s.groupKeyPrefix + addr
+	return []string{groupKey, groupKey + ":@entities", s.revisionKey}
+}
+
+func (s *_RedisGroupStorage) getChannel(addr string) string {
+	return s.groupKeyPrefix + addr + ":@events"
+}
+
+func (s *_RedisGroupStorage) makeArgs(addr string, entIds []uid.Id) []any {
+	args := make([]any, 0, 3+len(entIds))
+	args = append(args, s.getChannel(addr), addr, s.entityGroupsKeyPrefix)
+	for _, entId := range entIds {
+		args = append(args, entId.String())
+	}
+	return args
+}
+
+func (s *_RedisGroupStorage) parseSnapshot(addr string, ret []any) (*GroupSnapshot, error) {
+	if len(ret) <= 0 {
+		return nil, fmt.Errorf("invalid group %q result", addr)
+	}
+
+	revision, _ := ret[0].(int64)
+
+	group := &GroupSnapshot{
+		Addr:     addr,
+		Entities: make([]uid.Id, 0, len(ret)-1),
+		Revision: revision,
+	}
+
+	for _, v := range ret[1:] {
+		if entId, ok := v.(string); ok {
+			group.Entities = append(group.Entities, uid.From(entId))
+		}
+	}
+
+	return group, nil
+}
@@ -0,0 +1,139 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package router
+
+import (
+	"context"
+	"git.golaxy.org/core/utils/uid"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGroupEvent(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    GroupEvent
+		ok      bool
+	}{
+		{"a:e1:3", GroupEvent{Type: GroupEventType_Add, EntityId: "e1", Revision: 3}, true},
+		{"r:e1:4", GroupEvent{Type: GroupEventType_Remove, EntityId: "e1", Revision: 4}, true},
+		{"d::5", GroupEvent{Type: GroupEventType_Delete, Revision: 5}, true},
+		{"x:e1:6", GroupEvent{}, false},
+		{"a:e1", GroupEvent{}, false},
+		{"a:e1:rev", GroupEvent{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseGroupEvent(tt.payload)
+		if ok != tt.ok {
+			t.Errorf("parseGroupEvent(%q) ok = %v, want %v", tt.payload, ok, tt.ok)
+			continue
+		}
+		if ok && (got.Type != tt.want.Type || got.EntityId != tt.want.EntityId || got.Revision != tt.want.Revision) {
+			t.Errorf("parseGroupEvent(%q) = %+v, want %+v", tt.payload, got, tt.want)
+		}
+	}
+}
+
+func TestRedisGroupStorageKeys(t *testing.T) {
+	cli := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	defer cli.Close()
+
+	// 所有key使用分组key前缀作为hash tag，自动补齐key前缀分隔符
+	s := NewRedisGroupStorage(cli, "groups", "entity_groups", "").(*_RedisGroupStorage)
+
+	keys := s.getKeys("mc.g1")
+	if want := []string{"{groups}:mc.g1", "{groups}:mc.g1:@entities", "{groups}:@revision"}; !slices.Equal(keys, want) {
+		t.Fatalf("got keys %q, want %q", keys, want)
+	}
+	if channel := s.getChannel("mc.g1"); channel != "{groups}:mc.g1:@events" {
+		t.Fatalf("got channel %q, want %q", channel, "{groups}:mc.g1:@events")
+	}
+
+	args := s.makeArgs("mc.g1", []uid.Id{"e1", "e2"})
+	if want := []any{"{groups}:mc.g1:@events", "mc.g1", "{groups}:entity_groups:", "e1", "e2"}; !slices.Equal(args, want) {
+		t.Fatalf("got args %v, want %v", args, want)
+	}
+}
+
+func TestRedisGroupStorageParseSnapshot(t *testing.T) {
+	cli := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	defer cli.Close()
+
+	s := NewRedisGroupStorage(cli, "groups", "entity_groups", "").(*_RedisGroupStorage)
+
+	group, err := s.parseSnapshot("mc.g1", []any{int64(7), "e2", "e1"})
+	if err != nil {
+		t.Fatalf("parse snapshot failed, %s", err)
+	}
+	if group.Addr != "mc.g1" || group.Revision != 7 || !slices.Equal(group.Entities, []uid.Id{"e2", "e1"}) {
+		t.Fatalf("got snapshot %+v, want addr mc.g1 revision 7 entities [e2 e1]", group)
+	}
+
+	if _, err := s.parseSnapshot("mc.g1", nil); err == nil {
+		t.Fatal("parse empty snapshot succeeded, want error")
+	}
+}
+
+func TestRedisGroupStorageHashTag(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer cli.Close()
+
+	s := NewRedisGroupStorage(cli, "groups", "entity_groups", "")
+	ctx := context.Background()
+
+	if err := s.Init(ctx); err != nil {
+		t.Fatalf("init failed, %s", err)
+	}
+
+	group, err := s.Create(ctx, "mc.g1", time.Minute)
+	if err != nil {
+		t.Fatalf("create failed, %s", err)
+	}
+	if err := s.Add(ctx, group, "e1", "e2"); err != nil {
+		t.Fatalf("add failed, %s", err)
+	}
+
+	// 脚本中拼接的key与KEYS使用相同的hash tag
+	for _, key := range mr.Keys() {
+		if !strings.HasPrefix(key, "{groups}:") {
+			t.Fatalf("key %q without hash tag {groups}", key)
+		}
+	}
+
+	if addrs, _, err := s.GetEntityGroups(ctx, "e1"); err != nil || !slices.Equal(addrs, []string{"mc.g1"}) {
+		t.Fatalf("got entity groups %q, %v, want [mc.g1]", addrs, err)
+	}
+
+	if err := s.Delete(ctx, "mc.g1"); err != nil {
+		t.Fatalf("delete failed, %s", err)
+	}
+	if addrs, _, err := s.GetEntityGroups(ctx, "e1"); err != nil || len(addrs) != 0 {
+		t.Fatalf("got entity groups %q, %v, want none", addrs, err)
+	}
+	if keys := mr.Keys(); !slices.Equal(keys, []string{"{groups}:@revision"}) {
+		t.Fatalf("got keys %q after delete, want only revision", keys)
+	}
+}
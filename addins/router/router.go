@@ -81,6 +81,7 @@ type _Router struct {
 	options           RouterOptions
 	gate              gate.IGate
 	client            *etcdv3.Client
+	storage           IGroupStorage
 	planning          concurrent.LockedMap[uid.Id, *_Mapping]
 	groupCache        *concurrent.Cache[string, *_Group]
 	entityGroupsCache *concurrent.Cache[uid.Id, []string]
@@ -93,25 +94,23 @@ func (r *_Router) Init(svcCtx service.Context, _ runtime.Context) {
 	r.svcCtx = svcCtx
 	r.gate = gate.Using(r.svcCtx)
 
-	if r.options.EtcdClient == nil {
-		cli, err := etcdv3.New(r.configure())
-		if err != nil {
-			log.Panicf(svcCtx, "new etcd client failed, %s", err)
+	if r.options.GroupStorage == nil {
+		if r.options.EtcdClient == nil {
+			cli, err := etcdv3.New(r.configure())
+			if err != nil {
+				log.Panicf(svcCtx, "new etcd client failed, %s", err)
+			}
+			r.client = cli
+		} else {
+			r.client = r.options.EtcdClient
 		}
-		r.client = cli
+		r.storage = NewEtcdGroupStorage(r.client, r.options.GroupKeyPrefix, r.options.EntityGroupsKeyPrefix)
 	} else {
-		r.client = r.options.EtcdClient
+		r.storage = r.options.GroupStorage
 	}
 
-	for _, ep := range r.client.Endpoints() {
-		func() {
-			ctx, cancel := context.WithTimeout(r.svcCtx, 3*time.Second)
-			defer cancel()
-
-			if _, err := r.client.Status(ctx, ep); err != nil {
-				log.Panicf(r.svcCtx, "status etcd %q failed, %s", ep, err)
-			}
-		}()
+	if err := r.storage.Init(r.svcCtx); err != nil {
+		log.Panicf(r.svcCtx, "init group storage failed, %s", err)
 	}
 
 	r.groupCache = concurrent.NewCache[string, *_Group]()
@@ -125,7 +124,7 @@ func (r *_Router) Init(svcCtx service.Context, _ runtime.Context) {
 func (r *_Router) Shut(svcCtx service.Context, _ runtime.Context) {
 	log.Infof(svcCtx, "shut addin %q", self.Name)
 
-	if r.options.EtcdClient == nil {
+	if r.options.GroupStorage == nil && r.options.EtcdClient == nil {
 		if r.client != nil {
 			r.client.Close()
 		}
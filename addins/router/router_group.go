@@ -21,17 +21,11 @@ package router
 
 import (
 	"context"
-	"errors"
 	"git.golaxy.org/core/utils/generic"
 	"git.golaxy.org/core/utils/uid"
 	"git.golaxy.org/framework/addins/gate"
 	"git.golaxy.org/framework/net/gtp/transport"
 	"git.golaxy.org/framework/utils/binaryutil"
-	etcdv3 "go.etcd.io/etcd/client/v3"
-	"math"
-	"path"
-	"strconv"
-	"strings"
 )
 
 // AddGroup 添加分组
@@ -40,55 +34,14 @@ func (r *_Router) AddGroup(ctx context.Context, name string) (IGroup, error) {
 		ctx = context.Background()
 	}
 
-	lgr, err := r.client.Grant(ctx, int64(math.Ceil(r.options.GroupTTL.Seconds())))
+	snapshot, err := r.storage.Create(ctx, gate.CliDetails.DomainMulticast.Join(name), r.options.GroupTTL)
 	if err != nil {
 		return nil, err
 	}
-	leaseId := lgr.ID
 
-	groupAddr := gate.CliDetails.DomainMulticast.Join(name)
-	groupKey := path.Join(r.options.GroupKeyPrefix, groupAddr)
+	group := r.newGroup(snapshot)
 
-	tr, err := r.client.Txn(ctx).
-		If(etcdv3.Compare(etcdv3.Version(groupKey), "=", 0)).
-		Then(
-			etcdv3.OpPut(groupKey, strconv.Itoa(int(leaseId)), etcdv3.WithLease(leaseId)),
-		).
-		Else(
-			etcdv3.OpGet(groupKey),
-			etcdv3.OpGet(groupKey+"/",
-				etcdv3.WithPrefix(),
-				etcdv3.WithSort(etcdv3.SortByModRevision, etcdv3.SortDescend),
-				etcdv3.WithIgnoreValue(),
-			),
-		).
-		Commit()
-	if err != nil {
-		return nil, err
-	}
-
-	var entIds []uid.Id
-
-	if !tr.Succeeded {
-		if len(tr.Responses[0].GetResponseRange().Kvs) <= 0 {
-			return nil, errors.New("missing groupKey")
-		}
-
-		l, err := strconv.Atoi(string(tr.Responses[0].GetResponseRange().Kvs[0].Value))
-		if err != nil {
-			return nil, errors.New("missing groupKey leaseId")
-		}
-		leaseId = etcdv3.LeaseID(l)
-
-		entIds = make([]uid.Id, 0, len(tr.Responses[1].GetResponseRange().Kvs))
-		for _, kv := range tr.Responses[1].GetResponseRange().Kvs {
-			entIds = append(entIds, uid.From(path.Base(string(kv.Key))))
-		}
-	}
-
-	group := r.newGroup(groupKey, leaseId, tr.Header.Revision, entIds)
-
-	cached := r.groupCache.Set(group.GetName(), group, tr.Header.Revision, 0)
+	cached := r.groupCache.Set(group.GetName(), group, snapshot.Revision, 0)
 	if cached == group {
 		go group.mainLoop()
 	}
@@ -102,28 +55,7 @@ func (r *_Router) DeleteGroup(ctx context.Context, name string) {
 		ctx = context.Background()
 	}
 
-	groupAddr := gate.CliDetails.DomainMulticast.Join(name)
-	groupKey := path.Join(r.options.GroupKeyPrefix, groupAddr)
-
-	gr, err := r.client.Get(ctx, groupKey)
-	if err != nil {
-		return
-	}
-
-	if len(gr.Kvs) <= 0 {
-		return
-	}
-
-	l, err := strconv.Atoi(string(gr.Kvs[0].Value))
-	if err != nil {
-		return
-	}
-	leaseId := etcdv3.LeaseID(l)
-
-	_, err = r.client.Revoke(context.Background(), leaseId)
-	if err != nil {
-		return
-	}
+	r.storage.Delete(ctx, gate.CliDetails.DomainMulticast.Join(name))
 }
 
 // GetGroup 查询分组
@@ -137,42 +69,14 @@ func (r *_Router) GetGroup(ctx context.Context, name string) (IGroup, bool) {
 		return group, true
 	}
 
-	groupAddr := gate.CliDetails.DomainMulticast.Join(name)
-	groupKey := path.Join(r.options.GroupKeyPrefix, groupAddr)
-
-	tr, err := r.client.Txn(ctx).
-		If(etcdv3.Compare(etcdv3.Version(groupKey), "!=", 0)).
-		Then(
-			etcdv3.OpGet(groupKey),
-			etcdv3.OpGet(groupKey+"/",
-				etcdv3.WithPrefix(),
-				etcdv3.WithSort(etcdv3.SortByModRevision, etcdv3.SortDescend),
-				etcdv3.WithIgnoreValue(),
-			),
-		).
-		Commit()
+	snapshot, err := r.storage.Get(ctx, gate.CliDetails.DomainMulticast.Join(name))
 	if err != nil {
 		return nil, false
 	}
 
-	if !tr.Succeeded || len(tr.Responses[0].GetResponseRange().Kvs) <= 0 {
-		return nil, false
-	}
+	group = r.newGroup(snapshot)
 
-	l, err := strconv.Atoi(string(tr.Responses[0].GetResponseRange().Kvs[0].Value))
-	if err != nil {
-		return nil, false
-	}
-	leaseId := etcdv3.LeaseID(l)
-
-	entIds := make([]uid.Id, 0, len(tr.Responses[1].GetResponseRange().Kvs))
-	for _, kv := range tr.Responses[1].GetResponseRange().Kvs {
-		entIds = append(entIds, uid.From(path.Base(string(kv.Key))))
-	}
-
-	group = r.newGroup(groupKey, leaseId, tr.Header.Revision, entIds)
-
-	cached := r.groupCache.Set(group.GetName(), group, tr.Header.Revision, 0)
+	cached := r.groupCache.Set(group.GetName(), group, snapshot.Revision, 0)
 	if cached == group {
 		go group.mainLoop()
 	}
@@ -225,13 +129,13 @@ func (r *_Router) EachGroups(ctx context.Context, entityId uid.Id, fun generic.A
 	}
 }
 
-func (r *_Router) newGroup(groupKey string, leaseId etcdv3.LeaseID, revision int64, entIds []uid.Id) *_Group {
+func (r *_Router) newGroup(snapshot *GroupSnapshot) *_Group {
 	group := &_Group{
 		router:   r,
-		groupKey: groupKey,
-		leaseId:  leaseId,
-		revision: revision,
-		entities: entIds,
+		addr:     snapshot.Addr,
+		leaseId:  snapshot.LeaseId,
+		revision: snapshot.Revision,
+		entities: snapshot.Entities,
 	}
 
 	group.Context, group.terminate = context.WithCancel(r.svcCtx)
@@ -250,21 +154,11 @@ func (r *_Router) newGroup(groupKey string, leaseId etcdv3.LeaseID, revision int
 func (r *_Router) getEntityGroupAddrs(ctx context.Context, entityId uid.Id) []string {
 	groupAddrs, ok := r.entityGroupsCache.Get(entityId)
 	if !ok {
-		gr, err := r.client.Get(ctx, path.Join(r.options.EntityGroupsKeyPrefix, entityId.String())+"/",
-			etcdv3.WithPrefix(),
-			etcdv3.WithSort(etcdv3.SortByModRevision, etcdv3.SortDescend),
-			etcdv3.WithIgnoreValue())
-		if err != nil || len(gr.Kvs) <= 0 {
+		addrs, revision, err := r.storage.GetEntityGroups(ctx, entityId)
+		if err != nil || len(addrs) <= 0 {
 			return nil
 		}
-
-		groupAddrs = make([]string, 0, len(gr.Kvs))
-
-		for _, kv := range gr.Kvs {
-			groupAddrs = append(groupAddrs, strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(string(kv.Key), r.options.EntityGroupsKeyPrefix), entityId.String()), "/"))
-		}
-
-		groupAddrs = r.entityGroupsCache.Set(entityId, groupAddrs, gr.Header.Revision, r.options.EntityGroupsCacheTTL)
+		groupAddrs = r.entityGroupsCache.Set(entityId, addrs, revision, r.options.EntityGroupsCacheTTL)
 	}
 	return groupAddrs
 }
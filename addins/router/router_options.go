@@ -31,6 +31,7 @@ import (
 )
 
 type RouterOptions struct {
+	GroupStorage           IGroupStorage
 	EtcdClient             *clientv3.Client
 	EtcdConfig             *clientv3.Config
 	GroupKeyPrefix         string
@@ -53,6 +54,7 @@ type _Option struct{}
 // Default 默认值
 func (_Option) Default() option.Setting[RouterOptions] {
 	return func(options *RouterOptions) {
+		With.GroupStorage(nil)(options)
 		With.EtcdClient(nil)(options)
 		With.EtcdConfig(nil)(options)
 		With.GroupKeyPrefix("/golaxy/groups/")(options)
@@ -67,21 +69,28 @@ func (_Option) Default() option.Setting[RouterOptions] {
 	}
 }
 
-// EtcdClient etcd客户端，最优先使用
+// GroupStorage 分组存储，最优先使用，未设置时使用etcd存储
+func (_Option) GroupStorage(storage IGroupStorage) option.Setting[RouterOptions] {
+	return func(o *RouterOptions) {
+		o.GroupStorage = storage
+	}
+}
+
+// EtcdClient etcd客户端，使用etcd存储时，最优先使用
 func (_Option) EtcdClient(cli *clientv3.Client) option.Setting[RouterOptions] {
 	return func(o *RouterOptions) {
 		o.EtcdClient = cli
 	}
 }
 
-// EtcdConfig etcd配置，次优先使用
+// EtcdConfig etcd配置，使用etcd存储时，次优先使用
 func (_Option) EtcdConfig(config *clientv3.Config) option.Setting[RouterOptions] {
 	return func(o *RouterOptions) {
 		o.EtcdConfig = config
 	}
 }
 
-// GroupKeyPrefix 所有分组key的前缀
+// GroupKeyPrefix 使用etcd存储时，所有分组key的前缀
 func (_Option) GroupKeyPrefix(prefix string) option.Setting[RouterOptions] {
 	return func(options *RouterOptions) {
 		if prefix != "" && !strings.HasSuffix(prefix, "/") {
@@ -116,7 +125,7 @@ func (_Option) GroupSendEventChanSize(size int) option.Setting[RouterOptions] {
 	}
 }
 
-// EntityGroupsKeyPrefix 实体的分组key的前缀
+// EntityGroupsKeyPrefix 使用etcd存储时，实体的分组key的前缀
 func (_Option) EntityGroupsKeyPrefix(prefix string) option.Setting[RouterOptions] {
 	return func(options *RouterOptions) {
 		if prefix != "" && !strings.HasSuffix(prefix, "/") {
@@ -20,24 +20,34 @@
 package rpc
 
 import (
+	"context"
 	"git.golaxy.org/core/runtime"
 	"git.golaxy.org/core/service"
 	"git.golaxy.org/core/utils/async"
 	"git.golaxy.org/core/utils/option"
 	"git.golaxy.org/framework/addins/log"
+	"git.golaxy.org/framework/addins/metrics"
 	"git.golaxy.org/framework/addins/rpc/callpath"
 	"git.golaxy.org/framework/addins/rpc/rpcpcsr"
+	"git.golaxy.org/framework/addins/rpc/rpcstream"
 	"git.golaxy.org/framework/addins/rpcstack"
 	"git.golaxy.org/framework/utils/concurrent"
 	"sync/atomic"
+	"time"
 )
 
 // IRPC RPC支持
 type IRPC interface {
 	// RPC RPC调用
 	RPC(dst string, cc rpcstack.CallChain, cp callpath.CallPath, args ...any) async.AsyncRet
+	// DeadlineRPC 设置截止时间的RPC调用，deadline为零值时使用默认的Future超时时间
+	DeadlineRPC(dst string, deadline time.Time, cc rpcstack.CallChain, cp callpath.CallPath, args ...any) async.AsyncRet
 	// OnewayRPC 单向RPC调用
 	OnewayRPC(dst string, cc rpcstack.CallChain, cp callpath.CallPath, args ...any) error
+	// StreamRPC 流式RPC调用，window为流控窗口，返回的句柄依次产出数据项，结束时关闭，提前停止接收时需要调用Close，截止时间限制整个流式答复，为零值时只限制等待下一个数据项的空闲时间
+	StreamRPC(dst string, deadline time.Time, window int, cc rpcstack.CallChain, cp callpath.CallPath, args ...any) rpcstream.Stream
+	// Invoke 使用调用信息发起RPC调用，可以携带跨服务传播的栈变量，单向RPC返回的异步调用结果只包含投递错误
+	Invoke(call *ClientCall) async.AsyncRet
 }
 
 func newRPC(settings ...option.Setting[RPCOptions]) IRPC {
@@ -51,6 +61,7 @@ type _RPC struct {
 	options    RPCOptions
 	terminated atomic.Bool
 	deliverers []rpcpcsr.IDeliverer
+	invoker    ClientInvoker
 }
 
 // Init 初始化插件
@@ -58,6 +69,7 @@ func (r *_RPC) Init(svcCtx service.Context, _ runtime.Context) {
 	log.Infof(svcCtx, "init addin %q", self.Name)
 
 	r.svcCtx = svcCtx
+	r.invoker = makeClientInvoker(r.invoke, r.options.Interceptors)
 
 	for _, p := range r.options.Processors {
 		if deliverer, ok := p.(rpcpcsr.IDeliverer); ok {
@@ -87,50 +99,101 @@ func (r *_RPC) Shut(svcCtx service.Context, _ runtime.Context) {
 
 // RPC RPC调用
 func (r *_RPC) RPC(dst string, cc rpcstack.CallChain, cp callpath.CallPath, args ...any) async.AsyncRet {
+	return r.DeadlineRPC(dst, time.Time{}, cc, cp, args...)
+}
+
+// DeadlineRPC 设置截止时间的RPC调用，deadline为零值时使用默认的Future超时时间
+func (r *_RPC) DeadlineRPC(dst string, deadline time.Time, cc rpcstack.CallChain, cp callpath.CallPath, args ...any) async.AsyncRet {
+	return r.Invoke(&ClientCall{
+		Dst:       dst,
+		Deadline:  deadline,
+		CallChain: cc,
+		CallPath:  cp,
+		Args:      args,
+	})
+}
+
+// OnewayRPC 单向RPC调用
+func (r *_RPC) OnewayRPC(dst string, cc rpcstack.CallChain, cp callpath.CallPath, args ...any) error {
+	ret := <-r.Invoke(&ClientCall{
+		Dst:       dst,
+		Oneway:    true,
+		CallChain: cc,
+		CallPath:  cp,
+		Args:      args,
+	})
+	return ret.Error
+}
+
+// StreamRPC 流式RPC调用，window为流控窗口，返回的句柄依次产出数据项，结束时关闭，提前停止接收时需要调用Close，截止时间限制整个流式答复，为零值时只限制等待下一个数据项的空闲时间
+func (r *_RPC) StreamRPC(dst string, deadline time.Time, window int, cc rpcstack.CallChain, cp callpath.CallPath, args ...any) rpcstream.Stream {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ret := r.Invoke(&ClientCall{
+		Dst:       dst,
+		Deadline:  deadline,
+		Window:    max(window, 1),
+		Context:   ctx,
+		CallChain: cc,
+		CallPath:  cp,
+		Args:      args,
+	})
+
+	return rpcstream.MakeStream(ret, cancel)
+}
+
+// Invoke 使用调用信息发起RPC调用，可以携带跨服务传播的栈变量，单向RPC返回的异步调用结果只包含投递错误
+func (r *_RPC) Invoke(call *ClientCall) async.AsyncRet {
 	if r.terminated.Load() {
 		ret := concurrent.MakeRespAsyncRet()
 		ret.Push(async.MakeRet(nil, rpcpcsr.ErrTerminated))
 		return ret.ToAsyncRet()
 	}
 
-	if cc == nil {
-		cc = rpcstack.EmptyCallChain
+	if call.CallChain == nil {
+		call.CallChain = rpcstack.EmptyCallChain
 	}
 
+	return r.invoker(call)
+}
+
+// invoke 投递RPC，统计调用次数与耗时，请求RPC由投递器在填入返回结果时统计
+func (r *_RPC) invoke(call *ClientCall) async.AsyncRet {
 	for i := range r.deliverers {
 		deliverer := r.deliverers[i]
 
-		if !deliverer.Match(r.svcCtx, dst, cc, cp, false) {
+		if !deliverer.Match(r.svcCtx, call.Dst, call.CallChain, call.CallPath, call.Oneway) {
 			continue
 		}
 
-		return deliverer.Request(r.svcCtx, dst, cc, cp, args)
-	}
-
-	ret := concurrent.MakeRespAsyncRet()
-	ret.Push(async.MakeRet(nil, rpcpcsr.ErrUndeliverable))
-	return ret.ToAsyncRet()
-}
+		if call.Oneway {
+			start := time.Now()
+			err := deliverer.Notify(r.svcCtx, call.Dst, call.Trace, call.CallChain, call.Baggage, call.CallPath, call.Args)
+			r.observeRPC(call.CallPath, start, err)
+			return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, err))
+		}
 
-// OnewayRPC 单向RPC调用
-func (r *_RPC) OnewayRPC(dst string, cc rpcstack.CallChain, cp callpath.CallPath, args ...any) error {
-	if r.terminated.Load() {
-		return rpcpcsr.ErrTerminated
-	}
+		if call.Window > 0 {
+			streamDeliverer, ok := deliverer.(rpcpcsr.IStreamDeliverer)
+			if !ok {
+				r.observeRPC(call.CallPath, time.Now(), rpcpcsr.ErrStreamUnsupported)
+				return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, rpcpcsr.ErrStreamUnsupported))
+			}
+			return streamDeliverer.StreamRequest(r.svcCtx, call.Context, call.Dst, call.Deadline, call.Window, call.Trace, call.CallChain, call.Baggage, call.CallPath, call.Args)
+		}
 
-	if cc == nil {
-		cc = rpcstack.EmptyCallChain
+		return deliverer.Request(r.svcCtx, call.Dst, call.Deadline, call.Trace, call.CallChain, call.Baggage, call.CallPath, call.Args)
 	}
 
-	for i := range r.deliverers {
-		deliverer := r.deliverers[i]
-
-		if !deliverer.Match(r.svcCtx, dst, cc, cp, true) {
-			continue
-		}
+	r.observeRPC(call.CallPath, time.Now(), rpcpcsr.ErrUndeliverable)
+	return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, rpcpcsr.ErrUndeliverable))
+}
 
-		return deliverer.Notify(r.svcCtx, dst, cc, cp, args)
+// observeRPC 统计调用方的RPC调用次数与耗时，未安装指标插件时不统计
+func (r *_RPC) observeRPC(cp callpath.CallPath, start time.Time, err error) {
+	m, ok := metrics.Lookup(r.svcCtx)
+	if !ok {
+		return
 	}
-
-	return rpcpcsr.ErrUndeliverable
+	m.ObserveRPC(metrics.RPCRole_Client, cp, time.Since(start), err)
 }
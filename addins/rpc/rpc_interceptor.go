@@ -0,0 +1,64 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpc
+
+import (
+	"context"
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/framework/addins/rpc/callpath"
+	"git.golaxy.org/framework/addins/rpcstack"
+	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/utils/tracing"
+	"time"
+)
+
+// ClientCall 客户端RPC调用信息
+type ClientCall struct {
+	Dst       string              // 目标地址
+	Deadline  time.Time           // 截止时间，零值表示使用默认的Future超时时间
+	Oneway    bool                // 是否为单向RPC
+	Window    int                 // 流式答复的流控窗口，大于0表示流式调用，异步调用结果依次产出数据项，结束时关闭
+	Context   context.Context     // 流式调用的上下文，取消时放弃接收并通知被调用方停止发送，为nil时不可取消
+	Trace     tracing.SpanContext // 链路追踪上下文，嵌套调用时为调用方的上下文，随请求传播
+	CallChain rpcstack.CallChain  // 调用链
+	Baggage   variant.Map         // 跨服务传播的栈变量
+	CallPath  callpath.CallPath   // 调用路径
+	Args      []any               // 参数列表
+}
+
+// ClientInvoker 客户端RPC调用执行器，单向RPC返回的异步调用结果只包含投递错误
+type ClientInvoker = func(call *ClientCall) async.AsyncRet
+
+// ClientInterceptor 客户端RPC拦截器，在投递RPC前后执行，可以修改调用信息后调用invoker继续执行，也可以直接返回结果中断执行，不能返回nil
+type ClientInterceptor = func(call *ClientCall, invoker ClientInvoker) async.AsyncRet
+
+// makeClientInvoker 创建客户端RPC调用执行器，按顺序串联拦截器，第一个拦截器在最外层
+func makeClientInvoker(invoker ClientInvoker, interceptors []ClientInterceptor) ClientInvoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		if interceptor == nil {
+			continue
+		}
+		invoker = func(call *ClientCall) async.AsyncRet {
+			return interceptor(call, next)
+		}
+	}
+	return invoker
+}
@@ -25,7 +25,8 @@ import (
 )
 
 type RPCOptions struct {
-	Processors []any
+	Processors   []any
+	Interceptors []ClientInterceptor
 }
 
 var With _Option
@@ -34,7 +35,8 @@ type _Option struct{}
 
 func (_Option) Default() option.Setting[RPCOptions] {
 	return func(options *RPCOptions) {
-		With.Processors(rpcpcsr.NewServiceProcessor(nil, true))(options)
+		With.Processors(rpcpcsr.NewServiceProcessor())(options)
+		With.Interceptors()(options)
 	}
 }
 
@@ -43,3 +45,10 @@ func (_Option) Processors(processors ...any) option.Setting[RPCOptions] {
 		options.Processors = processors
 	}
 }
+
+// Interceptors 客户端拦截器，按顺序串联，第一个拦截器在最外层
+func (_Option) Interceptors(interceptors ...ClientInterceptor) option.Setting[RPCOptions] {
+	return func(options *RPCOptions) {
+		options.Interceptors = interceptors
+	}
+}
@@ -0,0 +1,108 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpc
+
+import (
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/core/utils/types"
+	"git.golaxy.org/framework/net/gap/variant"
+)
+
+// AsyncResultVoid 转换没有返回值的方法的异步调用结果，errIdx为方法error返回值的位置，小于0表示没有，方法返回的error作为调用错误
+func AsyncResultVoid(asyncRet async.AsyncRet, errIdx int) async.AsyncRet {
+	converted := async.MakeAsyncRet()
+	go func() {
+		ret := <-asyncRet
+		if err := methodError(ret, errIdx); err != nil {
+			async.Return(converted, async.MakeRet(nil, err))
+			return
+		}
+		async.Return(converted, async.MakeRet(nil, ResultVoid(ret).Error))
+	}()
+	return converted
+}
+
+// AsyncResult1 转换有1个返回值的方法的异步调用结果，errIdx为方法error返回值的位置，小于0表示没有，方法返回的error作为调用错误
+func AsyncResult1[T1 any](asyncRet async.AsyncRet, errIdx int) async.AsyncRetT[T1] {
+	return asyncResult(asyncRet, errIdx, func(ret async.Ret) (T1, error) {
+		return Result1[T1](ret).Extract()
+	})
+}
+
+// AsyncResult2 转换有2个返回值的方法的异步调用结果，errIdx为方法error返回值的位置，小于0表示没有，方法返回的error作为调用错误
+func AsyncResult2[T1, T2 any](asyncRet async.AsyncRet, errIdx int) async.AsyncRetT[ResultTuple2[T1, T2]] {
+	return asyncResult(asyncRet, errIdx, func(ret async.Ret) (ResultTuple2[T1, T2], error) {
+		rtp := Result2[T1, T2](ret)
+		return rtp, rtp.Error
+	})
+}
+
+// AsyncResult3 转换有3个返回值的方法的异步调用结果，errIdx为方法error返回值的位置，小于0表示没有，方法返回的error作为调用错误
+func AsyncResult3[T1, T2, T3 any](asyncRet async.AsyncRet, errIdx int) async.AsyncRetT[ResultTuple3[T1, T2, T3]] {
+	return asyncResult(asyncRet, errIdx, func(ret async.Ret) (ResultTuple3[T1, T2, T3], error) {
+		rtp := Result3[T1, T2, T3](ret)
+		return rtp, rtp.Error
+	})
+}
+
+// AsyncResult4 转换有4个返回值的方法的异步调用结果，errIdx为方法error返回值的位置，小于0表示没有，方法返回的error作为调用错误
+func AsyncResult4[T1, T2, T3, T4 any](asyncRet async.AsyncRet, errIdx int) async.AsyncRetT[ResultTuple4[T1, T2, T3, T4]] {
+	return asyncResult(asyncRet, errIdx, func(ret async.Ret) (ResultTuple4[T1, T2, T3, T4], error) {
+		rtp := Result4[T1, T2, T3, T4](ret)
+		return rtp, rtp.Error
+	})
+}
+
+// AsyncResult5 转换有5个返回值的方法的异步调用结果，errIdx为方法error返回值的位置，小于0表示没有，方法返回的error作为调用错误
+func AsyncResult5[T1, T2, T3, T4, T5 any](asyncRet async.AsyncRet, errIdx int) async.AsyncRetT[ResultTuple5[T1, T2, T3, T4, T5]] {
+	return asyncResult(asyncRet, errIdx, func(ret async.Ret) (ResultTuple5[T1, T2, T3, T4, T5], error) {
+		rtp := Result5[T1, T2, T3, T4, T5](ret)
+		return rtp, rtp.Error
+	})
+}
+
+func asyncResult[T any](asyncRet async.AsyncRet, errIdx int, parse func(ret async.Ret) (T, error)) async.AsyncRetT[T] {
+	asyncRetT := async.MakeAsyncRetT[T]()
+	go func() {
+		ret := <-asyncRet
+		if err := methodError(ret, errIdx); err != nil {
+			async.ReturnT(asyncRetT, async.MakeRetT[T](types.ZeroT[T](), err))
+			return
+		}
+		v, err := parse(ret)
+		async.ReturnT(asyncRetT, async.MakeRetT[T](v, err))
+	}()
+	return asyncRetT
+}
+
+// methodError 获取方法返回的error
+func methodError(ret async.Ret, errIdx int) error {
+	if !ret.OK() || errIdx < 0 {
+		return nil
+	}
+
+	retArr, ok := ret.Value.(variant.Array)
+	if !ok || len(retArr) <= errIdx {
+		return nil
+	}
+
+	err, _ := parseRet[error](retArr, errIdx)
+	return err
+}
@@ -0,0 +1,222 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// reservedNames 生成代码使用的标识符，方法参数与之重名时需要改名
+var reservedNames = []string{"p", "method", "args", "async", "rpc", "rpcutil", "uid"}
+
+type _Proxied struct {
+	Type, Field, Doc string
+}
+
+var proxiedTab = map[string]_Proxied{
+	kindEntity:  {Type: "rpcutil.EntityProxied", Field: "ep", Doc: "实体组件"},
+	kindRuntime: {Type: "rpcutil.RuntimeProxied", Field: "rp", Doc: "运行时插件"},
+	kindService: {Type: "rpcutil.ServiceProxied", Field: "sp", Doc: "服务插件"},
+	kindClient:  {Type: "rpcutil.EntityProxied", Field: "ep", Doc: "客户端过程"},
+}
+
+var proxyTemplate = template.Must(template.New("proxy").Funcs(template.FuncMap{
+	"params":  genParams,
+	"args":    genArgs,
+	"result":  genResult,
+	"convert": genConvert,
+}).Parse(`{{if .Header}}{{.Header}}
+
+{{end}}// Code generated by {{.Command}}; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+	{{if .Name}}{{.Name}} {{end}}{{printf "%q" .Path}}
+{{- end}}
+)
+
+// {{.Ctor}} 创建{{.TypeName}}的RPC代理，{{.Target}}
+func {{.Ctor}}({{.Proxied.Field}} {{.Proxied.Type}}) {{.Proxy}} {
+	return {{.Proxy}}{ {{- .Proxied.Field}}: {{.Proxied.Field}}{{if .Service}}, service: {{printf "%q" .Service}}{{end -}} }
+}
+
+// {{.Proxy}} {{.TypeName}}的RPC代理，用于强类型调用{{.Proxied.Doc}}{{printf "%q" .Name}}的方法
+type {{.Proxy}} struct {
+	{{.Proxied.Field}} {{.Proxied.Type}}
+{{- if eq .Kind "entity" "runtime"}}
+	service string
+{{- else if eq .Kind "service"}}
+	nodeId uid.Id
+{{- end}}
+}
+{{if eq .Kind "entity" "runtime"}}
+// WithService 指定调用的服务，为空时使用全局负载均衡调用
+func (p {{.Proxy}}) WithService(service string) {{.Proxy}} {
+	p.service = service
+	return p
+}
+{{else if eq .Kind "service"}}
+// WithNode 指定调用的服务节点，不指定时使用负载均衡调用
+func (p {{.Proxy}}) WithNode(nodeId uid.Id) {{.Proxy}} {
+	p.nodeId = nodeId
+	return p
+}
+{{end}}
+{{- range .Methods}}
+// {{.Name}} 调用{{$.TypeName}}.{{.Name}}
+func (p {{$.Proxy}}) {{.Name}}({{params .}}) {{result .}} {
+	return {{convert . (printf "p.invoke(%q%s)" .Name (args .))}}
+}
+{{end}}
+func (p {{.Proxy}}) invoke(method string, args ...any) async.AsyncRet {
+{{- if eq .Kind "entity" "runtime"}}
+	if p.service == "" {
+		return p.{{.Proxied.Field}}.GlobalBalanceRPC(false, {{printf "%q" .Name}}, method, args...)
+	}
+	return p.{{.Proxied.Field}}.RPC(p.service, {{printf "%q" .Name}}, method, args...)
+{{- else if eq .Kind "service"}}
+	if p.nodeId.IsNil() {
+		return p.{{.Proxied.Field}}.BalanceRPC({{printf "%q" .Name}}, method, args...)
+	}
+	return p.{{.Proxied.Field}}.RPC(p.nodeId, {{printf "%q" .Name}}, method, args...)
+{{- else}}
+	return p.{{.Proxied.Field}}.CliRPC({{printf "%q" .Name}}, method, args...)
+{{- end}}
+}
+`))
+
+// generate 生成代理代码，service为实体组件与运行时插件代理默认调用的服务，为空时默认使用全局负载均衡调用
+func generate(src *_Source, kind, name, service, command string) ([]byte, error) {
+	imports := []_Import{{Path: asyncPath}}
+	if slices.ContainsFunc(src.Methods, func(method _Method) bool { return !method.Async }) {
+		imports = append(imports, _Import{Path: "git.golaxy.org/framework/addins/rpc"})
+	}
+	imports = append(imports, _Import{Path: "git.golaxy.org/framework/addins/rpc/rpcutil"})
+	if kind == kindService {
+		imports = append(imports, _Import{Path: "git.golaxy.org/core/utils/uid"})
+	}
+	for _, imp := range src.Imports {
+		if !slices.Contains(imports, imp) {
+			imports = append(imports, imp)
+		}
+	}
+	slices.SortFunc(imports, func(a, b _Import) int { return strings.Compare(a.Path, b.Path) })
+
+	proxy, ctor := proxyNames(src.TypeName)
+
+	var buf bytes.Buffer
+	err := proxyTemplate.Execute(&buf, map[string]any{
+		"Header":   src.Header,
+		"Command":  command,
+		"Package":  src.Package,
+		"Imports":  imports,
+		"Kind":     kind,
+		"Name":     name,
+		"Service":  service,
+		"Target":   proxyTarget(kind, service),
+		"TypeName": src.TypeName,
+		"Proxy":    proxy,
+		"Ctor":     ctor,
+		"Proxied":  proxiedTab[kind],
+		"Methods":  src.Methods,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// proxyTarget 代理默认调用的目标，写入构造函数的注释
+func proxyTarget(kind, service string) string {
+	switch kind {
+	case kindEntity, kindRuntime:
+		if service != "" {
+			return "默认调用服务" + strconv.Quote(service) + "，使用WithService指定其他服务"
+		}
+		return "默认使用全局负载均衡调用，使用WithService指定服务"
+	case kindService:
+		return "默认使用负载均衡调用，使用WithNode指定服务节点"
+	default:
+		return "调用实体所在的客户端"
+	}
+}
+
+// proxyNames 代理类型与构造函数的名称，与源类型的导出性保持一致
+func proxyNames(typeName string) (proxy, ctor string) {
+	proxy = typeName + "Proxy"
+	if ast.IsExported(typeName) {
+		return proxy, "New" + proxy
+	}
+
+	base := []rune(strings.TrimLeft(typeName, "_"))
+	if len(base) > 0 {
+		base[0] = unicode.ToUpper(base[0])
+	}
+
+	return proxy, "new" + string(base) + "Proxy"
+}
+
+func genParams(method _Method) string {
+	var params []string
+	for _, param := range method.Params {
+		params = append(params, param.Name+" "+param.Type)
+	}
+	return strings.Join(params, ", ")
+}
+
+func genArgs(method _Method) string {
+	var args string
+	for _, param := range method.Params {
+		args += ", " + param.Name
+	}
+	return args
+}
+
+func genResult(method _Method) string {
+	switch {
+	case method.Async, len(method.Results) <= 0:
+		return "async.AsyncRet"
+	case len(method.Results) == 1:
+		return "async.AsyncRetT[" + method.Results[0] + "]"
+	default:
+		return "async.AsyncRetT[rpc.ResultTuple" + strconv.Itoa(len(method.Results)) + "[" + strings.Join(method.Results, ", ") + "]]"
+	}
+}
+
+func genConvert(method _Method, call string) string {
+	switch {
+	case method.Async:
+		return call
+	case len(method.Results) <= 0:
+		return "rpc.AsyncResultVoid(" + call + ", " + strconv.Itoa(method.ErrIdx) + ")"
+	default:
+		return "rpc.AsyncResult" + strconv.Itoa(len(method.Results)) + "[" + strings.Join(method.Results, ", ") + "](" + call + ", " + strconv.Itoa(method.ErrIdx) + ")"
+	}
+}
@@ -0,0 +1,78 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	cmd := &cobra.Command{
+		Short: "生成强类型RPC代理工具。",
+		Long: `读取组件、插件或客户端过程类型的方法，生成强类型RPC代理，在go:generate中使用，例如：
+//go:generate go run git.golaxy.org/framework/addins/rpc/rpcc --type=Bag --kind=entity --service=game`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dstFile, err := run(_Options{
+				TypeName: viper.GetString("type"),
+				Kind:     viper.GetString("kind"),
+				Name:     viper.GetString("name"),
+				Service:  viper.GetString("service"),
+				Excludes: viper.GetStringSlice("exclude"),
+				File:     viper.GetString("file"),
+				Package:  viper.GetString("package"),
+				Output:   viper.GetString("output"),
+				Command:  fmt.Sprintf("rpcc %s", strings.Join(os.Args[1:], " ")),
+			})
+			if err != nil {
+				return err
+			}
+
+			log.Printf("saved to %s", dstFile)
+			return nil
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		CompletionOptions: cobra.CompletionOptions{
+			DisableDefaultCmd:   true,
+			DisableNoDescFlag:   true,
+			DisableDescriptions: true,
+		},
+	}
+	cmd.Flags().String("type", "", "type name of component, add-in or procedure")
+	cmd.Flags().String("kind", kindEntity, fmt.Sprintf("proxy kind, one of %s", strings.Join(kinds, ", ")))
+	cmd.Flags().String("name", "", "component, add-in or procedure name used in rpc, default is type name")
+	cmd.Flags().String("service", "", fmt.Sprintf("default service called by %s or %s proxy, default is global balance", kindEntity, kindRuntime))
+	cmd.Flags().StringSlice("exclude", nil, "methods excluded from proxy")
+	cmd.Flags().String("file", os.Getenv("GOFILE"), "source file declaring the type")
+	cmd.Flags().String("package", os.Getenv("GOPACKAGE"), "source package name")
+	cmd.Flags().String("output", "", "output file, default is <file>.rpc.gen.go")
+
+	if err := cmd.Execute(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,390 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	kindEntity  = "entity"  // 实体组件
+	kindRuntime = "runtime" // 运行时插件
+	kindService = "service" // 服务插件
+	kindClient  = "client"  // 客户端过程
+)
+
+var kinds = []string{kindEntity, kindRuntime, kindService, kindClient}
+
+// lifecycleMethods 生命周期方法，不生成代理
+var lifecycleMethods = []string{"Awake", "OnEnable", "Start", "Update", "LateUpdate", "OnDisable", "Shut", "Dispose", "Init", "Callee"}
+
+const (
+	asyncPath    = "git.golaxy.org/core/utils/async"
+	rpcstackPath = "git.golaxy.org/framework/addins/rpcstack"
+)
+
+// maxResults 强类型结果支持的最大返回值数量
+const maxResults = 5
+
+var versionRegexp = regexp.MustCompile(`^v[0-9]+$`)
+
+type _Import struct {
+	Name, Path string
+}
+
+type _Param struct {
+	Name, Type string
+}
+
+type _Method struct {
+	Name    string
+	Params  []_Param
+	Results []string
+	ErrIdx  int
+	Async   bool
+}
+
+type _Source struct {
+	Package  string
+	Header   string
+	TypeName string
+	Imports  []_Import
+	Methods  []_Method
+}
+
+type _Package struct {
+	name     string
+	fset     *token.FileSet
+	files    map[string]*ast.File
+	srcs     map[string][]byte
+	fileKeys []string
+}
+
+// parsePackage 解析包
+func parsePackage(dir, pkgName string) (*_Package, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &_Package{
+		name:  pkgName,
+		fset:  token.NewFileSet(),
+		files: map[string]*ast.File{},
+		srcs:  map[string][]byte{},
+	}
+
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".go") || strings.HasSuffix(fileName, "_test.go") || strings.HasSuffix(fileName, ".gen.go") {
+			continue
+		}
+
+		src, err := os.ReadFile(filepath.Join(dir, fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		file, err := parser.ParseFile(pkg.fset, fileName, src, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		if pkg.name == "" {
+			pkg.name = file.Name.Name
+		} else if file.Name.Name != pkg.name {
+			continue
+		}
+
+		pkg.files[fileName] = file
+		pkg.srcs[fileName] = src
+		pkg.fileKeys = append(pkg.fileKeys, fileName)
+	}
+
+	slices.Sort(pkg.fileKeys)
+
+	return pkg, nil
+}
+
+// lookupType 查找类型，解析类型的方法
+func (pkg *_Package) lookupType(typeName, fileName string, excludes []string, kind string) (*_Source, error) {
+	file, ok := pkg.files[fileName]
+	if !ok {
+		return nil, fmt.Errorf("rpcc: file %q not found in package %q", fileName, pkg.name)
+	}
+
+	if !pkg.hasType(typeName) {
+		return nil, fmt.Errorf("rpcc: type %q not found in package %q", typeName, pkg.name)
+	}
+
+	src := &_Source{
+		Package:  pkg.name,
+		Header:   pkg.header(fileName, file),
+		TypeName: typeName,
+	}
+
+	for _, key := range pkg.fileKeys {
+		f := pkg.files[key]
+
+		for _, decl := range f.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Recv == nil || !funcDecl.Name.IsExported() {
+				continue
+			}
+
+			if receiverName(funcDecl.Recv) != typeName || slices.Contains(excludes, funcDecl.Name.Name) {
+				continue
+			}
+
+			method, ok := pkg.parseMethod(f, funcDecl, kind, src)
+			if !ok {
+				continue
+			}
+
+			src.Methods = append(src.Methods, method)
+		}
+	}
+
+	return src, nil
+}
+
+func (pkg *_Package) hasType(typeName string) bool {
+	for _, file := range pkg.files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				if spec.(*ast.TypeSpec).Name.Name == typeName {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// header 获取源文件包声明前的版权声明
+func (pkg *_Package) header(fileName string, file *ast.File) string {
+	if len(file.Comments) <= 0 {
+		return ""
+	}
+
+	comment := file.Comments[0]
+	if comment == file.Doc || comment.End() >= file.Package {
+		return ""
+	}
+
+	return string(pkg.srcs[fileName][:pkg.fset.Position(comment.End()).Offset])
+}
+
+func (pkg *_Package) parseMethod(file *ast.File, funcDecl *ast.FuncDecl, kind string, src *_Source) (_Method, bool) {
+	method := _Method{
+		Name:   funcDecl.Name.Name,
+		ErrIdx: -1,
+	}
+
+	var params []*ast.Field
+	for _, field := range funcDecl.Type.Params.List {
+		for range max(len(field.Names), 1) {
+			params = append(params, field)
+		}
+	}
+
+	// 去除前置参数context.Context与调用链
+	if len(params) > 0 && kind != kindClient && isType(file, params[0].Type, "context", "Context") {
+		params = params[1:]
+	}
+	if len(params) > 0 && isType(file, params[0].Type, rpcstackPath, "CallChain") {
+		params = params[1:]
+	}
+
+	var names []string
+	for _, field := range funcDecl.Type.Params.List {
+		if len(field.Names) <= 0 {
+			names = append(names, "")
+			continue
+		}
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	names = names[len(names)-len(params):]
+
+	for i, field := range params {
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			log.Printf("rpcc: skip method %s.%s, variadic parameter is not supported", src.TypeName, method.Name)
+			return method, false
+		}
+
+		name := names[i]
+		if name == "" || name == "_" {
+			name = "a" + strconv.Itoa(i)
+		} else if slices.Contains(reservedNames, name) {
+			name += "_"
+		}
+
+		method.Params = append(method.Params, _Param{Name: name, Type: pkg.typeString(file, field.Type, src)})
+	}
+
+	var results []ast.Expr
+	if funcDecl.Type.Results != nil {
+		for _, field := range funcDecl.Type.Results.List {
+			for range max(len(field.Names), 1) {
+				results = append(results, field.Type)
+			}
+		}
+	}
+
+	if len(results) > 0 {
+		if ident, ok := results[len(results)-1].(*ast.Ident); ok && ident.Name == "error" {
+			method.ErrIdx = len(results) - 1
+			results = results[:len(results)-1]
+		}
+	}
+
+	for _, result := range results {
+		switch result.(type) {
+		case *ast.ChanType, *ast.FuncType:
+			log.Printf("rpcc: skip method %s.%s, stream reply is not supported", src.TypeName, method.Name)
+			return method, false
+		}
+	}
+
+	if len(results) == 1 && method.ErrIdx < 0 && isType(file, results[0], asyncPath, "AsyncRet") {
+		if kind == kindClient {
+			log.Printf("rpcc: skip method %s.%s, async result is not supported by procedure", src.TypeName, method.Name)
+			return method, false
+		}
+		method.Async = true
+		return method, true
+	}
+
+	if len(results) > maxResults {
+		log.Printf("rpcc: skip method %s.%s, too many results", src.TypeName, method.Name)
+		return method, false
+	}
+
+	for _, result := range results {
+		method.Results = append(method.Results, pkg.typeString(file, result, src))
+	}
+
+	return method, true
+}
+
+// typeString 获取类型表达式的代码，并记录表达式使用的导入包
+func (pkg *_Package) typeString(file *ast.File, expr ast.Expr, src *_Source) string {
+	ast.Inspect(expr, func(node ast.Node) bool {
+		sel, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		ident, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		imp, ok := lookupImport(file, ident.Name)
+		if ok && !slices.Contains(src.Imports, imp) {
+			src.Imports = append(src.Imports, imp)
+		}
+		return false
+	})
+
+	var buf bytes.Buffer
+	printer.Fprint(&buf, pkg.fset, expr)
+	return buf.String()
+}
+
+func receiverName(recv *ast.FieldList) string {
+	if len(recv.List) <= 0 {
+		return ""
+	}
+
+	expr := recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+
+	return ident.Name
+}
+
+// isType 判断类型表达式是否为指定包中的类型
+func isType(file *ast.File, expr ast.Expr, pkgPath, typeName string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != typeName {
+		return false
+	}
+
+	ident, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+
+	imp, ok := lookupImport(file, ident.Name)
+	return ok && imp.Path == pkgPath
+}
+
+func lookupImport(file *ast.File, name string) (_Import, bool) {
+	for _, spec := range file.Imports {
+		impPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		if spec.Name != nil {
+			if spec.Name.Name == name {
+				return _Import{Name: name, Path: impPath}, true
+			}
+			continue
+		}
+
+		if importName(impPath) == name {
+			return _Import{Path: impPath}, true
+		}
+	}
+	return _Import{}, false
+}
+
+func importName(impPath string) string {
+	base := path.Base(impPath)
+	if versionRegexp.MatchString(base) {
+		base = path.Base(path.Dir(impPath))
+	}
+	return strings.TrimPrefix(strings.TrimSuffix(base, ".go"), "go-")
+}
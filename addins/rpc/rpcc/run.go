@@ -0,0 +1,91 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// _Options 生成选项
+type _Options struct {
+	TypeName string   // 组件、插件或客户端过程的类型名
+	Kind     string   // 代理类型
+	Name     string   // 调用时使用的组件、插件或客户端过程名，为空时使用类型名
+	Service  string   // 实体组件与运行时插件代理默认调用的服务，为空时默认使用全局负载均衡调用
+	Excludes []string // 不生成代理的方法
+	File     string   // 声明类型的源文件
+	Package  string   // 源文件的包名
+	Output   string   // 输出文件，为空时使用<file>.rpc.gen.go
+	Command  string   // 生成命令，写入生成代码的注释
+}
+
+// run 生成代理代码并写入输出文件，返回输出文件路径
+func run(options _Options) (string, error) {
+	if options.TypeName == "" {
+		return "", errors.New("rpcc: --type must be specified")
+	}
+
+	if !slices.Contains(kinds, options.Kind) {
+		return "", fmt.Errorf("rpcc: --kind must be one of %s", strings.Join(kinds, ", "))
+	}
+
+	if options.Service != "" && options.Kind != kindEntity && options.Kind != kindRuntime {
+		return "", fmt.Errorf("rpcc: --service can only be used with --kind=%s or --kind=%s", kindEntity, kindRuntime)
+	}
+
+	if options.Name == "" {
+		options.Name = options.TypeName
+	}
+
+	if options.File == "" {
+		return "", errors.New("rpcc: --file or $GOFILE must be specified")
+	}
+
+	pkg, err := parsePackage(filepath.Dir(options.File), options.Package)
+	if err != nil {
+		return "", err
+	}
+
+	src, err := pkg.lookupType(options.TypeName, filepath.Base(options.File), append(slices.Clone(lifecycleMethods), options.Excludes...), options.Kind)
+	if err != nil {
+		return "", err
+	}
+
+	dstFile := options.Output
+	if dstFile == "" {
+		dstFile = strings.TrimSuffix(options.File, ".go") + ".rpc.gen.go"
+	}
+
+	code, err := generate(src, options.Kind, options.Name, options.Service, options.Command)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(dstFile, code, 0644); err != nil {
+		return "", err
+	}
+
+	return dstFile, nil
+}
@@ -0,0 +1,118 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const bagSource = `package bag
+
+import "context"
+
+type Bag struct{}
+
+func (b *Bag) Awake() {}
+
+func (b *Bag) AddItem(ctx context.Context, id string, n int) (int, error) { return n, nil }
+
+func (b *Bag) Clear() error { return nil }
+`
+
+func writeSource(t *testing.T) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "bag.go")
+	if err := os.WriteFile(file, []byte(bagSource), 0644); err != nil {
+		t.Fatalf("write source failed, %s", err)
+	}
+	return file
+}
+
+func TestRun(t *testing.T) {
+	file := writeSource(t)
+
+	dstFile, err := run(_Options{TypeName: "Bag", Kind: kindEntity, Service: "game", File: file, Package: "bag", Command: "rpcc test"})
+	if err != nil {
+		t.Fatalf("run failed, %s", err)
+	}
+	if want := strings.TrimSuffix(file, ".go") + ".rpc.gen.go"; dstFile != want {
+		t.Fatalf("got output %q, want %q", dstFile, want)
+	}
+
+	// 生成的文件不可执行，其他用户不可写
+	info, err := os.Stat(dstFile)
+	if err != nil {
+		t.Fatalf("stat output failed, %s", err)
+	}
+	if perm := info.Mode().Perm(); perm&0133 != 0 {
+		t.Fatalf("got output file mode %v", perm)
+	}
+
+	code, err := os.ReadFile(dstFile)
+	if err != nil {
+		t.Fatalf("read output failed, %s", err)
+	}
+
+	for _, want := range []string{
+		"// Code generated by rpcc test; DO NOT EDIT.",
+		`// NewBagProxy 创建Bag的RPC代理，默认调用服务"game"，使用WithService指定其他服务`,
+		`return BagProxy{ep: ep, service: "game"}`,
+		"func (p BagProxy) AddItem(id string, n int) async.AsyncRetT[int]",
+		"func (p BagProxy) Clear() async.AsyncRet",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Fatalf("output missing %q, got\n%s", want, code)
+		}
+	}
+	if strings.Contains(string(code), "Awake") {
+		t.Fatalf("output contains lifecycle method, got\n%s", code)
+	}
+
+	// 未指定服务时，默认调用目标写入注释
+	dstFile, err = run(_Options{TypeName: "Bag", Kind: kindRuntime, File: file, Package: "bag", Output: filepath.Join(filepath.Dir(file), "out.go")})
+	if err != nil {
+		t.Fatalf("run failed, %s", err)
+	}
+	code, _ = os.ReadFile(dstFile)
+	if !strings.Contains(string(code), "默认使用全局负载均衡调用") || strings.Contains(string(code), `service: "`) {
+		t.Fatalf("unexpected default target, got\n%s", code)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	file := writeSource(t)
+
+	// 参数错误时返回错误，不panic
+	cases := map[string]_Options{
+		"missing type":         {Kind: kindEntity, File: file},
+		"invalid kind":         {TypeName: "Bag", Kind: "other", File: file},
+		"service with service": {TypeName: "Bag", Kind: kindService, Service: "game", File: file},
+		"missing file":         {TypeName: "Bag", Kind: kindEntity},
+		"type not found":       {TypeName: "Box", Kind: kindEntity, File: file},
+	}
+	for name, options := range cases {
+		if _, err := run(options); err == nil {
+			t.Errorf("%s: run succeeded, want error", name)
+		}
+	}
+}
@@ -31,6 +31,8 @@ import (
 	"git.golaxy.org/framework/net/gap"
 	"git.golaxy.org/framework/net/gap/codec"
 	"git.golaxy.org/framework/net/gtp"
+	"git.golaxy.org/framework/utils/concurrent"
+	"git.golaxy.org/framework/utils/tracing"
 	"go.uber.org/zap"
 	"time"
 )
@@ -38,19 +40,23 @@ import (
 // BuildRPCli 创建RPC客户端
 func BuildRPCli() RPCliCreator {
 	return RPCliCreator{
-		rttSampling:    3,
-		msgCreator:     gap.DefaultMsgCreator(),
-		reduceCallPath: true,
+		rttSampling:       3,
+		msgCreator:        gap.DefaultMsgCreator(),
+		reduceCallPath:    true,
+		streamIdleTimeout: 30 * time.Second,
 	}
 }
 
 // RPCliCreator RPC客户端构建器
 type RPCliCreator struct {
-	settings       []option.Setting[cli.ClientOptions]
-	rttSampling    int
-	msgCreator     gap.IMsgCreator
-	reduceCallPath bool
-	mainProc       IProcedure
+	settings          []option.Setting[cli.ClientOptions]
+	rttSampling       int
+	msgCreator        gap.IMsgCreator
+	reduceCallPath    bool
+	streamIdleTimeout time.Duration
+	mainProc          IProcedure
+	traceExporter     tracing.IExporter
+	traceSampling     float64
 }
 
 func (ctor RPCliCreator) SetNetProtocol(p cli.NetProtocol) RPCliCreator {
@@ -236,6 +242,11 @@ func (ctor RPCliCreator) SetReduceCallPath(b bool) RPCliCreator {
 	return ctor
 }
 
+func (ctor RPCliCreator) SetStreamIdleTimeout(d time.Duration) RPCliCreator {
+	ctor.streamIdleTimeout = d
+	return ctor
+}
+
 func (ctor RPCliCreator) SetMainProcedure(proc any) RPCliCreator {
 	_proc, ok := proc.(IProcedure)
 	if !ok {
@@ -245,6 +256,12 @@ func (ctor RPCliCreator) SetMainProcedure(proc any) RPCliCreator {
 	return ctor
 }
 
+func (ctor RPCliCreator) SetTraceExporter(exporter tracing.IExporter, sampleRatio float64) RPCliCreator {
+	ctor.traceExporter = exporter
+	ctor.traceSampling = sampleRatio
+	return ctor
+}
+
 func (ctor RPCliCreator) SetZapLogger(logger *zap.Logger) RPCliCreator {
 	ctor.settings = append(ctor.settings, cli.With.ZapLogger(logger))
 	return ctor
@@ -274,11 +291,20 @@ func (ctor RPCliCreator) Connect(ctx context.Context, endpoint string) (*RPCli,
 	}
 
 	rpcli := &RPCli{
-		Client:         client,
-		encoder:        codec.MakeEncoder(),
-		decoder:        codec.MakeDecoder(ctor.msgCreator),
-		remoteTime:     *remoteTime,
-		reduceCallPath: ctor.reduceCallPath,
+		Client:            client,
+		encoder:           codec.MakeEncoder(),
+		decoder:           codec.MakeDecoder(ctor.msgCreator),
+		remoteTime:        *remoteTime,
+		reduceCallPath:    ctor.reduceCallPath,
+		streamIdleTimeout: ctor.streamIdleTimeout,
+		flows:             concurrent.MakeLockedMap[_StreamKey, *_StreamFlow](0),
+		receivers:         concurrent.MakeLockedMap[int64, *concurrent.RespStream](0),
+	}
+
+	if ctor.traceExporter != nil {
+		rpcli.tracer = tracing.NewTracer("cli", client.GetSessionId().String(), ctor.traceExporter, ctor.traceSampling, func(err error) {
+			client.GetLogger().Errorf("export span failed, %s", err)
+		})
 	}
 
 	if ctor.mainProc != nil {
@@ -20,15 +20,19 @@
 package rpcli
 
 import (
+	"context"
 	"errors"
 	"git.golaxy.org/core/utils/async"
 	"git.golaxy.org/core/utils/generic"
 	"git.golaxy.org/framework/addins/gate/cli"
 	"git.golaxy.org/framework/addins/rpc/callpath"
+	"git.golaxy.org/framework/addins/rpc/rpcstream"
 	"git.golaxy.org/framework/net/gap"
 	"git.golaxy.org/framework/net/gap/codec"
 	"git.golaxy.org/framework/net/gap/variant"
 	"git.golaxy.org/framework/utils/concurrent"
+	"git.golaxy.org/framework/utils/tracing"
+	"time"
 )
 
 var (
@@ -37,16 +41,29 @@ var (
 	ErrMethodNotFound               = errors.New("rpc: method not found")                // 找不到方法
 	ErrMethodParameterCountMismatch = errors.New("rpc: method parameter count mismatch") // 方法参数数量不匹配
 	ErrMethodParameterTypeMismatch  = errors.New("rpc: method parameter type mismatch")  // 方法参数类型不匹配
+	ErrStreamNotAccepted            = errors.New("rpc: stream reply not accepted")       // 调用方不接受流式答复
 )
 
+func init() {
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_ProcedureNotFound, ErrProcedureNotFound)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_MethodNotFound, ErrMethodNotFound)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_MethodParameterCountMismatch, ErrMethodParameterCountMismatch)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_MethodParameterTypeMismatch, ErrMethodParameterTypeMismatch)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_StreamNotAccepted, ErrStreamNotAccepted)
+}
+
 // RPCli RCP客户端
 type RPCli struct {
 	*cli.Client
-	encoder        codec.Encoder
-	decoder        codec.Decoder
-	remoteTime     cli.ResponseTime
-	reduceCallPath bool
-	procs          generic.SliceMap[string, IProcedure]
+	encoder           codec.Encoder
+	decoder           codec.Decoder
+	remoteTime        cli.ResponseTime
+	reduceCallPath    bool
+	streamIdleTimeout time.Duration
+	tracer            *tracing.Tracer
+	procs             generic.SliceMap[string, IProcedure]
+	flows             concurrent.LockedMap[_StreamKey, *_StreamFlow]
+	receivers         concurrent.LockedMap[int64, *concurrent.RespStream]
 }
 
 // GetRemoteTime 获取对端时间
@@ -56,43 +73,112 @@ func (c *RPCli) GetRemoteTime() cli.ResponseTime {
 
 // RPC RPC调用
 func (c *RPCli) RPC(service, comp, method string, args ...any) async.AsyncRet {
-	ret := concurrent.MakeRespAsyncRet()
-	future := concurrent.MakeFuture(c.GetFutures(), nil, ret)
+	return c.TimeoutRPC(0, service, comp, method, args...)
+}
 
-	vargs, err := variant.MakeReadonlyArray(args)
-	if err != nil {
-		future.Cancel(err)
-		return ret.ToAsyncRet()
-	}
+// TimeoutRPC 设置超时时间的RPC调用，timeout小于等于0时使用默认的Future超时时间
+func (c *RPCli) TimeoutRPC(timeout time.Duration, service, comp, method string, args ...any) async.AsyncRet {
+	return c.request(nil, timeout, 0, service, comp, method, args)
+}
 
+// StreamRPC 流式RPC调用，window为流控窗口，返回的句柄依次产出数据项，结束时关闭，提前停止接收时需要调用Close，
+// timeout限制整个流式答复，小于等于0时只限制等待下一个数据项的空闲时间
+func (c *RPCli) StreamRPC(window int, timeout time.Duration, service, comp, method string, args ...any) rpcstream.Stream {
+	ctx, cancel := context.WithCancel(context.Background())
+	return rpcstream.MakeStream(c.request(ctx, timeout, max(window, 1), service, comp, method, args), cancel)
+}
+
+// request 请求，window大于0时为流式请求，ctx取消时放弃接收流式答复
+func (c *RPCli) request(ctx context.Context, timeout time.Duration, window int, service, comp, method string, args []any) async.AsyncRet {
 	cp := callpath.CallPath{
 		Category: callpath.Entity,
 		Script:   comp,
 		Method:   method,
 	}
 
+	// 开始新的链路，调用结束时结束Span
+	span := c.tracer.StartSpan(tracing.SpanContext{}, cp.String(), tracing.SpanKind_Client)
+	span.SetAttribute("rpc.dst", service)
+
+	var ret async.AsyncRet
+	var resp concurrent.Resp
+	var stream *concurrent.RespStream
+	var future concurrent.Future
+
+	if window > 0 {
+		// 每消费半个窗口的数据项，向被调用方确认
+		stream = concurrent.MakeRespStream(ctx, int64(window), c.streamIdleTimeout, func(consumed int64) {
+			c.ackStream(service, future.Id, consumed)
+		})
+		resp = stream
+		ret = stream.ToAsyncRet()
+
+		// 未设置超时时间时，不限制整个流式答复的时长，使用空闲超时时间
+		if timeout <= 0 {
+			timeout = concurrent.FutureNoTimeout
+		}
+	} else {
+		respRet := concurrent.MakeRespAsyncRet()
+		resp = respRet
+		ret = respRet.ToAsyncRet()
+	}
+
+	future = concurrent.MakeFuture(c.GetFutures(), nil, _TracedResp{resp: resp, span: span}, timeout)
+
+	// 接收流式答复，请求结束时停止接收，调用方放弃接收或空闲超时时取消Future
+	if stream != nil {
+		c.receivers.Add(future.Id, stream)
+		context.AfterFunc(future.Finish, func() { c.receivers.Delete(future.Id) })
+
+		go func() {
+			select {
+			case <-stream.Done():
+				if err := stream.Err(); err != nil {
+					future.Cancel(err)
+				}
+			case <-future.Finish.Done():
+			}
+		}()
+	}
+
+	vargs, err := variant.MakeReadonlyArray(args)
+	if err != nil {
+		future.Cancel(err)
+		return ret
+	}
+
 	cpBuf, err := cp.Encode(c.reduceCallPath)
 	if err != nil {
 		future.Cancel(err)
-		return ret.ToAsyncRet()
+		return ret
+	}
+
+	// 截止时间使用对端时间，避免两端时钟不一致，不超时时为0
+	var deadline int64
+	if !future.Deadline.IsZero() {
+		deadline = c.remoteTime.NowTime().Add(time.Until(future.Deadline)).UnixMilli()
 	}
 
 	msg := &gap.MsgRPCRequest{
-		CorrId: future.Id,
-		Path:   cpBuf,
-		Args:   vargs,
+		CorrId:   future.Id,
+		Deadline: deadline,
+		Window:   int64(window),
+		Trace:    span.Context(),
+		Path:     cpBuf,
+		Args:     vargs,
 	}
 
 	msgBuf, err := gap.Marshal(msg)
 	if err != nil {
 		future.Cancel(err)
-		return ret.ToAsyncRet()
+		return ret
 	}
 	defer msgBuf.Release()
 
 	forwardMsg := &gap.MsgForward{
 		Dst:       service,
 		CorrId:    msg.CorrId,
+		Trace:     msg.Trace,
 		TransId:   msg.MsgId(),
 		TransData: msgBuf.Data(),
 	}
@@ -100,39 +186,52 @@ func (c *RPCli) RPC(service, comp, method string, args ...any) async.AsyncRet {
 	mpBuf, err := c.encoder.Encode(gap.Origin{Timestamp: c.remoteTime.NowTime().UnixMilli()}, 0, forwardMsg)
 	if err != nil {
 		future.Cancel(err)
-		return ret.ToAsyncRet()
+		return ret
 	}
 	defer mpBuf.Release()
 
 	if err = c.SendData(mpBuf.Data()); err != nil {
 		future.Cancel(err)
-		return ret.ToAsyncRet()
+		return ret
 	}
 
-	return ret.ToAsyncRet()
+	// 放弃请求时，通知被调用方取消
+	context.AfterFunc(future.Finish, func() {
+		if future.Abandoned() {
+			c.cancel(service, future.Id)
+		}
+	})
+
+	return ret
 }
 
 // OnewayRPC 单向RPC调用
-func (c *RPCli) OnewayRPC(service, comp, method string, args ...any) error {
-	vargs, err := variant.MakeReadonlyArray(args)
-	if err != nil {
-		return err
-	}
-
+func (c *RPCli) OnewayRPC(service, comp, method string, args ...any) (err error) {
 	cp := callpath.CallPath{
 		Category: callpath.Entity,
 		Script:   comp,
 		Method:   method,
 	}
 
+	// 开始新的链路，发送完成时结束Span
+	span := c.tracer.StartSpan(tracing.SpanContext{}, cp.String(), tracing.SpanKind_Client)
+	span.SetAttribute("rpc.dst", service)
+	defer func() { span.End(err) }()
+
+	vargs, err := variant.MakeReadonlyArray(args)
+	if err != nil {
+		return err
+	}
+
 	cpBuf, err := cp.Encode(c.reduceCallPath)
 	if err != nil {
 		return err
 	}
 
 	msg := &gap.MsgOnewayRPC{
-		Path: cpBuf,
-		Args: vargs,
+		Trace: span.Context(),
+		Path:  cpBuf,
+		Args:  vargs,
 	}
 
 	msgBuf, err := gap.Marshal(msg)
@@ -143,6 +242,7 @@ func (c *RPCli) OnewayRPC(service, comp, method string, args ...any) error {
 
 	forwardMsg := &gap.MsgForward{
 		Dst:       service,
+		Trace:     msg.Trace,
 		TransId:   msg.MsgId(),
 		TransData: msgBuf.Data(),
 	}
@@ -159,3 +259,56 @@ func (c *RPCli) OnewayRPC(service, comp, method string, args ...any) error {
 
 	return nil
 }
+
+// cancel 通知被调用方取消RPC请求
+func (c *RPCli) cancel(service string, corrId int64) {
+	if err := c.forward(service, &gap.MsgRPCCancel{CorrId: corrId}); err != nil {
+		c.GetLogger().Errorf("rpc cancel(%d) to service:%q failed, %s", corrId, service, err)
+		return
+	}
+
+	c.GetLogger().Debugf("rpc cancel(%d) to service:%q ok", corrId, service)
+}
+
+// ackStream 向发送流式答复的服务确认已消费的数据项
+func (c *RPCli) ackStream(service string, corrId, consumed int64) {
+	if err := c.forward(service, &gap.MsgRPCStreamAck{CorrId: corrId, Seq: consumed}); err != nil {
+		c.GetLogger().Errorf("rpc stream ack(%d) to service:%q failed, %s", corrId, service, err)
+		return
+	}
+}
+
+// forward 通过通信中转服务转发消息
+func (c *RPCli) forward(dst string, msg gap.Msg) error {
+	msgBuf, err := gap.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	defer msgBuf.Release()
+
+	forwardMsg := &gap.MsgForward{
+		Dst:       dst,
+		TransId:   msg.MsgId(),
+		TransData: msgBuf.Data(),
+	}
+
+	mpBuf, err := c.encoder.Encode(gap.Origin{Timestamp: c.remoteTime.NowTime().UnixMilli()}, 0, forwardMsg)
+	if err != nil {
+		return err
+	}
+	defer mpBuf.Release()
+
+	return c.SendData(mpBuf.Data())
+}
+
+// _TracedResp 填入返回结果时，结束调用的Span
+type _TracedResp struct {
+	resp concurrent.Resp
+	span *tracing.Span
+}
+
+// Push 填入返回结果
+func (r _TracedResp) Push(ret async.Ret) error {
+	r.span.End(ret.Error)
+	return r.resp.Push(ret)
+}
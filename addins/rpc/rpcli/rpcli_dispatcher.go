@@ -23,9 +23,11 @@ import (
 	"fmt"
 	"git.golaxy.org/core/utils/async"
 	"git.golaxy.org/framework/addins/rpc/callpath"
+	"git.golaxy.org/framework/addins/rpc/rpcstream"
 	"git.golaxy.org/framework/addins/rpcstack"
 	"git.golaxy.org/framework/net/gap"
 	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/utils/tracing"
 	"reflect"
 	"time"
 )
@@ -49,6 +51,18 @@ func (c *RPCli) handleRecvData(data []byte) error {
 
 	case gap.MsgId_RPC_Reply:
 		return c.resolve(mp.Msg.(*gap.MsgRPCReply))
+
+	case gap.MsgId_RPC_Cancel:
+		return c.acceptCancel(mp.Head.Src, mp.Msg.(*gap.MsgRPCCancel))
+
+	case gap.MsgId_RPC_StreamItem:
+		return c.acceptStreamItem(mp.Msg.(*gap.MsgRPCStreamItem))
+
+	case gap.MsgId_RPC_StreamEnd:
+		return c.resolveStreamEnd(mp.Msg.(*gap.MsgRPCStreamEnd))
+
+	case gap.MsgId_RPC_StreamAck:
+		return c.acceptStreamAck(mp.Head.Src, mp.Msg.(*gap.MsgRPCStreamAck))
 	}
 
 	return nil
@@ -60,16 +74,24 @@ func (c *RPCli) acceptNotify(src gap.Origin, req *gap.MsgOnewayRPC) error {
 		return fmt.Errorf("parse rpc notify path:%q failed, %s", req.Path, err)
 	}
 
-	cc := append(req.CallChain, rpcstack.Call{Svc: src.Svc, Addr: src.Addr, Timestamp: time.UnixMilli(src.Timestamp).Local(), Transit: true})
-
 	switch cp.Category {
 	case callpath.Client:
-		if rets, err := c.callProc(cc, cp.Script, cp.Method, req.Args); err != nil {
+		span := c.tracer.StartSpan(req.Trace, cp.String(), tracing.SpanKind_Server)
+		span.SetAttribute("rpc.src", src.Addr)
+
+		cc := append(req.CallChain, rpcstack.Call{Svc: src.Svc, Addr: src.Addr, Timestamp: time.UnixMilli(src.Timestamp).Local(), Transit: true})
+
+		rets, source, err := c.callProc(cc, cp.Script, cp.Method, req.Args)
+		if source != nil {
+			err = ErrStreamNotAccepted
+		}
+		if err != nil {
 			c.GetLogger().Errorf("rpc notify entity:%q, method:%q calls failed, %s", cp.Id, cp.Method, err)
 		} else {
 			c.GetLogger().Debugf("rpc notify entity:%q, method:%q calls finished", cp.Id, cp.Method)
 			rets.Release()
 		}
+		span.End(err)
 		return nil
 	}
 
@@ -80,28 +102,49 @@ func (c *RPCli) acceptRequest(src gap.Origin, req *gap.MsgRPCRequest) error {
 	cp, err := callpath.Parse(req.Path)
 	if err != nil {
 		err = fmt.Errorf("parse rpc request(%d) path %q failed, %s", req.CorrId, req.Path, err)
-		go c.reply(src, req.CorrId, nil, err)
+		go c.reply(src, req.CorrId, req.Trace, nil, err)
 		return err
 	}
 
-	cc := append(req.CallChain, rpcstack.Call{Svc: src.Svc, Addr: src.Addr, Timestamp: time.UnixMilli(src.Timestamp).Local(), Transit: true})
-
 	switch cp.Category {
 	case callpath.Client:
-		rets, err := c.callProc(cc, cp.Script, cp.Method, req.Args)
+		span := c.tracer.StartSpan(req.Trace, cp.String(), tracing.SpanKind_Server)
+		span.SetAttribute("rpc.src", src.Addr)
+		trace := span.ContextOr(req.Trace)
+
+		cc := append(req.CallChain, rpcstack.Call{Svc: src.Svc, Addr: src.Addr, Timestamp: time.UnixMilli(src.Timestamp).Local(), Transit: true})
+
+		rets, source, err := c.callProc(cc, cp.Script, cp.Method, req.Args)
+		if source != nil {
+			if req.Window > 0 {
+				// 流式答复在单独的协程中发送，避免阻塞接收消息
+				go func() {
+					err := c.replyStream(src, req, trace, source)
+					if err != nil {
+						c.GetLogger().Errorf("rpc request(%d) entity:%q, method:%q stream failed, %s", req.CorrId, cp.Id, cp.Method, err)
+					} else {
+						c.GetLogger().Debugf("rpc request(%d) entity:%q, method:%q stream finished", req.CorrId, cp.Id, cp.Method)
+					}
+					span.End(err)
+				}()
+				return nil
+			}
+			err = ErrStreamNotAccepted
+		}
 		if err != nil {
 			c.GetLogger().Errorf("rpc request(%d) entity:%q, method:%q calls failed, %s", req.CorrId, cp.Id, cp.Method, err)
 		} else {
 			c.GetLogger().Debugf("rpc request(%d) entity:%q, method:%q calls finished", req.CorrId, cp.Id, cp.Method)
 		}
-		go c.reply(src, req.CorrId, rets, err)
+		span.End(err)
+		go c.reply(src, req.CorrId, trace, rets, err)
 		return nil
 	}
 
 	return nil
 }
 
-func (c *RPCli) reply(src gap.Origin, corrId int64, rets variant.Array, retErr error) {
+func (c *RPCli) reply(src gap.Origin, corrId int64, trace tracing.SpanContext, rets variant.Array, retErr error) {
 	defer rets.Release()
 
 	if corrId == 0 {
@@ -110,6 +153,7 @@ func (c *RPCli) reply(src gap.Origin, corrId int64, rets variant.Array, retErr e
 
 	msg := &gap.MsgRPCReply{
 		CorrId: corrId,
+		Trace:  trace,
 		Rets:   rets,
 	}
 
@@ -127,6 +171,7 @@ func (c *RPCli) reply(src gap.Origin, corrId int64, rets variant.Array, retErr e
 	forwardMsg := &gap.MsgForward{
 		Dst:       src.Addr,
 		CorrId:    msg.CorrId,
+		Trace:     trace,
 		TransId:   msg.MsgId(),
 		TransData: msgBuf.Data(),
 	}
@@ -160,23 +205,31 @@ func (c *RPCli) resolve(reply *gap.MsgRPCReply) error {
 	return c.GetFutures().Resolve(reply.CorrId, ret)
 }
 
-func (c *RPCli) callProc(cc rpcstack.CallChain, procedure, method string, args variant.Array) (rets variant.Array, err error) {
+func (c *RPCli) callProc(cc rpcstack.CallChain, procedure, method string, args variant.Array) (rets variant.Array, source *rpcstream.Source, err error) {
 	proc, ok := c.procs.Get(procedure)
 	if !ok {
-		return nil, ErrProcedureNotFound
+		return nil, nil, ErrProcedureNotFound
 	}
 
 	methodRV := proc.GetReflected().MethodByName(method)
 	if !methodRV.IsValid() {
-		return nil, ErrMethodNotFound
+		return nil, nil, ErrMethodNotFound
 	}
 
 	argsRV, err := parseArgs(methodRV, cc, args)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	// 方法返回channel或迭代器时，发送流式答复
+	retsRV := methodRV.Call(argsRV)
+	if rpcstream.IsSource(retsRV) {
+		source, err = rpcstream.MakeSource(retsRV)
+		return nil, source, err
 	}
 
-	return variant.MakeSerializedArray(methodRV.Call(argsRV))
+	rets, err = variant.MakeSerializedArray(retsRV)
+	return rets, nil, err
 }
 
 func parseArgs(methodRV reflect.Value, cc rpcstack.CallChain, args variant.Array) ([]reflect.Value, error) {
@@ -0,0 +1,116 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcli
+
+import (
+	"context"
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/framework/addins/rpc/rpcstream"
+	"git.golaxy.org/framework/net/gap"
+	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/utils/tracing"
+	"time"
+)
+
+// _StreamKey 流式答复标识，不同调用方的关联Id可能重复，需要与调用方地址组合
+type _StreamKey struct {
+	Src    string
+	CorrId int64
+}
+
+// _StreamFlow 发送中的流式答复
+type _StreamFlow struct {
+	flow   *rpcstream.Flow
+	cancel context.CancelFunc
+}
+
+// replyStream 发送流式答复，数据源遍历结束后发送结束消息，调用方取消调用、超过截止时间或连接关闭时结束
+func (c *RPCli) replyStream(src gap.Origin, req *gap.MsgRPCRequest, trace tracing.SpanContext, source *rpcstream.Source) error {
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	// 截止时间为对端时间，转换为本地时间
+	if req.Deadline != 0 {
+		ctx, cancel = context.WithDeadline(c, time.Now().Add(time.UnixMilli(req.Deadline).Sub(c.remoteTime.NowTime())))
+	} else {
+		ctx, cancel = context.WithCancel(c)
+	}
+	defer cancel()
+
+	key := _StreamKey{Src: src.Addr, CorrId: req.CorrId}
+	flow := rpcstream.NewFlow(req.Window)
+
+	c.flows.Add(key, &_StreamFlow{flow: flow, cancel: cancel})
+	defer c.flows.Delete(key)
+
+	send := func(msg gap.Msg) error {
+		return c.forward(src.Addr, msg)
+	}
+
+	err := source.Send(ctx, flow, req.CorrId, send)
+
+	msg := &gap.MsgRPCStreamEnd{
+		CorrId: req.CorrId,
+		Trace:  trace,
+	}
+
+	if err != nil {
+		msg.Error = *variant.MakeError(err)
+	}
+
+	if endErr := send(msg); endErr != nil && err == nil {
+		err = endErr
+	}
+
+	return err
+}
+
+func (c *RPCli) acceptStreamAck(src gap.Origin, req *gap.MsgRPCStreamAck) error {
+	if stream, ok := c.flows.Get(_StreamKey{Src: src.Addr, CorrId: req.CorrId}); ok {
+		stream.flow.Ack(req.Seq)
+	}
+	return nil
+}
+
+func (c *RPCli) acceptCancel(src gap.Origin, req *gap.MsgRPCCancel) error {
+	// 只有流式答复可以取消
+	if stream, ok := c.flows.Get(_StreamKey{Src: src.Addr, CorrId: req.CorrId}); ok {
+		stream.cancel()
+		c.GetLogger().Debugf("rpc request(%d) stream canceled, src:%q", req.CorrId, src.Addr)
+	}
+	return nil
+}
+
+func (c *RPCli) acceptStreamItem(item *gap.MsgRPCStreamItem) error {
+	if stream, ok := c.receivers.Get(item.CorrId); ok {
+		stream.Yield(async.MakeRet(item.Rets, nil))
+	}
+	return nil
+}
+
+func (c *RPCli) resolveStreamEnd(end *gap.MsgRPCStreamEnd) error {
+	ret := async.Ret{}
+
+	if !end.Error.OK() {
+		ret.Error = &end.Error
+	}
+
+	return c.GetFutures().Resolve(end.CorrId, ret)
+}
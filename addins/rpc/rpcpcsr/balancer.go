@@ -0,0 +1,308 @@
+package rpcpcsr
+
+import (
+	"cmp"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/discovery"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// IBalancer 负载均衡器，RPC投递器使用负载均衡器从服务节点中选择目标节点
+type IBalancer interface {
+	// Select 从候选节点中选择目标节点，key为调用方提供的哈希key，可能为空，返回false表示无法选择
+	Select(service string, nodes []discovery.Node, key string) (discovery.Node, bool)
+	// Track 跟踪向节点发起的请求，请求结束时调用返回的函数
+	Track(nodeId uid.Id) func()
+}
+
+// NewWeightedRoundRobinBalancer 创建平滑加权轮询负载均衡器，节点权重读取节点元数据weightKey，缺省权重为1
+func NewWeightedRoundRobinBalancer(weightKey string) IBalancer {
+	return &_WeightedRoundRobinBalancer{
+		weightKey: weightKey,
+	}
+}
+
+// NewLeastOutstandingBalancer 创建最少未完成请求负载均衡器，选择未完成请求数与权重之比最小的节点，节点权重读取节点元数据weightKey，缺省权重为1
+func NewLeastOutstandingBalancer(weightKey string) IBalancer {
+	return &_LeastOutstandingBalancer{
+		weightKey: weightKey,
+	}
+}
+
+// NewConsistentHashBalancer 创建一致性哈希负载均衡器，相同哈希key的请求投递至相同的节点，适用于有状态服务的粘性分区。
+// replicas为每个节点的虚拟节点数量；loadFactor为有界负载系数，节点未完成请求数超过平均值的loadFactor倍时，顺延至哈希环上的下一个节点，<=1表示不限制；
+// 哈希key为空时，使用fallback选择节点，fallback为nil时随机选择节点。
+func NewConsistentHashBalancer(replicas int, loadFactor float64, fallback IBalancer) IBalancer {
+	if replicas <= 0 {
+		replicas = 100
+	}
+	return &_ConsistentHashBalancer{
+		replicas:   replicas,
+		loadFactor: loadFactor,
+		fallback:   fallback,
+	}
+}
+
+// _Outstanding 统计节点未完成的请求数，只记录有未完成请求的节点，节点离开服务发现后，在请求全部结束时删除记录
+type _Outstanding struct {
+	mutex  sync.Mutex
+	counts map[uid.Id]int64
+}
+
+// Track 跟踪向节点发起的请求，请求结束时调用返回的函数
+func (o *_Outstanding) Track(nodeId uid.Id) func() {
+	o.mutex.Lock()
+	if o.counts == nil {
+		o.counts = map[uid.Id]int64{}
+	}
+	o.counts[nodeId]++
+	o.mutex.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			o.mutex.Lock()
+			defer o.mutex.Unlock()
+
+			if o.counts[nodeId]--; o.counts[nodeId] <= 0 {
+				delete(o.counts, nodeId)
+			}
+		})
+	}
+}
+
+func (o *_Outstanding) count(nodeId uid.Id) int64 {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.counts[nodeId]
+}
+
+type _WeightedRoundRobinBalancer struct {
+	_Outstanding
+	weightKey string
+	mutex     sync.Mutex
+	current   map[string]map[uid.Id]int64
+}
+
+// Select 从候选节点中选择目标节点，key为调用方提供的哈希key，可能为空，返回false表示无法选择
+func (b *_WeightedRoundRobinBalancer) Select(service string, nodes []discovery.Node, key string) (discovery.Node, bool) {
+	if len(nodes) <= 0 {
+		return discovery.Node{}, false
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.current == nil {
+		b.current = map[string]map[uid.Id]int64{}
+	}
+
+	current := b.current[service]
+	if current == nil {
+		current = map[uid.Id]int64{}
+		b.current[service] = current
+	}
+
+	// 删除已离开服务发现的节点
+	for nodeId := range current {
+		if !slices.ContainsFunc(nodes, func(node discovery.Node) bool { return node.Id == nodeId }) {
+			delete(current, nodeId)
+		}
+	}
+
+	var total int64
+	best := -1
+
+	for i := range nodes {
+		weight := getNodeWeight(&nodes[i], b.weightKey)
+		total += weight
+
+		w := current[nodes[i].Id] + weight
+		current[nodes[i].Id] = w
+
+		if best < 0 || w > current[nodes[best].Id] {
+			best = i
+		}
+	}
+
+	current[nodes[best].Id] -= total
+
+	return nodes[best], true
+}
+
+type _LeastOutstandingBalancer struct {
+	_Outstanding
+	weightKey string
+}
+
+// Select 从候选节点中选择目标节点，key为调用方提供的哈希key，可能为空，返回false表示无法选择
+func (b *_LeastOutstandingBalancer) Select(service string, nodes []discovery.Node, key string) (discovery.Node, bool) {
+	if len(nodes) <= 0 {
+		return discovery.Node{}, false
+	}
+
+	// 随机起点，避免负载相同时总是选择同一个节点
+	offset := rand.IntN(len(nodes))
+	best := -1
+	bestLoad := math.Inf(1)
+
+	for i := range nodes {
+		node := &nodes[(offset+i)%len(nodes)]
+
+		load := float64(b.count(node.Id)) / float64(getNodeWeight(node, b.weightKey))
+		if load < bestLoad {
+			best = (offset + i) % len(nodes)
+			bestLoad = load
+		}
+	}
+
+	return nodes[best], true
+}
+
+type _ConsistentHashRing struct {
+	signature string
+	hashes    []uint64
+	nodes     []uid.Id
+}
+
+type _ConsistentHashBalancer struct {
+	_Outstanding
+	replicas   int
+	loadFactor float64
+	fallback   IBalancer
+	rings      sync.Map
+}
+
+// Select 从候选节点中选择目标节点，key为调用方提供的哈希key，可能为空，返回false表示无法选择
+func (b *_ConsistentHashBalancer) Select(service string, nodes []discovery.Node, key string) (discovery.Node, bool) {
+	if len(nodes) <= 0 {
+		return discovery.Node{}, false
+	}
+
+	if key == "" {
+		if b.fallback != nil {
+			return b.fallback.Select(service, nodes, key)
+		}
+		return nodes[rand.IntN(len(nodes))], true
+	}
+
+	ring := b.getRing(service, nodes)
+
+	hash := hashString(key)
+	pos, _ := slices.BinarySearch(ring.hashes, hash)
+
+	// 有界负载，节点负载过高时顺延至下一个节点
+	var limit float64
+	if b.loadFactor > 1 {
+		var total int64
+		for i := range nodes {
+			total += b.count(nodes[i].Id)
+		}
+		limit = math.Ceil(float64(total+1) / float64(len(nodes)) * b.loadFactor)
+	}
+
+	var first uid.Id
+
+	for i := range ring.hashes {
+		nodeId := ring.nodes[(pos+i)%len(ring.nodes)]
+		if i == 0 {
+			first = nodeId
+		}
+		if limit <= 0 || float64(b.count(nodeId)+1) <= limit {
+			return nodes[slices.IndexFunc(nodes, func(node discovery.Node) bool { return node.Id == nodeId })], true
+		}
+	}
+
+	return nodes[slices.IndexFunc(nodes, func(node discovery.Node) bool { return node.Id == first })], true
+}
+
+// Track 跟踪向节点发起的请求，请求结束时调用返回的函数
+func (b *_ConsistentHashBalancer) Track(nodeId uid.Id) func() {
+	done := b._Outstanding.Track(nodeId)
+	if b.fallback == nil {
+		return done
+	}
+	fallbackDone := b.fallback.Track(nodeId)
+	return func() {
+		done()
+		fallbackDone()
+	}
+}
+
+func (b *_ConsistentHashBalancer) getRing(service string, nodes []discovery.Node) *_ConsistentHashRing {
+	ids := make([]string, 0, len(nodes))
+	for i := range nodes {
+		ids = append(ids, nodes[i].Id.String())
+	}
+	slices.Sort(ids)
+	signature := strings.Join(ids, ",")
+
+	if v, ok := b.rings.Load(service); ok {
+		if ring := v.(*_ConsistentHashRing); ring.signature == signature {
+			return ring
+		}
+	}
+
+	type _Point struct {
+		hash   uint64
+		nodeId uid.Id
+	}
+
+	points := make([]_Point, 0, len(ids)*b.replicas)
+	for _, id := range ids {
+		for i := 0; i < b.replicas; i++ {
+			points = append(points, _Point{
+				hash:   hashString(id + "#" + strconv.Itoa(i)),
+				nodeId: uid.From(id),
+			})
+		}
+	}
+	slices.SortFunc(points, func(a, b _Point) int {
+		return cmp.Compare(a.hash, b.hash)
+	})
+
+	ring := &_ConsistentHashRing{
+		signature: signature,
+		hashes:    make([]uint64, 0, len(points)),
+		nodes:     make([]uid.Id, 0, len(points)),
+	}
+	for _, point := range points {
+		ring.hashes = append(ring.hashes, point.hash)
+		ring.nodes = append(ring.nodes, point.nodeId)
+	}
+
+	b.rings.Store(service, ring)
+	return ring
+}
+
+func getNodeWeight(node *discovery.Node, weightKey string) int64 {
+	if weightKey == "" {
+		return 1
+	}
+	weight, err := strconv.ParseInt(node.Meta[weightKey], 10, 64)
+	if err != nil || weight <= 0 {
+		return 1
+	}
+	return weight
+}
+
+// hashString 计算字符串哈希值，不同进程中计算结果一致
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+
+	// splitmix64，改善fnv哈希值的分布
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
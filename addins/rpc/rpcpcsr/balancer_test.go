@@ -0,0 +1,192 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"fmt"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/discovery"
+	"testing"
+)
+
+func makeBalanceNodes(weights ...int) []discovery.Node {
+	nodes := make([]discovery.Node, 0, len(weights))
+	for i, weight := range weights {
+		nodes = append(nodes, discovery.Node{
+			Id:   uid.From(fmt.Sprintf("node%d", i)),
+			Meta: map[string]string{"weight": fmt.Sprint(weight)},
+		})
+	}
+	return nodes
+}
+
+func TestWeightedRoundRobinBalancer(t *testing.T) {
+	b := NewWeightedRoundRobinBalancer("weight")
+	nodes := makeBalanceNodes(1, 2, 3)
+
+	// 一个轮次内按权重比例选择节点
+	counts := map[uid.Id]int{}
+	for range 6 * 100 {
+		node, ok := b.Select("svc", nodes, "")
+		if !ok {
+			t.Fatal("select failed")
+		}
+		counts[node.Id]++
+	}
+	for i, want := range []int{100, 200, 300} {
+		if got := counts[nodes[i].Id]; got != want {
+			t.Errorf("node %q selected %d times, want %d", nodes[i].Id, got, want)
+		}
+	}
+
+	// 平滑加权，权重最大的节点不会连续选中全部权重次数
+	var seq []uid.Id
+	for range 6 {
+		node, _ := b.Select("svc", nodes, "")
+		seq = append(seq, node.Id)
+	}
+	if seq[0] == seq[1] && seq[1] == seq[2] {
+		t.Errorf("got sequence %v, want smooth interleaving", seq)
+	}
+
+	if _, ok := b.Select("svc", nil, ""); ok {
+		t.Error("select from empty nodes succeeded, want failure")
+	}
+}
+
+func TestWeightedRoundRobinBalancerPrune(t *testing.T) {
+	b := NewWeightedRoundRobinBalancer("").(*_WeightedRoundRobinBalancer)
+	nodes := makeBalanceNodes(1, 1, 1)
+
+	b.Select("svc", nodes, "")
+	b.Select("svc", nodes[1:], "")
+
+	// 离开服务发现的节点不再保留状态
+	if _, ok := b.current["svc"][nodes[0].Id]; ok {
+		t.Fatalf("got state of removed node %q, want pruned", nodes[0].Id)
+	}
+	if len(b.current["svc"]) != 2 {
+		t.Fatalf("got %d node states, want 2", len(b.current["svc"]))
+	}
+}
+
+func TestLeastOutstandingBalancer(t *testing.T) {
+	b := NewLeastOutstandingBalancer("weight")
+	nodes := makeBalanceNodes(1, 1)
+
+	done1 := b.Track(nodes[0].Id)
+	done2 := b.Track(nodes[0].Id)
+
+	for range 10 {
+		if node, _ := b.Select("svc", nodes, ""); node.Id != nodes[1].Id {
+			t.Fatalf("selected %q, want least outstanding node %q", node.Id, nodes[1].Id)
+		}
+	}
+
+	// 未完成请求数按权重折算
+	weighted := makeBalanceNodes(4, 1)
+	done3 := b.Track(weighted[1].Id)
+	if node, _ := b.Select("svc", weighted, ""); node.Id != weighted[0].Id {
+		t.Fatalf("selected %q, want weighted node %q", node.Id, weighted[0].Id)
+	}
+
+	done1()
+	done1()
+	done2()
+	done3()
+
+	// 请求全部结束后删除节点记录
+	if counts := b.(*_LeastOutstandingBalancer).counts; len(counts) != 0 {
+		t.Fatalf("got outstanding counts %v, want empty", counts)
+	}
+}
+
+func TestConsistentHashBalancer(t *testing.T) {
+	b := NewConsistentHashBalancer(100, 0, nil)
+	nodes := makeBalanceNodes(1, 1, 1, 1)
+
+	// 相同key选择相同节点
+	selected := map[string]uid.Id{}
+	for i := range 100 {
+		key := fmt.Sprintf("key%d", i)
+		node, ok := b.Select("svc", nodes, key)
+		if !ok {
+			t.Fatal("select failed")
+		}
+		selected[key] = node.Id
+
+		if again, _ := b.Select("svc", nodes, key); again.Id != node.Id {
+			t.Fatalf("key %q selected %q then %q, want sticky", key, node.Id, again.Id)
+		}
+	}
+
+	// 节点离开时，只有映射到该节点的key迁移
+	for key, nodeId := range selected {
+		node, _ := b.Select("svc", nodes[1:], key)
+		if nodeId != nodes[0].Id && node.Id != nodeId {
+			t.Fatalf("key %q moved from %q to %q after unrelated node left", key, nodeId, node.Id)
+		}
+	}
+}
+
+func TestConsistentHashBalancerBoundedLoad(t *testing.T) {
+	b := NewConsistentHashBalancer(100, 1.5, nil)
+	nodes := makeBalanceNodes(1, 1)
+
+	hot, _ := b.Select("svc", nodes, "hot")
+
+	var dones []func()
+	for range 4 {
+		dones = append(dones, b.Track(hot.Id))
+	}
+
+	// 节点负载超过平均值的loadFactor倍时顺延至下一个节点
+	if node, _ := b.Select("svc", nodes, "hot"); node.Id == hot.Id {
+		t.Fatalf("selected overloaded node %q, want relief", node.Id)
+	}
+
+	for _, done := range dones {
+		done()
+	}
+
+	if node, _ := b.Select("svc", nodes, "hot"); node.Id != hot.Id {
+		t.Fatalf("selected %q after load drained, want %q", node.Id, hot.Id)
+	}
+}
+
+func TestGetNodeWeight(t *testing.T) {
+	tests := []struct {
+		meta map[string]string
+		key  string
+		want int64
+	}{
+		{map[string]string{"weight": "5"}, "weight", 5},
+		{map[string]string{"weight": "5"}, "", 1},
+		{map[string]string{"weight": "0"}, "weight", 1},
+		{map[string]string{"weight": "x"}, "weight", 1},
+		{nil, "weight", 1},
+	}
+	for _, tt := range tests {
+		node := discovery.Node{Meta: tt.meta}
+		if got := getNodeWeight(&node, tt.key); got != tt.want {
+			t.Errorf("getNodeWeight(%v, %q) = %d, want %d", tt.meta, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"errors"
+	"git.golaxy.org/core/service"
+	"git.golaxy.org/core/utils/option"
+	"git.golaxy.org/framework/addins/log"
+	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/utils/concurrent"
+	"sync"
+	"time"
+)
+
+// CircuitState 熔断器状态
+type CircuitState int32
+
+const (
+	CircuitState_Closed   CircuitState = iota // 关闭，正常投递请求
+	CircuitState_Open                         // 打开，快速失败
+	CircuitState_HalfOpen                     // 半开，放行少量探测请求
+)
+
+// String 状态名称
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitState_Closed:
+		return "closed"
+	case CircuitState_Open:
+		return "open"
+	case CircuitState_HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// ICircuitBreaker 熔断器接口，按投递目标（服务节点地址）分别熔断
+type ICircuitBreaker interface {
+	// Allow 是否允许向目标投递请求，熔断器打开时返回ErrCircuitOpen，允许时请求结束后需要调用report报告结果
+	Allow(target string) (report func(err error), err error)
+	// State 查询目标的熔断器状态
+	State(target string) CircuitState
+}
+
+// NewCircuitBreaker 创建熔断器，请求超时与投递失败视为失败，被调用方返回的错误与调用方取消请求不影响熔断器状态
+func NewCircuitBreaker(settings ...option.Setting[CircuitBreakerOptions]) ICircuitBreaker {
+	return &_CircuitBreaker{
+		options: option.Make(BreakerWith.Default(), settings...),
+	}
+}
+
+// initCircuitBreaker 初始化RPC处理器使用的熔断器，breaker为nil时使用默认选项创建
+func initCircuitBreaker(svcCtx service.Context, breaker ICircuitBreaker) ICircuitBreaker {
+	if breaker == nil {
+		breaker = NewCircuitBreaker()
+	}
+	if b, ok := breaker.(*_CircuitBreaker); ok {
+		b.init(svcCtx)
+	}
+	return breaker
+}
+
+type _CircuitBreaker struct {
+	options  CircuitBreakerOptions
+	svcCtx   service.Context
+	circuits sync.Map
+}
+
+// init 初始化，用于输出状态变化日志
+func (b *_CircuitBreaker) init(svcCtx service.Context) {
+	b.svcCtx = svcCtx
+}
+
+// Allow 是否允许向目标投递请求，熔断器打开时返回ErrCircuitOpen，允许时请求结束后需要调用report报告结果
+func (b *_CircuitBreaker) Allow(target string) (func(err error), error) {
+	start := time.Now()
+
+	v, ok := b.circuits.Load(target)
+	if !ok {
+		// 目标健康时不创建熔断状态，出现失败或慢请求时再创建
+		return func(err error) {
+			failed, slow, ok := b.classify(err, start)
+			if !ok || (!failed && !slow) {
+				return
+			}
+			v, _ := b.circuits.LoadOrStore(target, &_Circuit{})
+			v.(*_Circuit).report(b, target, -1, failed, slow)
+		}, nil
+	}
+
+	circuit := v.(*_Circuit)
+
+	gen, err := circuit.allow(b, target)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(err error) {
+		failed, slow, ok := b.classify(err, start)
+		if !ok {
+			circuit.release(gen)
+			return
+		}
+		circuit.report(b, target, gen, failed, slow)
+	}, nil
+}
+
+// State 查询目标的熔断器状态
+func (b *_CircuitBreaker) State(target string) CircuitState {
+	v, ok := b.circuits.Load(target)
+	if !ok {
+		return CircuitState_Closed
+	}
+	return v.(*_Circuit).current(b)
+}
+
+// classify 判断请求结果是否为失败或慢请求，返回false表示结果不影响熔断器状态
+func (b *_CircuitBreaker) classify(err error, start time.Time) (failed, slow, ok bool) {
+	if err != nil {
+		// 被调用方返回的错误，说明节点可以正常处理请求
+		var remoteErr *variant.Error
+		if errors.As(err, &remoteErr) {
+			return false, false, true
+		}
+		// 调用方取消请求或服务关闭
+		if errors.Is(err, concurrent.ErrFutureCanceled) || errors.Is(err, concurrent.ErrFuturesClosed) {
+			return false, false, false
+		}
+		failed = true
+	}
+
+	slow = b.options.SlowCallDuration > 0 && time.Since(start) >= b.options.SlowCallDuration
+	return failed, slow, true
+}
+
+func (b *_CircuitBreaker) changed(target string, curr, last CircuitState) {
+	if b.svcCtx != nil {
+		log.Infof(b.svcCtx, "rpc circuit breaker of target %q state %q => %q", target, last, curr)
+	}
+
+	b.options.StateChangedHandler.SafeCall(func(panicErr error) bool {
+		if panicErr != nil && b.svcCtx != nil {
+			log.Errorf(b.svcCtx, "handle rpc circuit breaker of target %q state changed failed, %s", target, panicErr)
+		}
+		return false
+	}, target, curr, last)
+}
+
+// _Circuit 单个目标的熔断状态
+type _Circuit struct {
+	mutex     sync.Mutex
+	state     CircuitState
+	gen       int64 // 状态版本号，状态变化后，之前放行的请求结果不再影响熔断器
+	failures  int
+	slows     int
+	openedAt  time.Time
+	probes    int
+	successes int
+}
+
+func (c *_Circuit) allow(b *_CircuitBreaker, target string) (int64, error) {
+	c.mutex.Lock()
+
+	last := c.state
+	c.tryHalfOpen(b)
+
+	var err error
+
+	switch c.state {
+	case CircuitState_Open:
+		err = ErrCircuitOpen
+	case CircuitState_HalfOpen:
+		if c.probes >= b.options.HalfOpenProbes {
+			err = ErrCircuitOpen
+		} else {
+			c.probes++
+		}
+	}
+
+	curr, gen := c.state, c.gen
+	c.mutex.Unlock()
+
+	if last != curr {
+		b.changed(target, curr, last)
+	}
+
+	return gen, err
+}
+
+// release 释放不影响熔断器状态的探测请求
+func (c *_Circuit) release(gen int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.gen == gen && c.state == CircuitState_HalfOpen && c.probes > 0 {
+		c.probes--
+	}
+}
+
+// report 报告请求结果，gen<0表示请求放行时熔断状态还未创建
+func (c *_Circuit) report(b *_CircuitBreaker, target string, gen int64, failed, slow bool) {
+	c.mutex.Lock()
+
+	if (gen >= 0 && gen != c.gen) || (gen < 0 && c.state != CircuitState_Closed) {
+		c.mutex.Unlock()
+		return
+	}
+
+	last := c.state
+
+	switch c.state {
+	case CircuitState_Closed:
+		if failed {
+			c.failures++
+		} else {
+			c.failures = 0
+		}
+		if slow {
+			c.slows++
+		} else {
+			c.slows = 0
+		}
+
+		if (b.options.FailureThreshold > 0 && c.failures >= b.options.FailureThreshold) ||
+			(b.options.SlowCallThreshold > 0 && c.slows >= b.options.SlowCallThreshold) {
+			c.setState(CircuitState_Open)
+		} else if c.failures <= 0 && c.slows <= 0 {
+			// 目标恢复健康，删除熔断状态
+			b.circuits.CompareAndDelete(target, c)
+		}
+
+	case CircuitState_HalfOpen:
+		if failed || slow {
+			c.setState(CircuitState_Open)
+		} else if c.successes++; c.successes >= b.options.HalfOpenProbes {
+			c.setState(CircuitState_Closed)
+		}
+	}
+
+	curr := c.state
+	c.mutex.Unlock()
+
+	if last != curr {
+		b.changed(target, curr, last)
+	}
+}
+
+func (c *_Circuit) current(b *_CircuitBreaker) CircuitState {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.state == CircuitState_Open && time.Since(c.openedAt) >= b.options.OpenDuration {
+		return CircuitState_HalfOpen
+	}
+	return c.state
+}
+
+func (c *_Circuit) tryHalfOpen(b *_CircuitBreaker) {
+	if c.state == CircuitState_Open && time.Since(c.openedAt) >= b.options.OpenDuration {
+		c.setState(CircuitState_HalfOpen)
+	}
+}
+
+func (c *_Circuit) setState(state CircuitState) {
+	c.state = state
+	c.gen++
+	c.failures = 0
+	c.slows = 0
+	c.probes = 0
+	c.successes = 0
+	if state == CircuitState_Open {
+		c.openedAt = time.Now()
+	}
+}
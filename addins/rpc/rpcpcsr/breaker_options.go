@@ -0,0 +1,96 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"git.golaxy.org/core/utils/generic"
+	"git.golaxy.org/core/utils/option"
+	"time"
+)
+
+// CircuitStateChangedHandler 熔断器状态变化的处理器（args: [target, curState, lastState]）
+type CircuitStateChangedHandler = generic.DelegateVoid3[string, CircuitState, CircuitState]
+
+// CircuitBreakerOptions 熔断器的所有选项
+type CircuitBreakerOptions struct {
+	FailureThreshold    int                        // 连续失败多少次后打开熔断器，<=0表示不按失败次数熔断
+	SlowCallDuration    time.Duration              // 请求耗时达到该值时视为慢请求，<=0表示不统计慢请求
+	SlowCallThreshold   int                        // 连续慢请求多少次后打开熔断器，<=0表示不按慢请求熔断
+	OpenDuration        time.Duration              // 熔断器打开后，等待多久进入半开状态
+	HalfOpenProbes      int                        // 半开状态下放行的探测请求数，全部成功后关闭熔断器
+	StateChangedHandler CircuitStateChangedHandler // 熔断器状态变化的处理器
+}
+
+var BreakerWith _BreakerOption
+
+type _BreakerOption struct{}
+
+// Default 默认值
+func (_BreakerOption) Default() option.Setting[CircuitBreakerOptions] {
+	return func(options *CircuitBreakerOptions) {
+		BreakerWith.FailureThreshold(5).Apply(options)
+		BreakerWith.SlowCall(0, 5).Apply(options)
+		BreakerWith.OpenDuration(5 * time.Second).Apply(options)
+		BreakerWith.HalfOpenProbes(1).Apply(options)
+		BreakerWith.StateChangedHandler(nil).Apply(options)
+	}
+}
+
+// FailureThreshold 连续失败多少次后打开熔断器，<=0表示不按失败次数熔断
+func (_BreakerOption) FailureThreshold(n int) option.Setting[CircuitBreakerOptions] {
+	return func(options *CircuitBreakerOptions) {
+		options.FailureThreshold = n
+	}
+}
+
+// SlowCall 请求耗时达到duration时视为慢请求，连续threshold次慢请求后打开熔断器，duration<=0表示不统计慢请求
+func (_BreakerOption) SlowCall(duration time.Duration, threshold int) option.Setting[CircuitBreakerOptions] {
+	return func(options *CircuitBreakerOptions) {
+		options.SlowCallDuration = duration
+		options.SlowCallThreshold = threshold
+	}
+}
+
+// OpenDuration 熔断器打开后，等待多久进入半开状态
+func (_BreakerOption) OpenDuration(d time.Duration) option.Setting[CircuitBreakerOptions] {
+	return func(options *CircuitBreakerOptions) {
+		if d <= 0 {
+			d = 5 * time.Second
+		}
+		options.OpenDuration = d
+	}
+}
+
+// HalfOpenProbes 半开状态下放行的探测请求数，全部成功后关闭熔断器
+func (_BreakerOption) HalfOpenProbes(n int) option.Setting[CircuitBreakerOptions] {
+	return func(options *CircuitBreakerOptions) {
+		if n <= 0 {
+			n = 1
+		}
+		options.HalfOpenProbes = n
+	}
+}
+
+// StateChangedHandler 熔断器状态变化的处理器
+func (_BreakerOption) StateChangedHandler(handler CircuitStateChangedHandler) option.Setting[CircuitBreakerOptions] {
+	return func(options *CircuitBreakerOptions) {
+		options.StateChangedHandler = handler
+	}
+}
@@ -0,0 +1,168 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"errors"
+	"git.golaxy.org/core/utils/generic"
+	"git.golaxy.org/framework/addins/dsvc"
+	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/net/netpath"
+	"git.golaxy.org/framework/utils/concurrent"
+	"sync"
+	"testing"
+	"time"
+)
+
+func mustAllow(t *testing.T, b ICircuitBreaker, target string) func(err error) {
+	t.Helper()
+	report, err := b.Allow(target)
+	if err != nil {
+		t.Fatalf("allow %q failed, %s", target, err)
+	}
+	return report
+}
+
+func TestCircuitBreakerFailures(t *testing.T) {
+	var mutex sync.Mutex
+	var changes []CircuitState
+
+	b := NewCircuitBreaker(
+		BreakerWith.FailureThreshold(3),
+		BreakerWith.OpenDuration(50*time.Millisecond),
+		BreakerWith.StateChangedHandler(generic.CastDelegateVoid3(func(target string, curr, last CircuitState) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			changes = append(changes, curr)
+		})),
+	)
+
+	// 被调用方返回的错误与调用方取消请求不计入失败
+	mustAllow(t, b, "n1")(&variant.Error{Message: "remote"})
+	mustAllow(t, b, "n1")(concurrent.ErrFutureCanceled)
+
+	for i := 0; i < 3; i++ {
+		mustAllow(t, b, "n1")(ErrUndeliverable)
+	}
+	if s := b.State("n1"); s != CircuitState_Open {
+		t.Fatalf("got state %q, want %q", s, CircuitState_Open)
+	}
+	if _, err := b.Allow("n1"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow open circuit returned %v, want %v", err, ErrCircuitOpen)
+	}
+
+	// 其他目标不受影响
+	if s := b.State("n2"); s != CircuitState_Closed {
+		t.Fatalf("got state %q of other target, want %q", s, CircuitState_Closed)
+	}
+
+	// 等待后进入半开状态，只放行探测请求，探测失败重新打开
+	time.Sleep(60 * time.Millisecond)
+	report := mustAllow(t, b, "n1")
+	if _, err := b.Allow("n1"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow second probe returned %v, want %v", err, ErrCircuitOpen)
+	}
+	report(ErrUndeliverable)
+	if s := b.State("n1"); s != CircuitState_Open {
+		t.Fatalf("got state %q after failed probe, want %q", s, CircuitState_Open)
+	}
+
+	// 探测成功后关闭
+	time.Sleep(60 * time.Millisecond)
+	mustAllow(t, b, "n1")(nil)
+	if s := b.State("n1"); s != CircuitState_Closed {
+		t.Fatalf("got state %q after probe succeeded, want %q", s, CircuitState_Closed)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	want := []CircuitState{CircuitState_Open, CircuitState_HalfOpen, CircuitState_Open, CircuitState_HalfOpen, CircuitState_Closed}
+	if len(changes) != len(want) {
+		t.Fatalf("got state changes %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("got state changes %v, want %v", changes, want)
+		}
+	}
+}
+
+func TestCircuitBreakerSlowCalls(t *testing.T) {
+	b := NewCircuitBreaker(
+		BreakerWith.FailureThreshold(0),
+		BreakerWith.SlowCall(10*time.Millisecond, 2),
+	)
+
+	for i := 0; i < 2; i++ {
+		report := mustAllow(t, b, "n1")
+		time.Sleep(15 * time.Millisecond)
+		report(nil)
+	}
+	if s := b.State("n1"); s != CircuitState_Open {
+		t.Fatalf("got state %q, want %q", s, CircuitState_Open)
+	}
+}
+
+type _CountingBreaker struct {
+	ICircuitBreaker
+	targets []string
+}
+
+func (b *_CountingBreaker) Allow(target string) (func(err error), error) {
+	b.targets = append(b.targets, target)
+	return b.ICircuitBreaker.Allow(target)
+}
+
+func TestAllowCircuit(t *testing.T) {
+	details := &dsvc.NodeDetails{}
+	details.DomainRoot = netpath.Domain{Path: "svc", Sep: "."}
+	details.DomainBalance = netpath.Domain{Path: "svc.lb", Sep: "."}
+	details.DomainUnicast = netpath.Domain{Path: "svc.ep", Sep: "."}
+
+	b := &_CountingBreaker{ICircuitBreaker: NewCircuitBreaker(BreakerWith.FailureThreshold(1))}
+
+	// 负载均衡地址不使用熔断器，失败不会熔断整个服务
+	for i := 0; i < 3; i++ {
+		report, err := allowCircuit(b, details, details.MakeBalanceAddr("user"))
+		if err != nil || report != nil {
+			t.Fatalf("allow balance addr returned report %v, err %v, want nil", report != nil, err)
+		}
+	}
+	if len(b.targets) != 0 {
+		t.Fatalf("breaker used for balance addr, targets %v", b.targets)
+	}
+
+	// 节点地址使用熔断器
+	nodeAddr := details.DomainUnicast.Join("n1")
+
+	report, err := allowCircuit(b, details, nodeAddr)
+	if err != nil || report == nil {
+		t.Fatalf("allow node addr returned report %v, err %v", report != nil, err)
+	}
+	report(ErrUndeliverable)
+
+	if _, err := allowCircuit(b, details, nodeAddr); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow open node addr returned %v, want %v", err, ErrCircuitOpen)
+	}
+	if len(b.targets) != 2 || b.targets[0] != nodeAddr {
+		t.Fatalf("got breaker targets %v, want node addr", b.targets)
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"git.golaxy.org/core/utils/async"
 	"git.golaxy.org/core/utils/types"
 	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/rpc/rpcstream"
 	"git.golaxy.org/framework/addins/rpcstack"
 	"git.golaxy.org/framework/net/gap/variant"
 	"reflect"
@@ -41,15 +42,20 @@ type ICallee interface {
 
 var (
 	callChainRT = reflect.TypeFor[rpcstack.CallChain]()
+	contextRT   = reflect.TypeFor[context.Context]()
 )
 
-func CallService(svcCtx service.Context, cc rpcstack.CallChain, addInName, method string, args variant.Array) (_ variant.Array, err error) {
+func CallService(ctx context.Context, svcCtx service.Context, cc rpcstack.CallChain, addInName, method string, args variant.Array) (_ any, err error) {
 	defer func() {
 		if panicErr := types.Panic2Err(recover()); panicErr != nil {
 			err = fmt.Errorf("%w: %w", core.ErrPanicked, panicErr)
 		}
 	}()
 
+	if err := contextErr(ctx); err != nil {
+		return nil, err
+	}
+
 	var scriptRV reflect.Value
 
 	if addInName == "" {
@@ -80,15 +86,15 @@ func CallService(svcCtx service.Context, cc rpcstack.CallChain, addInName, metho
 		}
 	}
 
-	argsRV, err := parseArgs(methodRV, cc, args)
+	argsRV, err := parseArgs(methodRV, ctx, cc, args)
 	if err != nil {
 		return nil, err
 	}
 
-	return variant.MakeSerializedArray(methodRV.Call(argsRV))
+	return makeRets(methodRV.Call(argsRV))
 }
 
-func CallRuntime(svcCtx service.Context, cc rpcstack.CallChain, entityId uid.Id, addInName, method string, args variant.Array) (_ async.AsyncRet, err error) {
+func CallRuntime(ctx context.Context, svcCtx service.Context, cc rpcstack.CallChain, entityId uid.Id, addInName, method string, args variant.Array) (_ async.AsyncRet, err error) {
 	defer func() {
 		if panicErr := types.Panic2Err(recover()); panicErr != nil {
 			err = fmt.Errorf("%w: %w", core.ErrPanicked, panicErr)
@@ -96,6 +102,11 @@ func CallRuntime(svcCtx service.Context, cc rpcstack.CallChain, entityId uid.Id,
 	}()
 
 	return svcCtx.CallAsync(entityId, func(entity ec.Entity, _ ...any) async.Ret {
+		// 在实体线程中排队期间，调用可能已被取消或超过截止时间
+		if err := contextErr(ctx); err != nil {
+			return async.MakeRet(nil, err)
+		}
+
 		var scriptRV reflect.Value
 
 		if addInName == "" {
@@ -126,13 +137,13 @@ func CallRuntime(svcCtx service.Context, cc rpcstack.CallChain, entityId uid.Id,
 			}
 		}
 
-		argsRV, err := parseArgs(methodRV, cc, args)
+		argsRV, err := parseArgs(methodRV, ctx, cc, args)
 		if err != nil {
 			return async.MakeRet(nil, err)
 		}
 
 		stack := rpcstack.Using(runtime.Current(entity))
-		rpcstack.UnsafeRPCStack(stack).PushCallChain(cc)
+		rpcstack.UnsafeRPCStack(stack).PushCallChain(ctx, cc)
 		defer rpcstack.UnsafeRPCStack(stack).PopCallChain()
 
 		retsRV := methodRV.Call(argsRV)
@@ -142,11 +153,11 @@ func CallRuntime(svcCtx service.Context, cc rpcstack.CallChain, entityId uid.Id,
 			}
 		}
 
-		return async.MakeRet(variant.MakeSerializedArray(retsRV))
+		return async.MakeRet(makeRets(retsRV))
 	}), nil
 }
 
-func CallEntity(svcCtx service.Context, cc rpcstack.CallChain, entityId uid.Id, component, method string, args variant.Array) (_ async.AsyncRet, err error) {
+func CallEntity(ctx context.Context, svcCtx service.Context, cc rpcstack.CallChain, entityId uid.Id, component, method string, args variant.Array) (_ async.AsyncRet, err error) {
 	defer func() {
 		if panicErr := types.Panic2Err(recover()); panicErr != nil {
 			err = fmt.Errorf("%w: %w", core.ErrPanicked, panicErr)
@@ -154,6 +165,11 @@ func CallEntity(svcCtx service.Context, cc rpcstack.CallChain, entityId uid.Id,
 	}()
 
 	return svcCtx.CallAsync(entityId, func(entity ec.Entity, _ ...any) async.Ret {
+		// 在实体线程中排队期间，调用可能已被取消或超过截止时间
+		if err := contextErr(ctx); err != nil {
+			return async.MakeRet(nil, err)
+		}
+
 		var scriptRV reflect.Value
 
 		if component == "" {
@@ -179,13 +195,13 @@ func CallEntity(svcCtx service.Context, cc rpcstack.CallChain, entityId uid.Id,
 			}
 		}
 
-		argsRV, err := parseArgs(methodRV, cc, args)
+		argsRV, err := parseArgs(methodRV, ctx, cc, args)
 		if err != nil {
 			return async.MakeRet(nil, err)
 		}
 
 		stack := rpcstack.Using(runtime.Current(entity))
-		rpcstack.UnsafeRPCStack(stack).PushCallChain(cc)
+		rpcstack.UnsafeRPCStack(stack).PushCallChain(ctx, cc)
 		defer rpcstack.UnsafeRPCStack(stack).PopCallChain()
 
 		retsRV := methodRV.Call(argsRV)
@@ -195,21 +211,32 @@ func CallEntity(svcCtx service.Context, cc rpcstack.CallChain, entityId uid.Id,
 			}
 		}
 
-		return async.MakeRet(variant.MakeSerializedArray(retsRV))
+		return async.MakeRet(makeRets(retsRV))
 	}), nil
 }
 
-func parseArgs(methodRV reflect.Value, cc rpcstack.CallChain, args variant.Array) ([]reflect.Value, error) {
+func parseArgs(methodRV reflect.Value, ctx context.Context, cc rpcstack.CallChain, args variant.Array) ([]reflect.Value, error) {
 	methodRT := methodRV.Type()
 	var argsRV []reflect.Value
 	var argsPos int
 
+	// 方法的前置参数，支持context.Context与调用链
 	switch methodRT.NumIn() {
+	case len(args) + 2:
+		if methodRT.In(0) != contextRT || !callChainRT.AssignableTo(methodRT.In(1)) {
+			return nil, ErrMethodParameterTypeMismatch
+		}
+		argsRV = append(make([]reflect.Value, 0, len(args)+2), reflect.ValueOf(&ctx).Elem(), reflect.ValueOf(cc))
+		argsPos = 2
+
 	case len(args) + 1:
-		if !callChainRT.AssignableTo(methodRT.In(0)) {
+		if methodRT.In(0) == contextRT {
+			argsRV = append(make([]reflect.Value, 0, len(args)+1), reflect.ValueOf(&ctx).Elem())
+		} else if callChainRT.AssignableTo(methodRT.In(0)) {
+			argsRV = append(make([]reflect.Value, 0, len(args)+1), reflect.ValueOf(cc))
+		} else {
 			return nil, ErrMethodParameterTypeMismatch
 		}
-		argsRV = append(make([]reflect.Value, 0, len(args)+1), reflect.ValueOf(cc))
 		argsPos = 1
 
 	case len(args):
@@ -231,31 +258,52 @@ func parseArgs(methodRV reflect.Value, cc rpcstack.CallChain, args variant.Array
 	return argsRV, nil
 }
 
-func waitAsyncRet(ctx context.Context, asyncRet async.AsyncRet) (variant.Array, error) {
+// makeRets 使用方法返回值创建调用结果，方法返回channel或迭代器时，创建流式答复的数据源
+func makeRets(retsRV []reflect.Value) (any, error) {
+	if rpcstream.IsSource(retsRV) {
+		return rpcstream.MakeSource(retsRV)
+	}
+	return variant.MakeSerializedArray(retsRV)
+}
+
+// waitReply 等待调用结果，结果为流式答复时，返回流式答复的数据源
+func waitReply(ctx context.Context, asyncRet async.AsyncRet) (variant.Array, *rpcstream.Source, error) {
 	for {
 		ret := asyncRet.Wait(ctx)
 		if !ret.OK() {
-			return nil, ret.Error
+			return nil, nil, ret.Error
 		}
 
 		var ok bool
 		asyncRet, ok = ret.Value.(async.AsyncRet)
 		if ok {
 			if asyncRet == nil {
-				return nil, ErrAsyncMethodReturnedNil
+				return nil, nil, ErrAsyncMethodReturnedNil
 			}
 			continue
 		}
 
-		if rets, ok := ret.Value.(variant.Array); ok {
-			return rets, nil
+		switch v := ret.Value.(type) {
+		case variant.Array:
+			return v, nil, nil
+		case *rpcstream.Source:
+			return nil, v, nil
 		}
 
 		rets, err := variant.MakeSerializedArray([]any{ret.Value})
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		return rets, nil
+		return rets, nil, nil
+	}
+}
+
+// waitAsyncRet 等待调用结果，调用方不接受流式答复
+func waitAsyncRet(ctx context.Context, asyncRet async.AsyncRet) (variant.Array, error) {
+	rets, source, err := waitReply(ctx, asyncRet)
+	if source != nil {
+		return nil, ErrStreamNotAccepted
 	}
+	return rets, err
 }
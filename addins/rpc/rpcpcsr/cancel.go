@@ -0,0 +1,95 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"context"
+	"errors"
+	"git.golaxy.org/framework/utils/concurrent"
+	"time"
+)
+
+// _CallKey 调用标识，不同调用方的关联Id可能重复，需要与调用方地址组合
+type _CallKey struct {
+	Src    string
+	CorrId int64
+}
+
+// _Calls 正在处理的RPC请求，用于支持调用方取消调用
+type _Calls struct {
+	cancels concurrent.LockedMap[_CallKey, context.CancelFunc]
+}
+
+func (c *_Calls) init() {
+	c.cancels = concurrent.MakeLockedMap[_CallKey, context.CancelFunc](0)
+}
+
+// begin 开始处理RPC请求，创建可取消的调用上下文，截止时间不为零值时，超过截止时间自动取消，处理结束时需要调用返回的函数
+func (c *_Calls) begin(parent context.Context, src string, corrId int64, deadline time.Time) (context.Context, func()) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	if deadline.IsZero() {
+		ctx, cancel = context.WithCancel(parent)
+	} else {
+		ctx, cancel = context.WithDeadline(parent, deadline)
+	}
+
+	if corrId == 0 {
+		return ctx, cancel
+	}
+
+	key := _CallKey{Src: src, CorrId: corrId}
+	c.cancels.Add(key, cancel)
+
+	return ctx, func() {
+		c.cancels.Delete(key)
+		cancel()
+	}
+}
+
+// cancel 取消正在处理的RPC请求
+func (c *_Calls) cancel(src string, corrId int64) bool {
+	key := _CallKey{Src: src, CorrId: corrId}
+
+	cancel, ok := c.cancels.Get(key)
+	if !ok {
+		return false
+	}
+	c.cancels.Delete(key)
+
+	cancel()
+	return true
+}
+
+// contextErr 检查调用上下文，已取消或超过截止时间时返回错误
+func contextErr(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+	switch err := ctx.Err(); {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrDeadlineExceeded
+	default:
+		return ErrCanceled
+	}
+}
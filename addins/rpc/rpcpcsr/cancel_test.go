@@ -0,0 +1,85 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCallsCancel(t *testing.T) {
+	var calls _Calls
+	calls.init()
+
+	ctx1, end1 := calls.begin(context.Background(), "src1", 1, time.Time{})
+	ctx2, end2 := calls.begin(context.Background(), "src2", 1, time.Time{})
+	defer end2()
+
+	// 不同调用方的关联Id相互独立
+	if !calls.cancel("src1", 1) {
+		t.Fatal("cancel call failed")
+	}
+	if !errors.Is(contextErr(ctx1), ErrCanceled) {
+		t.Fatalf("got ctx err %v, want %v", contextErr(ctx1), ErrCanceled)
+	}
+	if err := contextErr(ctx2); err != nil {
+		t.Fatalf("got ctx err %v for other caller, want nil", err)
+	}
+
+	if calls.cancel("src1", 1) {
+		t.Fatal("cancel call twice succeeded")
+	}
+	end1()
+
+	// 处理结束后不能再取消
+	end2()
+	if calls.cancel("src2", 1) {
+		t.Fatal("cancel ended call succeeded")
+	}
+	if n := calls.cancels.Len(); n != 0 {
+		t.Fatalf("got %d pending calls, want 0", n)
+	}
+}
+
+func TestCallsDeadline(t *testing.T) {
+	var calls _Calls
+	calls.init()
+
+	ctx, end := calls.begin(context.Background(), "src", 1, time.Now().Add(10*time.Millisecond))
+	defer end()
+
+	<-ctx.Done()
+	if !errors.Is(contextErr(ctx), ErrDeadlineExceeded) {
+		t.Fatalf("got ctx err %v, want %v", contextErr(ctx), ErrDeadlineExceeded)
+	}
+
+	// 关联Id为0的单程调用不记录
+	_, end0 := calls.begin(context.Background(), "src", 0, time.Time{})
+	defer end0()
+	if calls.cancel("src", 0) {
+		t.Fatal("cancel oneway call succeeded")
+	}
+
+	if err := contextErr(nil); err != nil {
+		t.Fatalf("got err %v for nil ctx, want nil", err)
+	}
+}
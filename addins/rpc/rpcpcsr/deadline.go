@@ -0,0 +1,69 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"git.golaxy.org/framework/net/gap"
+	"time"
+)
+
+// futureTimeout 根据截止时间计算Future超时时间，截止时间为零值时返回0，使用默认的Future超时时间
+func futureTimeout(deadline time.Time) (time.Duration, bool) {
+	if deadline.IsZero() {
+		return 0, true
+	}
+	timeout := time.Until(deadline)
+	return timeout, timeout > 0
+}
+
+// unixDeadline 截止时间转换为Unix毫秒时间戳，零值转换为0
+func unixDeadline(deadline time.Time) int64 {
+	if deadline.IsZero() {
+		return 0
+	}
+	return deadline.UnixMilli()
+}
+
+// parseDeadline Unix毫秒时间戳转换为截止时间，0转换为零值
+func parseDeadline(deadline int64) time.Time {
+	if deadline <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(deadline).Local()
+}
+
+// deadlineExceeded 是否已超过截止时间
+func deadlineExceeded(deadline time.Time) bool {
+	return !deadline.IsZero() && !time.Now().Before(deadline)
+}
+
+// transDeadlineExceeded 中转的RPC请求是否已超过截止时间
+func transDeadlineExceeded(transId gap.MsgId, transData []byte) bool {
+	if transId != gap.MsgId_RPC_Request {
+		return false
+	}
+
+	deadline, err := gap.ReadRPCRequestDeadline(transData)
+	if err != nil {
+		return false
+	}
+
+	return deadlineExceeded(parseDeadline(deadline))
+}
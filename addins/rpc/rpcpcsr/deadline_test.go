@@ -0,0 +1,86 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"git.golaxy.org/framework/net/gap"
+	"testing"
+	"time"
+)
+
+func TestFutureTimeout(t *testing.T) {
+	// 未设置截止时间时使用默认超时时间
+	if timeout, ok := futureTimeout(time.Time{}); !ok || timeout != 0 {
+		t.Fatalf("got %v, %v, want 0, true", timeout, ok)
+	}
+
+	if timeout, ok := futureTimeout(time.Now().Add(time.Minute)); !ok || timeout <= 0 || timeout > time.Minute {
+		t.Fatalf("got %v, %v, want within (0, 1m]", timeout, ok)
+	}
+
+	if _, ok := futureTimeout(time.Now().Add(-time.Second)); ok {
+		t.Fatal("got ok for passed deadline, want false")
+	}
+}
+
+func TestParseDeadline(t *testing.T) {
+	if ms := unixDeadline(time.Time{}); ms != 0 {
+		t.Fatalf("got %d for zero deadline, want 0", ms)
+	}
+	if deadline := parseDeadline(0); !deadline.IsZero() {
+		t.Fatalf("got %v for 0, want zero", deadline)
+	}
+
+	deadline := time.Now().Add(time.Minute)
+	if got := parseDeadline(unixDeadline(deadline)); got.UnixMilli() != deadline.UnixMilli() {
+		t.Fatalf("got %v, want %v", got, deadline)
+	}
+
+	if deadlineExceeded(time.Time{}) || deadlineExceeded(deadline) {
+		t.Fatal("got exceeded for zero or future deadline")
+	}
+	if !deadlineExceeded(time.Now().Add(-time.Second)) {
+		t.Fatal("got not exceeded for passed deadline")
+	}
+}
+
+func TestTransDeadlineExceeded(t *testing.T) {
+	encode := func(deadline time.Time) []byte {
+		msg := &gap.MsgRPCRequest{CorrId: 1, Deadline: unixDeadline(deadline)}
+		buf := make([]byte, msg.Size())
+		msg.Read(buf)
+		return buf
+	}
+
+	if transDeadlineExceeded(gap.MsgId_RPC_Request, encode(time.Time{})) {
+		t.Fatal("got exceeded for request without deadline")
+	}
+	if transDeadlineExceeded(gap.MsgId_RPC_Request, encode(time.Now().Add(time.Minute))) {
+		t.Fatal("got exceeded for future deadline")
+	}
+	if !transDeadlineExceeded(gap.MsgId_RPC_Request, encode(time.Now().Add(-time.Second))) {
+		t.Fatal("got not exceeded for passed deadline")
+	}
+
+	// 非RPC请求不检查截止时间
+	if transDeadlineExceeded(gap.MsgId_OnewayRPC, encode(time.Now().Add(-time.Second))) {
+		t.Fatal("got exceeded for non request message")
+	}
+}
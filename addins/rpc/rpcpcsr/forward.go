@@ -23,6 +23,7 @@ import (
 	"context"
 	"git.golaxy.org/core/service"
 	"git.golaxy.org/core/utils/generic"
+	"git.golaxy.org/core/utils/option"
 	"git.golaxy.org/core/utils/types"
 	"git.golaxy.org/framework/addins/dentq"
 	"git.golaxy.org/framework/addins/dsvc"
@@ -31,19 +32,25 @@ import (
 	"git.golaxy.org/framework/addins/rpcstack"
 	"git.golaxy.org/framework/net/gap"
 	"git.golaxy.org/framework/net/gap/codec"
+	"time"
 )
 
 // PermissionValidator 权限验证器
 type PermissionValidator = generic.Delegate2[rpcstack.CallChain, callpath.CallPath, bool]
 
 // NewForwardProcessor RPC转发处理器，用于S<->G的通信
-func NewForwardProcessor(transitService string, mc gap.IMsgCreator, permValidator PermissionValidator, reduceCallPath bool) any {
+func NewForwardProcessor(transitService string, mc gap.IMsgCreator, settings ...option.Setting[ForwardProcessorOptions]) any {
+	options := option.Make(ForwardWith.Default(), settings...)
+
 	return &_ForwardProcessor{
-		encoder:        codec.MakeEncoder(),
-		decoder:        codec.MakeDecoder(mc),
-		transitService: transitService,
-		permValidator:  permValidator,
-		reduceCallPath: reduceCallPath,
+		encoder:           codec.MakeEncoder(),
+		decoder:           codec.MakeDecoder(mc),
+		transitService:    transitService,
+		permValidator:     options.PermValidator,
+		reduceCallPath:    options.ReduceCallPath,
+		breaker:           options.Breaker,
+		streamIdleTimeout: options.StreamIdleTimeout,
+		interceptors:      options.Interceptors,
 	}
 }
 
@@ -58,7 +65,13 @@ type _ForwardProcessor struct {
 	transitBroadcastAddr string
 	permValidator        PermissionValidator
 	reduceCallPath       bool
+	breaker              ICircuitBreaker
+	streamIdleTimeout    time.Duration
 	watcher              dsvc.IWatcher
+	calls                _Calls
+	streams              _Streams
+	interceptors         []ServerInterceptor
+	handler              ServerHandler
 }
 
 // Init 初始化
@@ -67,6 +80,10 @@ func (p *_ForwardProcessor) Init(svcCtx service.Context) {
 	p.dist = dsvc.Using(svcCtx)
 	p.dentq = dentq.Using(svcCtx)
 	p.transitBroadcastAddr = p.dist.GetNodeDetails().MakeBroadcastAddr(p.transitService)
+	p.breaker = initCircuitBreaker(svcCtx, p.breaker)
+	p.calls.init()
+	p.streams.init()
+	p.handler = makeServerHandler(svcCtx, p.interceptors)
 	p.watcher = p.dist.WatchMsg(context.Background(), generic.CastDelegate2(p.handleMsg))
 
 	log.Debugf(p.svcCtx, "rpc processor %q started", types.FullName(*p))
@@ -20,6 +20,7 @@
 package rpcpcsr
 
 import (
+	"context"
 	"git.golaxy.org/core/service"
 	"git.golaxy.org/core/utils/async"
 	"git.golaxy.org/core/utils/uid"
@@ -31,6 +32,7 @@ import (
 	"git.golaxy.org/framework/net/gap"
 	"git.golaxy.org/framework/net/gap/variant"
 	"git.golaxy.org/framework/utils/concurrent"
+	"git.golaxy.org/framework/utils/tracing"
 	"slices"
 	"time"
 )
@@ -52,27 +54,76 @@ func (p *_ForwardProcessor) Match(svcCtx service.Context, dst string, cc rpcstac
 }
 
 // Request 请求
-func (p *_ForwardProcessor) Request(svcCtx service.Context, dst string, cc rpcstack.CallChain, cp callpath.CallPath, args []any) async.AsyncRet {
-	ret := concurrent.MakeRespAsyncRet()
-	future := concurrent.MakeFuture(p.dist.GetFutures(), nil, ret)
+func (p *_ForwardProcessor) Request(svcCtx service.Context, dst string, deadline time.Time, trace tracing.SpanContext, cc rpcstack.CallChain, baggage variant.Map, cp callpath.CallPath, args []any) async.AsyncRet {
+	return p.request(svcCtx, nil, dst, deadline, 0, trace, cc, baggage, cp, args)
+}
+
+// StreamRequest 流式请求
+func (p *_ForwardProcessor) StreamRequest(svcCtx service.Context, ctx context.Context, dst string, deadline time.Time, window int, trace tracing.SpanContext, cc rpcstack.CallChain, baggage variant.Map, cp callpath.CallPath, args []any) async.AsyncRet {
+	return p.request(svcCtx, ctx, dst, deadline, max(window, 1), trace, cc, baggage, cp, args)
+}
+
+// request 请求，window大于0时为流式请求，ctx取消时放弃接收流式答复
+func (p *_ForwardProcessor) request(svcCtx service.Context, ctx context.Context, dst string, deadline time.Time, window int, trace tracing.SpanContext, cc rpcstack.CallChain, baggage variant.Map, cp callpath.CallPath, args []any) async.AsyncRet {
+	// 统计调用次数与耗时，填入返回结果时记录
+	observe := observeClientRPC(p.svcCtx, cp)
+
+	timeout, ok := futureTimeout(deadline)
+	if !ok {
+		return failClientRPC(observe, ErrDeadlineExceeded)
+	}
+
+	// 未设置截止时间的流式请求，不限制整个流式答复的时长，使用空闲超时时间
+	if window > 0 && deadline.IsZero() {
+		timeout = concurrent.FutureNoTimeout
+	}
 
 	entId, _ := gate.CliDetails.DomainUnicast.Relative(dst)
 	forwardAddr, err := p.getDistEntityForwardAddr(uid.From(entId))
 	if err != nil {
-		future.Cancel(err)
-		return ret.ToAsyncRet()
+		return failClientRPC(observe, err)
 	}
 
 	vargs, err := variant.MakeReadonlyArray(args)
 	if err != nil {
-		future.Cancel(err)
-		return ret.ToAsyncRet()
+		return failClientRPC(observe, err)
 	}
 
 	cpBuf, err := cp.Encode(p.reduceCallPath)
 	if err != nil {
-		future.Cancel(err)
-		return ret.ToAsyncRet()
+		return failClientRPC(observe, err)
+	}
+
+	// 通信中转节点的熔断器打开时快速失败
+	report, err := p.breaker.Allow(forwardAddr)
+	if err != nil {
+		return failClientRPC(observe, err)
+	}
+
+	// 请求结束时向熔断器报告结果
+	var ret async.AsyncRet
+	var stream *_StreamReceiver
+	resp := &_DeliverResp{report: report, observe: observe}
+
+	if window > 0 {
+		// 流式请求收到第一个数据项时向熔断器报告结果，避免持续时间较长的流式答复被判定为慢调用
+		stream = p.streams.receive(ctx, window, p.streamIdleTimeout, report, func(_ string, corrId, consumed int64) {
+			p.ackStream(forwardAddr, dst, corrId, consumed)
+		})
+		resp.resp = stream.resp
+		resp.report = stream.report
+		ret = stream.resp.ToAsyncRet()
+	} else {
+		respRet := concurrent.MakeRespAsyncRet()
+		resp.resp = respRet
+		ret = respRet.ToAsyncRet()
+	}
+
+	future := concurrent.MakeFuture(p.dist.GetFutures(), nil, resp, timeout)
+
+	endStream := func() {}
+	if stream != nil {
+		endStream = p.streams.begin(stream, future)
 	}
 
 	nextCC := append(cc, rpcstack.Call{
@@ -84,6 +135,10 @@ func (p *_ForwardProcessor) Request(svcCtx service.Context, dst string, cc rpcst
 
 	msg := &gap.MsgRPCRequest{
 		CorrId:    future.Id,
+		Deadline:  unixDeadline(future.Deadline),
+		Window:    int64(window),
+		Trace:     trace,
+		Baggage:   baggage,
 		CallChain: nextCC,
 		Path:      cpBuf,
 		Args:      vargs,
@@ -91,29 +146,40 @@ func (p *_ForwardProcessor) Request(svcCtx service.Context, dst string, cc rpcst
 
 	msgBuf, err := gap.Marshal(msg)
 	if err != nil {
+		endStream()
 		future.Cancel(err)
-		return ret.ToAsyncRet()
+		return ret
 	}
 	defer msgBuf.Release()
 
 	forwardMsg := &gap.MsgForward{
 		Dst:       dst,
 		CorrId:    msg.CorrId,
+		Trace:     msg.Trace,
 		TransId:   msg.MsgId(),
 		TransData: msgBuf.Data(),
 	}
 
 	if err = p.dist.SendMsg(forwardAddr, forwardMsg); err != nil {
+		endStream()
 		future.Cancel(err)
-		return ret.ToAsyncRet()
+		return ret
 	}
 
+	// 调用方放弃请求时，通知被调用方取消
+	context.AfterFunc(future.Finish, func() {
+		endStream()
+		if future.Abandoned() {
+			p.cancel(forwardAddr, dst, future.Id)
+		}
+	})
+
 	log.Debugf(p.svcCtx, "rpc request(%d) forwarding to dst:%q, path:%q ok", future.Id, forwardAddr, cp)
-	return ret.ToAsyncRet()
+	return ret
 }
 
 // Notify 通知
-func (p *_ForwardProcessor) Notify(svcCtx service.Context, dst string, cc rpcstack.CallChain, cp callpath.CallPath, args []any) error {
+func (p *_ForwardProcessor) Notify(svcCtx service.Context, dst string, trace tracing.SpanContext, cc rpcstack.CallChain, baggage variant.Map, cp callpath.CallPath, args []any) error {
 	forwardAddr, err := p.getForwardAddr(dst)
 	if err != nil {
 		return err
@@ -137,6 +203,8 @@ func (p *_ForwardProcessor) Notify(svcCtx service.Context, dst string, cc rpcsta
 	})
 
 	msg := &gap.MsgOnewayRPC{
+		Trace:     trace,
+		Baggage:   baggage,
 		CallChain: nextCC,
 		Path:      cpBuf,
 		Args:      vargs,
@@ -150,6 +218,7 @@ func (p *_ForwardProcessor) Notify(svcCtx service.Context, dst string, cc rpcsta
 
 	forwardMsg := &gap.MsgForward{
 		Dst:       dst,
+		Trace:     msg.Trace,
 		TransId:   msg.MsgId(),
 		TransData: bs.Data(),
 	}
@@ -162,6 +231,54 @@ func (p *_ForwardProcessor) Notify(svcCtx service.Context, dst string, cc rpcsta
 	return nil
 }
 
+// cancel 通过通信中转服务，通知被调用方取消请求
+func (p *_ForwardProcessor) cancel(forwardAddr, dst string, corrId int64) {
+	msgBuf, err := gap.Marshal(&gap.MsgRPCCancel{CorrId: corrId})
+	if err != nil {
+		log.Errorf(p.svcCtx, "rpc cancel(%d) forwarding to dst:%q failed, %s", corrId, forwardAddr, err)
+		return
+	}
+	defer msgBuf.Release()
+
+	forwardMsg := &gap.MsgForward{
+		Dst:       dst,
+		TransId:   gap.MsgId_RPC_Cancel,
+		TransData: msgBuf.Data(),
+	}
+
+	if err = p.dist.SendMsg(forwardAddr, forwardMsg); err != nil {
+		log.Errorf(p.svcCtx, "rpc cancel(%d) forwarding to dst:%q failed, %s", corrId, forwardAddr, err)
+		return
+	}
+
+	log.Debugf(p.svcCtx, "rpc cancel(%d) forwarding to dst:%q ok", corrId, forwardAddr)
+}
+
+// ackStream 通过通信中转服务，向发送流式答复的客户端确认已消费的数据项
+func (p *_ForwardProcessor) ackStream(forwardAddr, dst string, corrId, consumed int64) {
+	if err := p.forward(forwardAddr, dst, &gap.MsgRPCStreamAck{CorrId: corrId, Seq: consumed}); err != nil {
+		log.Errorf(p.svcCtx, "rpc stream ack(%d) forwarding to dst:%q failed, %s", corrId, forwardAddr, err)
+		return
+	}
+}
+
+// forward 通过通信中转服务转发消息
+func (p *_ForwardProcessor) forward(forwardAddr, dst string, msg gap.Msg) error {
+	msgBuf, err := gap.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	defer msgBuf.Release()
+
+	forwardMsg := &gap.MsgForward{
+		Dst:       dst,
+		TransId:   msg.MsgId(),
+		TransData: msgBuf.Data(),
+	}
+
+	return p.dist.SendMsg(forwardAddr, forwardMsg)
+}
+
 func (p *_ForwardProcessor) getForwardAddr(dst string) (string, error) {
 	nodeId, ok := gate.CliDetails.DomainUnicast.Relative(dst)
 	if ok {
@@ -29,6 +29,7 @@ import (
 	"git.golaxy.org/framework/addins/rpcstack"
 	"git.golaxy.org/framework/net/gap"
 	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/utils/tracing"
 	"time"
 )
 
@@ -55,15 +56,15 @@ func (p *_ForwardProcessor) acceptForward(transit gap.Origin, req *gap.MsgForwar
 		if err := gap.Unmarshal(msg, req.TransData); err != nil {
 			return err
 		}
-		return p.acceptNotify(req.Src, transit, req.Dst, msg)
+		return p.acceptNotify(req.Src, transit, req.Dst, req.Trace, msg)
 
 	case gap.MsgId_RPC_Request:
 		msg := &gap.MsgRPCRequest{}
 		if err := gap.Unmarshal(msg, req.TransData); err != nil {
-			go p.reply(req.Src, transit, req.CorrId, nil, err)
+			go p.reply(req.Src, transit, req.CorrId, req.Trace, nil, err)
 			return err
 		}
-		return p.acceptRequest(req.Src, transit, req.Dst, msg)
+		return p.acceptRequest(req.Src, transit, req.Dst, req.Trace, msg)
 
 	case gap.MsgId_RPC_Reply:
 		msg := &gap.MsgRPCReply{}
@@ -71,18 +72,54 @@ func (p *_ForwardProcessor) acceptForward(transit gap.Origin, req *gap.MsgForwar
 			return err
 		}
 		return p.resolve(msg)
+
+	case gap.MsgId_RPC_Cancel:
+		msg := &gap.MsgRPCCancel{}
+		if err := gap.Unmarshal(msg, req.TransData); err != nil {
+			return err
+		}
+		return p.acceptCancel(req.Src, transit, req.Dst, msg)
+
+	case gap.MsgId_RPC_StreamItem:
+		msg := &gap.MsgRPCStreamItem{}
+		if err := gap.Unmarshal(msg, req.TransData); err != nil {
+			return err
+		}
+		p.streams.yield(req.Src.Addr, msg)
+		return nil
+
+	case gap.MsgId_RPC_StreamEnd:
+		msg := &gap.MsgRPCStreamEnd{}
+		if err := gap.Unmarshal(msg, req.TransData); err != nil {
+			return err
+		}
+		return resolveStreamEnd(p.dist.GetFutures(), msg)
+
+	case gap.MsgId_RPC_StreamAck:
+		msg := &gap.MsgRPCStreamAck{}
+		if err := gap.Unmarshal(msg, req.TransData); err != nil {
+			return err
+		}
+		p.streams.ack(req.Src.Addr, msg)
+		return nil
 	}
 
 	return nil
 }
 
-func (p *_ForwardProcessor) acceptNotify(src, transit gap.Origin, dst string, req *gap.MsgOnewayRPC) error {
+func (p *_ForwardProcessor) acceptNotify(src, transit gap.Origin, dst string, transitTrace tracing.SpanContext, req *gap.MsgOnewayRPC) error {
 	cp, err := callpath.Parse(req.Path)
 	if err != nil {
 		return fmt.Errorf("parse rpc notify failed, src:%q, dst:%q, transit:%q, path:%q, %s", src.Addr, dst, transit.Addr, req.Path, err)
 	}
 	cp.Id = uid.From(dst)
 
+	parent := relayedTrace(transitTrace, req.Trace)
+	span := startSpan(p.svcCtx, parent, cp.String(), tracing.SpanKind_Server)
+	span.SetAttribute("rpc.src", src.Addr)
+	span.SetAttribute("rpc.transit", transit.Addr)
+	trace := span.ContextOr(parent)
+
 	cc := rpcstack.CallChain{
 		{Svc: src.Svc, Addr: src.Addr, Timestamp: time.UnixMilli(src.Timestamp).Local(), Transit: false},
 		{Svc: transit.Svc, Addr: transit.Addr, Timestamp: time.UnixMilli(transit.Timestamp).Local(), Transit: true},
@@ -97,75 +134,66 @@ func (p *_ForwardProcessor) acceptNotify(src, transit gap.Origin, dst string, re
 		}
 		if err != nil {
 			log.Errorf(p.svcCtx, "rpc notify permission verification failed, src:%q, dst:%q, transit:%q, path:%q, %s", src.Addr, dst, transit.Addr, req.Path, err)
+			span.End(err)
 			return nil
 		}
 	}
 
-	switch cp.Category {
-	case callpath.Service:
-		go func() {
-			rets, err := CallService(p.svcCtx, cc, cp.Script, cp.Method, req.Args)
-			if err != nil {
-				log.Errorf(p.svcCtx, "rpc notify service addIn:%q, method:%q calls failed, src:%q, dst:%q, transit:%q, path:%q, %s", cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path, err)
-			} else {
-				log.Debugf(p.svcCtx, "rpc notify service addIn:%q, method:%q calls finished, src:%q, dst:%q, transit:%q, path:%q", cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path)
-				rets.Release()
-			}
-		}()
-
+	if !dispatchable(cp) {
+		span.End(nil)
 		return nil
+	}
 
-	case callpath.Runtime:
-		asyncRet, err := CallRuntime(p.svcCtx, cc, cp.Id, cp.Script, cp.Method, req.Args)
-		if err != nil {
-			log.Errorf(p.svcCtx, "rpc notify entity:%q, runtime addIn:%q, method:%q calls failed, src:%q, dst:%q, transit:%q, path:%q, %s", cp.Id, cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path, err)
-			return nil
-		}
+	call := &ServerCall{
+		Context:   p.svcCtx,
+		Oneway:    true,
+		Trace:     trace,
+		CallChain: cc,
+		Baggage:   req.Baggage,
+		CallPath:  cp,
+		Args:      req.Args,
+	}
 
-		go func() {
-			rets, err := waitAsyncRet(p.svcCtx, asyncRet)
-			if err != nil {
-				log.Errorf(p.svcCtx, "rpc notify entity:%q, runtime addIn:%q, method:%q calls failed, src:%q, dst:%q, transit:%q, path:%q, %s", cp.Id, cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path, err)
-			} else {
-				log.Debugf(p.svcCtx, "rpc notify entity:%q, runtime addIn:%q, method:%q calls finished, src:%q, dst:%q, transit:%q, path:%q,", cp.Id, cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path)
-				rets.Release()
-			}
-		}()
+	start := time.Now()
+	asyncRet := p.handler(call)
 
-		return nil
-
-	case callpath.Entity:
-		asyncRet, err := CallEntity(p.svcCtx, cc, cp.Id, cp.Script, cp.Method, req.Args)
+	go func() {
+		rets, err := waitAsyncRet(p.svcCtx, asyncRet)
+		span.End(err)
+		observeRPC(p.svcCtx, cp, start, err)
 		if err != nil {
-			log.Errorf(p.svcCtx, "rpc notify entity:%q, component:%q, method:%q calls failed, src:%q, dst:%q, transit:%q, path:%q, %s", cp.Id, cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path, err)
-			return nil
+			log.Errorf(p.svcCtx, "rpc notify %s calls failed, src:%q, dst:%q, transit:%q, path:%q, %s", describeCallPath(cp), src.Addr, dst, transit.Addr, req.Path, err)
+		} else {
+			log.Debugf(p.svcCtx, "rpc notify %s calls finished, src:%q, dst:%q, transit:%q, path:%q", describeCallPath(cp), src.Addr, dst, transit.Addr, req.Path)
+			rets.Release()
 		}
-
-		go func() {
-			rets, err := waitAsyncRet(p.svcCtx, asyncRet)
-			if err != nil {
-				log.Errorf(p.svcCtx, "rpc notify entity:%q, component:%q, method:%q calls failed, src:%q, dst:%q, transit:%q, path:%q, %s", cp.Id, cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path, err)
-			} else {
-				log.Debugf(p.svcCtx, "rpc notify entity:%q, component:%q, method:%q calls finished, src:%q, dst:%q, transit:%q, path:%q", cp.Id, cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path)
-				rets.Release()
-			}
-		}()
-
-		return nil
-	}
+	}()
 
 	return nil
 }
 
-func (p *_ForwardProcessor) acceptRequest(src, transit gap.Origin, dst string, req *gap.MsgRPCRequest) error {
+func (p *_ForwardProcessor) acceptRequest(src, transit gap.Origin, dst string, transitTrace tracing.SpanContext, req *gap.MsgRPCRequest) error {
 	cp, err := callpath.Parse(req.Path)
 	if err != nil {
 		err = fmt.Errorf("parse rpc request(%d) failed, src:%q, dst:%q, transit:%q, path:%q, %s", req.CorrId, src.Addr, dst, transit.Addr, req.Path, err)
-		go p.reply(src, transit, req.CorrId, nil, err)
+		go p.reply(src, transit, req.CorrId, req.Trace, nil, err)
 		return err
 	}
 	cp.Id = uid.From(dst)
 
+	// 已超过截止时间，调用方已不再等待结果，丢弃请求
+	deadline := parseDeadline(req.Deadline)
+	if deadlineExceeded(deadline) {
+		log.Debugf(p.svcCtx, "rpc request(%d) deadline exceeded, dropped, src:%q, dst:%q, transit:%q, path:%q", req.CorrId, src.Addr, dst, transit.Addr, req.Path)
+		return nil
+	}
+
+	parent := relayedTrace(transitTrace, req.Trace)
+	span := startSpan(p.svcCtx, parent, cp.String(), tracing.SpanKind_Server)
+	span.SetAttribute("rpc.src", src.Addr)
+	span.SetAttribute("rpc.transit", transit.Addr)
+	trace := span.ContextOr(parent)
+
 	cc := rpcstack.CallChain{
 		{Svc: src.Svc, Addr: src.Addr, Timestamp: time.UnixMilli(src.Timestamp).Local(), Transit: false},
 		{Svc: transit.Svc, Addr: transit.Addr, Timestamp: time.UnixMilli(transit.Timestamp).Local(), Transit: true},
@@ -180,72 +208,77 @@ func (p *_ForwardProcessor) acceptRequest(src, transit gap.Origin, dst string, r
 		}
 		if err != nil {
 			log.Errorf(p.svcCtx, "rpc request(%d) permission verification failed, src:%q, dst:%q, transit:%q, path:%q, %s", req.CorrId, src.Addr, dst, transit.Addr, req.Path, err)
-			go p.reply(src, transit, req.CorrId, nil, err)
+			span.End(err)
+			go p.reply(src, transit, req.CorrId, trace, nil, err)
 			return nil
 		}
 	}
 
-	switch cp.Category {
-	case callpath.Service:
-		go func() {
-			rets, err := CallService(p.svcCtx, cc, cp.Script, cp.Method, req.Args)
-			if err != nil {
-				log.Errorf(p.svcCtx, "rpc request(%d) service addIn:%q, method:%q calls failed, src:%q, dst:%q, transit:%q, path:%q, %s", req.CorrId, cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path, err)
-			} else {
-				log.Debugf(p.svcCtx, "rpc request(%d) service addIn:%q, method:%q calls finished, src:%q, dst:%q, transit:%q, path:%q", req.CorrId, cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path)
-			}
-			p.reply(src, transit, req.CorrId, rets, err)
-		}()
-
+	if !dispatchable(cp) {
+		span.End(nil)
 		return nil
+	}
 
-	case callpath.Runtime:
-		asyncRet, err := CallRuntime(p.svcCtx, cc, cp.Id, cp.Script, cp.Method, req.Args)
-		if err != nil {
-			log.Errorf(p.svcCtx, "rpc request(%d) entity:%q, runtime addIn:%q, method:%q calls failed, src:%q, dst:%q, transit:%q, path:%q, %s", req.CorrId, cp.Id, cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path, err)
-			go p.reply(src, transit, req.CorrId, nil, err)
-			return nil
-		}
+	// 调用上下文，调用方取消调用或超过截止时间时取消
+	ctx, finish := p.calls.begin(p.svcCtx, src.Addr, req.CorrId, deadline)
+
+	call := &ServerCall{
+		Context:   ctx,
+		CorrId:    req.CorrId,
+		Window:    req.Window,
+		Trace:     trace,
+		CallChain: cc,
+		Baggage:   req.Baggage,
+		CallPath:  cp,
+		Args:      req.Args,
+	}
 
-		go func() {
-			rets, err := waitAsyncRet(p.svcCtx, asyncRet)
-			if err != nil {
-				log.Errorf(p.svcCtx, "rpc request(%d) entity:%q, runtime addIn:%q, method:%q calls failed, src:%q, dst:%q, transit:%q, path:%q, %s", req.CorrId, cp.Id, cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path, err)
-				p.reply(src, transit, req.CorrId, nil, err)
-			} else {
-				log.Debugf(p.svcCtx, "rpc request(%d) entity:%q, runtime addIn:%q, method:%q calls finished, src:%q, dst:%q, transit:%q, path:%q", req.CorrId, cp.Id, cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path)
-				p.reply(src, transit, req.CorrId, rets, nil)
+	start := time.Now()
+	asyncRet := p.handler(call)
+
+	go func() {
+		defer finish()
+
+		rets, source, err := waitReply(p.svcCtx, asyncRet)
+		if source != nil {
+			if req.Window > 0 {
+				// 通过通信中转服务发送流式答复，调用方取消调用或超过截止时间时结束
+				err = p.streams.send(ctx, src.Addr, req.CorrId, req.Window, trace, source, func(msg gap.Msg) error {
+					return p.forward(transit.Addr, src.Addr, msg)
+				})
+				span.End(err)
+				observeRPC(p.svcCtx, cp, start, err)
+				if err != nil {
+					log.Errorf(p.svcCtx, "rpc request(%d) %s stream failed, src:%q, dst:%q, transit:%q, path:%q, %s", req.CorrId, describeCallPath(cp), src.Addr, dst, transit.Addr, req.Path, err)
+				} else {
+					log.Debugf(p.svcCtx, "rpc request(%d) %s stream finished, src:%q, dst:%q, transit:%q, path:%q", req.CorrId, describeCallPath(cp), src.Addr, dst, transit.Addr, req.Path)
+				}
+				return
 			}
-		}()
-
-		return nil
+			err = ErrStreamNotAccepted
+		}
 
-	case callpath.Entity:
-		asyncRet, err := CallEntity(p.svcCtx, cc, cp.Id, cp.Script, cp.Method, req.Args)
+		span.End(err)
+		observeRPC(p.svcCtx, cp, start, err)
 		if err != nil {
-			log.Errorf(p.svcCtx, "rpc request(%d) entity:%q, component:%q, method:%q calls failed, src:%q, dst:%q, transit:%q, path:%q, %s", req.CorrId, cp.Id, cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path, err)
-			go p.reply(src, transit, req.CorrId, nil, err)
-			return nil
+			log.Errorf(p.svcCtx, "rpc request(%d) %s calls failed, src:%q, dst:%q, transit:%q, path:%q, %s", req.CorrId, describeCallPath(cp), src.Addr, dst, transit.Addr, req.Path, err)
+		} else {
+			log.Debugf(p.svcCtx, "rpc request(%d) %s calls finished, src:%q, dst:%q, transit:%q, path:%q", req.CorrId, describeCallPath(cp), src.Addr, dst, transit.Addr, req.Path)
 		}
+		p.reply(src, transit, req.CorrId, trace, rets, err)
+	}()
 
-		go func() {
-			rets, err := waitAsyncRet(p.svcCtx, asyncRet)
-			if err != nil {
-				log.Errorf(p.svcCtx, "rpc request(%d) entity:%q, component:%q, method:%q calls failed, src:%q, dst:%q, transit:%q, path:%q, %s", req.CorrId, cp.Id, cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path, err)
-				p.reply(src, transit, req.CorrId, nil, err)
-			} else {
-				log.Debugf(p.svcCtx, "rpc request(%d) entity:%q, component:%q, method:%q calls finished, src:%q, dst:%q, transit:%q, path:%q", req.CorrId, cp.Id, cp.Script, cp.Method, src.Addr, dst, transit.Addr, req.Path)
-				p.reply(src, transit, req.CorrId, rets, nil)
-			}
-		}()
+	return nil
+}
 
-		return nil
+func (p *_ForwardProcessor) acceptCancel(src, transit gap.Origin, dst string, req *gap.MsgRPCCancel) error {
+	if p.calls.cancel(src.Addr, req.CorrId) {
+		log.Debugf(p.svcCtx, "rpc request(%d) canceled, src:%q, dst:%q, transit:%q", req.CorrId, src.Addr, dst, transit.Addr)
 	}
-
 	return nil
 }
 
-func (p *_ForwardProcessor) reply(src, transit gap.Origin, corrId int64, rets variant.Array, retErr error) {
+func (p *_ForwardProcessor) reply(src, transit gap.Origin, corrId int64, trace tracing.SpanContext, rets variant.Array, retErr error) {
 	defer rets.Release()
 
 	if corrId == 0 {
@@ -254,6 +287,7 @@ func (p *_ForwardProcessor) reply(src, transit gap.Origin, corrId int64, rets va
 
 	msg := &gap.MsgRPCReply{
 		CorrId: corrId,
+		Trace:  trace,
 		Rets:   rets,
 	}
 
@@ -271,6 +305,7 @@ func (p *_ForwardProcessor) reply(src, transit gap.Origin, corrId int64, rets va
 	forwardMsg := &gap.MsgForward{
 		Dst:       src.Addr,
 		CorrId:    corrId,
+		Trace:     trace,
 		TransId:   msg.MsgId(),
 		TransData: msgBuf.Data(),
 	}
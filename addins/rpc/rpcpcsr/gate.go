@@ -23,6 +23,7 @@ import (
 	"context"
 	"git.golaxy.org/core/service"
 	"git.golaxy.org/core/utils/generic"
+	"git.golaxy.org/core/utils/option"
 	"git.golaxy.org/core/utils/types"
 	"git.golaxy.org/framework/addins/dentq"
 	"git.golaxy.org/framework/addins/dsvc"
@@ -34,10 +35,13 @@ import (
 )
 
 // NewGateProcessor 创建网关RPC处理器，用于C<->G的通信
-func NewGateProcessor(mc gap.IMsgCreator) any {
+func NewGateProcessor(mc gap.IMsgCreator, settings ...option.Setting[GateProcessorOptions]) any {
+	options := option.Make(GateWith.Default(), settings...)
+
 	return &_GateProcessor{
-		encoder: codec.MakeEncoder(),
-		decoder: codec.MakeDecoder(mc),
+		encoder:      codec.MakeEncoder(),
+		decoder:      codec.MakeDecoder(mc),
+		interceptors: options.Interceptors,
 	}
 }
 
@@ -52,6 +56,7 @@ type _GateProcessor struct {
 	decoder        codec.Decoder
 	sessionWatcher gate.IWatcher
 	msgWatcher     dsvc.IWatcher
+	interceptors   []ServerInterceptor
 }
 
 // Init 初始化
@@ -20,11 +20,15 @@
 package rpcpcsr
 
 import (
+	"context"
+	"git.golaxy.org/core/utils/async"
 	"git.golaxy.org/framework/addins/dentq"
 	"git.golaxy.org/framework/addins/gate"
 	"git.golaxy.org/framework/addins/log"
+	"git.golaxy.org/framework/addins/rpc/callpath"
 	"git.golaxy.org/framework/net/gap"
 	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/utils/tracing"
 	"slices"
 	"time"
 )
@@ -58,21 +62,35 @@ func (p *_GateProcessor) handleRecvData(session gate.ISession, data []byte) erro
 
 func (p *_GateProcessor) acceptInbound(session gate.ISession, timestamp int64, req *gap.MsgForward) error {
 	switch req.TransId {
-	case gap.MsgId_RPC_Request, gap.MsgId_RPC_Reply, gap.MsgId_OnewayRPC:
+	case gap.MsgId_RPC_Request, gap.MsgId_RPC_Reply, gap.MsgId_OnewayRPC, gap.MsgId_RPC_Cancel,
+		gap.MsgId_RPC_StreamItem, gap.MsgId_RPC_StreamEnd, gap.MsgId_RPC_StreamAck:
 		break
 	default:
 		return nil
 	}
 
+	// 已超过截止时间，调用方已不再等待结果，丢弃请求
+	if transDeadlineExceeded(req.TransId, req.TransData) {
+		log.Debugf(p.svcCtx, "inbound forwarding session:%q rpc request(%d) to dst:%q deadline exceeded, dropped", session.GetId(), req.CorrId, req.Dst)
+		return nil
+	}
+
+	// 只追踪中转的调用，不追踪答复、取消与流式答复
+	var span *tracing.Span
+	if req.TransId == gap.MsgId_RPC_Request || req.TransId == gap.MsgId_OnewayRPC {
+		span = startSpan(p.svcCtx, req.Trace, "inbound:"+req.Dst, tracing.SpanKind_Relay)
+		span.SetAttribute("rpc.session", session.GetId().String())
+	}
+
 	entity, cliAddr, ok := p.router.LookupEntity(session.GetId())
 	if !ok {
-		go p.finishInbound(session, req.Dst, req.CorrId, ErrEntityNotFound)
+		go p.finishInbound(session, req.Dst, req.CorrId, span, ErrEntityNotFound)
 		return ErrEntityNotFound
 	}
 
 	distEntity, ok := p.dentq.GetDistEntity(entity.GetId())
 	if !ok {
-		go p.finishInbound(session, req.Dst, req.CorrId, ErrDistEntityNotFound)
+		go p.finishInbound(session, req.Dst, req.CorrId, span, ErrDistEntityNotFound)
 		return ErrDistEntityNotFound
 	}
 
@@ -80,33 +98,153 @@ func (p *_GateProcessor) acceptInbound(session gate.ISession, timestamp int64, r
 		return node.Service == req.Dst || node.RemoteAddr == req.Dst
 	})
 	if nodeIdx < 0 {
-		go p.finishInbound(session, req.Dst, req.CorrId, ErrDistEntityNodeNotFound)
+		go p.finishInbound(session, req.Dst, req.CorrId, span, ErrDistEntityNodeNotFound)
 		return ErrDistEntityNodeNotFound
 	}
 	node := distEntity.Nodes[nodeIdx]
 
-	msg := &gap.MsgForward{
-		Src: gap.Origin{
-			Svc:       gate.CliDetails.DomainRoot.Path,
-			Addr:      cliAddr,
-			Timestamp: timestamp,
-		},
-		Dst:       entity.GetId().String(), // 目标实体
-		CorrId:    req.CorrId,
-		TransId:   req.TransId,
-		TransData: req.TransData,
+	forward := func(transData []byte) error {
+		msg := &gap.MsgForward{
+			Src: gap.Origin{
+				Svc:       gate.CliDetails.DomainRoot.Path,
+				Addr:      cliAddr,
+				Timestamp: timestamp,
+			},
+			Dst:       entity.GetId().String(), // 目标实体
+			CorrId:    req.CorrId,
+			Trace:     span.ContextOr(req.Trace),
+			TransId:   req.TransId,
+			TransData: transData,
+		}
+		return p.dist.SendMsg(node.RemoteAddr, msg)
 	}
 
-	if err := p.dist.SendMsg(node.RemoteAddr, msg); err != nil {
-		go p.finishInbound(session, node.RemoteAddr, req.CorrId, err)
+	var err error
+	if len(p.interceptors) > 0 && (req.TransId == gap.MsgId_RPC_Request || req.TransId == gap.MsgId_OnewayRPC) {
+		err = p.interceptInbound(req.TransId, req.TransData, forward)
+	} else {
+		err = forward(req.TransData)
+	}
+	if err != nil {
+		go p.finishInbound(session, node.RemoteAddr, req.CorrId, span, err)
 		return err
 	}
 
-	go p.finishInbound(session, req.Dst, req.CorrId, nil)
+	go p.finishInbound(session, req.Dst, req.CorrId, span, nil)
 	return nil
 }
 
-func (p *_GateProcessor) finishInbound(session gate.ISession, dst string, corrId int64, err error) {
+// interceptInbound 执行网关拦截器，拦截器调用handler时，使用修改后的调用信息转发请求
+func (p *_GateProcessor) interceptInbound(transId gap.MsgId, transData []byte, forward func(transData []byte) error) error {
+	var call *ServerCall
+	var path []byte
+	var deadline time.Time
+
+	switch transId {
+	case gap.MsgId_RPC_Request:
+		req := &gap.MsgRPCRequest{}
+		if _, err := req.Write(transData); err != nil {
+			return err
+		}
+		cp, err := callpath.Parse(req.Path)
+		if err != nil {
+			return err
+		}
+		call = &ServerCall{
+			CorrId:    req.CorrId,
+			Window:    req.Window,
+			Trace:     req.Trace,
+			CallChain: req.CallChain,
+			Baggage:   req.Baggage,
+			CallPath:  cp,
+			Args:      req.Args,
+		}
+		path, deadline = req.Path, parseDeadline(req.Deadline)
+
+	case gap.MsgId_OnewayRPC:
+		req := &gap.MsgOnewayRPC{}
+		if _, err := req.Write(transData); err != nil {
+			return err
+		}
+		cp, err := callpath.Parse(req.Path)
+		if err != nil {
+			return err
+		}
+		call = &ServerCall{
+			Oneway:    true,
+			Trace:     req.Trace,
+			CallChain: req.CallChain,
+			Baggage:   req.Baggage,
+			CallPath:  cp,
+			Args:      req.Args,
+		}
+		path = req.Path
+
+	default:
+		return forward(transData)
+	}
+
+	var cancel context.CancelFunc
+	if deadline.IsZero() {
+		call.Context, cancel = context.WithCancel(p.svcCtx)
+	} else {
+		call.Context, cancel = context.WithDeadline(p.svcCtx, deadline)
+	}
+	defer cancel()
+
+	cp := call.CallPath
+
+	handler := chainInterceptors(func(call *ServerCall) async.AsyncRet {
+		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, p.forwardIntercepted(call, cp, path, deadline, forward)))
+	}, p.interceptors)
+
+	return handler(call).Wait(call.Context).Error
+}
+
+// forwardIntercepted 转发拦截器处理后的请求，调用路径未修改时，保留原始的调用路径编码
+func (p *_GateProcessor) forwardIntercepted(call *ServerCall, cp callpath.CallPath, path []byte, deadline time.Time, forward func(transData []byte) error) error {
+	if call.CallPath != cp {
+		var err error
+		path, err = call.CallPath.Encode(false)
+		if err != nil {
+			return err
+		}
+	}
+
+	var msg gap.Msg
+	if call.Oneway {
+		msg = &gap.MsgOnewayRPC{
+			Trace:     call.Trace,
+			Baggage:   call.Baggage,
+			CallChain: call.CallChain,
+			Path:      path,
+			Args:      call.Args,
+		}
+	} else {
+		msg = &gap.MsgRPCRequest{
+			CorrId:    call.CorrId,
+			Deadline:  unixDeadline(deadline),
+			Window:    call.Window,
+			Trace:     call.Trace,
+			Baggage:   call.Baggage,
+			CallChain: call.CallChain,
+			Path:      path,
+			Args:      call.Args,
+		}
+	}
+
+	msgBuf, err := gap.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	defer msgBuf.Release()
+
+	return forward(msgBuf.Data())
+}
+
+func (p *_GateProcessor) finishInbound(session gate.ISession, dst string, corrId int64, span *tracing.Span, err error) {
+	span.End(err)
+
 	if err == nil {
 		if corrId != 0 {
 			log.Debugf(p.svcCtx, "inbound forwarding session:%q rpc request(%d) to dst:%q finish", session.GetId(), corrId, dst)
@@ -116,14 +254,14 @@ func (p *_GateProcessor) finishInbound(session gate.ISession, dst string, corrId
 	} else {
 		if corrId != 0 {
 			log.Errorf(p.svcCtx, "inbound forwarding session:%q rpc request(%d) to dst:%q failed, %s", session.GetId(), corrId, dst, err)
-			p.replyInboundFailed(session, corrId, err)
+			p.replyInboundFailed(session, corrId, span.Context(), err)
 		} else {
 			log.Errorf(p.svcCtx, "inbound forwarding session:%q rpc notify to dst:%q failed, %s", session.GetId(), dst, err)
 		}
 	}
 }
 
-func (p *_GateProcessor) replyInboundFailed(session gate.ISession, corrId int64, retErr error) {
+func (p *_GateProcessor) replyInboundFailed(session gate.ISession, corrId int64, trace tracing.SpanContext, retErr error) {
 	if corrId == 0 || retErr == nil {
 		return
 	}
@@ -131,7 +269,7 @@ func (p *_GateProcessor) replyInboundFailed(session gate.ISession, corrId int64,
 	bs, err := p.encoder.Encode(
 		gap.Origin{Svc: p.svcCtx.GetName(), Addr: p.dist.GetNodeDetails().LocalAddr, Timestamp: time.Now().UnixMilli()},
 		0,
-		&gap.MsgRPCReply{CorrId: corrId, Error: *variant.MakeError(retErr)},
+		&gap.MsgRPCReply{CorrId: corrId, Trace: trace, Error: *variant.MakeError(retErr)},
 	)
 	if err != nil {
 		log.Errorf(p.svcCtx, "rpc reply(%d) inbound failed to session:%q failed, %s", corrId, session.GetId(), err)
@@ -0,0 +1,116 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"bytes"
+	"errors"
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/framework/addins/rpc/callpath"
+	"git.golaxy.org/framework/net/gap"
+	"git.golaxy.org/framework/net/gap/variant"
+	"testing"
+	"time"
+)
+
+func TestGateInterceptInbound(t *testing.T) {
+	cp := callpath.CallPath{Category: callpath.Entity, Id: "e1", Script: "comp", Method: "C_Echo"}
+	path, _ := cp.Encode(false)
+	args, _ := variant.MakeReadonlyArray([]any{"a"})
+	deadline := time.Now().Add(time.Minute)
+
+	req := &gap.MsgRPCRequest{CorrId: 7, Deadline: deadline.UnixMilli(), Path: path, Args: args}
+	reqBuf, err := gap.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal failed, %s", err)
+	}
+	defer reqBuf.Release()
+
+	replacedArgs, _ := variant.MakeReadonlyArray([]any{"b", "c"})
+
+	p := &_GateProcessor{
+		svcCtx: _FakeServiceContext{},
+		interceptors: []ServerInterceptor{func(call *ServerCall, handler ServerHandler) async.AsyncRet {
+			// 拦截器可以观察到请求的截止时间
+			if d, ok := call.Context.Deadline(); !ok || d.UnixMilli() != deadline.UnixMilli() {
+				t.Errorf("got deadline %v, %v, want %v", d, ok, deadline)
+			}
+			call.Args = replacedArgs
+			return handler(call)
+		}},
+	}
+
+	var forwarded []byte
+	err = p.interceptInbound(gap.MsgId_RPC_Request, reqBuf.Data(), func(transData []byte) error {
+		forwarded = bytes.Clone(transData)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("intercept failed, %s", err)
+	}
+
+	// 转发修改后的请求，调用路径未修改时保留原始编码
+	decoded := &gap.MsgRPCRequest{}
+	if _, err := decoded.Write(forwarded); err != nil {
+		t.Fatalf("decode forwarded failed, %s", err)
+	}
+	if decoded.CorrId != req.CorrId || decoded.Deadline != req.Deadline || !bytes.Equal(decoded.Path, path) || len(decoded.Args) != 2 {
+		t.Fatalf("got forwarded %+v, want corr id %d with 2 args", decoded, req.CorrId)
+	}
+}
+
+func TestGateInterceptInboundReject(t *testing.T) {
+	cp := callpath.CallPath{Category: callpath.Service, Script: "svc", Method: "C_Echo"}
+	path, _ := cp.Encode(false)
+
+	notify := &gap.MsgOnewayRPC{Path: path}
+	notifyBuf, err := gap.Marshal(notify)
+	if err != nil {
+		t.Fatalf("marshal failed, %s", err)
+	}
+	defer notifyBuf.Release()
+
+	errDenied := errors.New("denied")
+
+	p := &_GateProcessor{
+		svcCtx: _FakeServiceContext{},
+		interceptors: []ServerInterceptor{func(call *ServerCall, handler ServerHandler) async.AsyncRet {
+			if !call.Oneway || call.CallPath != cp {
+				t.Errorf("got call %+v, want oneway %v", call, cp)
+			}
+			return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, errDenied))
+		}},
+	}
+
+	// 拦截器拒绝时不转发请求
+	forwarded := false
+	err = p.interceptInbound(gap.MsgId_OnewayRPC, notifyBuf.Data(), func(transData []byte) error {
+		forwarded = true
+		return nil
+	})
+	if !errors.Is(err, errDenied) || forwarded {
+		t.Fatalf("got err %v, forwarded %v, want %v without forwarding", err, forwarded, errDenied)
+	}
+
+	// 格式错误的请求不执行拦截器
+	if err := p.interceptInbound(gap.MsgId_OnewayRPC, []byte{0xff}, func([]byte) error { return nil }); err == nil {
+		t.Fatal("intercept malformed request succeeded, want error")
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"git.golaxy.org/framework/addins/router"
 	"git.golaxy.org/framework/net/gap"
 	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/utils/tracing"
 	"time"
 )
 
@@ -48,6 +49,19 @@ func (p *_GateProcessor) handleMsg(topic string, mp gap.MsgPacket) error {
 }
 
 func (p *_GateProcessor) acceptOutbound(src gap.Origin, req *gap.MsgForward) {
+	// 已超过截止时间，调用方已不再等待结果，丢弃请求
+	if transDeadlineExceeded(req.TransId, req.TransData) {
+		log.Debugf(p.svcCtx, "outbound forwarding src:%q rpc request(%d) to remote:%q deadline exceeded, dropped", src.Addr, req.CorrId, req.Dst)
+		return
+	}
+
+	// 只追踪中转的调用，不追踪答复与取消，中转的消息内容不变，对端的Span为调用方的子Span
+	var span *tracing.Span
+	if req.TransId == gap.MsgId_RPC_Request || req.TransId == gap.MsgId_OnewayRPC {
+		span = startSpan(p.svcCtx, req.Trace, "outbound:"+req.Dst, tracing.SpanKind_Relay)
+		span.SetAttribute("rpc.src", src.Addr)
+	}
+
 	// 目标为单播地址，为了保持消息时序，在实体线程中，向对端发送消息
 	entId, ok := gate.CliDetails.DomainUnicast.Relative(req.Dst)
 	if ok {
@@ -74,7 +88,7 @@ func (p *_GateProcessor) acceptOutbound(src gap.Origin, req *gap.MsgForward) {
 
 			return async.MakeRet(nil, nil)
 		})
-		go func() { p.finishOutbound(src, req, (<-asyncRet).Error) }()
+		go func() { p.finishOutbound(src, req, span, (<-asyncRet).Error) }()
 		return
 	}
 
@@ -88,17 +102,17 @@ func (p *_GateProcessor) acceptOutbound(src gap.Origin, req *gap.MsgForward) {
 				&gap.SerializedMsg{Id: req.TransId, Data: req.TransData},
 			)
 			if err != nil {
-				go p.finishOutbound(src, req, err)
+				go p.finishOutbound(src, req, span, err)
 				return
 			}
 
 			// 为了保持消息时序，使用分组发送数据的channel
 			select {
 			case group.SendDataChan() <- mpBuf:
-				go p.finishOutbound(src, req, nil)
+				go p.finishOutbound(src, req, span, nil)
 			default:
 				mpBuf.Release()
-				go p.finishOutbound(src, req, ErrGroupChanIsFull)
+				go p.finishOutbound(src, req, span, ErrGroupChanIsFull)
 			}
 		})
 		return
@@ -108,7 +122,7 @@ func (p *_GateProcessor) acceptOutbound(src gap.Origin, req *gap.MsgForward) {
 	if gate.CliDetails.DomainMulticast.Contains(req.Dst) {
 		group, ok := p.router.GetGroupByAddr(p.svcCtx, req.Dst)
 		if !ok {
-			go p.finishOutbound(src, req, ErrGroupNotFound)
+			go p.finishOutbound(src, req, span, ErrGroupNotFound)
 			return
 		}
 
@@ -118,26 +132,28 @@ func (p *_GateProcessor) acceptOutbound(src gap.Origin, req *gap.MsgForward) {
 			&gap.SerializedMsg{Id: req.TransId, Data: req.TransData},
 		)
 		if err != nil {
-			go p.finishOutbound(src, req, err)
+			go p.finishOutbound(src, req, span, err)
 			return
 		}
 
 		// 为了保持消息时序，使用分组发送数据的channel
 		select {
 		case group.SendDataChan() <- mpBuf:
-			go p.finishOutbound(src, req, nil)
+			go p.finishOutbound(src, req, span, nil)
 		default:
 			mpBuf.Release()
-			go p.finishOutbound(src, req, ErrGroupChanIsFull)
+			go p.finishOutbound(src, req, span, ErrGroupChanIsFull)
 		}
 		return
 	}
 
 	// 目的地址错误
-	go p.finishOutbound(src, req, ErrIncorrectDestAddress)
+	go p.finishOutbound(src, req, span, ErrIncorrectDestAddress)
 }
 
-func (p *_GateProcessor) finishOutbound(src gap.Origin, req *gap.MsgForward, err error) {
+func (p *_GateProcessor) finishOutbound(src gap.Origin, req *gap.MsgForward, span *tracing.Span, err error) {
+	span.End(err)
+
 	if err == nil {
 		if req.CorrId != 0 {
 			log.Debugf(p.svcCtx, "outbound forwarding src:%q rpc request(%d) to remote:%q finish", src.Addr, req.CorrId, req.Dst)
@@ -147,20 +163,21 @@ func (p *_GateProcessor) finishOutbound(src gap.Origin, req *gap.MsgForward, err
 	} else {
 		if req.CorrId != 0 {
 			log.Errorf(p.svcCtx, "outbound forwarding src:%q rpc request(%d) to remote:%q failed, %s", src.Addr, req.CorrId, req.Dst, err)
-			p.replyOutboundFailed(src, req.CorrId, err)
+			p.replyOutboundFailed(src, req.CorrId, span.ContextOr(req.Trace), err)
 		} else {
 			log.Errorf(p.svcCtx, "outbound forwarding src:%q rpc notify to remote:%q failed, %s", src.Addr, req.Dst, err)
 		}
 	}
 }
 
-func (p *_GateProcessor) replyOutboundFailed(src gap.Origin, corrId int64, retErr error) {
+func (p *_GateProcessor) replyOutboundFailed(src gap.Origin, corrId int64, trace tracing.SpanContext, retErr error) {
 	if corrId == 0 || retErr == nil {
 		return
 	}
 
 	msg := &gap.MsgRPCReply{
 		CorrId: corrId,
+		Trace:  trace,
 		Error:  *variant.MakeError(retErr),
 	}
 
@@ -0,0 +1,144 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"context"
+	"fmt"
+	"git.golaxy.org/core/service"
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/framework/addins/rpc/callpath"
+	"git.golaxy.org/framework/addins/rpcstack"
+	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/utils/tracing"
+)
+
+// ServerCall 服务端RPC调用信息
+type ServerCall struct {
+	Context   context.Context     // 调用上下文，调用方取消调用或超过截止时间时取消
+	CorrId    int64               // 关联Id，单向RPC为0
+	Oneway    bool                // 是否为单向RPC
+	Window    int64               // 流式答复的流控窗口，大于0表示调用方接受流式答复
+	Trace     tracing.SpanContext // 链路追踪上下文，方法内发起的RPC将其作为父Span传播
+	CallChain rpcstack.CallChain  // 调用链
+	Baggage   variant.Map         // 调用方跨服务传播的栈变量
+	CallPath  callpath.CallPath   // 调用路径
+	Args      variant.Array       // 参数列表
+}
+
+// ServerHandler 服务端方法调用处理器，返回的异步调用结果值为variant.Array，方法返回channel或迭代器时为流式答复的数据源*rpcstream.Source
+type ServerHandler = func(call *ServerCall) async.AsyncRet
+
+// ServerInterceptor 服务端RPC拦截器，在分发器调用方法前后执行，可以修改调用信息后调用handler继续执行，也可以直接返回结果中断执行，不能返回nil
+type ServerInterceptor = func(call *ServerCall, handler ServerHandler) async.AsyncRet
+
+// makeServerHandler 创建服务端方法调用处理器，按顺序串联拦截器，第一个拦截器在最外层
+func makeServerHandler(svcCtx service.Context, interceptors []ServerInterceptor) ServerHandler {
+	return chainInterceptors(func(call *ServerCall) async.AsyncRet {
+		return dispatch(svcCtx, call)
+	}, interceptors)
+}
+
+// chainInterceptors 使用拦截器包装处理器，按顺序串联拦截器，第一个拦截器在最外层
+func chainInterceptors(handler ServerHandler, interceptors []ServerInterceptor) ServerHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		if interceptor == nil {
+			continue
+		}
+		handler = func(call *ServerCall) async.AsyncRet {
+			return interceptor(call, next)
+		}
+	}
+
+	return handler
+}
+
+// dispatch 分发方法调用
+func dispatch(svcCtx service.Context, call *ServerCall) async.AsyncRet {
+	cp := &call.CallPath
+
+	// 调用上下文携带调用方传播的栈变量与链路追踪上下文，服务方法可以从context.Context参数中获取
+	ctx := rpcstack.ContextWithTrace(rpcstack.ContextWithBaggage(call.Context, call.Baggage), call.Trace)
+
+	switch cp.Category {
+	case callpath.Service:
+		asyncRet := async.MakeAsyncRet()
+		go func() {
+			async.Return(asyncRet, async.MakeRet(CallService(ctx, svcCtx, call.CallChain, cp.Script, cp.Method, call.Args)))
+		}()
+		return asyncRet
+
+	case callpath.Runtime:
+		asyncRet, err := CallRuntime(ctx, svcCtx, call.CallChain, cp.Id, cp.Script, cp.Method, call.Args)
+		if err != nil {
+			return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, err))
+		}
+		return flattenAsyncRet(svcCtx, asyncRet)
+
+	case callpath.Entity:
+		asyncRet, err := CallEntity(ctx, svcCtx, call.CallChain, cp.Id, cp.Script, cp.Method, call.Args)
+		if err != nil {
+			return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, err))
+		}
+		return flattenAsyncRet(svcCtx, asyncRet)
+
+	default:
+		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, ErrUndeliverable))
+	}
+}
+
+// dispatchable 调用路径是否可以分发
+func dispatchable(cp callpath.CallPath) bool {
+	switch cp.Category {
+	case callpath.Service, callpath.Runtime, callpath.Entity:
+		return true
+	default:
+		return false
+	}
+}
+
+// flattenAsyncRet 等待异步方法的嵌套结果，使拦截器观察到的结果值统一为variant.Array或流式答复的数据源
+func flattenAsyncRet(ctx context.Context, asyncRet async.AsyncRet) async.AsyncRet {
+	flattened := async.MakeAsyncRet()
+	go func() {
+		rets, source, err := waitReply(ctx, asyncRet)
+		if source != nil {
+			async.Return(flattened, async.MakeRet(source, nil))
+			return
+		}
+		async.Return(flattened, async.MakeRet(rets, err))
+	}()
+	return flattened
+}
+
+// describeCallPath 描述调用路径，用于输出日志
+func describeCallPath(cp callpath.CallPath) string {
+	switch cp.Category {
+	case callpath.Service:
+		return fmt.Sprintf("service addIn:%q, method:%q", cp.Script, cp.Method)
+	case callpath.Runtime:
+		return fmt.Sprintf("entity:%q, runtime addIn:%q, method:%q", cp.Id, cp.Script, cp.Method)
+	case callpath.Entity:
+		return fmt.Sprintf("entity:%q, component:%q, method:%q", cp.Id, cp.Script, cp.Method)
+	default:
+		return fmt.Sprintf("path:%q", cp)
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"context"
+	"errors"
+	"git.golaxy.org/core/service"
+	"git.golaxy.org/core/utils/async"
+	"slices"
+	"testing"
+	"time"
+)
+
+// _FakeServiceContext 测试用服务上下文，只支持context.Context的方法
+type _FakeServiceContext struct {
+	service.Context
+}
+
+func (_FakeServiceContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (_FakeServiceContext) Done() <-chan struct{}       { return nil }
+func (_FakeServiceContext) Err() error                  { return nil }
+func (_FakeServiceContext) Value(key any) any           { return nil }
+
+func TestChainInterceptors(t *testing.T) {
+	var order []string
+
+	trace := func(name string) ServerInterceptor {
+		return func(call *ServerCall, handler ServerHandler) async.AsyncRet {
+			order = append(order, name+">")
+			ret := handler(call)
+			order = append(order, "<"+name)
+			return ret
+		}
+	}
+
+	handler := chainInterceptors(func(call *ServerCall) async.AsyncRet {
+		order = append(order, "handler")
+		return async.Return(async.MakeAsyncRet(), async.MakeRet(call.CorrId, nil))
+	}, []ServerInterceptor{trace("a"), nil, trace("b")})
+
+	// 第一个拦截器在最外层，nil拦截器被跳过
+	ret := handler(&ServerCall{CorrId: 1}).Wait(context.Background())
+	if !ret.OK() || ret.Value != int64(1) {
+		t.Fatalf("got ret %+v, want 1", ret)
+	}
+	if want := []string{"a>", "b>", "handler", "<b", "<a"}; !slices.Equal(order, want) {
+		t.Fatalf("got order %v, want %v", order, want)
+	}
+
+	// 拦截器不调用handler时中断执行
+	errDenied := errors.New("denied")
+	called := false
+
+	handler = chainInterceptors(func(call *ServerCall) async.AsyncRet {
+		called = true
+		return async.Return(async.MakeAsyncRet(), async.VoidRet)
+	}, []ServerInterceptor{func(call *ServerCall, handler ServerHandler) async.AsyncRet {
+		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, errDenied))
+	}})
+
+	if ret := handler(&ServerCall{}).Wait(context.Background()); !errors.Is(ret.Error, errDenied) || called {
+		t.Fatalf("got ret %+v, called %v, want %v without calling handler", ret, called, errDenied)
+	}
+}
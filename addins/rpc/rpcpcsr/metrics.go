@@ -0,0 +1,57 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"git.golaxy.org/core/service"
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/framework/addins/metrics"
+	"git.golaxy.org/framework/addins/rpc/callpath"
+	"time"
+)
+
+// observeRPC 统计被调用方的RPC调用次数与耗时，未安装指标插件时不统计
+func observeRPC(svcCtx service.Context, cp callpath.CallPath, start time.Time, err error) {
+	m, ok := metrics.Lookup(svcCtx)
+	if !ok {
+		return
+	}
+	m.ObserveRPC(metrics.RPCRole_Server, cp, time.Since(start), err)
+}
+
+// observeClientRPC 开始统计调用方的RPC调用次数与耗时，返回请求结束时记录结果的函数，未安装指标插件时返回nil
+func observeClientRPC(svcCtx service.Context, cp callpath.CallPath) func(err error) {
+	m, ok := metrics.Lookup(svcCtx)
+	if !ok {
+		return nil
+	}
+	start := time.Now()
+	return func(err error) {
+		m.ObserveRPC(metrics.RPCRole_Client, cp, time.Since(start), err)
+	}
+}
+
+// failClientRPC 请求未发出即失败时，记录结果并返回包含错误的异步调用结果
+func failClientRPC(observe func(err error), err error) async.AsyncRet {
+	if observe != nil {
+		observe(err)
+	}
+	return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, err))
+}
@@ -0,0 +1,64 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/utils/concurrent"
+)
+
+// NodeError 负载均衡请求失败时的错误，记录处理请求的服务节点，调用方重试时可以排除该节点
+type NodeError struct {
+	NodeId uid.Id // 服务节点id
+	Err    error  // 原始错误
+}
+
+// Error 错误信息
+func (e *NodeError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 获取原始错误
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// _DeliverResp 投递请求的响应，请求结束时向熔断器报告结果并统计调用耗时，负载均衡请求失败时使用NodeError包装错误
+type _DeliverResp struct {
+	resp    concurrent.Resp
+	nodeId  uid.Id
+	report  func(err error)
+	observe func(err error)
+}
+
+// Push 填入返回结果
+func (r *_DeliverResp) Push(ret async.Ret) error {
+	if r.report != nil {
+		r.report(ret.Error)
+	}
+	if r.observe != nil {
+		r.observe(ret.Error)
+	}
+	if !ret.OK() && r.nodeId != uid.Nil {
+		ret.Error = &NodeError{NodeId: r.nodeId, Err: ret.Error}
+	}
+	return r.resp.Push(ret)
+}
@@ -20,11 +20,16 @@
 package rpcpcsr
 
 import (
+	"context"
 	"errors"
 	"git.golaxy.org/core/service"
 	"git.golaxy.org/core/utils/async"
 	"git.golaxy.org/framework/addins/rpc/callpath"
 	"git.golaxy.org/framework/addins/rpcstack"
+	"git.golaxy.org/framework/net/gap"
+	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/utils/tracing"
+	"time"
 )
 
 var (
@@ -45,14 +50,50 @@ var (
 	ErrMethodParameterTypeMismatch  = errors.New("rpc: method parameter type mismatch")    // 方法参数类型不匹配
 	ErrAsyncMethodReturnedNil       = errors.New("rpc: async method returned nil")         // 异步方法返回值为nil
 	ErrPermissionDenied             = errors.New("rpc: permission denied")                 // 权限不足
+	ErrDeadlineExceeded             = errors.New("rpc: deadline exceeded")                 // 超过截止时间
+	ErrCanceled                     = errors.New("rpc: canceled")                          // 调用方已取消
+	ErrCircuitOpen                  = errors.New("rpc: circuit breaker is open")           // 目标的熔断器已打开
+	ErrStreamNotAccepted            = errors.New("rpc: stream reply not accepted")         // 调用方不接受流式答复
+	ErrStreamUnsupported            = errors.New("rpc: stream request unsupported")        // 投递器不支持流式请求
 )
 
+func init() {
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_Undeliverable, ErrUndeliverable)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_Terminated, ErrTerminated)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_EntityNotFound, ErrEntityNotFound)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_SessionNotFound, ErrSessionNotFound)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_GroupNotFound, ErrGroupNotFound)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_GroupChanIsFull, ErrGroupChanIsFull)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_DistEntityNotFound, ErrDistEntityNotFound)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_DistEntityNodeNotFound, ErrDistEntityNodeNotFound)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_IncorrectDestAddress, ErrIncorrectDestAddress)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_AddInNotFound, ErrAddInNotFound)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_AddInInactive, ErrAddInInactive)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_MethodNotFound, ErrMethodNotFound)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_ComponentNotFound, ErrComponentNotFound)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_MethodParameterCountMismatch, ErrMethodParameterCountMismatch)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_MethodParameterTypeMismatch, ErrMethodParameterTypeMismatch)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_AsyncMethodReturnedNil, ErrAsyncMethodReturnedNil)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_PermissionDenied, ErrPermissionDenied)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_DeadlineExceeded, ErrDeadlineExceeded)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_Canceled, ErrCanceled)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_CircuitOpen, ErrCircuitOpen)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_StreamNotAccepted, ErrStreamNotAccepted)
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_StreamUnsupported, ErrStreamUnsupported)
+}
+
 // IDeliverer RPC投递器接口
 type IDeliverer interface {
 	// Match 是否匹配
 	Match(svcCtx service.Context, dst string, cc rpcstack.CallChain, cp callpath.CallPath, oneway bool) bool
-	// Request 请求
-	Request(svcCtx service.Context, dst string, cc rpcstack.CallChain, cp callpath.CallPath, args []any) async.AsyncRet
-	// Notify 通知
-	Notify(svcCtx service.Context, dst string, cc rpcstack.CallChain, cp callpath.CallPath, args []any) error
+	// Request 请求，deadline为零值时使用默认的Future超时时间，trace为随请求传播的链路追踪上下文，baggage为跨服务传播的栈变量
+	Request(svcCtx service.Context, dst string, deadline time.Time, trace tracing.SpanContext, cc rpcstack.CallChain, baggage variant.Map, cp callpath.CallPath, args []any) async.AsyncRet
+	// Notify 通知，trace为随请求传播的链路追踪上下文，baggage为跨服务传播的栈变量
+	Notify(svcCtx service.Context, dst string, trace tracing.SpanContext, cc rpcstack.CallChain, baggage variant.Map, cp callpath.CallPath, args []any) error
+}
+
+// IStreamDeliverer 支持流式请求的RPC投递器接口
+type IStreamDeliverer interface {
+	// StreamRequest 流式请求，window为流控窗口，异步调用结果依次产出数据项，结束时关闭，deadline限制整个流式答复，为零值时只限制等待下一个数据项的空闲时间，ctx取消时放弃接收并通知被调用方停止发送
+	StreamRequest(svcCtx service.Context, ctx context.Context, dst string, deadline time.Time, window int, trace tracing.SpanContext, cc rpcstack.CallChain, baggage variant.Map, cp callpath.CallPath, args []any) async.AsyncRet
 }
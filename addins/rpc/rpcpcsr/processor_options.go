@@ -0,0 +1,175 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"git.golaxy.org/core/utils/option"
+	"time"
+)
+
+// ServiceProcessorOptions 分布式服务间的RPC处理器的所有选项
+type ServiceProcessorOptions struct {
+	PermValidator     PermissionValidator // 权限验证器
+	ReduceCallPath    bool                // 是否压缩调用路径
+	Balancer          IBalancer           // 负载均衡器，为nil时，负载均衡请求由消息队列的负载均衡队列组随机选择节点
+	Breaker           ICircuitBreaker     // 按目标节点熔断的熔断器，为nil时使用默认选项创建
+	StreamIdleTimeout time.Duration       // 流式请求等待下一个数据项的空闲超时时间，小于等于0时不限制
+	Interceptors      []ServerInterceptor // 服务端拦截器，按顺序串联
+}
+
+var ServiceWith _ServiceOption
+
+type _ServiceOption struct{}
+
+// Default 默认值
+func (_ServiceOption) Default() option.Setting[ServiceProcessorOptions] {
+	return func(options *ServiceProcessorOptions) {
+		ServiceWith.PermValidator(nil).Apply(options)
+		ServiceWith.ReduceCallPath(true).Apply(options)
+		ServiceWith.Balancer(nil).Apply(options)
+		ServiceWith.Breaker(nil).Apply(options)
+		ServiceWith.StreamIdleTimeout(30 * time.Second).Apply(options)
+		ServiceWith.Interceptors().Apply(options)
+	}
+}
+
+// PermValidator 权限验证器
+func (_ServiceOption) PermValidator(validator PermissionValidator) option.Setting[ServiceProcessorOptions] {
+	return func(options *ServiceProcessorOptions) {
+		options.PermValidator = validator
+	}
+}
+
+// ReduceCallPath 是否压缩调用路径
+func (_ServiceOption) ReduceCallPath(b bool) option.Setting[ServiceProcessorOptions] {
+	return func(options *ServiceProcessorOptions) {
+		options.ReduceCallPath = b
+	}
+}
+
+// Balancer 负载均衡器，为nil时，负载均衡请求由消息队列的负载均衡队列组随机选择节点
+func (_ServiceOption) Balancer(balancer IBalancer) option.Setting[ServiceProcessorOptions] {
+	return func(options *ServiceProcessorOptions) {
+		options.Balancer = balancer
+	}
+}
+
+// Breaker 按目标节点熔断的熔断器，为nil时使用默认选项创建
+func (_ServiceOption) Breaker(breaker ICircuitBreaker) option.Setting[ServiceProcessorOptions] {
+	return func(options *ServiceProcessorOptions) {
+		options.Breaker = breaker
+	}
+}
+
+// StreamIdleTimeout 流式请求等待下一个数据项的空闲超时时间，未设置截止时间的流式请求不限制整个流式答复的时长，超过空闲超时时间时放弃接收，小于等于0时不限制
+func (_ServiceOption) StreamIdleTimeout(d time.Duration) option.Setting[ServiceProcessorOptions] {
+	return func(options *ServiceProcessorOptions) {
+		options.StreamIdleTimeout = d
+	}
+}
+
+// Interceptors 服务端拦截器，按顺序串联，第一个拦截器在最外层
+func (_ServiceOption) Interceptors(interceptors ...ServerInterceptor) option.Setting[ServiceProcessorOptions] {
+	return func(options *ServiceProcessorOptions) {
+		options.Interceptors = interceptors
+	}
+}
+
+// ForwardProcessorOptions RPC转发处理器的所有选项
+type ForwardProcessorOptions struct {
+	PermValidator     PermissionValidator // 权限验证器
+	ReduceCallPath    bool                // 是否压缩调用路径
+	Breaker           ICircuitBreaker     // 按通信中转节点熔断的熔断器，为nil时使用默认选项创建
+	StreamIdleTimeout time.Duration       // 流式请求等待下一个数据项的空闲超时时间，小于等于0时不限制
+	Interceptors      []ServerInterceptor // 服务端拦截器，按顺序串联
+}
+
+var ForwardWith _ForwardOption
+
+type _ForwardOption struct{}
+
+// Default 默认值
+func (_ForwardOption) Default() option.Setting[ForwardProcessorOptions] {
+	return func(options *ForwardProcessorOptions) {
+		ForwardWith.PermValidator(nil).Apply(options)
+		ForwardWith.ReduceCallPath(true).Apply(options)
+		ForwardWith.Breaker(nil).Apply(options)
+		ForwardWith.StreamIdleTimeout(30 * time.Second).Apply(options)
+		ForwardWith.Interceptors().Apply(options)
+	}
+}
+
+// PermValidator 权限验证器
+func (_ForwardOption) PermValidator(validator PermissionValidator) option.Setting[ForwardProcessorOptions] {
+	return func(options *ForwardProcessorOptions) {
+		options.PermValidator = validator
+	}
+}
+
+// ReduceCallPath 是否压缩调用路径
+func (_ForwardOption) ReduceCallPath(b bool) option.Setting[ForwardProcessorOptions] {
+	return func(options *ForwardProcessorOptions) {
+		options.ReduceCallPath = b
+	}
+}
+
+// Breaker 按通信中转节点熔断的熔断器，为nil时使用默认选项创建
+func (_ForwardOption) Breaker(breaker ICircuitBreaker) option.Setting[ForwardProcessorOptions] {
+	return func(options *ForwardProcessorOptions) {
+		options.Breaker = breaker
+	}
+}
+
+// StreamIdleTimeout 流式请求等待下一个数据项的空闲超时时间，未设置截止时间的流式请求不限制整个流式答复的时长，超过空闲超时时间时放弃接收，小于等于0时不限制
+func (_ForwardOption) StreamIdleTimeout(d time.Duration) option.Setting[ForwardProcessorOptions] {
+	return func(options *ForwardProcessorOptions) {
+		options.StreamIdleTimeout = d
+	}
+}
+
+// Interceptors 服务端拦截器，按顺序串联，第一个拦截器在最外层
+func (_ForwardOption) Interceptors(interceptors ...ServerInterceptor) option.Setting[ForwardProcessorOptions] {
+	return func(options *ForwardProcessorOptions) {
+		options.Interceptors = interceptors
+	}
+}
+
+// GateProcessorOptions 网关RPC处理器的所有选项
+type GateProcessorOptions struct {
+	Interceptors []ServerInterceptor // 网关拦截器，按顺序串联，在转发客户端的RPC请求前执行
+}
+
+var GateWith _GateOption
+
+type _GateOption struct{}
+
+// Default 默认值
+func (_GateOption) Default() option.Setting[GateProcessorOptions] {
+	return func(options *GateProcessorOptions) {
+		GateWith.Interceptors().Apply(options)
+	}
+}
+
+// Interceptors 网关拦截器，按顺序串联，在转发客户端的RPC请求前执行，可以检查、修改或拒绝请求，拦截器调用的handler负责转发请求，返回的结果值为nil
+func (_GateOption) Interceptors(interceptors ...ServerInterceptor) option.Setting[GateProcessorOptions] {
+	return func(options *GateProcessorOptions) {
+		options.Interceptors = interceptors
+	}
+}
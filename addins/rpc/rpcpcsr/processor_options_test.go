@@ -0,0 +1,59 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/core/utils/option"
+	"testing"
+	"time"
+)
+
+func TestProcessorOptions(t *testing.T) {
+	// 默认压缩调用路径，限制流式请求的空闲时间，未设置负载均衡器、熔断器与拦截器
+	options := option.Make(ServiceWith.Default())
+	if !options.ReduceCallPath || options.Balancer != nil || options.Breaker != nil || options.StreamIdleTimeout <= 0 || len(options.Interceptors) != 0 {
+		t.Fatalf("unexpected default service processor options %+v", options)
+	}
+
+	breaker := NewCircuitBreaker()
+	interceptor := func(call *ServerCall, handler ServerHandler) async.AsyncRet { return handler(call) }
+
+	options = option.Make(ServiceWith.Default(),
+		ServiceWith.ReduceCallPath(false),
+		ServiceWith.Balancer(NewLeastOutstandingBalancer("")),
+		ServiceWith.Breaker(breaker),
+		ServiceWith.StreamIdleTimeout(time.Second),
+		ServiceWith.Interceptors(interceptor, interceptor),
+	)
+	if options.ReduceCallPath || options.Balancer == nil || options.Breaker != breaker || options.StreamIdleTimeout != time.Second || len(options.Interceptors) != 2 {
+		t.Fatalf("unexpected service processor options %+v", options)
+	}
+
+	forwardOptions := option.Make(ForwardWith.Default(), ForwardWith.Breaker(breaker), ForwardWith.StreamIdleTimeout(0), ForwardWith.Interceptors(interceptor))
+	if !forwardOptions.ReduceCallPath || forwardOptions.Breaker != breaker || forwardOptions.StreamIdleTimeout != 0 || len(forwardOptions.Interceptors) != 1 {
+		t.Fatalf("unexpected forward processor options %+v", forwardOptions)
+	}
+
+	gateOptions := option.Make(GateWith.Default(), GateWith.Interceptors(interceptor))
+	if len(gateOptions.Interceptors) != 1 {
+		t.Fatalf("unexpected gate processor options %+v", gateOptions)
+	}
+}
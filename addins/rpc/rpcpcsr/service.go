@@ -23,32 +23,54 @@ import (
 	"context"
 	"git.golaxy.org/core/service"
 	"git.golaxy.org/core/utils/generic"
+	"git.golaxy.org/core/utils/option"
 	"git.golaxy.org/core/utils/types"
 	"git.golaxy.org/framework/addins/dsvc"
 	"git.golaxy.org/framework/addins/log"
+	"git.golaxy.org/framework/utils/concurrent"
+	"time"
 )
 
 // NewServiceProcessor 创建分布式服务间的RPC处理器
-func NewServiceProcessor(permValidator PermissionValidator, reduceCallPath bool) any {
+func NewServiceProcessor(settings ...option.Setting[ServiceProcessorOptions]) any {
+	options := option.Make(ServiceWith.Default(), settings...)
+
 	return &_ServiceProcessor{
-		permValidator:  permValidator,
-		reduceCallPath: reduceCallPath,
+		permValidator:     options.PermValidator,
+		reduceCallPath:    options.ReduceCallPath,
+		balancer:          options.Balancer,
+		breaker:           options.Breaker,
+		streamIdleTimeout: options.StreamIdleTimeout,
+		interceptors:      options.Interceptors,
 	}
 }
 
 // _ServiceProcessor 分布式服务间的RPC处理器
 type _ServiceProcessor struct {
-	svcCtx         service.Context
-	dist           dsvc.IDistService
-	watcher        dsvc.IWatcher
-	permValidator  PermissionValidator
-	reduceCallPath bool
+	svcCtx            service.Context
+	dist              dsvc.IDistService
+	watcher           dsvc.IWatcher
+	permValidator     PermissionValidator
+	reduceCallPath    bool
+	balancer          IBalancer
+	breaker           ICircuitBreaker
+	streamIdleTimeout time.Duration
+	balanceNodes      concurrent.LockedMap[string, *_BalanceNodes]
+	calls             _Calls
+	streams           _Streams
+	interceptors      []ServerInterceptor
+	handler           ServerHandler
 }
 
 // Init 初始化
 func (p *_ServiceProcessor) Init(svcCtx service.Context) {
 	p.svcCtx = svcCtx
 	p.dist = dsvc.Using(svcCtx)
+	p.balanceNodes = concurrent.MakeLockedMap[string, *_BalanceNodes](0)
+	p.breaker = initCircuitBreaker(svcCtx, p.breaker)
+	p.calls.init()
+	p.streams.init()
+	p.handler = makeServerHandler(svcCtx, p.interceptors)
 	p.watcher = p.dist.WatchMsg(context.Background(), generic.CastDelegate2(p.handleMsg))
 
 	log.Debugf(p.svcCtx, "rpc processor %q started", types.FullName(*p))
@@ -20,14 +20,23 @@
 package rpcpcsr
 
 import (
+	"context"
 	"git.golaxy.org/core/service"
 	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/dentq"
+	"git.golaxy.org/framework/addins/discovery"
+	"git.golaxy.org/framework/addins/dsvc"
 	"git.golaxy.org/framework/addins/log"
 	"git.golaxy.org/framework/addins/rpc/callpath"
 	"git.golaxy.org/framework/addins/rpcstack"
 	"git.golaxy.org/framework/net/gap"
 	"git.golaxy.org/framework/net/gap/variant"
 	"git.golaxy.org/framework/utils/concurrent"
+	"git.golaxy.org/framework/utils/tracing"
+	"math/rand/v2"
+	"slices"
+	"time"
 )
 
 // Match 是否匹配
@@ -49,40 +58,116 @@ func (p *_ServiceProcessor) Match(svcCtx service.Context, dst string, cc rpcstac
 }
 
 // Request 请求
-func (p *_ServiceProcessor) Request(svcCtx service.Context, dst string, cc rpcstack.CallChain, cp callpath.CallPath, args []any) async.AsyncRet {
-	ret := concurrent.MakeRespAsyncRet()
-	future := concurrent.MakeFuture(p.dist.GetFutures(), nil, ret)
+func (p *_ServiceProcessor) Request(svcCtx service.Context, dst string, deadline time.Time, trace tracing.SpanContext, cc rpcstack.CallChain, baggage variant.Map, cp callpath.CallPath, args []any) async.AsyncRet {
+	return p.request(svcCtx, nil, dst, deadline, 0, trace, cc, baggage, cp, args)
+}
+
+// StreamRequest 流式请求
+func (p *_ServiceProcessor) StreamRequest(svcCtx service.Context, ctx context.Context, dst string, deadline time.Time, window int, trace tracing.SpanContext, cc rpcstack.CallChain, baggage variant.Map, cp callpath.CallPath, args []any) async.AsyncRet {
+	return p.request(svcCtx, ctx, dst, deadline, max(window, 1), trace, cc, baggage, cp, args)
+}
+
+// request 请求，window大于0时为流式请求，ctx取消时放弃接收流式答复
+func (p *_ServiceProcessor) request(svcCtx service.Context, ctx context.Context, dst string, deadline time.Time, window int, trace tracing.SpanContext, cc rpcstack.CallChain, baggage variant.Map, cp callpath.CallPath, args []any) async.AsyncRet {
+	// 统计调用次数与耗时，填入返回结果时记录
+	observe := observeClientRPC(p.svcCtx, cp)
+
+	timeout, ok := futureTimeout(deadline)
+	if !ok {
+		return failClientRPC(observe, ErrDeadlineExceeded)
+	}
+
+	// 未设置截止时间的流式请求，不限制整个流式答复的时长，使用空闲超时时间
+	if window > 0 && deadline.IsZero() {
+		timeout = concurrent.FutureNoTimeout
+	}
 
 	vargs, err := variant.MakeReadonlyArray(args)
 	if err != nil {
-		future.Cancel(err)
-		return ret.ToAsyncRet()
+		return failClientRPC(observe, err)
 	}
 
 	cpBuf, err := cp.Encode(p.reduceCallPath)
 	if err != nil {
-		future.Cancel(err)
-		return ret.ToAsyncRet()
+		return failClientRPC(observe, err)
+	}
+
+	dst, nodeId, done, err := p.balance(dst, cp)
+	if err != nil {
+		return failClientRPC(observe, err)
+	}
+
+	// 目标的熔断器打开时快速失败
+	report, err := allowCircuit(p.breaker, p.dist.GetNodeDetails(), dst)
+	if err != nil {
+		done()
+		if nodeId != uid.Nil {
+			err = &NodeError{NodeId: nodeId, Err: err}
+		}
+		return failClientRPC(observe, err)
+	}
+
+	// 请求结束时向熔断器报告结果，负载均衡选中的节点请求失败时返回NodeError，调用方重试时可以排除该节点
+	var ret async.AsyncRet
+	var stream *_StreamReceiver
+	resp := &_DeliverResp{nodeId: nodeId, report: report, observe: observe}
+
+	if window > 0 {
+		// 流式请求收到第一个数据项时向熔断器报告结果，避免持续时间较长的流式答复被判定为慢调用
+		stream = p.streams.receive(ctx, window, p.streamIdleTimeout, report, p.ackStream)
+		resp.resp = stream.resp
+		resp.report = stream.report
+		ret = stream.resp.ToAsyncRet()
+	} else {
+		respRet := concurrent.MakeRespAsyncRet()
+		resp.resp = respRet
+		ret = respRet.ToAsyncRet()
+	}
+
+	future := concurrent.MakeFuture(p.dist.GetFutures(), nil, resp, timeout)
+
+	endStream := func() {}
+	if stream != nil {
+		endStream = p.streams.begin(stream, future)
 	}
 
 	msg := &gap.MsgRPCRequest{
 		CorrId:    future.Id,
+		Deadline:  unixDeadline(future.Deadline),
+		Window:    int64(window),
+		Trace:     trace,
+		Baggage:   baggage,
 		CallChain: cc,
 		Path:      cpBuf,
 		Args:      vargs,
 	}
 
 	if err = p.dist.SendMsg(dst, msg); err != nil {
+		done()
+		endStream()
 		future.Cancel(err)
-		return ret.ToAsyncRet()
+		return ret
 	}
 
+	// 请求结束时，减少节点未完成的请求数，调用方放弃请求时，通知被调用方取消，流式请求已收到数据项时，通知发送流式答复的节点
+	context.AfterFunc(future.Finish, func() {
+		done()
+		endStream()
+		if future.Abandoned() {
+			if stream != nil && stream.source() != "" {
+				p.cancel(stream.source(), future.Id)
+			} else {
+				p.cancel(dst, future.Id)
+			}
+		}
+	})
+
 	log.Debugf(p.svcCtx, "rpc request(%d) to dst:%q, path:%q ok", future.Id, dst, cp)
-	return ret.ToAsyncRet()
+	return ret
 }
 
 // Notify 通知
-func (p *_ServiceProcessor) Notify(svcCtx service.Context, dst string, cc rpcstack.CallChain, cp callpath.CallPath, args []any) error {
+func (p *_ServiceProcessor) Notify(svcCtx service.Context, dst string, trace tracing.SpanContext, cc rpcstack.CallChain, baggage variant.Map, cp callpath.CallPath, args []any) error {
 	vargs, err := variant.MakeReadonlyArray(args)
 	if err != nil {
 		return err
@@ -94,11 +179,19 @@ func (p *_ServiceProcessor) Notify(svcCtx service.Context, dst string, cc rpcsta
 	}
 
 	msg := &gap.MsgOnewayRPC{
+		Trace:     trace,
+		Baggage:   baggage,
 		CallChain: cc,
 		Path:      cpBuf,
 		Args:      vargs,
 	}
 
+	dst, _, done, err := p.balance(dst, cp)
+	if err != nil {
+		return err
+	}
+	defer done()
+
 	if err = p.dist.SendMsg(dst, msg); err != nil {
 		return err
 	}
@@ -106,3 +199,175 @@ func (p *_ServiceProcessor) Notify(svcCtx service.Context, dst string, cc rpcsta
 	log.Debugf(p.svcCtx, "rpc notify to dst:%q, path:%q ok", dst, cp)
 	return nil
 }
+
+// cancel 通知被调用方取消请求，目标为负载均衡地址时，无法确定处理请求的节点，不发送通知
+func (p *_ServiceProcessor) cancel(dst string, corrId int64) {
+	details := p.dist.GetNodeDetails()
+
+	if details.DomainBalance.Contains(dst) || details.DomainBalance.Equal(dst) {
+		return
+	}
+
+	if err := p.dist.SendMsg(dst, &gap.MsgRPCCancel{CorrId: corrId}); err != nil {
+		log.Errorf(p.svcCtx, "rpc cancel(%d) to dst:%q failed, %s", corrId, dst, err)
+		return
+	}
+
+	log.Debugf(p.svcCtx, "rpc cancel(%d) to dst:%q ok", corrId, dst)
+}
+
+// ackStream 向发送流式答复的节点确认已消费的数据项
+func (p *_ServiceProcessor) ackStream(src string, corrId, consumed int64) {
+	if src == "" {
+		return
+	}
+
+	if err := p.dist.SendMsg(src, &gap.MsgRPCStreamAck{CorrId: corrId, Seq: consumed}); err != nil {
+		log.Errorf(p.svcCtx, "rpc stream ack(%d) to dst:%q failed, %s", corrId, src, err)
+		return
+	}
+}
+
+// _BalanceNodes 负载均衡使用的服务节点缓存
+type _BalanceNodes struct {
+	nodes  []discovery.Node
+	expiry time.Time
+}
+
+// balance 负载均衡，使用负载均衡器选择目标节点，返回节点地址、节点id与请求结束时调用的函数，未选择节点时节点id为零值
+func (p *_ServiceProcessor) balance(dst string, cp callpath.CallPath) (string, uid.Id, func(), error) {
+	details := p.dist.GetNodeDetails()
+
+	service, key, ok := details.ParseBalanceAddr(dst)
+	if !ok {
+		return dst, uid.Nil, func() {}, nil
+	}
+
+	var nodes []discovery.Node
+
+	switch cp.Category {
+	case callpath.Entity, callpath.Runtime:
+		// 目标为分布式实体，只能从实体所在的节点中选择
+		distEntity, ok := dentq.Using(p.svcCtx).GetDistEntity(cp.Id)
+		if !ok {
+			return "", uid.Nil, nil, ErrDistEntityNotFound
+		}
+
+		var serviceNodes []discovery.Node
+		if p.balancer != nil {
+			serviceNodes = p.getServiceNodes(service)
+		}
+
+		for i := range distEntity.Nodes {
+			entityNode := &distEntity.Nodes[i]
+			if entityNode.Service != service {
+				continue
+			}
+
+			idx := slices.IndexFunc(serviceNodes, func(node discovery.Node) bool {
+				return node.Id == entityNode.Id
+			})
+			if idx >= 0 {
+				nodes = append(nodes, serviceNodes[idx])
+			} else {
+				nodes = append(nodes, discovery.Node{Id: entityNode.Id})
+			}
+		}
+
+		if len(nodes) <= 0 {
+			return "", uid.Nil, nil, ErrDistEntityNodeNotFound
+		}
+
+	default:
+		// 未设置负载均衡器，使用消息队列的负载均衡队列组
+		if p.balancer == nil {
+			return details.MakeBalanceAddr(service), uid.Nil, func() {}, nil
+		}
+
+		nodes = p.getServiceNodes(service)
+		if len(nodes) <= 0 {
+			return details.MakeBalanceAddr(service), uid.Nil, func() {}, nil
+		}
+	}
+
+	// 排除熔断器已打开的节点
+	nodes = p.excludeOpenCircuits(nodes)
+
+	var node discovery.Node
+	var selected bool
+
+	if p.balancer != nil {
+		node, selected = p.balancer.Select(service, nodes, key)
+	}
+	if !selected {
+		node = nodes[rand.IntN(len(nodes))]
+	}
+
+	nodeAddr, err := details.MakeNodeAddr(node.Id)
+	if err != nil {
+		return "", uid.Nil, nil, err
+	}
+
+	if p.balancer == nil {
+		return nodeAddr, node.Id, func() {}, nil
+	}
+
+	return nodeAddr, node.Id, p.balancer.Track(node.Id), nil
+}
+
+// allowCircuit 目标为服务节点地址时，使用熔断器判断是否允许投递，目标为负载均衡地址时，处理请求的节点由消息队列选择，
+// 按地址熔断会使整个服务熔断，因此不使用熔断器，返回的report为nil
+func allowCircuit(breaker ICircuitBreaker, details *dsvc.NodeDetails, dst string) (func(err error), error) {
+	if !details.DomainUnicast.Contains(dst) {
+		return nil, nil
+	}
+	return breaker.Allow(dst)
+}
+
+// excludeOpenCircuits 排除熔断器已打开的节点，全部已打开时不排除，由熔断器快速失败
+func (p *_ServiceProcessor) excludeOpenCircuits(nodes []discovery.Node) []discovery.Node {
+	details := p.dist.GetNodeDetails()
+
+	var healthy []discovery.Node
+
+	for i := range nodes {
+		nodeAddr, err := details.MakeNodeAddr(nodes[i].Id)
+		if err == nil && p.breaker.State(nodeAddr) == CircuitState_Open {
+			if healthy == nil {
+				healthy = slices.Clone(nodes[:i])
+			}
+			continue
+		}
+		if healthy != nil {
+			healthy = append(healthy, nodes[i])
+		}
+	}
+
+	if len(healthy) <= 0 {
+		return nodes
+	}
+	return healthy
+}
+
+// getServiceNodes 查询服务节点，在本地短暂缓存，避免每次请求都查询服务发现
+func (p *_ServiceProcessor) getServiceNodes(service string) []discovery.Node {
+	now := time.Now()
+
+	cached, ok := p.balanceNodes.Get(service)
+	if ok && now.Before(cached.expiry) {
+		return cached.nodes
+	}
+
+	svc, err := discovery.Using(p.svcCtx).GetService(p.svcCtx, service)
+	if err != nil {
+		log.Debugf(p.svcCtx, "get service %q nodes for balancing failed, %s", service, err)
+		return nil
+	}
+
+	p.balanceNodes.Add(service, &_BalanceNodes{
+		nodes:  svc.Nodes,
+		expiry: now.Add(3 * time.Second),
+	})
+
+	return svc.Nodes
+}
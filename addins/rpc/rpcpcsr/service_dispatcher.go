@@ -27,6 +27,7 @@ import (
 	"git.golaxy.org/framework/addins/rpcstack"
 	"git.golaxy.org/framework/net/gap"
 	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/utils/tracing"
 	"time"
 )
 
@@ -45,6 +46,20 @@ func (p *_ServiceProcessor) handleMsg(topic string, mp gap.MsgPacket) error {
 
 	case gap.MsgId_RPC_Reply:
 		return p.resolve(mp.Msg.(*gap.MsgRPCReply))
+
+	case gap.MsgId_RPC_Cancel:
+		return p.acceptCancel(mp.Head.Src, mp.Msg.(*gap.MsgRPCCancel))
+
+	case gap.MsgId_RPC_StreamItem:
+		p.streams.yield(mp.Head.Src.Addr, mp.Msg.(*gap.MsgRPCStreamItem))
+		return nil
+
+	case gap.MsgId_RPC_StreamEnd:
+		return resolveStreamEnd(p.dist.GetFutures(), mp.Msg.(*gap.MsgRPCStreamEnd))
+
+	case gap.MsgId_RPC_StreamAck:
+		p.streams.ack(mp.Head.Src.Addr, mp.Msg.(*gap.MsgRPCStreamAck))
+		return nil
 	}
 
 	return nil
@@ -60,6 +75,10 @@ func (p *_ServiceProcessor) acceptNotify(src gap.Origin, req *gap.MsgOnewayRPC)
 		return nil
 	}
 
+	span := startSpan(p.svcCtx, req.Trace, cp.String(), tracing.SpanKind_Server)
+	span.SetAttribute("rpc.src", src.Addr)
+	trace := span.ContextOr(req.Trace)
+
 	cc := append(req.CallChain, rpcstack.Call{Svc: src.Svc, Addr: src.Addr, Timestamp: time.UnixMilli(src.Timestamp).Local(), Transit: false})
 
 	if len(p.permValidator) > 0 {
@@ -71,62 +90,40 @@ func (p *_ServiceProcessor) acceptNotify(src gap.Origin, req *gap.MsgOnewayRPC)
 		}
 		if err != nil {
 			log.Errorf(p.svcCtx, "rpc notify permission verification failed, src:%q, path:%q, %s", src.Addr, req.Path, err)
+			span.End(err)
 			return nil
 		}
 	}
 
-	switch cp.Category {
-	case callpath.Service:
-		go func() {
-			rets, err := CallService(p.svcCtx, cc, cp.Script, cp.Method, req.Args)
-			if err != nil {
-				log.Errorf(p.svcCtx, "rpc notify service addIn:%q, method:%q calls failed, %s", cp.Script, cp.Method, err)
-			} else {
-				log.Debugf(p.svcCtx, "rpc notify service addIn:%q, method:%q calls finished", cp.Script, cp.Method)
-				rets.Release()
-			}
-		}()
-
+	if !dispatchable(cp) {
+		span.End(nil)
 		return nil
+	}
 
-	case callpath.Runtime:
-		asyncRet, err := CallRuntime(p.svcCtx, cc, cp.Id, cp.Script, cp.Method, req.Args)
-		if err != nil {
-			log.Errorf(p.svcCtx, "rpc notify entity:%q, runtime addIn:%q, method:%q calls failed, %s", cp.Id, cp.Script, cp.Method, err)
-			return nil
-		}
-
-		go func() {
-			rets, err := waitAsyncRet(p.svcCtx, asyncRet)
-			if err != nil {
-				log.Errorf(p.svcCtx, "rpc notify entity:%q, runtime addIn:%q, method:%q calls failed, %s", cp.Id, cp.Script, cp.Method, err)
-			} else {
-				log.Debugf(p.svcCtx, "rpc notify entity:%q, runtime addIn:%q, method:%q calls finished", cp.Id, cp.Script, cp.Method)
-				rets.Release()
-			}
-		}()
+	call := &ServerCall{
+		Context:   p.svcCtx,
+		Oneway:    true,
+		Trace:     trace,
+		CallChain: cc,
+		Baggage:   req.Baggage,
+		CallPath:  cp,
+		Args:      req.Args,
+	}
 
-		return nil
+	start := time.Now()
+	asyncRet := p.handler(call)
 
-	case callpath.Entity:
-		asyncRet, err := CallEntity(p.svcCtx, cc, cp.Id, cp.Script, cp.Method, req.Args)
+	go func() {
+		rets, err := waitAsyncRet(p.svcCtx, asyncRet)
+		span.End(err)
+		observeRPC(p.svcCtx, cp, start, err)
 		if err != nil {
-			log.Errorf(p.svcCtx, "rpc notify entity:%q, component:%q, method:%q calls failed, %s", cp.Id, cp.Script, cp.Method, err)
-			return nil
+			log.Errorf(p.svcCtx, "rpc notify %s calls failed, %s", describeCallPath(cp), err)
+		} else {
+			log.Debugf(p.svcCtx, "rpc notify %s calls finished", describeCallPath(cp))
+			rets.Release()
 		}
-
-		go func() {
-			rets, err := waitAsyncRet(p.svcCtx, asyncRet)
-			if err != nil {
-				log.Errorf(p.svcCtx, "rpc notify entity:%q, component:%q, method:%q calls failed, %s", cp.Id, cp.Script, cp.Method, err)
-			} else {
-				log.Debugf(p.svcCtx, "rpc notify entity:%q, component:%q, method:%q calls finished", cp.Id, cp.Script, cp.Method)
-				rets.Release()
-			}
-		}()
-
-		return nil
-	}
+	}()
 
 	return nil
 }
@@ -135,10 +132,21 @@ func (p *_ServiceProcessor) acceptRequest(src gap.Origin, req *gap.MsgRPCRequest
 	cp, err := callpath.Parse(req.Path)
 	if err != nil {
 		err = fmt.Errorf("parse rpc request(%d) path %q failed, %s", req.CorrId, req.Path, err)
-		go p.reply(src, req.CorrId, nil, err)
+		go p.reply(src, req.CorrId, req.Trace, nil, err)
 		return err
 	}
 
+	// 已超过截止时间，调用方已不再等待结果，丢弃请求
+	deadline := parseDeadline(req.Deadline)
+	if deadlineExceeded(deadline) {
+		log.Debugf(p.svcCtx, "rpc request(%d) deadline exceeded, dropped, src:%q, path:%q", req.CorrId, src.Addr, req.Path)
+		return nil
+	}
+
+	span := startSpan(p.svcCtx, req.Trace, cp.String(), tracing.SpanKind_Server)
+	span.SetAttribute("rpc.src", src.Addr)
+	trace := span.ContextOr(req.Trace)
+
 	cc := append(req.CallChain, rpcstack.Call{Svc: src.Svc, Addr: src.Addr, Timestamp: time.UnixMilli(src.Timestamp).Local(), Transit: false})
 
 	if len(p.permValidator) > 0 {
@@ -150,72 +158,77 @@ func (p *_ServiceProcessor) acceptRequest(src gap.Origin, req *gap.MsgRPCRequest
 		}
 		if err != nil {
 			log.Errorf(p.svcCtx, "rpc request(%d) permission verification failed, src:%q, path:%q, %s", req.CorrId, src.Addr, req.Path, err)
-			go p.reply(src, req.CorrId, nil, err)
+			span.End(err)
+			go p.reply(src, req.CorrId, trace, nil, err)
 			return nil
 		}
 	}
 
-	switch cp.Category {
-	case callpath.Service:
-		go func() {
-			rets, err := CallService(p.svcCtx, cc, cp.Script, cp.Method, req.Args)
-			if err != nil {
-				log.Errorf(p.svcCtx, "rpc request(%d) service addIn:%q, method:%q calls failed, %s", req.CorrId, cp.Script, cp.Method, err)
-			} else {
-				log.Debugf(p.svcCtx, "rpc request(%d) service addIn:%q, method:%q calls finished", req.CorrId, cp.Script, cp.Method)
-			}
-			p.reply(src, req.CorrId, rets, err)
-		}()
-
+	if !dispatchable(cp) {
+		span.End(nil)
 		return nil
+	}
 
-	case callpath.Runtime:
-		asyncRet, err := CallRuntime(p.svcCtx, cc, cp.Id, cp.Script, cp.Method, req.Args)
-		if err != nil {
-			log.Errorf(p.svcCtx, "rpc request(%d) entity:%q, runtime addIn:%q, method:%q calls failed, %s", req.CorrId, cp.Id, cp.Script, cp.Method, err)
-			go p.reply(src, req.CorrId, nil, err)
-			return nil
-		}
+	// 调用上下文，调用方取消调用或超过截止时间时取消
+	ctx, finish := p.calls.begin(p.svcCtx, src.Addr, req.CorrId, deadline)
+
+	call := &ServerCall{
+		Context:   ctx,
+		CorrId:    req.CorrId,
+		Window:    req.Window,
+		Trace:     trace,
+		CallChain: cc,
+		Baggage:   req.Baggage,
+		CallPath:  cp,
+		Args:      req.Args,
+	}
 
-		go func() {
-			rets, err := waitAsyncRet(p.svcCtx, asyncRet)
-			if err != nil {
-				log.Errorf(p.svcCtx, "rpc request(%d) entity:%q, runtime addIn:%q, method:%q calls failed, %s", req.CorrId, cp.Id, cp.Script, cp.Method, err)
-				p.reply(src, req.CorrId, nil, err)
-			} else {
-				log.Debugf(p.svcCtx, "rpc request(%d) entity:%q, runtime addIn:%q, method:%q calls finished", req.CorrId, cp.Id, cp.Script, cp.Method)
-				p.reply(src, req.CorrId, rets, nil)
+	start := time.Now()
+	asyncRet := p.handler(call)
+
+	go func() {
+		defer finish()
+
+		rets, source, err := waitReply(p.svcCtx, asyncRet)
+		if source != nil {
+			if req.Window > 0 {
+				// 发送流式答复，调用方取消调用或超过截止时间时结束
+				err = p.streams.send(ctx, src.Addr, req.CorrId, req.Window, trace, source, func(msg gap.Msg) error {
+					return p.dist.SendMsg(src.Addr, msg)
+				})
+				span.End(err)
+				observeRPC(p.svcCtx, cp, start, err)
+				if err != nil {
+					log.Errorf(p.svcCtx, "rpc request(%d) %s stream failed, %s", req.CorrId, describeCallPath(cp), err)
+				} else {
+					log.Debugf(p.svcCtx, "rpc request(%d) %s stream finished", req.CorrId, describeCallPath(cp))
+				}
+				return
 			}
-		}()
-
-		return nil
+			err = ErrStreamNotAccepted
+		}
 
-	case callpath.Entity:
-		asyncRet, err := CallEntity(p.svcCtx, cc, cp.Id, cp.Script, cp.Method, req.Args)
+		span.End(err)
+		observeRPC(p.svcCtx, cp, start, err)
 		if err != nil {
-			log.Errorf(p.svcCtx, "rpc request(%d) entity:%q, component:%q, method:%q calls failed, %s", req.CorrId, cp.Id, cp.Script, cp.Method, err)
-			go p.reply(src, req.CorrId, nil, err)
-			return nil
+			log.Errorf(p.svcCtx, "rpc request(%d) %s calls failed, %s", req.CorrId, describeCallPath(cp), err)
+		} else {
+			log.Debugf(p.svcCtx, "rpc request(%d) %s calls finished", req.CorrId, describeCallPath(cp))
 		}
+		p.reply(src, req.CorrId, trace, rets, err)
+	}()
 
-		go func() {
-			rets, err := waitAsyncRet(p.svcCtx, asyncRet)
-			if err != nil {
-				log.Errorf(p.svcCtx, "rpc request(%d) entity:%q, component:%q, method:%q calls failed, %s", req.CorrId, cp.Id, cp.Script, cp.Method, err)
-				p.reply(src, req.CorrId, nil, err)
-			} else {
-				log.Debugf(p.svcCtx, "rpc request(%d) entity:%q, component:%q, method:%q calls finished", req.CorrId, cp.Id, cp.Script, cp.Method)
-				p.reply(src, req.CorrId, rets, nil)
-			}
-		}()
+	return nil
+}
 
-		return nil
+func (p *_ServiceProcessor) acceptCancel(src gap.Origin, req *gap.MsgRPCCancel) error {
+	if p.calls.cancel(src.Addr, req.CorrId) {
+		log.Debugf(p.svcCtx, "rpc request(%d) canceled, src:%q", req.CorrId, src.Addr)
 	}
-
 	return nil
 }
 
-func (p *_ServiceProcessor) reply(src gap.Origin, corrId int64, rets variant.Array, retErr error) {
+func (p *_ServiceProcessor) reply(src gap.Origin, corrId int64, trace tracing.SpanContext, rets variant.Array, retErr error) {
 	defer rets.Release()
 
 	if corrId == 0 {
@@ -224,6 +237,7 @@ func (p *_ServiceProcessor) reply(src gap.Origin, corrId int64, rets variant.Arr
 
 	msg := &gap.MsgRPCReply{
 		CorrId: corrId,
+		Trace:  trace,
 		Rets:   rets,
 	}
 
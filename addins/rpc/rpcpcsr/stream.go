@@ -0,0 +1,157 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"context"
+	"errors"
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/framework/addins/rpc/rpcstream"
+	"git.golaxy.org/framework/net/gap"
+	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/utils/concurrent"
+	"git.golaxy.org/framework/utils/tracing"
+	"sync/atomic"
+	"time"
+)
+
+// _Streams 流式答复，被调用方记录发送中的流控窗口，调用方记录接收中的流式答复
+type _Streams struct {
+	flows     concurrent.LockedMap[_CallKey, *rpcstream.Flow]
+	receivers concurrent.LockedMap[int64, *_StreamReceiver]
+}
+
+func (s *_Streams) init() {
+	s.flows = concurrent.MakeLockedMap[_CallKey, *rpcstream.Flow](0)
+	s.receivers = concurrent.MakeLockedMap[int64, *_StreamReceiver](0)
+}
+
+// send 发送流式答复，数据源遍历结束后发送结束消息，返回结束原因
+func (s *_Streams) send(ctx context.Context, src string, corrId, window int64, trace tracing.SpanContext, source *rpcstream.Source, send func(msg gap.Msg) error) error {
+	key := _CallKey{Src: src, CorrId: corrId}
+	flow := rpcstream.NewFlow(window)
+
+	s.flows.Add(key, flow)
+	defer s.flows.Delete(key)
+
+	err := source.Send(ctx, flow, corrId, send)
+	if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		err = contextErr(ctx)
+	}
+
+	msg := &gap.MsgRPCStreamEnd{
+		CorrId: corrId,
+		Trace:  trace,
+	}
+
+	if err != nil {
+		msg.Error = *variant.MakeError(err)
+	}
+
+	if endErr := send(msg); endErr != nil && err == nil {
+		err = endErr
+	}
+
+	return err
+}
+
+// ack 调用方确认已消费的数据项
+func (s *_Streams) ack(src string, req *gap.MsgRPCStreamAck) bool {
+	flow, ok := s.flows.Get(_CallKey{Src: src, CorrId: req.CorrId})
+	if !ok {
+		return false
+	}
+	flow.Ack(req.Seq)
+	return true
+}
+
+// receive 创建接收中的流式答复，ctx取消时放弃接收，idle为等待下一个数据项的空闲超时时间，report为向熔断器报告结果的函数，收到第一个数据项或结束时报告，ack为向被调用方确认已消费数据项的函数
+func (s *_Streams) receive(ctx context.Context, window int, idle time.Duration, report func(err error), ack func(src string, corrId, consumed int64)) *_StreamReceiver {
+	r := &_StreamReceiver{breaker: report}
+	r.resp = concurrent.MakeRespStream(ctx, int64(window), idle, func(consumed int64) {
+		ack(r.source(), r.corrId.Load(), consumed)
+	})
+	return r
+}
+
+// begin 开始接收流式答复，接收结束时需要调用返回的函数，调用方放弃接收或空闲超时时取消Future
+func (s *_Streams) begin(r *_StreamReceiver, future concurrent.Future) func() {
+	r.corrId.Store(future.Id)
+	s.receivers.Add(future.Id, r)
+
+	go func() {
+		select {
+		case <-r.resp.Done():
+			// 提前停止产出时，取消Future，调用方放弃请求时通知被调用方停止发送
+			if err := r.resp.Err(); err != nil {
+				future.Cancel(err)
+			}
+		case <-future.Finish.Done():
+		}
+	}()
+
+	return func() {
+		s.receivers.Delete(future.Id)
+	}
+}
+
+// yield 接收流式答复的数据项
+func (s *_Streams) yield(src string, item *gap.MsgRPCStreamItem) bool {
+	r, ok := s.receivers.Get(item.CorrId)
+	if !ok {
+		return false
+	}
+	r.src.Store(src)
+	r.report(nil)
+	return r.resp.Yield(async.MakeRet(item.Rets, nil))
+}
+
+// _StreamReceiver 接收中的流式答复
+type _StreamReceiver struct {
+	resp     *concurrent.RespStream
+	corrId   atomic.Int64
+	src      atomic.Value
+	breaker  func(err error)
+	reported atomic.Bool
+}
+
+// report 向熔断器报告结果，只报告一次
+func (r *_StreamReceiver) report(err error) {
+	if r.breaker != nil && r.reported.CompareAndSwap(false, true) {
+		r.breaker(err)
+	}
+}
+
+// source 发送流式答复的被调用方地址，未收到数据项时为空
+func (r *_StreamReceiver) source() string {
+	src, _ := r.src.Load().(string)
+	return src
+}
+
+// resolveStreamEnd 使用流式答复结束消息解决Future
+func resolveStreamEnd(fs *concurrent.Futures, end *gap.MsgRPCStreamEnd) error {
+	ret := async.Ret{}
+
+	if !end.Error.OK() {
+		ret.Error = &end.Error
+	}
+
+	return fs.Resolve(end.CorrId, ret)
+}
@@ -0,0 +1,128 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"context"
+	"errors"
+	"git.golaxy.org/framework/net/gap"
+	"git.golaxy.org/framework/utils/concurrent"
+	"testing"
+	"time"
+)
+
+var testFutures = concurrent.NewFutures(context.Background(), time.Minute)
+
+func beginStream(t *testing.T, s *_Streams, ctx context.Context, idle time.Duration) (*_StreamReceiver, concurrent.Future, func()) {
+	t.Helper()
+	fs := testFutures
+	r := s.receive(ctx, 1, idle, nil, func(string, int64, int64) {})
+	future := concurrent.MakeFuture(fs, nil, &_DeliverResp{resp: r.resp, report: r.report}, concurrent.FutureNoTimeout)
+	return r, future, s.begin(r, future)
+}
+
+func waitFinish(t *testing.T, future concurrent.Future) {
+	t.Helper()
+	select {
+	case <-future.Finish.Done():
+	case <-time.After(time.Second):
+		t.Fatal("future not finished")
+	}
+}
+
+func TestStreamsAbandon(t *testing.T) {
+	var s _Streams
+	s.init()
+
+	// 调用方放弃接收时取消Future，放弃请求需要通知被调用方停止发送
+	ctx, cancel := context.WithCancel(context.Background())
+	r, future, end := beginStream(t, &s, ctx, 0)
+	defer end()
+
+	if !s.yield("src", &gap.MsgRPCStreamItem{CorrId: future.Id}) {
+		t.Fatal("yield stream item failed")
+	}
+	<-r.resp.ToAsyncRet()
+
+	cancel()
+	waitFinish(t, future)
+
+	if !future.Abandoned() {
+		t.Fatal("canceled stream future not abandoned")
+	}
+	if r.source() != "src" {
+		t.Fatalf("got source %q, want %q", r.source(), "src")
+	}
+	if _, ok := <-r.resp.ToAsyncRet(); ok {
+		t.Fatal("got item after canceled")
+	}
+}
+
+func TestStreamsIdleTimeout(t *testing.T) {
+	var s _Streams
+	s.init()
+
+	// 等待下一个数据项超时时，产出超时错误并取消Future
+	r, future, end := beginStream(t, &s, nil, 20*time.Millisecond)
+	defer end()
+
+	ret := <-r.resp.ToAsyncRet()
+	if !errors.Is(ret.Error, concurrent.ErrFutureTimeout) {
+		t.Fatalf("got error %v, want %v", ret.Error, concurrent.ErrFutureTimeout)
+	}
+
+	waitFinish(t, future)
+	if !future.Abandoned() {
+		t.Fatal("idle timeout stream future not abandoned")
+	}
+}
+
+func TestStreamsEnd(t *testing.T) {
+	var s _Streams
+	s.init()
+
+	r, future, end := beginStream(t, &s, nil, 0)
+
+	s.yield("src", &gap.MsgRPCStreamItem{CorrId: future.Id})
+	if err := resolveStreamEnd(testFutures, &gap.MsgRPCStreamEnd{CorrId: future.Id}); err != nil {
+		t.Fatalf("resolve stream end failed, %s", err)
+	}
+	end()
+
+	var items int
+	for ret := range r.resp.ToAsyncRet() {
+		if !ret.OK() {
+			t.Fatalf("got error %v", ret.Error)
+		}
+		items++
+	}
+	if items != 1 {
+		t.Fatalf("got %d items, want 1", items)
+	}
+
+	// 正常结束时不通知被调用方
+	waitFinish(t, future)
+	if future.Abandoned() {
+		t.Fatal("ended stream future abandoned")
+	}
+	if s.yield("src", &gap.MsgRPCStreamItem{CorrId: future.Id}) {
+		t.Fatal("yield after end succeeded")
+	}
+}
@@ -0,0 +1,44 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcpcsr
+
+import (
+	"git.golaxy.org/core/extension"
+	"git.golaxy.org/core/service"
+	"git.golaxy.org/framework/addins/tracer"
+	"git.golaxy.org/framework/utils/tracing"
+)
+
+// startSpan 开始Span，未安装链路追踪插件时返回nil
+func startSpan(svcCtx service.Context, parent tracing.SpanContext, name string, kind tracing.SpanKind) *tracing.Span {
+	addIn, ok := svcCtx.GetAddInManager().Get(tracer.Name)
+	if !ok || addIn.State() != extension.AddInState_Active {
+		return nil
+	}
+	return tracer.Using(svcCtx).StartSpan(parent, name, kind)
+}
+
+// relayedTrace 经过中转的调用，优先使用中转方的链路追踪上下文，中转方未追踪时使用调用方的上下文
+func relayedTrace(transit, src tracing.SpanContext) tracing.SpanContext {
+	if transit.IsValid() {
+		return transit
+	}
+	return src
+}
@@ -0,0 +1,69 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcstream
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// NewFlow 创建流控窗口
+func NewFlow(window int64) *Flow {
+	return &Flow{
+		window: max(window, 1),
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// Flow 流式答复的流控窗口，未确认的数据项达到窗口大小时，发送方等待调用方确认
+type Flow struct {
+	window int64
+	acked  atomic.Int64
+	notify chan struct{}
+}
+
+// Ack 调用方确认已消费的数据项，seq为已消费的最大数据项序号
+func (f *Flow) Ack(seq int64) {
+	for {
+		acked := f.acked.Load()
+		if seq <= acked {
+			return
+		}
+		if f.acked.CompareAndSwap(acked, seq) {
+			break
+		}
+	}
+
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (f *Flow) wait(ctx context.Context, seq int64) error {
+	for seq-f.acked.Load() > f.window {
+		select {
+		case <-f.notify:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/core/utils/types"
+	"git.golaxy.org/framework/net/gap"
+	"git.golaxy.org/framework/net/gap/variant"
+	"reflect"
+)
+
+var (
+	ErrStreamMethodReturnedNil = errors.New("rpc: stream method returned nil") // 流式方法返回值为nil
+)
+
+func init() {
+	variant.ErrorRegistry().Declare(gap.ErrCode_RPC_StreamMethodReturnedNil, ErrStreamMethodReturnedNil)
+}
+
+var (
+	errorRT = reflect.TypeFor[error]()
+	boolRT  = reflect.TypeFor[bool]()
+)
+
+// IsSource 方法返回值是否为流式答复，支持返回<-chan T、chan T、iter.Seq[T]、iter.Seq2[T, error]，可以在最后附加一个error返回值
+func IsSource(retsRV []reflect.Value) bool {
+	switch len(retsRV) {
+	case 1:
+	case 2:
+		if retsRV[1].Type() != errorRT {
+			return false
+		}
+	default:
+		return false
+	}
+
+	rt := retsRV[0].Type()
+
+	switch rt.Kind() {
+	case reflect.Chan:
+		return rt.ChanDir()&reflect.RecvDir != 0
+	case reflect.Func:
+		return isSeq(rt)
+	default:
+		return false
+	}
+}
+
+// MakeSource 使用方法返回值创建流式答复的数据源，附加的error返回值不为nil时返回该错误
+func MakeSource(retsRV []reflect.Value) (*Source, error) {
+	if !IsSource(retsRV) {
+		return nil, fmt.Errorf("%w: not a stream method", core.ErrArgs)
+	}
+
+	if len(retsRV) == 2 && !retsRV[1].IsNil() {
+		return nil, retsRV[1].Interface().(error)
+	}
+
+	sourceRV := retsRV[0]
+	if sourceRV.IsNil() {
+		return nil, ErrStreamMethodReturnedNil
+	}
+
+	if sourceRV.Kind() == reflect.Chan {
+		return &Source{foreach: chanForeach(sourceRV)}, nil
+	}
+	return &Source{foreach: seqForeach(sourceRV)}, nil
+}
+
+// Source 流式答复的数据源，被调用方法返回channel或迭代器时创建。
+// channel的元素类型为async.Ret时，错误的结果将结束流式答复；
+// 迭代器在发送流式答复的协程中迭代，不在实体线程中，需要自行保证线程安全。
+type Source struct {
+	foreach func(ctx context.Context, yield func(item any) error) error
+}
+
+// Send 发送流式答复的数据项，未确认的数据项达到流控窗口大小时，等待调用方确认，数据源遍历结束、出错或上下文取消时返回
+func (s *Source) Send(ctx context.Context, flow *Flow, corrId int64, send func(msg gap.Msg) error) (err error) {
+	defer func() {
+		if panicErr := types.Panic2Err(recover()); panicErr != nil {
+			err = fmt.Errorf("%w: %w", core.ErrPanicked, panicErr)
+		}
+	}()
+
+	var seq int64
+
+	return s.foreach(ctx, func(item any) error {
+		seq++
+
+		if err := flow.wait(ctx, seq); err != nil {
+			return err
+		}
+
+		rets, err := variant.MakeSerializedArray([]any{item})
+		if err != nil {
+			return err
+		}
+		defer rets.Release()
+
+		return send(&gap.MsgRPCStreamItem{
+			CorrId: corrId,
+			Seq:    seq,
+			Rets:   rets,
+		})
+	})
+}
+
+func isSeq(rt reflect.Type) bool {
+	if rt.NumIn() != 1 || rt.NumOut() != 0 {
+		return false
+	}
+
+	yieldRT := rt.In(0)
+	if yieldRT.Kind() != reflect.Func || yieldRT.NumOut() != 1 || yieldRT.Out(0) != boolRT {
+		return false
+	}
+
+	switch yieldRT.NumIn() {
+	case 1:
+		return true
+	case 2:
+		return yieldRT.In(1) == errorRT
+	default:
+		return false
+	}
+}
+
+func chanForeach(chRV reflect.Value) func(ctx context.Context, yield func(item any) error) error {
+	return func(ctx context.Context, yield func(item any) error) error {
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			{Dir: reflect.SelectRecv, Chan: chRV},
+		}
+
+		for {
+			chosen, recv, ok := reflect.Select(cases)
+			if chosen == 0 {
+				return ctx.Err()
+			}
+			if !ok {
+				return nil
+			}
+
+			item := recv.Interface()
+
+			if ret, ok := item.(async.Ret); ok {
+				if !ret.OK() {
+					return ret.Error
+				}
+				item = ret.Value
+			}
+
+			if err := yield(item); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func seqForeach(seqRV reflect.Value) func(ctx context.Context, yield func(item any) error) error {
+	return func(ctx context.Context, yield func(item any) error) error {
+		var err error
+
+		yieldRT := seqRV.Type().In(0)
+		pair := yieldRT.NumIn() == 2
+
+		yieldRV := reflect.MakeFunc(yieldRT, func(args []reflect.Value) []reflect.Value {
+			if err == nil {
+				if pair && !args[1].IsNil() {
+					err = args[1].Interface().(error)
+				} else if err = ctx.Err(); err == nil {
+					err = yield(args[0].Interface())
+				}
+			}
+			return []reflect.Value{reflect.ValueOf(err == nil)}
+		})
+
+		seqRV.Call([]reflect.Value{yieldRV})
+
+		return err
+	}
+}
@@ -0,0 +1,48 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcstream
+
+import (
+	"context"
+	"git.golaxy.org/core/utils/async"
+)
+
+// MakeStream 创建流式答复的接收句柄，cancel为放弃接收的函数
+func MakeStream(ret async.AsyncRet, cancel context.CancelFunc) Stream {
+	return Stream{ret: ret, cancel: cancel}
+}
+
+// Stream 流式答复的接收句柄，异步调用结果依次产出数据项，结束时关闭，提前停止接收时需要调用Close，通知被调用方停止发送
+type Stream struct {
+	ret    async.AsyncRet
+	cancel context.CancelFunc
+}
+
+// ToAsyncRet 转换为异步调用结果
+func (s Stream) ToAsyncRet() async.AsyncRet {
+	return s.ret
+}
+
+// Close 放弃接收，停止产出数据项，可以重复调用
+func (s Stream) Close() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
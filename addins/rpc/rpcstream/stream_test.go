@@ -0,0 +1,46 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcstream
+
+import (
+	"context"
+	"git.golaxy.org/core/utils/async"
+	"testing"
+)
+
+func TestStreamClose(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ret := async.MakeAsyncRet()
+
+	s := MakeStream(ret, cancel)
+	if s.ToAsyncRet() != ret {
+		t.Fatal("got different async ret")
+	}
+
+	// 关闭时取消调用上下文，可以重复调用
+	s.Close()
+	s.Close()
+	if ctx.Err() == nil {
+		t.Fatal("context not canceled after close")
+	}
+
+	// 零值句柄关闭时不panic
+	Stream{}.Close()
+}
@@ -20,10 +20,14 @@
 package rpcutil
 
 import (
+	"git.golaxy.org/core/runtime"
 	"git.golaxy.org/framework/addins/gate"
 	"git.golaxy.org/framework/addins/rpc/callpath"
 	"git.golaxy.org/framework/addins/rpcstack"
+	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/utils/tracing"
 	"strings"
+	"time"
 )
 
 const (
@@ -38,3 +42,66 @@ func CliRPCPermValidator(cc rpcstack.CallChain, cp callpath.CallPath) bool {
 	}
 	return strings.HasPrefix(cp.Method, "C_")
 }
+
+// _Deadline RPC截止时间设置
+type _Deadline struct {
+	timeout  time.Duration
+	deadline time.Time
+}
+
+// get 获取截止时间，同时设置超时时间与截止时间时，使用较早的截止时间，都未设置时返回零值
+func (d _Deadline) get() time.Time {
+	deadline := d.deadline
+
+	if d.timeout > 0 {
+		if timeoutDeadline := time.Now().Add(d.timeout); deadline.IsZero() || timeoutDeadline.Before(deadline) {
+			deadline = timeoutDeadline
+		}
+	}
+
+	return deadline
+}
+
+// fixed 使用当前时间计算截止时间并固定，超时时间不再生效，用于重试时各次调用共用同一个截止时间
+func (d _Deadline) fixed() _Deadline {
+	return _Deadline{deadline: d.get()}
+}
+
+// _CallStack 发起RPC时的调用堆栈信息
+type _CallStack struct {
+	cc       rpcstack.CallChain  // 调用链
+	baggage  variant.Map         // 需要跨服务传播的栈变量
+	deadline time.Time           // 嵌套调用时，调用方的截止时间
+	trace    tracing.SpanContext // 嵌套调用时，调用方的链路追踪上下文
+}
+
+// inheritDeadline 嵌套调用继承调用方的截止时间，使用较早的截止时间，都未设置时返回零值
+func (s _CallStack) inheritDeadline(deadline time.Time) time.Time {
+	if !s.deadline.IsZero() && (deadline.IsZero() || s.deadline.Before(deadline)) {
+		return s.deadline
+	}
+	return deadline
+}
+
+// callStack 获取调用链、需要跨服务传播的栈变量、调用方的截止时间与链路追踪上下文，rtCtx为nil时返回空调用链
+func callStack(rtCtx runtime.Context) (_CallStack, error) {
+	if rtCtx == nil {
+		return _CallStack{cc: rpcstack.EmptyCallChain}, nil
+	}
+
+	rpcStack := rpcstack.Using(rtCtx)
+
+	baggage, err := rpcStack.Baggage()
+	if err != nil {
+		return _CallStack{}, err
+	}
+
+	stack := _CallStack{
+		cc:      rpcStack.CallChain(),
+		baggage: baggage,
+	}
+	stack.deadline, _ = rpcStack.Context().Deadline()
+	stack.trace, _ = rpcstack.TraceFromContext(rpcStack.Context())
+
+	return stack, nil
+}
@@ -20,6 +20,7 @@
 package rpcutil
 
 import (
+	"context"
 	"git.golaxy.org/core"
 	"git.golaxy.org/core/runtime"
 	"git.golaxy.org/core/service"
@@ -32,9 +33,9 @@ import (
 	"git.golaxy.org/framework/addins/rpc"
 	"git.golaxy.org/framework/addins/rpc/callpath"
 	"git.golaxy.org/framework/addins/rpc/rpcpcsr"
-	"git.golaxy.org/framework/addins/rpcstack"
 	"math/rand"
 	"slices"
+	"time"
 )
 
 // ProxyEntity 代理实体
@@ -59,9 +60,13 @@ func ConcurrentProxyEntity(svcCtx service.Context, id uid.Id) EntityProxied {
 
 // EntityProxied 实体代理，用于向实体发送RPC
 type EntityProxied struct {
-	svcCtx service.Context
-	rtCtx  runtime.Context
-	id     uid.Id
+	svcCtx   service.Context
+	rtCtx    runtime.Context
+	id       uid.Id
+	deadline _Deadline
+	retry    *RetryPolicy
+	window   int
+	stream   context.Context
 }
 
 // GetId 获取实体id
@@ -69,6 +74,32 @@ func (p EntityProxied) GetId() uid.Id {
 	return p.id
 }
 
+// WithTimeout 设置RPC超时时间，覆盖默认的Future超时时间，在调用时开始计时，嵌套调用时调用方已设置截止时间时，使用较早的截止时间
+func (p EntityProxied) WithTimeout(timeout time.Duration) EntityProxied {
+	p.deadline.timeout = timeout
+	return p
+}
+
+// WithDeadline 设置RPC截止时间，覆盖默认的Future超时时间，嵌套调用时调用方已设置截止时间时，使用较早的截止时间
+func (p EntityProxied) WithDeadline(deadline time.Time) EntityProxied {
+	p.deadline.deadline = deadline
+	return p
+}
+
+// WithRetry 设置重试策略，只对注册为幂等方法的请求RPC生效，负载均衡模式重试时选择未失败过的服务节点
+func (p EntityProxied) WithRetry(policy RetryPolicy) EntityProxied {
+	p.retry = &policy
+	return p
+}
+
+// WithStream 设置流控窗口，发起流式RPC，异步调用结果依次产出数据项，结束时关闭，ctx取消时放弃接收并通知被调用方停止发送，
+// 提前停止接收时需要取消ctx，超时时间或截止时间限制整个流式答复，未设置时只限制等待下一个数据项的空闲时间，流式RPC不重试
+func (p EntityProxied) WithStream(ctx context.Context, window int) EntityProxied {
+	p.window = max(window, 1)
+	p.stream = ctx
+	return p
+}
+
 // RPC 向分布式实体目标服务发送RPC
 func (p EntityProxied) RPC(service, comp, method string, args ...any) async.AsyncRet {
 	if p.svcCtx == nil {
@@ -89,10 +120,10 @@ func (p EntityProxied) RPC(service, comp, method string, args ...any) async.Asyn
 		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, rpcpcsr.ErrDistEntityNodeNotFound))
 	}
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, err))
 	}
 
 	// 调用路径
@@ -103,11 +134,24 @@ func (p EntityProxied) RPC(service, comp, method string, args ...any) async.Asyn
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).RPC(distEntity.Nodes[nodeIdx].RemoteAddr, cc, cp, args...)
+	// 目标地址
+	dst := distEntity.Nodes[nodeIdx].RemoteAddr
+
+	// 调用开始时固定截止时间，重试的各次调用共用同一个截止时间
+	p.deadline = p.deadline.fixed()
+
+	return retryRPC(p.svcCtx, retryPolicy(p.retry, p.window), stack.inheritDeadline(p.deadline.get()), cp, func([]uid.Id) (async.AsyncRet, uid.Id) {
+		return p.invoke(dst, stack, cp, args), uid.Nil
+	})
 }
 
 // BalanceRPC 使用负载均衡模式，向分布式实体目标服务发送RPC
 func (p EntityProxied) BalanceRPC(service, comp, method string, args ...any) async.AsyncRet {
+	return p.HashBalanceRPC(service, "", comp, method, args...)
+}
+
+// HashBalanceRPC 使用一致性哈希负载均衡模式，向分布式实体目标服务发送RPC，RPC投递器使用一致性哈希负载均衡器时，相同key的请求投递至相同的服务节点
+func (p EntityProxied) HashBalanceRPC(service, key, comp, method string, args ...any) async.AsyncRet {
 	if p.svcCtx == nil {
 		exception.Panic("rpc: svcCtx is nil")
 	}
@@ -118,35 +162,20 @@ func (p EntityProxied) BalanceRPC(service, comp, method string, args ...any) asy
 		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, rpcpcsr.ErrDistEntityNotFound))
 	}
 
-	// 统计节点数量
-	var count int
-	for i := range distEntity.Nodes {
-		if distEntity.Nodes[i].Service == service {
-			count++
-		}
-	}
-	if count <= 0 {
+	// 检查分布式实体目标服务节点
+	if !slices.ContainsFunc(distEntity.Nodes, func(node dentq.Node) bool {
+		return node.Service == service
+	}) {
 		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, rpcpcsr.ErrDistEntityNodeNotFound))
 	}
 
-	// 随机目标节点
-	var dst string
-	offset := rand.Intn(count)
-
-	for i := range distEntity.Nodes {
-		if distEntity.Nodes[i].Service == service {
-			if offset <= 0 {
-				dst = distEntity.Nodes[i].RemoteAddr
-				break
-			}
-			offset--
-		}
-	}
+	// 目标地址，由RPC投递器在分布式实体所在的服务节点中选择
+	dst := dsvc.Using(p.svcCtx).GetNodeDetails().MakeHashBalanceAddr(service, key)
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, err))
 	}
 
 	// 调用路径
@@ -157,7 +186,16 @@ func (p EntityProxied) BalanceRPC(service, comp, method string, args ...any) asy
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).RPC(dst, cc, cp, args...)
+	// 调用开始时固定截止时间，重试的各次调用共用同一个截止时间
+	p.deadline = p.deadline.fixed()
+
+	return retryRPC(p.svcCtx, retryPolicy(p.retry, p.window), stack.inheritDeadline(p.deadline.get()), cp, func(excluded []uid.Id) (async.AsyncRet, uid.Id) {
+		// 重试时从分布式实体所在的节点中选择未失败过的节点
+		if node, ok := retryEntityNode(distEntity, excluded, func(node dentq.Node) bool { return node.Service == service }); ok {
+			return p.invoke(node.RemoteAddr, stack, cp, args), node.Id
+		}
+		return p.invoke(dst, stack, cp, args), uid.Nil
+	})
 }
 
 // GlobalBalanceRPC 使用全局负载均衡模式，向分布式实体任意服务发送RPC
@@ -172,15 +210,17 @@ func (p EntityProxied) GlobalBalanceRPC(excludeSelf bool, comp, method string, a
 		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, rpcpcsr.ErrDistEntityNotFound))
 	}
 
+	localAddr := dsvc.Using(p.svcCtx).GetNodeDetails().LocalAddr
+
 	// 随机目标节点
 	var dst string
+	var nodeId uid.Id
 
 	if excludeSelf {
 		if len(distEntity.Nodes) <= 1 {
 			return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, rpcpcsr.ErrDistEntityNodeNotFound))
 		}
 
-		localAddr := dsvc.Using(p.svcCtx).GetNodeDetails().LocalAddr
 		idx := rand.Intn(len(distEntity.Nodes))
 
 		if distEntity.Nodes[idx].RemoteAddr == localAddr {
@@ -188,18 +228,21 @@ func (p EntityProxied) GlobalBalanceRPC(excludeSelf bool, comp, method string, a
 		}
 
 		dst = distEntity.Nodes[idx].RemoteAddr
+		nodeId = distEntity.Nodes[idx].Id
 
 	} else {
 		if len(distEntity.Nodes) <= 0 {
 			return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, rpcpcsr.ErrDistEntityNodeNotFound))
 		}
-		dst = distEntity.Nodes[rand.Intn(len(distEntity.Nodes))].RemoteAddr
+		idx := rand.Intn(len(distEntity.Nodes))
+		dst = distEntity.Nodes[idx].RemoteAddr
+		nodeId = distEntity.Nodes[idx].Id
 	}
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, err))
 	}
 
 	// 调用路径
@@ -210,7 +253,16 @@ func (p EntityProxied) GlobalBalanceRPC(excludeSelf bool, comp, method string, a
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).RPC(dst, cc, cp, args...)
+	// 调用开始时固定截止时间，重试的各次调用共用同一个截止时间
+	p.deadline = p.deadline.fixed()
+
+	return retryRPC(p.svcCtx, retryPolicy(p.retry, p.window), stack.inheritDeadline(p.deadline.get()), cp, func(excluded []uid.Id) (async.AsyncRet, uid.Id) {
+		// 重试时从分布式实体所在的节点中选择未失败过的节点
+		if node, ok := retryEntityNode(distEntity, excluded, func(node dentq.Node) bool { return !excludeSelf || node.RemoteAddr != localAddr }); ok {
+			return p.invoke(node.RemoteAddr, stack, cp, args), node.Id
+		}
+		return p.invoke(dst, stack, cp, args), nodeId
+	})
 }
 
 // OnewayRPC 向分布式实体目标服务发送单向RPC
@@ -233,10 +285,10 @@ func (p EntityProxied) OnewayRPC(service, comp, method string, args ...any) erro
 		return rpcpcsr.ErrDistEntityNodeNotFound
 	}
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return err
 	}
 
 	// 调用路径
@@ -247,11 +299,25 @@ func (p EntityProxied) OnewayRPC(service, comp, method string, args ...any) erro
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).OnewayRPC(distEntity.Nodes[nodeIdx].RemoteAddr, cc, cp, args...)
+	ret := <-rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       distEntity.Nodes[nodeIdx].RemoteAddr,
+		Oneway:    true,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
+	return ret.Error
 }
 
 // BalanceOnewayRPC 使用负载均衡模式，向分布式实体目标服务发送单向RPC
 func (p EntityProxied) BalanceOnewayRPC(service, comp, method string, args ...any) error {
+	return p.HashBalanceOnewayRPC(service, "", comp, method, args...)
+}
+
+// HashBalanceOnewayRPC 使用一致性哈希负载均衡模式，向分布式实体目标服务发送单向RPC，RPC投递器使用一致性哈希负载均衡器时，相同key的请求投递至相同的服务节点
+func (p EntityProxied) HashBalanceOnewayRPC(service, key, comp, method string, args ...any) error {
 	if p.svcCtx == nil {
 		exception.Panic("rpc: svcCtx is nil")
 	}
@@ -262,35 +328,20 @@ func (p EntityProxied) BalanceOnewayRPC(service, comp, method string, args ...an
 		return rpcpcsr.ErrDistEntityNotFound
 	}
 
-	// 统计节点数量
-	var count int
-	for i := range distEntity.Nodes {
-		if distEntity.Nodes[i].Service == service {
-			count++
-		}
-	}
-	if count <= 0 {
+	// 检查分布式实体目标服务节点
+	if !slices.ContainsFunc(distEntity.Nodes, func(node dentq.Node) bool {
+		return node.Service == service
+	}) {
 		return rpcpcsr.ErrDistEntityNodeNotFound
 	}
 
-	// 随机目标节点
-	var dst string
-	offset := rand.Intn(count)
-
-	for i := range distEntity.Nodes {
-		if distEntity.Nodes[i].Service == service {
-			if offset <= 0 {
-				dst = distEntity.Nodes[i].RemoteAddr
-				break
-			}
-			offset--
-		}
-	}
+	// 目标地址，由RPC投递器在分布式实体所在的服务节点中选择
+	dst := dsvc.Using(p.svcCtx).GetNodeDetails().MakeHashBalanceAddr(service, key)
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return err
 	}
 
 	// 调用路径
@@ -301,7 +352,16 @@ func (p EntityProxied) BalanceOnewayRPC(service, comp, method string, args ...an
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).OnewayRPC(dst, cc, cp, args...)
+	ret := <-rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       dst,
+		Oneway:    true,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
+	return ret.Error
 }
 
 // GlobalBalanceOnewayRPC 使用全局负载均衡模式，向分布式实体任意服务发送单向RPC
@@ -340,10 +400,10 @@ func (p EntityProxied) GlobalBalanceOnewayRPC(excludeSelf bool, comp, method str
 		dst = distEntity.Nodes[rand.Intn(len(distEntity.Nodes))].RemoteAddr
 	}
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return err
 	}
 
 	// 调用路径
@@ -354,7 +414,16 @@ func (p EntityProxied) GlobalBalanceOnewayRPC(excludeSelf bool, comp, method str
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).OnewayRPC(dst, cc, cp, args...)
+	ret := <-rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       dst,
+		Oneway:    true,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
+	return ret.Error
 }
 
 // BroadcastOnewayRPC 使用广播模式，向分布式实体目标服务发送单向RPC
@@ -377,10 +446,10 @@ func (p EntityProxied) BroadcastOnewayRPC(excludeSelf bool, service, comp, metho
 		return rpcpcsr.ErrDistEntityNodeNotFound
 	}
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return err
 	}
 
 	// 调用路径
@@ -392,7 +461,16 @@ func (p EntityProxied) BroadcastOnewayRPC(excludeSelf bool, service, comp, metho
 		Method:     method,
 	}
 
-	return rpc.Using(p.svcCtx).OnewayRPC(distEntity.Nodes[nodeIdx].BroadcastAddr, cc, cp, args...)
+	ret := <-rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       distEntity.Nodes[nodeIdx].BroadcastAddr,
+		Oneway:    true,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
+	return ret.Error
 }
 
 // GlobalBroadcastOnewayRPC 使用全局广播模式，向分布式实体所有服务发送单向RPC
@@ -404,10 +482,10 @@ func (p EntityProxied) GlobalBroadcastOnewayRPC(excludeSelf bool, comp, method s
 	// 全局广播地址
 	dst := dsvc.Using(p.svcCtx).GetNodeDetails().GlobalBroadcastAddr
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return err
 	}
 
 	// 调用路径
@@ -419,7 +497,16 @@ func (p EntityProxied) GlobalBroadcastOnewayRPC(excludeSelf bool, comp, method s
 		Method:     method,
 	}
 
-	return rpc.Using(p.svcCtx).OnewayRPC(dst, cc, cp, args...)
+	ret := <-rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       dst,
+		Oneway:    true,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
+	return ret.Error
 }
 
 // CliRPC 向客户端发送RPC
@@ -431,10 +518,10 @@ func (p EntityProxied) CliRPC(proc, method string, args ...any) async.AsyncRet {
 	// 客户端地址
 	dst := gate.CliDetails.DomainUnicast.Join(p.id.String())
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, err))
 	}
 
 	// 调用路径
@@ -444,7 +531,17 @@ func (p EntityProxied) CliRPC(proc, method string, args ...any) async.AsyncRet {
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).RPC(dst, cc, cp, args...)
+	return rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       dst,
+		Deadline:  stack.inheritDeadline(p.deadline.get()),
+		Window:    p.window,
+		Context:   p.stream,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
 }
 
 // CliOnewayRPC 向客户端发送单向RPC
@@ -456,10 +553,10 @@ func (p EntityProxied) CliOnewayRPC(proc, method string, args ...any) error {
 	// 客户端地址
 	dst := gate.CliDetails.DomainUnicast.Join(p.id.String())
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return err
 	}
 
 	// 调用路径
@@ -469,7 +566,16 @@ func (p EntityProxied) CliOnewayRPC(proc, method string, args ...any) error {
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).OnewayRPC(dst, cc, cp, args...)
+	ret := <-rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       dst,
+		Oneway:    true,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
+	return ret.Error
 }
 
 // BroadcastCliOnewayRPC 向包含实体的所有分组发送单向RPC
@@ -481,10 +587,10 @@ func (p EntityProxied) BroadcastCliOnewayRPC(proc, method string, args ...any) e
 	// 客户端地址
 	dst := gate.CliDetails.DomainBroadcast.Join(p.id.String())
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return err
 	}
 
 	// 调用路径
@@ -494,5 +600,28 @@ func (p EntityProxied) BroadcastCliOnewayRPC(proc, method string, args ...any) e
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).OnewayRPC(dst, cc, cp, args...)
+	ret := <-rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       dst,
+		Oneway:    true,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
+	return ret.Error
+}
+
+func (p EntityProxied) invoke(dst string, stack _CallStack, cp callpath.CallPath, args []any) async.AsyncRet {
+	return rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       dst,
+		Deadline:  stack.inheritDeadline(p.deadline.get()),
+		Window:    p.window,
+		Context:   p.stream,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
 }
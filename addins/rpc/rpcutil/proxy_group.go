@@ -27,7 +27,6 @@ import (
 	"git.golaxy.org/framework/addins/gate"
 	"git.golaxy.org/framework/addins/rpc"
 	"git.golaxy.org/framework/addins/rpc/callpath"
-	"git.golaxy.org/framework/addins/rpcstack"
 )
 
 // ProxyGroup 代理分组
@@ -74,10 +73,10 @@ func (p GroupProxied) CliOnewayRPC(proc, method string, args ...any) error {
 		exception.Panic("rpc: svcCtx is nil")
 	}
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return err
 	}
 
 	// 调用路径
@@ -87,5 +86,14 @@ func (p GroupProxied) CliOnewayRPC(proc, method string, args ...any) error {
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).OnewayRPC(p.addr, cc, cp, args...)
+	ret := <-rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       p.addr,
+		Oneway:    true,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
+	return ret.Error
 }
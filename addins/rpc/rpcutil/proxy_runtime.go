@@ -20,6 +20,7 @@
 package rpcutil
 
 import (
+	"context"
 	"git.golaxy.org/core"
 	"git.golaxy.org/core/runtime"
 	"git.golaxy.org/core/service"
@@ -31,9 +32,9 @@ import (
 	"git.golaxy.org/framework/addins/rpc"
 	"git.golaxy.org/framework/addins/rpc/callpath"
 	"git.golaxy.org/framework/addins/rpc/rpcpcsr"
-	"git.golaxy.org/framework/addins/rpcstack"
 	"math/rand"
 	"slices"
+	"time"
 )
 
 // ProxyRuntime 代理运行时
@@ -61,6 +62,10 @@ type RuntimeProxied struct {
 	svcCtx   service.Context
 	rtCtx    runtime.Context
 	entityId uid.Id
+	deadline _Deadline
+	retry    *RetryPolicy
+	window   int
+	stream   context.Context
 }
 
 // GetEntityId 获取实体id
@@ -68,6 +73,32 @@ func (p RuntimeProxied) GetEntityId() uid.Id {
 	return p.entityId
 }
 
+// WithTimeout 设置RPC超时时间，覆盖默认的Future超时时间，在调用时开始计时，嵌套调用时调用方已设置截止时间时，使用较早的截止时间
+func (p RuntimeProxied) WithTimeout(timeout time.Duration) RuntimeProxied {
+	p.deadline.timeout = timeout
+	return p
+}
+
+// WithDeadline 设置RPC截止时间，覆盖默认的Future超时时间，嵌套调用时调用方已设置截止时间时，使用较早的截止时间
+func (p RuntimeProxied) WithDeadline(deadline time.Time) RuntimeProxied {
+	p.deadline.deadline = deadline
+	return p
+}
+
+// WithRetry 设置重试策略，只对注册为幂等方法的请求RPC生效，负载均衡模式重试时选择未失败过的服务节点
+func (p RuntimeProxied) WithRetry(policy RetryPolicy) RuntimeProxied {
+	p.retry = &policy
+	return p
+}
+
+// WithStream 设置流控窗口，发起流式RPC，异步调用结果依次产出数据项，结束时关闭，ctx取消时放弃接收并通知被调用方停止发送，
+// 提前停止接收时需要取消ctx，超时时间或截止时间限制整个流式答复，未设置时只限制等待下一个数据项的空闲时间，流式RPC不重试
+func (p RuntimeProxied) WithStream(ctx context.Context, window int) RuntimeProxied {
+	p.window = max(window, 1)
+	p.stream = ctx
+	return p
+}
+
 // RPC 向分布式实体目标服务的运行时发送RPC
 func (p RuntimeProxied) RPC(service, addIn, method string, args ...any) async.AsyncRet {
 	if p.svcCtx == nil {
@@ -88,10 +119,10 @@ func (p RuntimeProxied) RPC(service, addIn, method string, args ...any) async.As
 		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, rpcpcsr.ErrDistEntityNodeNotFound))
 	}
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, err))
 	}
 
 	// 调用路径
@@ -102,11 +133,24 @@ func (p RuntimeProxied) RPC(service, addIn, method string, args ...any) async.As
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).RPC(distEntity.Nodes[nodeIdx].RemoteAddr, cc, cp, args...)
+	// 目标地址
+	dst := distEntity.Nodes[nodeIdx].RemoteAddr
+
+	// 调用开始时固定截止时间，重试的各次调用共用同一个截止时间
+	p.deadline = p.deadline.fixed()
+
+	return retryRPC(p.svcCtx, retryPolicy(p.retry, p.window), stack.inheritDeadline(p.deadline.get()), cp, func([]uid.Id) (async.AsyncRet, uid.Id) {
+		return p.invoke(dst, stack, cp, args), uid.Nil
+	})
 }
 
 // BalanceRPC 使用负载均衡模式，向分布式实体目标服务的运行时发送RPC
 func (p RuntimeProxied) BalanceRPC(service, addIn, method string, args ...any) async.AsyncRet {
+	return p.HashBalanceRPC(service, "", addIn, method, args...)
+}
+
+// HashBalanceRPC 使用一致性哈希负载均衡模式，向分布式实体目标服务的运行时发送RPC，RPC投递器使用一致性哈希负载均衡器时，相同key的请求投递至相同的服务节点
+func (p RuntimeProxied) HashBalanceRPC(service, key, addIn, method string, args ...any) async.AsyncRet {
 	if p.svcCtx == nil {
 		exception.Panic("rpc: svcCtx is nil")
 	}
@@ -117,35 +161,20 @@ func (p RuntimeProxied) BalanceRPC(service, addIn, method string, args ...any) a
 		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, rpcpcsr.ErrDistEntityNotFound))
 	}
 
-	// 统计节点数量
-	var count int
-	for i := range distEntity.Nodes {
-		if distEntity.Nodes[i].Service == service {
-			count++
-		}
-	}
-	if count <= 0 {
+	// 检查分布式实体目标服务节点
+	if !slices.ContainsFunc(distEntity.Nodes, func(node dentq.Node) bool {
+		return node.Service == service
+	}) {
 		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, rpcpcsr.ErrDistEntityNodeNotFound))
 	}
 
-	// 随机目标节点
-	var dst string
-	offset := rand.Intn(count)
-
-	for i := range distEntity.Nodes {
-		if distEntity.Nodes[i].Service == service {
-			if offset <= 0 {
-				dst = distEntity.Nodes[i].RemoteAddr
-				break
-			}
-			offset--
-		}
-	}
+	// 目标地址，由RPC投递器在分布式实体所在的服务节点中选择
+	dst := dsvc.Using(p.svcCtx).GetNodeDetails().MakeHashBalanceAddr(service, key)
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, err))
 	}
 
 	// 调用路径
@@ -156,7 +185,16 @@ func (p RuntimeProxied) BalanceRPC(service, addIn, method string, args ...any) a
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).RPC(dst, cc, cp, args...)
+	// 调用开始时固定截止时间，重试的各次调用共用同一个截止时间
+	p.deadline = p.deadline.fixed()
+
+	return retryRPC(p.svcCtx, retryPolicy(p.retry, p.window), stack.inheritDeadline(p.deadline.get()), cp, func(excluded []uid.Id) (async.AsyncRet, uid.Id) {
+		// 重试时从分布式实体所在的节点中选择未失败过的节点
+		if node, ok := retryEntityNode(distEntity, excluded, func(node dentq.Node) bool { return node.Service == service }); ok {
+			return p.invoke(node.RemoteAddr, stack, cp, args), node.Id
+		}
+		return p.invoke(dst, stack, cp, args), uid.Nil
+	})
 }
 
 // GlobalBalanceRPC 使用全局负载均衡模式，向分布式实体任意服务的运行时发送RPC
@@ -171,15 +209,17 @@ func (p RuntimeProxied) GlobalBalanceRPC(excludeSelf bool, addIn, method string,
 		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, rpcpcsr.ErrDistEntityNotFound))
 	}
 
+	localAddr := dsvc.Using(p.svcCtx).GetNodeDetails().LocalAddr
+
 	// 随机目标节点
 	var dst string
+	var nodeId uid.Id
 
 	if excludeSelf {
 		if len(distEntity.Nodes) <= 1 {
 			return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, rpcpcsr.ErrDistEntityNodeNotFound))
 		}
 
-		localAddr := dsvc.Using(p.svcCtx).GetNodeDetails().LocalAddr
 		idx := rand.Intn(len(distEntity.Nodes))
 
 		if distEntity.Nodes[idx].RemoteAddr == localAddr {
@@ -187,18 +227,21 @@ func (p RuntimeProxied) GlobalBalanceRPC(excludeSelf bool, addIn, method string,
 		}
 
 		dst = distEntity.Nodes[idx].RemoteAddr
+		nodeId = distEntity.Nodes[idx].Id
 
 	} else {
 		if len(distEntity.Nodes) <= 0 {
 			return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, rpcpcsr.ErrDistEntityNodeNotFound))
 		}
-		dst = distEntity.Nodes[rand.Intn(len(distEntity.Nodes))].RemoteAddr
+		idx := rand.Intn(len(distEntity.Nodes))
+		dst = distEntity.Nodes[idx].RemoteAddr
+		nodeId = distEntity.Nodes[idx].Id
 	}
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, err))
 	}
 
 	// 调用路径
@@ -209,7 +252,16 @@ func (p RuntimeProxied) GlobalBalanceRPC(excludeSelf bool, addIn, method string,
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).RPC(dst, cc, cp, args...)
+	// 调用开始时固定截止时间，重试的各次调用共用同一个截止时间
+	p.deadline = p.deadline.fixed()
+
+	return retryRPC(p.svcCtx, retryPolicy(p.retry, p.window), stack.inheritDeadline(p.deadline.get()), cp, func(excluded []uid.Id) (async.AsyncRet, uid.Id) {
+		// 重试时从分布式实体所在的节点中选择未失败过的节点
+		if node, ok := retryEntityNode(distEntity, excluded, func(node dentq.Node) bool { return !excludeSelf || node.RemoteAddr != localAddr }); ok {
+			return p.invoke(node.RemoteAddr, stack, cp, args), node.Id
+		}
+		return p.invoke(dst, stack, cp, args), nodeId
+	})
 }
 
 // OnewayRPC 向分布式实体目标服务的运行时发送单向RPC
@@ -232,10 +284,10 @@ func (p RuntimeProxied) OnewayRPC(service, addIn, method string, args ...any) er
 		return rpcpcsr.ErrDistEntityNodeNotFound
 	}
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return err
 	}
 
 	// 调用路径
@@ -246,11 +298,25 @@ func (p RuntimeProxied) OnewayRPC(service, addIn, method string, args ...any) er
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).OnewayRPC(distEntity.Nodes[nodeIdx].RemoteAddr, cc, cp, args...)
+	ret := <-rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       distEntity.Nodes[nodeIdx].RemoteAddr,
+		Oneway:    true,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
+	return ret.Error
 }
 
 // BalanceOnewayRPC 使用负载均衡模式，向分布式实体目标服务的运行时发送单向RPC
 func (p RuntimeProxied) BalanceOnewayRPC(service, addIn, method string, args ...any) error {
+	return p.HashBalanceOnewayRPC(service, "", addIn, method, args...)
+}
+
+// HashBalanceOnewayRPC 使用一致性哈希负载均衡模式，向分布式实体目标服务的运行时发送单向RPC，RPC投递器使用一致性哈希负载均衡器时，相同key的请求投递至相同的服务节点
+func (p RuntimeProxied) HashBalanceOnewayRPC(service, key, addIn, method string, args ...any) error {
 	if p.svcCtx == nil {
 		exception.Panic("rpc: svcCtx is nil")
 	}
@@ -261,35 +327,20 @@ func (p RuntimeProxied) BalanceOnewayRPC(service, addIn, method string, args ...
 		return rpcpcsr.ErrDistEntityNotFound
 	}
 
-	// 统计节点数量
-	var count int
-	for i := range distEntity.Nodes {
-		if distEntity.Nodes[i].Service == service {
-			count++
-		}
-	}
-	if count <= 0 {
+	// 检查分布式实体目标服务节点
+	if !slices.ContainsFunc(distEntity.Nodes, func(node dentq.Node) bool {
+		return node.Service == service
+	}) {
 		return rpcpcsr.ErrDistEntityNodeNotFound
 	}
 
-	// 随机目标节点
-	var dst string
-	offset := rand.Intn(count)
-
-	for i := range distEntity.Nodes {
-		if distEntity.Nodes[i].Service == service {
-			if offset <= 0 {
-				dst = distEntity.Nodes[i].RemoteAddr
-				break
-			}
-			offset--
-		}
-	}
+	// 目标地址，由RPC投递器在分布式实体所在的服务节点中选择
+	dst := dsvc.Using(p.svcCtx).GetNodeDetails().MakeHashBalanceAddr(service, key)
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return err
 	}
 
 	// 调用路径
@@ -300,7 +351,16 @@ func (p RuntimeProxied) BalanceOnewayRPC(service, addIn, method string, args ...
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).OnewayRPC(dst, cc, cp, args...)
+	ret := <-rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       dst,
+		Oneway:    true,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
+	return ret.Error
 }
 
 // GlobalBalanceOnewayRPC 使用全局负载均衡模式，向分布式实体任意服务的运行时发送单向RPC
@@ -339,10 +399,10 @@ func (p RuntimeProxied) GlobalBalanceOnewayRPC(excludeSelf bool, addIn, method s
 		dst = distEntity.Nodes[rand.Intn(len(distEntity.Nodes))].RemoteAddr
 	}
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return err
 	}
 
 	// 调用路径
@@ -353,7 +413,16 @@ func (p RuntimeProxied) GlobalBalanceOnewayRPC(excludeSelf bool, addIn, method s
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).OnewayRPC(dst, cc, cp, args...)
+	ret := <-rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       dst,
+		Oneway:    true,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
+	return ret.Error
 }
 
 // BroadcastOnewayRPC 使用广播模式，向分布式实体目标服务的运行时发送单向RPC
@@ -376,10 +445,10 @@ func (p RuntimeProxied) BroadcastOnewayRPC(excludeSelf bool, service, addIn, met
 		return rpcpcsr.ErrDistEntityNodeNotFound
 	}
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return err
 	}
 
 	// 调用路径
@@ -391,7 +460,16 @@ func (p RuntimeProxied) BroadcastOnewayRPC(excludeSelf bool, service, addIn, met
 		Method:     method,
 	}
 
-	return rpc.Using(p.svcCtx).OnewayRPC(distEntity.Nodes[nodeIdx].BroadcastAddr, cc, cp, args...)
+	ret := <-rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       distEntity.Nodes[nodeIdx].BroadcastAddr,
+		Oneway:    true,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
+	return ret.Error
 }
 
 // GlobalBroadcastOnewayRPC 使用全局广播模式，向分布式实体所有服务的运行时发送单向RPC
@@ -403,10 +481,10 @@ func (p RuntimeProxied) GlobalBroadcastOnewayRPC(excludeSelf bool, addIn, method
 	// 全局广播地址
 	dst := dsvc.Using(p.svcCtx).GetNodeDetails().GlobalBroadcastAddr
 
-	// 调用链
-	cc := rpcstack.EmptyCallChain
-	if p.rtCtx != nil {
-		cc = rpcstack.Using(p.rtCtx).CallChain()
+	// 调用链与跨服务传播的栈变量，嵌套调用时继承调用方的截止时间与链路追踪上下文
+	stack, err := callStack(p.rtCtx)
+	if err != nil {
+		return err
 	}
 
 	// 调用路径
@@ -418,5 +496,28 @@ func (p RuntimeProxied) GlobalBroadcastOnewayRPC(excludeSelf bool, addIn, method
 		Method:     method,
 	}
 
-	return rpc.Using(p.svcCtx).OnewayRPC(dst, cc, cp, args...)
+	ret := <-rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       dst,
+		Oneway:    true,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
+	return ret.Error
+}
+
+func (p RuntimeProxied) invoke(dst string, stack _CallStack, cp callpath.CallPath, args []any) async.AsyncRet {
+	return rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       dst,
+		Deadline:  stack.inheritDeadline(p.deadline.get()),
+		Window:    p.window,
+		Context:   p.stream,
+		Trace:     stack.trace,
+		CallChain: stack.cc,
+		Baggage:   stack.baggage,
+		CallPath:  cp,
+		Args:      args,
+	})
 }
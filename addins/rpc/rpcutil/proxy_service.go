@@ -20,6 +20,7 @@
 package rpcutil
 
 import (
+	"context"
 	"git.golaxy.org/core"
 	"git.golaxy.org/core/service"
 	"git.golaxy.org/core/utils/async"
@@ -29,6 +30,7 @@ import (
 	"git.golaxy.org/framework/addins/rpc"
 	"git.golaxy.org/framework/addins/rpc/callpath"
 	"git.golaxy.org/framework/addins/rpcstack"
+	"time"
 )
 
 // ProxyService 代理服务
@@ -50,8 +52,12 @@ func ProxyService(svcCtx service.Context, service ...string) ServiceProxied {
 
 // ServiceProxied 实体服务，用于向服务发送RPC
 type ServiceProxied struct {
-	svcCtx  service.Context
-	service string
+	svcCtx   service.Context
+	service  string
+	deadline _Deadline
+	retry    *RetryPolicy
+	window   int
+	stream   context.Context
 }
 
 // GetService 获取服务名
@@ -59,6 +65,32 @@ func (p ServiceProxied) GetService() string {
 	return p.service
 }
 
+// WithTimeout 设置RPC超时时间，覆盖默认的Future超时时间，在调用时开始计时
+func (p ServiceProxied) WithTimeout(timeout time.Duration) ServiceProxied {
+	p.deadline.timeout = timeout
+	return p
+}
+
+// WithDeadline 设置RPC截止时间，覆盖默认的Future超时时间
+func (p ServiceProxied) WithDeadline(deadline time.Time) ServiceProxied {
+	p.deadline.deadline = deadline
+	return p
+}
+
+// WithRetry 设置重试策略，只对注册为幂等方法的请求RPC生效，负载均衡模式重试时选择未失败过的服务节点
+func (p ServiceProxied) WithRetry(policy RetryPolicy) ServiceProxied {
+	p.retry = &policy
+	return p
+}
+
+// WithStream 设置流控窗口，发起流式RPC，异步调用结果依次产出数据项，结束时关闭，ctx取消时放弃接收并通知被调用方停止发送，
+// 提前停止接收时需要取消ctx，超时时间或截止时间限制整个流式答复，未设置时只限制等待下一个数据项的空闲时间，流式RPC不重试
+func (p ServiceProxied) WithStream(ctx context.Context, window int) ServiceProxied {
+	p.window = max(window, 1)
+	p.stream = ctx
+	return p
+}
+
 // RPC 向分布式服务指定节点发送RPC
 func (p ServiceProxied) RPC(nodeId uid.Id, addIn, method string, args ...any) async.AsyncRet {
 	if p.svcCtx == nil {
@@ -78,22 +110,34 @@ func (p ServiceProxied) RPC(nodeId uid.Id, addIn, method string, args ...any) as
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).RPC(dst, rpcstack.EmptyCallChain, cp, args...)
+	// 调用开始时固定截止时间，重试的各次调用共用同一个截止时间
+	p.deadline = p.deadline.fixed()
+
+	return retryRPC(p.svcCtx, retryPolicy(p.retry, p.window), p.deadline.get(), cp, func([]uid.Id) (async.AsyncRet, uid.Id) {
+		return p.request(dst, cp, args), uid.Nil
+	})
 }
 
 // BalanceRPC 使用负载均衡模式，向分布式服务发送RPC
 func (p ServiceProxied) BalanceRPC(addIn, method string, args ...any) async.AsyncRet {
+	return p.HashBalanceRPC("", addIn, method, args...)
+}
+
+// HashBalanceRPC 使用一致性哈希负载均衡模式，向分布式服务发送RPC，RPC投递器使用一致性哈希负载均衡器时，相同key的请求投递至相同的服务节点
+func (p ServiceProxied) HashBalanceRPC(key, addIn, method string, args ...any) async.AsyncRet {
 	if p.svcCtx == nil {
 		exception.Panic("rpc: svcCtx is nil")
 	}
 
+	details := dsvc.Using(p.svcCtx).GetNodeDetails()
+
 	// 目标地址
 	var dst string
 
 	if p.service != "" {
-		dst = dsvc.Using(p.svcCtx).GetNodeDetails().MakeBalanceAddr(p.service)
+		dst = details.MakeHashBalanceAddr(p.service, key)
 	} else {
-		dst = dsvc.Using(p.svcCtx).GetNodeDetails().GlobalBalanceAddr
+		dst = details.GlobalBalanceAddr
 	}
 
 	// 调用路径
@@ -103,7 +147,20 @@ func (p ServiceProxied) BalanceRPC(addIn, method string, args ...any) async.Asyn
 		Method:   method,
 	}
 
-	return rpc.Using(p.svcCtx).RPC(dst, rpcstack.EmptyCallChain, cp, args...)
+	// 调用开始时固定截止时间，重试的各次调用共用同一个截止时间
+	p.deadline = p.deadline.fixed()
+
+	return retryRPC(p.svcCtx, retryPolicy(p.retry, p.window), p.deadline.get(), cp, func(excluded []uid.Id) (async.AsyncRet, uid.Id) {
+		// 重试时从服务发现中选择未失败过的节点
+		if p.service != "" {
+			if nodeId, ok := retryServiceNode(p.svcCtx, p.service, excluded); ok {
+				if nodeAddr, err := details.MakeNodeAddr(nodeId); err == nil {
+					return p.request(nodeAddr, cp, args), nodeId
+				}
+			}
+		}
+		return p.request(dst, cp, args), uid.Nil
+	})
 }
 
 // OnewayRPC 向分布式服务指定节点发送单向RPC
@@ -130,6 +187,11 @@ func (p ServiceProxied) OnewayRPC(nodeId uid.Id, addIn, method string, args ...a
 
 // BalanceOnewayRPC 使用负载均衡模式，向分布式服务发送单向RPC
 func (p ServiceProxied) BalanceOnewayRPC(addIn, method string, args ...any) error {
+	return p.HashBalanceOnewayRPC("", addIn, method, args...)
+}
+
+// HashBalanceOnewayRPC 使用一致性哈希负载均衡模式，向分布式服务发送单向RPC，RPC投递器使用一致性哈希负载均衡器时，相同key的请求投递至相同的服务节点
+func (p ServiceProxied) HashBalanceOnewayRPC(key, addIn, method string, args ...any) error {
 	if p.svcCtx == nil {
 		exception.Panic("rpc: svcCtx is nil")
 	}
@@ -138,7 +200,7 @@ func (p ServiceProxied) BalanceOnewayRPC(addIn, method string, args ...any) erro
 	var dst string
 
 	if p.service != "" {
-		dst = dsvc.Using(p.svcCtx).GetNodeDetails().MakeBalanceAddr(p.service)
+		dst = dsvc.Using(p.svcCtx).GetNodeDetails().MakeHashBalanceAddr(p.service, key)
 	} else {
 		dst = dsvc.Using(p.svcCtx).GetNodeDetails().GlobalBalanceAddr
 	}
@@ -178,3 +240,16 @@ func (p ServiceProxied) BroadcastOnewayRPC(excludeSelf bool, addIn, method strin
 
 	return rpc.Using(p.svcCtx).OnewayRPC(dst, rpcstack.EmptyCallChain, cp, args...)
 }
+
+// request 发送请求RPC，设置了流控窗口时发送流式RPC
+func (p ServiceProxied) request(dst string, cp callpath.CallPath, args []any) async.AsyncRet {
+	return rpc.Using(p.svcCtx).Invoke(&rpc.ClientCall{
+		Dst:       dst,
+		Deadline:  p.deadline.get(),
+		Window:    p.window,
+		Context:   p.stream,
+		CallChain: rpcstack.EmptyCallChain,
+		CallPath:  cp,
+		Args:      args,
+	})
+}
@@ -0,0 +1,232 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcutil
+
+import (
+	"errors"
+	"git.golaxy.org/core/service"
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/dentq"
+	"git.golaxy.org/framework/addins/discovery"
+	"git.golaxy.org/framework/addins/rpc/callpath"
+	"git.golaxy.org/framework/addins/rpc/rpcpcsr"
+	"git.golaxy.org/framework/utils/concurrent"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryPolicy RPC重试策略，只重试幂等方法的请求RPC，单向RPC不重试
+type RetryPolicy struct {
+	MaxAttempts int                  // 最大尝试次数，包含首次调用，小于等于1时不重试
+	Backoff     time.Duration        // 首次重试前的等待时间
+	MaxBackoff  time.Duration        // 重试前的最大等待时间，小于等于0时不限制
+	Multiplier  float64              // 每次重试后等待时间的增长倍数，小于1时按1计算
+	Retryable   func(err error) bool // 判断错误是否可以重试，为nil时使用IsRetryable
+}
+
+// DefaultRetryPolicy 默认的重试策略
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     50 * time.Millisecond,
+	MaxBackoff:  time.Second,
+	Multiplier:  2,
+}
+
+// IsRetryable 默认的可重试错误判断，无法投递、找不到服务节点、熔断与请求超时时可以重试
+func IsRetryable(err error) bool {
+	return errors.Is(err, rpcpcsr.ErrUndeliverable) ||
+		errors.Is(err, rpcpcsr.ErrDistEntityNodeNotFound) ||
+		errors.Is(err, rpcpcsr.ErrCircuitOpen) ||
+		errors.Is(err, concurrent.ErrFutureTimeout)
+}
+
+func (policy *RetryPolicy) retryable(err error) bool {
+	if policy.Retryable != nil {
+		return policy.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+func (policy *RetryPolicy) nextBackoff(backoff time.Duration) time.Duration {
+	if policy.Multiplier > 1 {
+		backoff = time.Duration(float64(backoff) * policy.Multiplier)
+	}
+	if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+		backoff = policy.MaxBackoff
+	}
+	return backoff
+}
+
+type _IdempotentMethod struct {
+	script string
+	method string
+}
+
+var (
+	idempotentMutex    sync.RWMutex
+	idempotentMethods  = map[_IdempotentMethod]struct{}{}
+	idempotentPrefixes []string
+)
+
+// RegisterIdempotent 注册幂等方法，script为服务插件名、运行时插件名或实体组件名，设置重试策略后，幂等方法的RPC失败时可以重试
+func RegisterIdempotent(script string, methods ...string) {
+	idempotentMutex.Lock()
+	defer idempotentMutex.Unlock()
+
+	for _, method := range methods {
+		idempotentMethods[_IdempotentMethod{script: script, method: method}] = struct{}{}
+	}
+}
+
+// RegisterIdempotentPrefix 注册幂等方法的命名约定，方法名（去除客户端方法前缀C_后）以此为前缀时视为幂等方法，默认不包含任何前缀
+func RegisterIdempotentPrefix(prefixes ...string) {
+	idempotentMutex.Lock()
+	defer idempotentMutex.Unlock()
+
+	for _, prefix := range prefixes {
+		if prefix != "" && !slices.Contains(idempotentPrefixes, prefix) {
+			idempotentPrefixes = append(idempotentPrefixes, prefix)
+		}
+	}
+}
+
+// IsIdempotent 是否为幂等方法
+func IsIdempotent(script, method string) bool {
+	idempotentMutex.RLock()
+	defer idempotentMutex.RUnlock()
+
+	if _, ok := idempotentMethods[_IdempotentMethod{script: script, method: method}]; ok {
+		return true
+	}
+
+	method = strings.TrimPrefix(method, "C_")
+
+	return slices.ContainsFunc(idempotentPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(method, prefix)
+	})
+}
+
+// retryPolicy 流式RPC不重试
+func retryPolicy(policy *RetryPolicy, window int) *RetryPolicy {
+	if window > 0 {
+		return nil
+	}
+	return policy
+}
+
+// retryRPC 按重试策略发起RPC，未设置重试策略或方法不是幂等方法时只调用一次，
+// invoke每次调用时传入已失败的节点，返回异步调用结果与直接选择的节点id，由RPC投递器选择节点时返回零值
+func retryRPC(svcCtx service.Context, policy *RetryPolicy, deadline time.Time, cp callpath.CallPath, invoke func(excluded []uid.Id) (async.AsyncRet, uid.Id)) async.AsyncRet {
+	if policy == nil || policy.MaxAttempts <= 1 || !IsIdempotent(cp.Script, cp.Method) {
+		asyncRet, _ := invoke(nil)
+		return asyncRet
+	}
+
+	asyncRet := async.MakeAsyncRet()
+
+	go func() {
+		var excluded []uid.Id
+		backoff := policy.Backoff
+
+		for attempt := 1; ; attempt++ {
+			attemptRet, nodeId := invoke(excluded)
+			ret := <-attemptRet
+
+			if ret.OK() || attempt >= policy.MaxAttempts || !policy.retryable(ret.Error) {
+				async.Return(asyncRet, ret)
+				return
+			}
+
+			// 记录失败的节点，重试时排除
+			var nodeErr *rpcpcsr.NodeError
+			if errors.As(ret.Error, &nodeErr) {
+				nodeId = nodeErr.NodeId
+			}
+			if nodeId != uid.Nil && !slices.Contains(excluded, nodeId) {
+				excluded = append(excluded, nodeId)
+			}
+
+			// 等待后重试，等待结束时会超过截止时间，不再重试
+			if !deadline.IsZero() && !time.Now().Add(backoff).Before(deadline) {
+				async.Return(asyncRet, ret)
+				return
+			}
+
+			if backoff > 0 {
+				timer := time.NewTimer(backoff)
+				select {
+				case <-timer.C:
+				case <-svcCtx.Done():
+					timer.Stop()
+					async.Return(asyncRet, ret)
+					return
+				}
+			}
+
+			backoff = policy.nextBackoff(backoff)
+		}
+	}()
+
+	return asyncRet
+}
+
+// retryServiceNode 重试时从服务发现中选择未失败过的服务节点，没有可选节点时返回false
+func retryServiceNode(svcCtx service.Context, service string, excluded []uid.Id) (uid.Id, bool) {
+	if len(excluded) <= 0 {
+		return uid.Nil, false
+	}
+
+	svc, err := discovery.Using(svcCtx).GetService(svcCtx, service)
+	if err != nil {
+		return uid.Nil, false
+	}
+
+	nodes := slices.DeleteFunc(slices.Clone(svc.Nodes), func(node discovery.Node) bool {
+		return slices.Contains(excluded, node.Id)
+	})
+	if len(nodes) <= 0 {
+		return uid.Nil, false
+	}
+
+	return nodes[rand.IntN(len(nodes))].Id, true
+}
+
+// retryEntityNode 重试时从分布式实体所在的节点中选择满足条件且未失败过的节点，没有可选节点时返回false
+func retryEntityNode(distEntity *dentq.DistEntity, excluded []uid.Id, filter func(node dentq.Node) bool) (dentq.Node, bool) {
+	if len(excluded) <= 0 {
+		return dentq.Node{}, false
+	}
+
+	var nodes []dentq.Node
+	for _, node := range distEntity.Nodes {
+		if filter(node) && !slices.Contains(excluded, node.Id) {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) <= 0 {
+		return dentq.Node{}, false
+	}
+
+	return nodes[rand.IntN(len(nodes))], true
+}
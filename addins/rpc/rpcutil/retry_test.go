@@ -0,0 +1,222 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcutil
+
+import (
+	"errors"
+	"git.golaxy.org/core/service"
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/rpc/callpath"
+	"git.golaxy.org/framework/addins/rpc/rpcpcsr"
+	"slices"
+	"testing"
+	"time"
+)
+
+type _FakeServiceContext struct {
+	service.Context
+}
+
+func (_FakeServiceContext) Done() <-chan struct{} { return nil }
+
+func failedRet(err error) async.AsyncRet {
+	return async.Return(async.MakeAsyncRet(), async.MakeRet(nil, err))
+}
+
+func TestDeadlineFixed(t *testing.T) {
+	d := _Deadline{timeout: time.Minute}
+
+	// 固定后截止时间不再随当前时间变化
+	fixed := d.fixed()
+	first := fixed.get()
+	time.Sleep(10 * time.Millisecond)
+	if !fixed.get().Equal(first) {
+		t.Fatalf("fixed deadline changed, %v != %v", fixed.get(), first)
+	}
+	if !d.get().After(first) {
+		t.Fatal("deadline with timeout not recomputed")
+	}
+
+	// 同时设置超时时间与截止时间时，使用较早的截止时间
+	early := time.Now().Add(time.Second)
+	d = _Deadline{timeout: time.Minute, deadline: early}
+	if got := d.fixed().get(); !got.Equal(early) {
+		t.Fatalf("got deadline %v, want %v", got, early)
+	}
+
+	if got := (_Deadline{}).fixed().get(); !got.IsZero() {
+		t.Fatalf("got deadline %v, want zero", got)
+	}
+}
+
+func TestInheritDeadline(t *testing.T) {
+	now := time.Now()
+	stack := _CallStack{deadline: now.Add(time.Second)}
+
+	if got := stack.inheritDeadline(now.Add(time.Minute)); !got.Equal(now.Add(time.Second)) {
+		t.Fatalf("got %v, want caller deadline", got)
+	}
+	if got := stack.inheritDeadline(now.Add(time.Millisecond)); !got.Equal(now.Add(time.Millisecond)) {
+		t.Fatalf("got %v, want fixed deadline", got)
+	}
+	if got := stack.inheritDeadline(time.Time{}); !got.Equal(now.Add(time.Second)) {
+		t.Fatalf("got %v, want caller deadline", got)
+	}
+	if got := (_CallStack{}).inheritDeadline(time.Time{}); !got.IsZero() {
+		t.Fatalf("got %v, want zero", got)
+	}
+}
+
+func TestRetryRPC(t *testing.T) {
+	RegisterIdempotent("", "GetUser")
+
+	policy := &RetryPolicy{MaxAttempts: 3}
+	nodes := []uid.Id{uid.From("n1"), uid.From("n2"), uid.From("n3")}
+
+	// 幂等方法失败时重试，并排除已失败的节点
+	var excludedSeen [][]uid.Id
+	ret := <-retryRPC(_FakeServiceContext{}, policy, time.Time{}, callpath.CallPath{Method: "GetUser"}, func(excluded []uid.Id) (async.AsyncRet, uid.Id) {
+		excludedSeen = append(excludedSeen, slices.Clone(excluded))
+		nodeId := nodes[len(excludedSeen)-1]
+		return failedRet(&rpcpcsr.NodeError{NodeId: nodeId, Err: rpcpcsr.ErrUndeliverable}), uid.Nil
+	})
+	if len(excludedSeen) != 3 {
+		t.Fatalf("got %d attempts, want 3", len(excludedSeen))
+	}
+	if !slices.Equal(excludedSeen[2], nodes[:2]) {
+		t.Fatalf("got excluded nodes %v, want %v", excludedSeen[2], nodes[:2])
+	}
+	var nodeErr *rpcpcsr.NodeError
+	if !errors.As(ret.Error, &nodeErr) || nodeErr.NodeId != nodes[2] {
+		t.Fatalf("got error %v, want error of the last attempt", ret.Error)
+	}
+
+	// 重试成功
+	attempts := 0
+	ret = <-retryRPC(_FakeServiceContext{}, policy, time.Time{}, callpath.CallPath{Method: "GetUser"}, func([]uid.Id) (async.AsyncRet, uid.Id) {
+		attempts++
+		if attempts < 2 {
+			return failedRet(rpcpcsr.ErrUndeliverable), uid.Nil
+		}
+		return async.Return(async.MakeAsyncRet(), async.MakeRet(1, nil)), uid.Nil
+	})
+	if !ret.OK() || ret.Value != 1 || attempts != 2 {
+		t.Fatalf("got ret %+v after %d attempts, want 1 after 2 attempts", ret, attempts)
+	}
+
+	// 非幂等方法与不可重试的错误只调用一次
+	for _, c := range []struct {
+		method string
+		err    error
+	}{
+		{"UpdateUser", rpcpcsr.ErrUndeliverable},
+		{"GetUser", errors.New("not retryable")},
+	} {
+		attempts = 0
+		<-retryRPC(_FakeServiceContext{}, policy, time.Time{}, callpath.CallPath{Method: c.method}, func([]uid.Id) (async.AsyncRet, uid.Id) {
+			attempts++
+			return failedRet(c.err), uid.Nil
+		})
+		if attempts != 1 {
+			t.Fatalf("method %q error %v got %d attempts, want 1", c.method, c.err, attempts)
+		}
+	}
+}
+
+func TestRetryRPCDeadline(t *testing.T) {
+	RegisterIdempotent("", "GetUser")
+
+	policy := &RetryPolicy{MaxAttempts: 100, Backoff: 20 * time.Millisecond, Multiplier: 1}
+
+	// 所有重试共用调用开始时固定的截止时间，超过截止时间后不再重试
+	start := time.Now()
+	deadline := start.Add(100 * time.Millisecond)
+
+	attempts := 0
+	ret := <-retryRPC(_FakeServiceContext{}, policy, deadline, callpath.CallPath{Method: "GetUser"}, func([]uid.Id) (async.AsyncRet, uid.Id) {
+		attempts++
+		return failedRet(rpcpcsr.ErrUndeliverable), uid.Nil
+	})
+	if !errors.Is(ret.Error, rpcpcsr.ErrUndeliverable) {
+		t.Fatalf("got error %v, want %v", ret.Error, rpcpcsr.ErrUndeliverable)
+	}
+	if attempts < 2 || attempts > 5 {
+		t.Fatalf("got %d attempts, want attempts bounded by deadline", attempts)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond+50*time.Millisecond {
+		t.Fatalf("retry took %v, exceeds deadline", elapsed)
+	}
+
+	// 等待后会超过截止时间时，不再重试
+	attempts = 0
+	<-retryRPC(_FakeServiceContext{}, policy, time.Now().Add(10*time.Millisecond), callpath.CallPath{Method: "GetUser"}, func([]uid.Id) (async.AsyncRet, uid.Id) {
+		attempts++
+		return failedRet(rpcpcsr.ErrUndeliverable), uid.Nil
+	})
+	if attempts != 1 {
+		t.Fatalf("got %d attempts, want 1", attempts)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{Multiplier: 2, MaxBackoff: 300 * time.Millisecond}
+
+	backoff := 100 * time.Millisecond
+	for _, want := range []time.Duration{200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond} {
+		backoff = policy.nextBackoff(backoff)
+		if backoff != want {
+			t.Fatalf("got backoff %v, want %v", backoff, want)
+		}
+	}
+
+	// 增长倍数小于1时按1计算
+	policy = &RetryPolicy{Multiplier: 0.5}
+	if got := policy.nextBackoff(time.Second); got != time.Second {
+		t.Fatalf("got backoff %v, want %v", got, time.Second)
+	}
+}
+
+func TestIsIdempotent(t *testing.T) {
+	// 默认不视为幂等方法，注册后才可以重试
+	if IsIdempotent("user", "GetUser") {
+		t.Fatal("method is idempotent by default, want registered only")
+	}
+
+	RegisterIdempotent("user", "Touch")
+	RegisterIdempotentPrefix("Get", "List", "Peek")
+
+	cases := []struct {
+		script, method string
+		want           bool
+	}{
+		{"user", "GetUser", true},
+		{"user", "C_ListUsers", true},
+		{"user", "PeekUser", true},
+		{"user", "Touch", true},
+		{"other", "Touch", false},
+		{"user", "UpdateUser", false},
+	}
+	for _, c := range cases {
+		if got := IsIdempotent(c.script, c.method); got != c.want {
+			t.Errorf("IsIdempotent(%q, %q) = %v, want %v", c.script, c.method, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcstack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"git.golaxy.org/framework/net/gap/variant"
+	"strings"
+)
+
+var (
+	ErrBaggageTooLarge = errors.New("rpcstack: baggage too large") // 跨服务传播的栈变量超过限制
+)
+
+type _BaggageKey struct{}
+
+// ContextWithBaggage 在调用上下文中携带调用方传播的栈变量，被调用方压入调用链时还原为栈变量
+func ContextWithBaggage(ctx context.Context, baggage variant.Map) context.Context {
+	if len(baggage) <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, _BaggageKey{}, baggage)
+}
+
+// BaggageFromContext 获取调用上下文中携带的调用方传播的栈变量
+func BaggageFromContext(ctx context.Context) (variant.Map, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	baggage, ok := ctx.Value(_BaggageKey{}).(variant.Map)
+	return baggage, ok
+}
+
+// baggageAllowed 栈变量是否允许跨服务传播
+func (r *_RPCStack) baggageAllowed(key string) bool {
+	for _, allowed := range r.options.BaggageKeys {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// makeBaggage 按白名单选择需要跨服务传播的栈变量并序列化，超过数量或大小限制时返回错误
+func (r *_RPCStack) makeBaggage() (variant.Map, error) {
+	if len(r.options.BaggageKeys) <= 0 || len(r.variables) <= 0 {
+		return nil, nil
+	}
+
+	var baggage variant.Map
+	var size int
+
+	for _, kv := range r.variables {
+		if !r.baggageAllowed(kv.K) {
+			continue
+		}
+
+		varK, err := variant.CastReadonlyVariant(kv.K)
+		if err != nil {
+			return nil, err
+		}
+
+		varV, err := variant.CastReadonlyVariant(kv.V)
+		if err != nil {
+			return nil, err
+		}
+
+		size += varK.Size() + varV.Size()
+
+		if len(baggage) >= r.options.BaggageMaxEntries || size > r.options.BaggageMaxSize {
+			return nil, ErrBaggageTooLarge
+		}
+
+		baggage.ToUnorderedSliceMap().Add(varK, varV)
+	}
+
+	return baggage, nil
+}
+
+// restoreBaggage 按白名单与限制，将调用方传播的栈变量还原，超过限制的栈变量被丢弃
+func (r *_RPCStack) restoreBaggage(baggage variant.Map) {
+	var size int
+
+	for i := range baggage {
+		kv := &baggage[i]
+
+		if len(r.variables) >= r.options.BaggageMaxEntries {
+			return
+		}
+
+		size += kv.K.Size() + kv.V.Size()
+		if size > r.options.BaggageMaxSize {
+			return
+		}
+
+		key, ok := baggageIndirect(kv.K).(string)
+		if !ok || !r.baggageAllowed(key) {
+			continue
+		}
+
+		value := baggageIndirect(kv.V)
+		if value == nil {
+			continue
+		}
+
+		// 消息解码时引用了消息缓存，需要拷贝
+		switch v := value.(type) {
+		case string:
+			value = strings.Clone(v)
+		case []byte:
+			value = bytes.Clone(v)
+		}
+
+		r.variables.Add(strings.Clone(key), value)
+	}
+}
+
+// baggageIndirect 获取栈变量的原始值，本地投递时未经序列化，栈变量为只读值
+func baggageIndirect(v variant.Variant) any {
+	switch {
+	case v.Value != nil:
+		return v.Value.Indirect()
+	case v.ReadonlyValue != nil:
+		return v.ReadonlyValue.Indirect()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,169 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcstack
+
+import (
+	"context"
+	"errors"
+	"git.golaxy.org/framework/net/gap/variant"
+	"io"
+	"strings"
+	"testing"
+)
+
+func variablesToMap(variables Variables) map[string]any {
+	m := map[string]any{}
+	for _, kv := range variables {
+		m[kv.K] = kv.V
+	}
+	return m
+}
+
+func TestBaggageAllowed(t *testing.T) {
+	r := newRPCStack(With.BaggageKeys("tenant", "req.*")).(*_RPCStack)
+
+	cases := map[string]bool{
+		"tenant":   true,
+		"tenant.x": false,
+		"req.id":   true,
+		"req.":     true,
+		"req":      false,
+		"secret":   false,
+	}
+	for key, want := range cases {
+		if got := r.baggageAllowed(key); got != want {
+			t.Errorf("baggageAllowed(%q) = %v, want %v", key, got, want)
+		}
+	}
+
+	// 白名单为空时不传播
+	r = newRPCStack().(*_RPCStack)
+	if r.baggageAllowed("tenant") {
+		t.Fatalf("empty allowlist allowed key")
+	}
+}
+
+func TestBaggagePropagate(t *testing.T) {
+	caller := newRPCStack(With.BaggageKeys("tenant", "req.*")).(*_RPCStack)
+	caller.Variables().Add("tenant", "t1")
+	caller.Variables().Add("req.id", int64(42))
+	caller.Variables().Add("secret", "s")
+
+	baggage, err := caller.Baggage()
+	if err != nil {
+		t.Fatalf("Baggage: %v", err)
+	}
+	if len(baggage) != 2 {
+		t.Fatalf("baggage entries = %d, want 2", len(baggage))
+	}
+
+	// 被调用方压入调用链时还原栈变量
+	callee := newRPCStack(With.BaggageKeys("tenant", "req.*")).(*_RPCStack)
+	callee.pushCallChain(ContextWithBaggage(context.Background(), baggage), nil)
+
+	vars := variablesToMap(callee.variables)
+	if len(vars) != 2 || vars["tenant"] != "t1" || vars["req.id"] != int64(42) {
+		t.Fatalf("restored variables = %v", vars)
+	}
+
+	// 弹出调用链后清空栈变量
+	callee.popCallChain()
+	if len(callee.variables) != 0 {
+		t.Fatalf("variables not cleared after pop: %v", callee.variables)
+	}
+
+	// 经过序列化传输后还原栈变量
+	buf := make([]byte, baggage.Size())
+	if n, err := baggage.Read(buf); !errors.Is(err, io.EOF) || n != len(buf) {
+		t.Fatalf("Read baggage: %v", err)
+	}
+	var decoded variant.Map
+	if _, err := decoded.Write(buf); err != nil {
+		t.Fatalf("Write baggage: %v", err)
+	}
+
+	callee.pushCallChain(ContextWithBaggage(context.Background(), decoded), nil)
+
+	vars = variablesToMap(callee.variables)
+	if len(vars) != 2 || vars["tenant"] != "t1" || vars["req.id"] != int64(42) {
+		t.Fatalf("restored decoded variables = %v", vars)
+	}
+	callee.popCallChain()
+
+	// 被调用方白名单更严格时，丢弃不允许的栈变量
+	strict := newRPCStack(With.BaggageKeys("tenant")).(*_RPCStack)
+	strict.pushCallChain(ContextWithBaggage(context.Background(), baggage), nil)
+
+	vars = variablesToMap(strict.variables)
+	if len(vars) != 1 || vars["tenant"] != "t1" {
+		t.Fatalf("restored variables = %v", vars)
+	}
+}
+
+func TestBaggageTooLarge(t *testing.T) {
+	r := newRPCStack(With.BaggageKeys("*"), With.BaggageMaxEntries(1)).(*_RPCStack)
+	r.Variables().Add("a", "1")
+	r.Variables().Add("b", "2")
+
+	if _, err := r.Baggage(); !errors.Is(err, ErrBaggageTooLarge) {
+		t.Fatalf("Baggage with too many entries: %v, want %v", err, ErrBaggageTooLarge)
+	}
+
+	r = newRPCStack(With.BaggageKeys("*"), With.BaggageMaxSize(16)).(*_RPCStack)
+	r.Variables().Add("a", strings.Repeat("x", 32))
+
+	if _, err := r.Baggage(); !errors.Is(err, ErrBaggageTooLarge) {
+		t.Fatalf("Baggage with too large size: %v, want %v", err, ErrBaggageTooLarge)
+	}
+}
+
+func TestBaggageRestoreLimits(t *testing.T) {
+	caller := newRPCStack(With.BaggageKeys("*")).(*_RPCStack)
+	caller.Variables().Add("a", "1")
+	caller.Variables().Add("b", "2")
+	caller.Variables().Add("c", "3")
+
+	baggage, err := caller.Baggage()
+	if err != nil {
+		t.Fatalf("Baggage: %v", err)
+	}
+
+	// 被调用方限制更严格时，超过限制的栈变量被丢弃
+	callee := newRPCStack(With.BaggageKeys("*"), With.BaggageMaxEntries(2)).(*_RPCStack)
+	callee.pushCallChain(ContextWithBaggage(context.Background(), baggage), nil)
+
+	if len(callee.variables) != 2 {
+		t.Fatalf("restored variables = %v, want 2 entries", callee.variables)
+	}
+}
+
+func TestBaggageFromContext(t *testing.T) {
+	if _, ok := BaggageFromContext(nil); ok {
+		t.Fatalf("BaggageFromContext(nil) ok")
+	}
+
+	ctx := context.Background()
+	if ContextWithBaggage(ctx, nil) != ctx {
+		t.Fatalf("ContextWithBaggage with empty baggage changed context")
+	}
+	if _, ok := BaggageFromContext(ctx); ok {
+		t.Fatalf("BaggageFromContext without baggage ok")
+	}
+}
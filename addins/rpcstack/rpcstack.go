@@ -20,9 +20,12 @@
 package rpcstack
 
 import (
+	"context"
 	"git.golaxy.org/core/runtime"
 	"git.golaxy.org/core/service"
+	"git.golaxy.org/core/utils/option"
 	"git.golaxy.org/framework/addins/log"
+	"git.golaxy.org/framework/net/gap/variant"
 )
 
 // IRPCStack RPC调用堆栈支持
@@ -30,25 +33,33 @@ type IRPCStack interface {
 	iRPCStack
 	// CallChain 调用链
 	CallChain() CallChain
+	// Context 调用上下文，调用方取消调用或超过截止时间时，上下文被取消，可用于中止长时间运行的方法
+	Context() context.Context
 	// Variables 栈变量
 	Variables() *Variables
+	// Baggage 按白名单选择需要跨服务传播的栈变量并序列化，发起RPC时携带，被调用方执行方法前还原为栈变量
+	Baggage() (variant.Map, error)
 }
 
 type iRPCStack interface {
-	pushCallChain(cc CallChain)
+	pushCallChain(ctx context.Context, cc CallChain)
 	popCallChain()
 }
 
-func newRPCStack(...any) IRPCStack {
+func newRPCStack(settings ...option.Setting[RPCStackOptions]) IRPCStack {
 	return &_RPCStack{
+		options:   option.Make(With.Default(), settings...),
 		callChain: EmptyCallChain,
+		ctx:       context.Background(),
 		variables: nil,
 	}
 }
 
 type _RPCStack struct {
+	options   RPCStackOptions
 	rtCtx     runtime.Context
 	callChain CallChain
+	ctx       context.Context
 	variables Variables
 }
 
@@ -66,20 +77,39 @@ func (r *_RPCStack) CallChain() CallChain {
 	return r.callChain
 }
 
+// Context 调用上下文，调用方取消调用或超过截止时间时，上下文被取消，可用于中止长时间运行的方法
+func (r *_RPCStack) Context() context.Context {
+	return r.ctx
+}
+
 // Variables 栈变量
 func (r *_RPCStack) Variables() *Variables {
 	return &r.variables
 }
 
-func (r *_RPCStack) pushCallChain(cc CallChain) {
+// Baggage 按白名单选择需要跨服务传播的栈变量并序列化，发起RPC时携带，被调用方执行方法前还原为栈变量
+func (r *_RPCStack) Baggage() (variant.Map, error) {
+	return r.makeBaggage()
+}
+
+func (r *_RPCStack) pushCallChain(ctx context.Context, cc CallChain) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	if cc == nil {
 		cc = EmptyCallChain
 	}
 	r.callChain = cc
+	r.ctx = ctx
 	r.variables = nil
+
+	if baggage, ok := BaggageFromContext(ctx); ok {
+		r.restoreBaggage(baggage)
+	}
 }
 
 func (r *_RPCStack) popCallChain() {
 	r.callChain = EmptyCallChain
+	r.ctx = context.Background()
 	r.variables = nil
 }
@@ -0,0 +1,65 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcstack
+
+import (
+	"git.golaxy.org/core/utils/option"
+)
+
+// RPCStackOptions 所有选项
+type RPCStackOptions struct {
+	BaggageKeys       []string // 允许跨服务传播的栈变量名白名单，以*结尾表示前缀匹配，为空时不传播
+	BaggageMaxEntries int      // 跨服务传播的栈变量最大数量
+	BaggageMaxSize    int      // 跨服务传播的栈变量序列化后的最大字节数
+}
+
+var With _Option
+
+type _Option struct{}
+
+// Default 默认值
+func (_Option) Default() option.Setting[RPCStackOptions] {
+	return func(options *RPCStackOptions) {
+		With.BaggageKeys().Apply(options)
+		With.BaggageMaxEntries(16).Apply(options)
+		With.BaggageMaxSize(1024).Apply(options)
+	}
+}
+
+// BaggageKeys 允许跨服务传播的栈变量名白名单，以*结尾表示前缀匹配，为空时不传播
+func (_Option) BaggageKeys(keys ...string) option.Setting[RPCStackOptions] {
+	return func(options *RPCStackOptions) {
+		options.BaggageKeys = keys
+	}
+}
+
+// BaggageMaxEntries 跨服务传播的栈变量最大数量
+func (_Option) BaggageMaxEntries(n int) option.Setting[RPCStackOptions] {
+	return func(options *RPCStackOptions) {
+		options.BaggageMaxEntries = n
+	}
+}
+
+// BaggageMaxSize 跨服务传播的栈变量序列化后的最大字节数
+func (_Option) BaggageMaxSize(size int) option.Setting[RPCStackOptions] {
+	return func(options *RPCStackOptions) {
+		options.BaggageMaxSize = size
+	}
+}
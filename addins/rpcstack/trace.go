@@ -0,0 +1,44 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package rpcstack
+
+import (
+	"context"
+	"git.golaxy.org/framework/utils/tracing"
+)
+
+type _TraceKey struct{}
+
+// ContextWithTrace 在调用上下文中携带被调用方的链路追踪上下文，方法内发起的RPC将其作为父Span传播
+func ContextWithTrace(ctx context.Context, trace tracing.SpanContext) context.Context {
+	if !trace.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, _TraceKey{}, trace)
+}
+
+// TraceFromContext 获取调用上下文中携带的链路追踪上下文
+func TraceFromContext(ctx context.Context) (tracing.SpanContext, bool) {
+	if ctx == nil {
+		return tracing.SpanContext{}, false
+	}
+	trace, ok := ctx.Value(_TraceKey{}).(tracing.SpanContext)
+	return trace, ok
+}
@@ -19,6 +19,10 @@
 
 package rpcstack
 
+import (
+	"context"
+)
+
 // Deprecated: UnsafeFrame 访问RPC调用堆栈支持内部方法
 func UnsafeRPCStack(r IRPCStack) _UnsafeRPCStack {
 	return _UnsafeRPCStack{
@@ -30,8 +34,8 @@ type _UnsafeRPCStack struct {
 	IRPCStack
 }
 
-func (ur _UnsafeRPCStack) PushCallChain(cc CallChain) {
-	ur.pushCallChain(cc)
+func (ur _UnsafeRPCStack) PushCallChain(ctx context.Context, cc CallChain) {
+	ur.pushCallChain(ctx, cc)
 }
 
 func (ur _UnsafeRPCStack) PopCallChain() {
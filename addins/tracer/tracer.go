@@ -0,0 +1,72 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package tracer
+
+import (
+	"git.golaxy.org/core/runtime"
+	"git.golaxy.org/core/service"
+	"git.golaxy.org/core/utils/option"
+	"git.golaxy.org/framework/addins/log"
+	"git.golaxy.org/framework/utils/tracing"
+)
+
+// ITracer 链路追踪支持
+type ITracer interface {
+	// StartSpan 开始Span，parent有效时作为其子Span，否则按采样率开始新的链路
+	StartSpan(parent tracing.SpanContext, name string, kind tracing.SpanKind) *tracing.Span
+}
+
+func newTracer(settings ...option.Setting[TracerOptions]) ITracer {
+	return &_Tracer{
+		options: option.Make(With.Default(), settings...),
+	}
+}
+
+type _Tracer struct {
+	options TracerOptions
+	svcCtx  service.Context
+	tracer  *tracing.Tracer
+}
+
+// Init 初始化插件
+func (t *_Tracer) Init(svcCtx service.Context, _ runtime.Context) {
+	log.Infof(svcCtx, "init addin %q", self.Name)
+
+	t.svcCtx = svcCtx
+	t.tracer = tracing.NewTracer(svcCtx.GetName(), svcCtx.GetId().String(), t.options.Exporter, t.options.SampleRatio, func(err error) {
+		log.Errorf(svcCtx, "export span failed, %s", err)
+	})
+}
+
+// Shut 关闭插件
+func (t *_Tracer) Shut(svcCtx service.Context, _ runtime.Context) {
+	log.Infof(svcCtx, "shut addin %q", self.Name)
+
+	if t.options.Exporter != nil {
+		if err := t.options.Exporter.Close(); err != nil {
+			log.Errorf(svcCtx, "close span exporter failed, %s", err)
+		}
+	}
+}
+
+// StartSpan 开始Span，parent有效时作为其子Span，否则按采样率开始新的链路
+func (t *_Tracer) StartSpan(parent tracing.SpanContext, name string, kind tracing.SpanKind) *tracing.Span {
+	return t.tracer.StartSpan(parent, name, kind)
+}
@@ -0,0 +1,57 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package tracer
+
+import (
+	"git.golaxy.org/core/utils/option"
+	"git.golaxy.org/framework/utils/tracing"
+)
+
+// TracerOptions 所有选项
+type TracerOptions struct {
+	Exporter    tracing.IExporter // Span导出器，插件关闭时关闭
+	SampleRatio float64           // 开始新链路时的采样率，取值范围[0,1]
+}
+
+var With _Option
+
+type _Option struct{}
+
+// Default 默认值
+func (_Option) Default() option.Setting[TracerOptions] {
+	return func(options *TracerOptions) {
+		With.Exporter(nil).Apply(options)
+		With.SampleRatio(1).Apply(options)
+	}
+}
+
+// Exporter Span导出器，插件关闭时关闭
+func (_Option) Exporter(exporter tracing.IExporter) option.Setting[TracerOptions] {
+	return func(options *TracerOptions) {
+		options.Exporter = exporter
+	}
+}
+
+// SampleRatio 开始新链路时的采样率，取值范围[0,1]，延续已有链路时沿用上游的采样决策
+func (_Option) SampleRatio(ratio float64) option.Setting[TracerOptions] {
+	return func(options *TracerOptions) {
+		options.SampleRatio = ratio
+	}
+}
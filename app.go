@@ -25,6 +25,7 @@ import (
 	"git.golaxy.org/core"
 	"git.golaxy.org/core/utils/exception"
 	"git.golaxy.org/core/utils/generic"
+	"git.golaxy.org/framework/utils/metricutil"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"net"
@@ -52,6 +53,7 @@ type _ServPT struct {
 type App struct {
 	servicePTs               map[string]*_ServPT
 	startupConf              *viper.Viper
+	installer                any
 	initCB                   generic.DelegateVoid1[*cobra.Command]
 	startingCB, terminatedCB generic.DelegateVoid1[*App]
 }
@@ -78,7 +80,7 @@ func (app *App) Setup(name string, generic any) *App {
 		svcGeneric = newServiceInstantiation(generic)
 	}
 
-	svcGeneric.init(app.startupConf, name, svcGeneric)
+	svcGeneric.init(app, name, svcGeneric)
 
 	app.servicePTs[name] = &_ServPT{
 		generic: svcGeneric,
@@ -88,6 +90,12 @@ func (app *App) Setup(name string, generic any) *App {
 	return app
 }
 
+// Installer 设置插件安装器，服务与运行时未自行安装的插件，优先使用安装器安装，安装器可以实现各个Install*接口，组装完成时也会回调安装器实现的Built接口
+func (app *App) Installer(installer any) *App {
+	app.installer = installer
+	return app
+}
+
 // InitCB 初始化回调
 func (app *App) InitCB(cb generic.DelegateVoid1[*cobra.Command]) *App {
 	app.initCB = cb
@@ -130,11 +138,25 @@ func (app *App) Run() {
 			// 启动pprof
 			app.initPProf()
 
+			// 启动指标
+			app.initMetrics()
+
 			// 启动回调
 			app.startingCB.UnsafeCall(nil, app)
 
+			// 监听退出信号
+			ctx, cancel := context.WithCancel(context.Background())
+
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+
+			go func() {
+				<-sigChan
+				cancel()
+			}()
+
 			// 主循环
-			app.mainLoop()
+			app.mainLoop(ctx)
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
 			// 结束回调
@@ -159,6 +181,35 @@ func (app *App) Run() {
 	}
 }
 
+// RunContext 运行，不解析命令行参数，也不监听退出信号，启动参数配置使用默认值与已设置的值，ctx取消时终止所有服务，适用于测试或嵌入其他程序
+func (app *App) RunContext(ctx context.Context) {
+	app.lazyInit()
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cmd := &cobra.Command{}
+
+	// 初始化参数
+	app.initFlags(cmd)
+
+	// 初始化回调
+	app.initCB.UnsafeCall(nil, cmd)
+
+	// 合并启动参数配置
+	app.startupConf.BindPFlags(cmd.PersistentFlags())
+
+	// 启动回调
+	app.startingCB.UnsafeCall(nil, app)
+
+	// 主循环
+	app.mainLoop(ctx)
+
+	// 结束回调
+	app.terminatedCB.UnsafeCall(nil, app)
+}
+
 // GetStartupConf 获取启动参数配置
 func (app *App) GetStartupConf() *viper.Viper {
 	return app.startupConf
@@ -187,6 +238,7 @@ func (app *App) initFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().String("nats.address", "localhost:4222", "nats address")
 	cmd.PersistentFlags().String("nats.username", "", "nats auth username")
 	cmd.PersistentFlags().String("nats.password", "", "nats auth password")
+	cmd.PersistentFlags().Bool("nats.jetstream", false, "enable nats jetstream for at-least-once delivery")
 
 	// etcd参数
 	cmd.PersistentFlags().String("etcd.address", "localhost:2379", "etcd address")
@@ -201,6 +253,15 @@ func (app *App) initFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().Duration("service.dent_ttl", 10*time.Second, "ttl for distributed entity keepalive")
 	cmd.PersistentFlags().Bool("service.auto_recover", false, "enable panic auto recover")
 
+	// 链路追踪参数
+	cmd.PersistentFlags().String("tracing.dir", "", "tracing spans output directory path, empty to disable tracing")
+	cmd.PersistentFlags().Float64("tracing.sample_ratio", 1, "tracing sample ratio for new traces")
+
+	// RPC调用堆栈参数
+	cmd.PersistentFlags().StringSlice("rpcstack.baggage_keys", nil, "rpc stack variable keys propagated across services, a trailing * matches by prefix")
+	cmd.PersistentFlags().Int("rpcstack.baggage_max_entries", 16, "max entries of rpc stack variables propagated across services")
+	cmd.PersistentFlags().Int("rpcstack.baggage_max_size", 1024, "max encoded size of rpc stack variables propagated across services")
+
 	// 启动的服务列表
 	cmd.PersistentFlags().StringToString("startup.services", func() map[string]string {
 		ret := map[string]string{}
@@ -213,6 +274,10 @@ func (app *App) initFlags(cmd *cobra.Command) {
 	// pprof参数
 	cmd.PersistentFlags().Bool("pprof.enable", false, "enable pprof")
 	cmd.PersistentFlags().String("pprof.address", "0.0.0.0:6060", "pprof listening address")
+
+	// 指标参数
+	cmd.PersistentFlags().Bool("metrics.enable", false, "enable metrics")
+	cmd.PersistentFlags().String("metrics.address", "0.0.0.0:9090", "metrics listening address, serves prometheus text format at /metrics")
 }
 
 func (app *App) initPProf() {
@@ -234,18 +299,35 @@ func (app *App) initPProf() {
 	}()
 }
 
-func (app *App) mainLoop() {
-	// 监听退出信号
-	ctx, cancel := context.WithCancel(context.Background())
+func (app *App) initMetrics() {
+	if !app.GetStartupConf().GetBool("metrics.enable") {
+		return
+	}
 
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	addr := app.GetStartupConf().GetString("metrics.address")
+
+	_, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		exception.Panicf("%w: startup config [--metrics.address] = %q is invalid, %s", ErrFramework, addr, err)
+	}
+
+	// 与pprof使用相同地址时，共用pprof的监听
+	if app.GetStartupConf().GetBool("pprof.enable") && addr == app.GetStartupConf().GetString("pprof.address") {
+		http.Handle("/metrics", metricutil.DefaultRegistry)
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metricutil.DefaultRegistry)
 
 	go func() {
-		<-sigChan
-		cancel()
+		if err := http.ListenAndServe(addr, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			exception.Panicf("%w: interrupt listening %q, %s", ErrFramework, addr, err)
+		}
 	}()
+}
 
+func (app *App) mainLoop(ctx context.Context) {
 	// 启动所有服务
 	wg := &sync.WaitGroup{}
 
@@ -22,6 +22,7 @@ package framework
 import (
 	"git.golaxy.org/core/utils/async"
 	"git.golaxy.org/framework/addins/rpc/rpcutil"
+	"time"
 )
 
 // RPC 向分布式实体目标服务发送RPC
@@ -78,3 +79,8 @@ func (c *ComponentBehavior) CliOnewayRPC(proc, method string, args ...any) error
 func (c *ComponentBehavior) BroadcastCliOnewayRPC(proc, method string, args ...any) error {
 	return rpcutil.ProxyEntity(c, c.GetEntity().GetId()).BroadcastCliOnewayRPC(proc, method, args...)
 }
+
+// WithRPCTimeout 返回设置了RPC超时时间的实体代理，用于向分布式实体发送RPC，覆盖默认的Future超时时间
+func (c *ComponentBehavior) WithRPCTimeout(timeout time.Duration) rpcutil.EntityProxied {
+	return rpcutil.ProxyEntity(c, c.GetEntity().GetId()).WithTimeout(timeout)
+}
@@ -0,0 +1,270 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+// Package frameworktest 测试工具，在进程内启动多个服务组成的集群，使用进程内的消息队列、服务发现、分布式同步与分布式实体插件，无需依赖nats、etcd等外部中间件，适用于编写集成测试。
+package frameworktest
+
+import (
+	"context"
+	"fmt"
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/option"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework"
+	"git.golaxy.org/framework/addins/broker/local_broker"
+	"git.golaxy.org/framework/addins/dentr"
+	"git.golaxy.org/framework/addins/discovery/memory_discovery"
+	"git.golaxy.org/framework/addins/dsync/local_dsync"
+	"git.golaxy.org/framework/addins/rpc"
+	"git.golaxy.org/framework/addins/rpc/rpcutil"
+	"github.com/spf13/viper"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// NewCluster 创建进程内测试集群，测试结束时自动关闭
+func NewCluster(tb testing.TB, settings ...option.Setting[ClusterOptions]) *Cluster {
+	if tb == nil {
+		exception.Panicf("%w: tb is nil", core.ErrArgs)
+	}
+
+	c := &Cluster{
+		tb:          tb,
+		options:     option.Make(With.Default(), settings...),
+		hub:         local_broker.NewHub(),
+		registry:    memory_discovery.NewStore(),
+		dsync:       local_dsync.NewStore(),
+		dentStorage: dentr.NewMemoryStorage(),
+		nums:        map[string]int{},
+		services:    map[string][]framework.IServiceInstance{},
+		terminated:  make(chan struct{}),
+	}
+	c.ctx, c.terminate = context.WithCancel(context.Background())
+	c.app = framework.NewApp().Installer(_Installer{cluster: c})
+
+	return c
+}
+
+// Cluster 进程内测试集群
+type Cluster struct {
+	tb          testing.TB
+	options     ClusterOptions
+	app         *framework.App
+	hub         *local_broker.Hub
+	registry    *memory_discovery.Store
+	dsync       *local_dsync.Store
+	dentStorage *dentr.MemoryStorage
+	ctx         context.Context
+	terminate   context.CancelFunc
+	terminated  chan struct{}
+	started     bool
+	shutOnce    sync.Once
+	mutex       sync.Mutex
+	nums        map[string]int
+	services    map[string][]framework.IServiceInstance
+}
+
+// Setup 安装服务泛化类型，num为启动的服务实例数量
+func (c *Cluster) Setup(name string, generic any, num int) *Cluster {
+	if c.started {
+		exception.Panicf("%w: cluster already started", core.ErrArgs)
+	}
+	if num <= 0 {
+		exception.Panicf("%w: num less equal 0", core.ErrArgs)
+	}
+
+	c.app.Setup(name, generic)
+	c.nums[name] = num
+
+	return c
+}
+
+// Start 启动所有服务，并等待所有服务实例注册完成
+func (c *Cluster) Start() *Cluster {
+	c.tb.Helper()
+
+	if c.started {
+		c.tb.Fatal("frameworktest: cluster already started")
+	}
+	if len(c.nums) <= 0 {
+		c.tb.Fatal("frameworktest: no service setup")
+	}
+	c.started = true
+
+	startupConf := c.app.GetStartupConf()
+	startupConf.Set("log.dir", "")
+	startupConf.Set("log.stdout", true)
+	startupConf.Set("log.level", c.options.LogLevel)
+
+	services := map[string]string{}
+	for name, num := range c.nums {
+		services[name] = strconv.Itoa(num)
+	}
+	startupConf.Set("startup.services", services)
+
+	for k, v := range c.options.StartupConf {
+		startupConf.Set(k, v)
+	}
+
+	go func() {
+		defer close(c.terminated)
+		c.app.RunContext(c.ctx)
+	}()
+
+	c.tb.Cleanup(c.Shutdown)
+
+	c.WaitRegistered()
+
+	return c
+}
+
+// Shutdown 关闭所有服务，并等待所有服务终止，可以重复调用
+func (c *Cluster) Shutdown() {
+	c.tb.Helper()
+
+	c.shutOnce.Do(func() {
+		c.terminate()
+
+		if !c.started {
+			return
+		}
+
+		select {
+		case <-c.terminated:
+		case <-time.After(c.options.Timeout):
+			c.tb.Errorf("frameworktest: wait for services terminated timeout")
+		}
+	})
+}
+
+// WaitRegistered 等待所有服务实例完成组装并注册至服务发现
+func (c *Cluster) WaitRegistered() {
+	c.tb.Helper()
+
+	deadline := time.Now().Add(c.options.Timeout)
+
+	for {
+		pending := c.pending()
+		if pending == "" {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			c.tb.Fatalf("frameworktest: wait for services registered timeout, %s", pending)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// GetServices 获取服务的所有实例，按启动序号排序
+func (c *Cluster) GetServices(name string) []framework.IServiceInstance {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return slices.Clone(c.services[name])
+}
+
+// GetService 获取服务实例
+func (c *Cluster) GetService(name string, no int) framework.IServiceInstance {
+	c.tb.Helper()
+
+	services := c.GetServices(name)
+
+	idx := slices.IndexFunc(services, func(inst framework.IServiceInstance) bool {
+		return inst.GetStartupNo() == no
+	})
+	if idx < 0 {
+		c.tb.Fatalf("frameworktest: service %q instance %d not found", name, no)
+	}
+
+	return services[idx]
+}
+
+// GetStartupConf 获取启动参数配置
+func (c *Cluster) GetStartupConf() *viper.Viper {
+	return c.app.GetStartupConf()
+}
+
+// ProxyService 在服务实例from上代理服务
+func (c *Cluster) ProxyService(from framework.IServiceInstance, service string) rpcutil.ServiceProxied {
+	return rpcutil.ProxyService(from, service)
+}
+
+// ProxyEntity 在服务实例from上代理分布式实体，可以在测试协程中直接使用
+func (c *Cluster) ProxyEntity(from framework.IServiceInstance, id uid.Id) rpcutil.EntityProxied {
+	return rpcutil.ConcurrentProxyEntity(from, id)
+}
+
+// Await 等待异步结果，超时时测试失败
+func (c *Cluster) Await(ret async.AsyncRet) async.Ret {
+	c.tb.Helper()
+
+	select {
+	case r := <-ret:
+		return r
+	case <-time.After(c.options.Timeout):
+		c.tb.Fatalf("frameworktest: wait for async result timeout")
+	}
+
+	return async.Ret{}
+}
+
+// Results 等待RPC结果，超时时测试失败
+func (c *Cluster) Results(ret async.AsyncRet) rpc.ResultValues {
+	c.tb.Helper()
+	return rpc.Results(c.Await(ret))
+}
+
+func (c *Cluster) addService(inst framework.IServiceInstance) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	services := append(c.services[inst.GetName()], inst)
+	slices.SortFunc(services, func(a, b framework.IServiceInstance) int {
+		return a.GetStartupNo() - b.GetStartupNo()
+	})
+	c.services[inst.GetName()] = services
+}
+
+func (c *Cluster) pending() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for name, num := range c.nums {
+		services := c.services[name]
+
+		if len(services) < num {
+			return fmt.Sprintf("service %q not built", name)
+		}
+
+		for _, inst := range services {
+			if _, err := c.registry.GetServiceNode(name, inst.GetId()); err != nil {
+				return fmt.Sprintf("service %q node %q not registered", name, inst.GetId())
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,71 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package frameworktest
+
+import (
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/option"
+	"time"
+)
+
+// ClusterOptions 所有选项
+type ClusterOptions struct {
+	StartupConf map[string]any
+	LogLevel    string
+	Timeout     time.Duration
+}
+
+var With _Option
+
+type _Option struct{}
+
+// Default 默认值
+func (_Option) Default() option.Setting[ClusterOptions] {
+	return func(options *ClusterOptions) {
+		With.StartupConf(nil)(options)
+		With.LogLevel("warn")(options)
+		With.Timeout(10 * time.Second)(options)
+	}
+}
+
+// StartupConf 启动参数配置，会覆盖默认值，例如{"service.future_timeout": time.Second}
+func (_Option) StartupConf(conf map[string]any) option.Setting[ClusterOptions] {
+	return func(options *ClusterOptions) {
+		options.StartupConf = conf
+	}
+}
+
+// LogLevel 日志级别，日志输出至stdout
+func (_Option) LogLevel(level string) option.Setting[ClusterOptions] {
+	return func(options *ClusterOptions) {
+		options.LogLevel = level
+	}
+}
+
+// Timeout 等待服务注册、等待RPC结果与等待服务终止的超时时间
+func (_Option) Timeout(d time.Duration) option.Setting[ClusterOptions] {
+	return func(options *ClusterOptions) {
+		if d <= 0 {
+			exception.Panicf("%w: option Timeout can't be set to a value less equal 0", core.ErrArgs)
+		}
+		options.Timeout = d
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package frameworktest
+
+import (
+	"git.golaxy.org/framework"
+	"git.golaxy.org/framework/addins/rpc"
+	"testing"
+)
+
+type _EchoService struct {
+	framework.ServiceInstance
+}
+
+func (s *_EchoService) Echo(v string) string {
+	return v
+}
+
+func (s *_EchoService) StartupNo() int {
+	return s.GetStartupNo()
+}
+
+func TestClusterRPC(t *testing.T) {
+	c := NewCluster(t).
+		Setup("echo", &_EchoService{}, 2).
+		Setup("client", &_EchoService{}, 1).
+		Start()
+
+	client := c.GetService("client", 0)
+
+	// 负载均衡模式
+	v, err := rpc.Result1[string](c.Await(c.ProxyService(client, "echo").BalanceRPC("", "Echo", "hello"))).Extract()
+	if err != nil {
+		t.Fatalf("balance rpc failed, %s", err)
+	}
+	if v != "hello" {
+		t.Fatalf("balance rpc returned %q, want %q", v, "hello")
+	}
+
+	// 指定服务节点
+	for _, inst := range c.GetServices("echo") {
+		no, err := rpc.Result1[int](c.Await(c.ProxyService(client, "echo").RPC(inst.GetId(), "", "StartupNo"))).Extract()
+		if err != nil {
+			t.Fatalf("rpc node %q failed, %s", inst.GetId(), err)
+		}
+		if no != inst.GetStartupNo() {
+			t.Fatalf("rpc node %q returned startup no %d, want %d", inst.GetId(), no, inst.GetStartupNo())
+		}
+	}
+
+	// 方法不存在
+	if _, err := rpc.Result1[string](c.Await(c.ProxyService(client, "echo").BalanceRPC("", "NotExists"))).Extract(); err == nil {
+		t.Fatal("rpc not exists method succeeded")
+	}
+
+	c.Shutdown()
+
+	// 关闭后服务节点取消注册
+	for _, inst := range c.GetServices("echo") {
+		if _, err := c.registry.GetServiceNode("echo", inst.GetId()); err == nil {
+			t.Fatalf("service node %q still registered after shutdown", inst.GetId())
+		}
+	}
+}
+
+func TestClusterShutdownBeforeStart(t *testing.T) {
+	c := NewCluster(t).Setup("echo", &_EchoService{}, 1)
+	c.Shutdown()
+	c.Shutdown()
+}
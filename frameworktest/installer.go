@@ -0,0 +1,76 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package frameworktest
+
+import (
+	"git.golaxy.org/framework"
+	"git.golaxy.org/framework/addins/broker/local_broker"
+	"git.golaxy.org/framework/addins/dentq"
+	"git.golaxy.org/framework/addins/dentr"
+	"git.golaxy.org/framework/addins/discovery/memory_discovery"
+	"git.golaxy.org/framework/addins/dsync/local_dsync"
+)
+
+// _Installer 插件安装器，服务未自行安装的插件，使用进程内的实现替代依赖外部中间件的默认实现
+type _Installer struct {
+	cluster *Cluster
+}
+
+// InstallBroker 安装消息队列中间件插件
+func (i _Installer) InstallBroker(inst framework.IServiceInstance) {
+	local_broker.Install(inst,
+		local_broker.With.Hub(i.cluster.hub),
+	)
+}
+
+// InstallRegistry 安装服务发现插件
+func (i _Installer) InstallRegistry(inst framework.IServiceInstance) {
+	memory_discovery.Install(inst,
+		memory_discovery.With.Store(i.cluster.registry),
+		memory_discovery.With.TTL(inst.GetStartupConf().GetDuration("service.ttl"), true),
+	)
+}
+
+// InstallDistSync 安装分布式同步插件
+func (i _Installer) InstallDistSync(inst framework.IServiceInstance) {
+	local_dsync.Install(inst,
+		local_dsync.With.Store(i.cluster.dsync),
+	)
+}
+
+// InstallDistEntityQuerier 安装分布式实体查询插件
+func (i _Installer) InstallDistEntityQuerier(inst framework.IServiceInstance) {
+	dentq.Install(inst,
+		dentq.With.Backend(dentq.NewMemoryBackend(i.cluster.dentStorage)),
+	)
+}
+
+// InstallDistEntityRegistry 安装分布式实体注册插件
+func (i _Installer) InstallDistEntityRegistry(inst framework.IRuntimeInstance) {
+	dentr.Install(inst,
+		dentr.With.Storage(i.cluster.dentStorage),
+		dentr.With.TTL(inst.GetService().GetStartupConf().GetDuration("service.dent_ttl")),
+	)
+}
+
+// Built 组装完成，收集服务实例
+func (i _Installer) Built(inst framework.IServiceInstance) {
+	i.cluster.addService(inst)
+}
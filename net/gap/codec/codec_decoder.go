@@ -25,6 +25,9 @@ import (
 	"git.golaxy.org/core"
 	"git.golaxy.org/core/utils/exception"
 	"git.golaxy.org/framework/net/gap"
+	"git.golaxy.org/framework/net/gtp"
+	gtpcodec "git.golaxy.org/framework/net/gtp/codec"
+	"git.golaxy.org/framework/net/gtp/method"
 	"io"
 )
 
@@ -49,9 +52,17 @@ func MakeDecoder(msgCreator gap.IMsgCreator) Decoder {
 	}
 }
 
+// MakeLimitedDecoder 创建限制解压缩长度的消息包解码器，压缩消息解压缩后的长度超过上限时解码失败
+func MakeLimitedDecoder(msgCreator gap.IMsgCreator, maxUncompressedSize int) Decoder {
+	d := MakeDecoder(msgCreator)
+	d.MaxUncompressedSize = maxUncompressedSize
+	return d
+}
+
 // Decoder 消息包解码器
 type Decoder struct {
-	MsgCreator gap.IMsgCreator // 消息对象构建器
+	MsgCreator          gap.IMsgCreator // 消息对象构建器
+	MaxUncompressedSize int             // 压缩消息解压缩后的最大长度（字节），<=0表示不限制
 }
 
 // Decode 解码消息包
@@ -72,6 +83,16 @@ func (d Decoder) Decode(data []byte) (gap.MsgPacket, error) {
 		return gap.MsgPacket{}, fmt.Errorf("%w: %w (%d < %d)", ErrDecode, io.ErrShortBuffer, len(data), mp.Head.Len)
 	}
 
+	msgBuf := data[n:]
+
+	// 检查压缩标记
+	if mp.Head.Flags.Is(gap.Flag_Compressed) {
+		msgBuf, err = d.uncompress(data[n:mp.Head.Len])
+		if err != nil {
+			return gap.MsgPacket{}, err
+		}
+	}
+
 	// 创建消息体
 	msg, err := d.MsgCreator.New(mp.Head.MsgId)
 	if err != nil {
@@ -79,7 +100,7 @@ func (d Decoder) Decode(data []byte) (gap.MsgPacket, error) {
 	}
 
 	// 读取消息
-	if _, err = msg.Write(data[n:]); err != nil {
+	if _, err = msg.Write(msgBuf); err != nil {
 		return gap.MsgPacket{}, fmt.Errorf("%w: read msg failed, %w", ErrDecode, err)
 	}
 
@@ -87,3 +108,28 @@ func (d Decoder) Decode(data []byte) (gap.MsgPacket, error) {
 
 	return mp, nil
 }
+
+// uncompress 解压缩消息，消息内容为压缩算法与压缩消息
+func (d Decoder) uncompress(data []byte) ([]byte, error) {
+	if len(data) <= 0 {
+		return nil, fmt.Errorf("%w: %w: compressed msg too small", ErrDecode, io.ErrUnexpectedEOF)
+	}
+
+	cs, err := method.NewCompressionStream(gtp.Compression(data[0]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+
+	compressionModule := &gtpcodec.CompressionModule{
+		CompressionStream: cs,
+		MaxOriginalSize:   d.MaxUncompressedSize,
+	}
+
+	uncompressedBuf, err := compressionModule.Uncompress(data[1:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: uncompress msg failed, %w", ErrDecode, err)
+	}
+
+	// 解码的消息会引用解压缩的数据，不回收
+	return uncompressedBuf.Data(), nil
+}
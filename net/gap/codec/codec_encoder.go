@@ -23,7 +23,11 @@ import (
 	"errors"
 	"fmt"
 	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
 	"git.golaxy.org/framework/net/gap"
+	"git.golaxy.org/framework/net/gtp"
+	gtpcodec "git.golaxy.org/framework/net/gtp/codec"
+	"git.golaxy.org/framework/net/gtp/method"
 	"git.golaxy.org/framework/utils/binaryutil"
 )
 
@@ -43,11 +47,30 @@ func MakeEncoder() Encoder {
 	return Encoder{}
 }
 
+// MakeCompressionEncoder 创建支持压缩的消息包编码器，消息长度达到阀值时压缩
+func MakeCompressionEncoder(compression gtp.Compression, compressedSize int) Encoder {
+	if compression == gtp.Compression_None || compressedSize <= 0 {
+		return MakeEncoder()
+	}
+
+	if _, err := method.NewCompressionStream(compression); err != nil {
+		exception.Panicf("%w: %w", ErrEncode, err)
+	}
+
+	return Encoder{
+		Compression:    compression,
+		CompressedSize: compressedSize,
+	}
+}
+
 // Encoder 消息包编码器
-type Encoder struct{}
+type Encoder struct {
+	Compression    gtp.Compression // 压缩算法
+	CompressedSize int             // 启用压缩阀值（字节），<=0表示不开启
+}
 
 // Encode 编码消息包
-func (Encoder) Encode(src gap.Origin, seq int64, msg gap.MsgReader) (ret binaryutil.RecycleBytes, err error) {
+func (e Encoder) Encode(src gap.Origin, seq int64, msg gap.MsgReader) (ret binaryutil.RecycleBytes, err error) {
 	if msg == nil {
 		return binaryutil.NilRecycleBytes, fmt.Errorf("%w: %w: msg is nil", ErrEncode, core.ErrArgs)
 	}
@@ -60,6 +83,18 @@ func (Encoder) Encode(src gap.Origin, seq int64, msg gap.MsgReader) (ret binaryu
 		},
 		Msg: msg,
 	}
+
+	// 消息长度达到阀值时压缩，压缩后没有变小时不压缩
+	if e.Compression != gtp.Compression_None && e.CompressedSize > 0 && msg.Size() >= e.CompressedSize {
+		mpBuf, compressed, err := e.encodeCompressed(mp)
+		if err != nil {
+			return binaryutil.NilRecycleBytes, err
+		}
+		if compressed {
+			return mpBuf, nil
+		}
+	}
+
 	mp.Head.Len = uint32(mp.Size())
 
 	mpBuf := binaryutil.MakeRecycleBytes(int(mp.Head.Len))
@@ -75,3 +110,48 @@ func (Encoder) Encode(src gap.Origin, seq int64, msg gap.MsgReader) (ret binaryu
 
 	return mpBuf, nil
 }
+
+// encodeCompressed 编码压缩的消息包，消息内容为压缩算法与压缩消息
+func (e Encoder) encodeCompressed(mp gap.MsgPacket) (ret binaryutil.RecycleBytes, compressed bool, err error) {
+	msgBuf := binaryutil.MakeRecycleBytes(mp.Msg.Size())
+	defer msgBuf.Release()
+
+	if _, err := binaryutil.CopyToBuff(msgBuf.Data(), mp.Msg); err != nil {
+		return binaryutil.NilRecycleBytes, false, fmt.Errorf("%w: write msg failed, %w", ErrEncode, err)
+	}
+
+	// 压缩流有状态，编码器可能被并发使用，每次创建新的压缩流
+	cs, err := method.NewCompressionStream(e.Compression)
+	if err != nil {
+		return binaryutil.NilRecycleBytes, false, fmt.Errorf("%w: %w", ErrEncode, err)
+	}
+
+	compressedBuf, compressed, err := gtpcodec.NewCompressionModule(cs).Compress(msgBuf.Data())
+	if err != nil {
+		return binaryutil.NilRecycleBytes, false, fmt.Errorf("%w: compress msg failed, %w", ErrEncode, err)
+	}
+	if !compressed {
+		return binaryutil.NilRecycleBytes, false, nil
+	}
+	defer compressedBuf.Release()
+
+	mp.Head.Flags.Set(gap.Flag_Compressed, true)
+	mp.Head.Len = uint32(mp.Head.Size() + binaryutil.SizeofUint8() + len(compressedBuf.Data()))
+
+	mpBuf := binaryutil.MakeRecycleBytes(int(mp.Head.Len))
+	defer func() {
+		if !mpBuf.Equal(ret) {
+			mpBuf.Release()
+		}
+	}()
+
+	n, err := binaryutil.CopyToBuff(mpBuf.Data(), mp.Head)
+	if err != nil {
+		return binaryutil.NilRecycleBytes, false, fmt.Errorf("%w: write msg-packet-head failed, %w", ErrEncode, err)
+	}
+
+	mpBuf.Data()[n] = uint8(e.Compression)
+	copy(mpBuf.Data()[n+1:], compressedBuf.Data())
+
+	return mpBuf, true, nil
+}
@@ -0,0 +1,204 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"git.golaxy.org/framework/net/gap"
+	"git.golaxy.org/framework/net/gtp"
+	gtpcodec "git.golaxy.org/framework/net/gtp/codec"
+	"git.golaxy.org/framework/utils/binaryutil"
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+var compressions = []gtp.Compression{
+	gtp.Compression_Gzip,
+	gtp.Compression_Deflate,
+	gtp.Compression_Brotli,
+	gtp.Compression_LZ4,
+	gtp.Compression_Snappy,
+}
+
+// roundTrip 编码后解码消息包，检查消息内容与压缩标记
+func roundTrip(t *testing.T, encoder Encoder, data []byte, wantCompressed bool) {
+	t.Helper()
+
+	src := gap.Origin{Svc: "svc", Addr: "addr"}
+	msg := &gap.MsgForward{Dst: "dst", CorrId: 7, TransId: 3, TransData: data}
+
+	buf, err := encoder.Encode(src, 9, msg)
+	if err != nil {
+		t.Fatalf("encode failed, %s", err)
+	}
+	defer buf.Release()
+
+	mp, err := DefaultDecoder().Decode(buf.Data())
+	if err != nil {
+		t.Fatalf("decode failed, %s", err)
+	}
+
+	if compressed := mp.Head.Flags.Is(gap.Flag_Compressed); compressed != wantCompressed {
+		t.Fatalf("got compressed %v, want %v", compressed, wantCompressed)
+	}
+	if int(mp.Head.Len) != len(buf.Data()) {
+		t.Fatalf("got head len %d, want %d", mp.Head.Len, len(buf.Data()))
+	}
+	if mp.Head.Src != src || mp.Head.Seq != 9 {
+		t.Fatalf("got head %+v, want src %+v seq 9", mp.Head, src)
+	}
+
+	got, ok := mp.Msg.(*gap.MsgForward)
+	if !ok {
+		t.Fatalf("got msg %T, want *gap.MsgForward", mp.Msg)
+	}
+	if got.Dst != msg.Dst || got.CorrId != msg.CorrId || got.TransId != msg.TransId || !bytes.Equal(got.TransData, data) {
+		t.Fatalf("got msg %+v, want %+v", got, msg)
+	}
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	const threshold = 256
+
+	for _, compression := range compressions {
+		encoder := MakeCompressionEncoder(compression, threshold)
+
+		// 未达到阀值时不压缩
+		roundTrip(t, encoder, bytes.Repeat([]byte("a"), 16), false)
+
+		// 达到阀值时压缩
+		roundTrip(t, encoder, bytes.Repeat([]byte("inventory"), threshold), true)
+		roundTrip(t, encoder, bytes.Repeat([]byte("inventory"), 100000), true)
+	}
+}
+
+func TestCompressionIncompressible(t *testing.T) {
+	data := make([]byte, 4096)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	// 压缩后没有变小时不压缩
+	for _, compression := range compressions {
+		roundTrip(t, MakeCompressionEncoder(compression, 256), data, false)
+	}
+}
+
+func TestDecodeUncompressed(t *testing.T) {
+	data := bytes.Repeat([]byte("inventory"), 1024)
+
+	// 未开启压缩的编码器编码的消息包，解码器可以正常解码
+	roundTrip(t, MakeEncoder(), data, false)
+	roundTrip(t, MakeCompressionEncoder(gtp.Compression_None, 256), data, false)
+	roundTrip(t, MakeCompressionEncoder(gtp.Compression_Gzip, 0), data, false)
+
+	// 压缩阀值较大时，未达到阀值的消息包不压缩
+	roundTrip(t, MakeCompressionEncoder(gtp.Compression_Gzip, len(data)*2), data, false)
+}
+
+func TestDecodeInvalidCompression(t *testing.T) {
+	buf, err := MakeCompressionEncoder(gtp.Compression_Gzip, 256).Encode(gap.Origin{}, 1, &gap.MsgForward{TransData: bytes.Repeat([]byte("a"), 1024)})
+	if err != nil {
+		t.Fatalf("encode failed, %s", err)
+	}
+	defer buf.Release()
+
+	var head gap.MsgHead
+	n, err := head.Write(buf.Data())
+	if err != nil {
+		t.Fatalf("decode head failed, %s", err)
+	}
+
+	// 篡改压缩算法
+	data := bytes.Clone(buf.Data())
+	data[n] = 0xff
+
+	if _, err := DefaultDecoder().Decode(data); !errors.Is(err, ErrDecode) {
+		t.Fatalf("got decode error %v, want %v", err, ErrDecode)
+	}
+
+	// 篡改压缩数据
+	data = bytes.Clone(buf.Data())
+	for i := n + 1; i < len(data); i++ {
+		data[i] = 0xff
+	}
+
+	if _, err := DefaultDecoder().Decode(data); !errors.Is(err, ErrDecode) {
+		t.Fatalf("got decode error %v, want %v", err, ErrDecode)
+	}
+}
+
+func TestDecodeUncompressedSizeLimit(t *testing.T) {
+	data := bytes.Repeat([]byte("inventory"), 1024)
+
+	buf, err := MakeCompressionEncoder(gtp.Compression_Gzip, 256).Encode(gap.Origin{}, 1, &gap.MsgForward{TransData: data})
+	if err != nil {
+		t.Fatalf("encode failed, %s", err)
+	}
+	defer buf.Release()
+
+	// 解压缩后的长度未超过上限时正常解码
+	if _, err := MakeLimitedDecoder(gap.DefaultMsgCreator(), len(data)*2).Decode(buf.Data()); err != nil {
+		t.Fatalf("decode failed, %s", err)
+	}
+
+	// 解压缩后的长度超过上限时解码失败
+	if _, err := MakeLimitedDecoder(gap.DefaultMsgCreator(), len(data)/2).Decode(buf.Data()); !errors.Is(err, ErrDecode) {
+		t.Fatalf("got decode error %v, want %v", err, ErrDecode)
+	}
+
+	var head gap.MsgHead
+	n, err := head.Write(buf.Data())
+	if err != nil {
+		t.Fatalf("decode head failed, %s", err)
+	}
+
+	msgCompressed := gtp.MsgCompressed{}
+	if _, err := msgCompressed.Write(buf.Data()[n+1:]); err != nil {
+		t.Fatalf("read compressed msg failed, %s", err)
+	}
+
+	// 篡改解压缩后的长度
+	msgCompressed.OriginalSize = 1 << 30
+
+	forged := make([]byte, n+1+msgCompressed.Size())
+	copy(forged, buf.Data()[:n+1])
+	if _, err := binaryutil.CopyToBuff(forged[n+1:], msgCompressed); err != nil {
+		t.Fatalf("write compressed msg failed, %s", err)
+	}
+
+	head.Len = uint32(len(forged))
+	if _, err := binaryutil.CopyToBuff(forged, head); err != nil {
+		t.Fatalf("write head failed, %s", err)
+	}
+
+	// 解压缩前拒绝，不按篡改的长度分配内存
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	if _, err := MakeLimitedDecoder(gap.DefaultMsgCreator(), len(data)*2).Decode(forged); !errors.Is(err, gtpcodec.ErrCompress) {
+		t.Fatalf("got decode error %v, want %v", err, gtpcodec.ErrCompress)
+	}
+
+	runtime.ReadMemStats(&after)
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc >= uint64(msgCompressed.OriginalSize/2) {
+		t.Fatalf("allocated %d bytes before rejecting forged original size", alloc)
+	}
+}
@@ -0,0 +1,49 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package gap
+
+// 内置错误码，RPC错误跨服务传递时使用，自定义错误码需要大于0
+const (
+	ErrCode_Unknown                          int32 = -(iota + 1) // 未知错误
+	ErrCode_RPC_Undeliverable                                    // RPC无法投递
+	ErrCode_RPC_Terminated                                       // RPC已终止处理
+	ErrCode_RPC_EntityNotFound                                   // RPC找不到路由会话映射的实体
+	ErrCode_RPC_SessionNotFound                                  // RPC找不到路由实体映射的会话
+	ErrCode_RPC_GroupNotFound                                    // RPC找不到分组
+	ErrCode_RPC_GroupChanIsFull                                  // RPC分组发送数据的channel已满
+	ErrCode_RPC_DistEntityNotFound                               // RPC找不到分布式实体
+	ErrCode_RPC_DistEntityNodeNotFound                           // RPC找不到分布式实体的服务节点
+	ErrCode_RPC_IncorrectDestAddress                             // RPC错误的目的地址
+	ErrCode_RPC_AddInNotFound                                    // RPC找不到插件
+	ErrCode_RPC_AddInInactive                                    // RPC插件未激活
+	ErrCode_RPC_ProcedureNotFound                                // RPC找不到过程
+	ErrCode_RPC_ComponentNotFound                                // RPC找不到组件
+	ErrCode_RPC_MethodNotFound                                   // RPC找不到方法
+	ErrCode_RPC_MethodParameterCountMismatch                     // RPC方法参数数量不匹配
+	ErrCode_RPC_MethodParameterTypeMismatch                      // RPC方法参数类型不匹配
+	ErrCode_RPC_AsyncMethodReturnedNil                           // RPC异步方法返回值为nil
+	ErrCode_RPC_StreamMethodReturnedNil                          // RPC流式方法返回值为nil
+	ErrCode_RPC_PermissionDenied                                 // RPC权限不足
+	ErrCode_RPC_DeadlineExceeded                                 // RPC超过截止时间
+	ErrCode_RPC_Canceled                                         // RPC调用方已取消
+	ErrCode_RPC_CircuitOpen                                      // RPC目标的熔断器已打开
+	ErrCode_RPC_StreamNotAccepted                                // RPC调用方不接受流式答复
+	ErrCode_RPC_StreamUnsupported                                // RPC投递器不支持流式请求
+)
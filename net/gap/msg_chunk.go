@@ -0,0 +1,89 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package gap
+
+import (
+	"git.golaxy.org/framework/utils/binaryutil"
+	"io"
+)
+
+// MsgChunk 分块传输，消息包超过传输上限时，拆分为多个分块发送，接收方重组后再解码
+type MsgChunk struct {
+	TransferId int64  // 传输Id，同一源地址内唯一
+	Index      int64  // 分块序号，从0开始
+	Total      int64  // 分块总数
+	Data       []byte // 分块数据（引用）
+}
+
+// Read implements io.Reader
+func (m MsgChunk) Read(p []byte) (int, error) {
+	bs := binaryutil.NewBigEndianStream(p)
+	if err := bs.WriteVarint(m.TransferId); err != nil {
+		return bs.BytesWritten(), err
+	}
+	if err := bs.WriteVarint(m.Index); err != nil {
+		return bs.BytesWritten(), err
+	}
+	if err := bs.WriteVarint(m.Total); err != nil {
+		return bs.BytesWritten(), err
+	}
+	if err := bs.WriteBytes(m.Data); err != nil {
+		return bs.BytesWritten(), err
+	}
+	return bs.BytesWritten(), io.EOF
+}
+
+// Write implements io.Writer
+func (m *MsgChunk) Write(p []byte) (int, error) {
+	bs := binaryutil.NewBigEndianStream(p)
+	var err error
+
+	m.TransferId, err = bs.ReadVarint()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+
+	m.Index, err = bs.ReadVarint()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+
+	m.Total, err = bs.ReadVarint()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+
+	m.Data, err = bs.ReadBytesRef()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+
+	return bs.BytesRead(), nil
+}
+
+// Size 大小
+func (m MsgChunk) Size() int {
+	return binaryutil.SizeofVarint(m.TransferId) + binaryutil.SizeofVarint(m.Index) + binaryutil.SizeofVarint(m.Total) + binaryutil.SizeofBytes(m.Data)
+}
+
+// MsgId 消息Id
+func (MsgChunk) MsgId() MsgId {
+	return MsgId_Chunk
+}
@@ -21,16 +21,18 @@ package gap
 
 import (
 	"git.golaxy.org/framework/utils/binaryutil"
+	"git.golaxy.org/framework/utils/tracing"
 	"io"
 )
 
 // MsgForward 转发
 type MsgForward struct {
-	Src       Origin // 源信息
-	Dst       string // 目标地址
-	CorrId    int64  // 关联Id，用于支持Future等异步模型
-	TransId   MsgId  // 传输消息Id
-	TransData []byte // 传输消息内容（引用）
+	Src       Origin              // 源信息
+	Dst       string              // 目标地址
+	CorrId    int64               // 关联Id，用于支持Future等异步模型
+	Trace     tracing.SpanContext // 转发方的链路追踪上下文
+	TransId   MsgId               // 传输消息Id
+	TransData []byte              // 传输消息内容（引用）
 }
 
 // Read implements io.Reader
@@ -45,6 +47,9 @@ func (m MsgForward) Read(p []byte) (int, error) {
 	if err := bs.WriteVarint(m.CorrId); err != nil {
 		return bs.BytesWritten(), err
 	}
+	if _, err := binaryutil.CopyToByteStream(&bs, m.Trace); err != nil {
+		return bs.BytesWritten(), err
+	}
 	if err := bs.WriteUint32(m.TransId); err != nil {
 		return bs.BytesWritten(), err
 	}
@@ -74,6 +79,10 @@ func (m *MsgForward) Write(p []byte) (int, error) {
 		return bs.BytesRead(), err
 	}
 
+	if _, err = bs.WriteTo(&m.Trace); err != nil {
+		return bs.BytesRead(), err
+	}
+
 	m.TransId, err = bs.ReadUint32()
 	if err != nil {
 		return bs.BytesRead(), err
@@ -89,7 +98,7 @@ func (m *MsgForward) Write(p []byte) (int, error) {
 
 // Size 大小
 func (m MsgForward) Size() int {
-	return m.Src.Size() + binaryutil.SizeofString(m.Dst) + binaryutil.SizeofVarint(m.CorrId) + binaryutil.SizeofUint32() + binaryutil.SizeofBytes(m.TransData)
+	return m.Src.Size() + binaryutil.SizeofString(m.Dst) + binaryutil.SizeofVarint(m.CorrId) + m.Trace.Size() + binaryutil.SizeofUint32() + binaryutil.SizeofBytes(m.TransData)
 }
 
 // MsgId 消息Id
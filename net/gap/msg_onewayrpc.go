@@ -22,19 +22,28 @@ package gap
 import (
 	"git.golaxy.org/framework/net/gap/variant"
 	"git.golaxy.org/framework/utils/binaryutil"
+	"git.golaxy.org/framework/utils/tracing"
 	"io"
 )
 
 // MsgOnewayRPC 单程RPC请求
 type MsgOnewayRPC struct {
-	CallChain variant.CallChain // 调用链
-	Path      []byte            // 调用路径
-	Args      variant.Array     // 参数列表
+	Trace     tracing.SpanContext // 调用方的链路追踪上下文
+	Baggage   variant.Map         // 调用方跨服务传播的栈变量
+	CallChain variant.CallChain   // 调用链
+	Path      []byte              // 调用路径
+	Args      variant.Array       // 参数列表
 }
 
 // Read implements io.Reader
 func (m MsgOnewayRPC) Read(p []byte) (int, error) {
 	bs := binaryutil.NewBigEndianStream(p)
+	if _, err := binaryutil.CopyToByteStream(&bs, m.Trace); err != nil {
+		return bs.BytesWritten(), err
+	}
+	if _, err := binaryutil.CopyToByteStream(&bs, m.Baggage); err != nil {
+		return bs.BytesWritten(), err
+	}
 	if _, err := binaryutil.CopyToByteStream(&bs, m.CallChain); err != nil {
 		return bs.BytesWritten(), err
 	}
@@ -52,6 +61,14 @@ func (m *MsgOnewayRPC) Write(p []byte) (int, error) {
 	bs := binaryutil.NewBigEndianStream(p)
 	var err error
 
+	if _, err = bs.WriteTo(&m.Trace); err != nil {
+		return bs.BytesRead(), err
+	}
+
+	if _, err = bs.WriteTo(&m.Baggage); err != nil {
+		return bs.BytesRead(), err
+	}
+
 	if _, err = bs.WriteTo(&m.CallChain); err != nil {
 		return bs.BytesRead(), err
 	}
@@ -70,7 +87,7 @@ func (m *MsgOnewayRPC) Write(p []byte) (int, error) {
 
 // Size 大小
 func (m MsgOnewayRPC) Size() int {
-	return m.CallChain.Size() + binaryutil.SizeofBytes(m.Path) + m.Args.Size()
+	return m.Trace.Size() + m.Baggage.Size() + m.CallChain.Size() + binaryutil.SizeofBytes(m.Path) + m.Args.Size()
 }
 
 // MsgId 消息Id
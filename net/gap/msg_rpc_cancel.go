@@ -0,0 +1,62 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package gap
+
+import (
+	"git.golaxy.org/framework/utils/binaryutil"
+	"io"
+)
+
+// MsgRPCCancel RPC取消，调用方不再等待结果时发送，通知被调用方停止处理
+type MsgRPCCancel struct {
+	CorrId int64 // 关联Id，与取消的RPC请求一致
+}
+
+// Read implements io.Reader
+func (m MsgRPCCancel) Read(p []byte) (int, error) {
+	bs := binaryutil.NewBigEndianStream(p)
+	if err := bs.WriteVarint(m.CorrId); err != nil {
+		return bs.BytesWritten(), err
+	}
+	return bs.BytesWritten(), io.EOF
+}
+
+// Write implements io.Writer
+func (m *MsgRPCCancel) Write(p []byte) (int, error) {
+	bs := binaryutil.NewBigEndianStream(p)
+	var err error
+
+	m.CorrId, err = bs.ReadVarint()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+
+	return bs.BytesRead(), nil
+}
+
+// Size 大小
+func (m MsgRPCCancel) Size() int {
+	return binaryutil.SizeofVarint(m.CorrId)
+}
+
+// MsgId 消息Id
+func (MsgRPCCancel) MsgId() MsgId {
+	return MsgId_RPC_Cancel
+}
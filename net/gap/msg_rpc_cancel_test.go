@@ -0,0 +1,48 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package gap
+
+import (
+	"testing"
+)
+
+func TestMsgRPCCancel(t *testing.T) {
+	msg := &MsgRPCCancel{CorrId: 42}
+
+	buf := make([]byte, msg.Size())
+	msg.Read(buf)
+
+	// 内置消息可通过消息Id创建
+	created, err := DefaultMsgCreator().New(MsgId_RPC_Cancel)
+	if err != nil {
+		t.Fatalf("new msg failed, %s", err)
+	}
+
+	decoded, ok := created.(*MsgRPCCancel)
+	if !ok {
+		t.Fatalf("got msg %T, want *MsgRPCCancel", created)
+	}
+	if _, err := decoded.Write(buf); err != nil {
+		t.Fatalf("decode failed, %s", err)
+	}
+	if decoded.CorrId != msg.CorrId {
+		t.Fatalf("got corr id %d, want %d", decoded.CorrId, msg.CorrId)
+	}
+}
@@ -22,14 +22,16 @@ package gap
 import (
 	"git.golaxy.org/framework/net/gap/variant"
 	"git.golaxy.org/framework/utils/binaryutil"
+	"git.golaxy.org/framework/utils/tracing"
 	"io"
 )
 
 // MsgRPCReply RPC答复
 type MsgRPCReply struct {
-	CorrId int64         // 关联Id，用于支持Future等异步模型
-	Rets   variant.Array // 调用结果
-	Error  variant.Error // 调用错误
+	CorrId int64               // 关联Id，用于支持Future等异步模型
+	Trace  tracing.SpanContext // 被调用方的链路追踪上下文
+	Rets   variant.Array       // 调用结果
+	Error  variant.Error       // 调用错误
 }
 
 // Read implements io.Reader
@@ -38,6 +40,9 @@ func (m MsgRPCReply) Read(p []byte) (int, error) {
 	if err := bs.WriteVarint(m.CorrId); err != nil {
 		return bs.BytesWritten(), err
 	}
+	if _, err := binaryutil.CopyToByteStream(&bs, m.Trace); err != nil {
+		return bs.BytesWritten(), err
+	}
 	if _, err := binaryutil.CopyToByteStream(&bs, m.Rets); err != nil {
 		return bs.BytesWritten(), err
 	}
@@ -57,6 +62,10 @@ func (m *MsgRPCReply) Write(p []byte) (int, error) {
 		return bs.BytesRead(), err
 	}
 
+	if _, err = bs.WriteTo(&m.Trace); err != nil {
+		return bs.BytesRead(), err
+	}
+
 	if _, err = bs.WriteTo(&m.Rets); err != nil {
 		return bs.BytesRead(), err
 	}
@@ -70,7 +79,7 @@ func (m *MsgRPCReply) Write(p []byte) (int, error) {
 
 // Size 大小
 func (m MsgRPCReply) Size() int {
-	return binaryutil.SizeofVarint(m.CorrId) + m.Rets.Size() + m.Error.Size()
+	return binaryutil.SizeofVarint(m.CorrId) + m.Trace.Size() + m.Rets.Size() + m.Error.Size()
 }
 
 // MsgId 消息Id
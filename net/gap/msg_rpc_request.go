@@ -22,15 +22,20 @@ package gap
 import (
 	"git.golaxy.org/framework/net/gap/variant"
 	"git.golaxy.org/framework/utils/binaryutil"
+	"git.golaxy.org/framework/utils/tracing"
 	"io"
 )
 
 // MsgRPCRequest RPC请求
 type MsgRPCRequest struct {
-	CorrId    int64             // 关联Id，用于支持Future等异步模型
-	CallChain variant.CallChain // 调用链
-	Path      []byte            // 调用路径
-	Args      variant.Array     // 参数列表
+	CorrId    int64               // 关联Id，用于支持Future等异步模型
+	Deadline  int64               // 截止时间（Unix毫秒时间戳），0表示不限制
+	Window    int64               // 流式答复的流控窗口，大于0表示调用方接受流式答复
+	Trace     tracing.SpanContext // 调用方的链路追踪上下文
+	Baggage   variant.Map         // 调用方跨服务传播的栈变量
+	CallChain variant.CallChain   // 调用链
+	Path      []byte              // 调用路径
+	Args      variant.Array       // 参数列表
 }
 
 // Read implements io.Reader
@@ -39,6 +44,18 @@ func (m MsgRPCRequest) Read(p []byte) (int, error) {
 	if err := bs.WriteVarint(m.CorrId); err != nil {
 		return bs.BytesWritten(), err
 	}
+	if err := bs.WriteVarint(m.Deadline); err != nil {
+		return bs.BytesWritten(), err
+	}
+	if err := bs.WriteVarint(m.Window); err != nil {
+		return bs.BytesWritten(), err
+	}
+	if _, err := binaryutil.CopyToByteStream(&bs, m.Trace); err != nil {
+		return bs.BytesWritten(), err
+	}
+	if _, err := binaryutil.CopyToByteStream(&bs, m.Baggage); err != nil {
+		return bs.BytesWritten(), err
+	}
 	if _, err := binaryutil.CopyToByteStream(&bs, m.CallChain); err != nil {
 		return bs.BytesWritten(), err
 	}
@@ -61,6 +78,24 @@ func (m *MsgRPCRequest) Write(p []byte) (int, error) {
 		return bs.BytesRead(), err
 	}
 
+	m.Deadline, err = bs.ReadVarint()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+
+	m.Window, err = bs.ReadVarint()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+
+	if _, err = bs.WriteTo(&m.Trace); err != nil {
+		return bs.BytesRead(), err
+	}
+
+	if _, err = bs.WriteTo(&m.Baggage); err != nil {
+		return bs.BytesRead(), err
+	}
+
 	if _, err = bs.WriteTo(&m.CallChain); err != nil {
 		return bs.BytesRead(), err
 	}
@@ -79,10 +114,21 @@ func (m *MsgRPCRequest) Write(p []byte) (int, error) {
 
 // Size 大小
 func (m MsgRPCRequest) Size() int {
-	return binaryutil.SizeofVarint(m.CorrId) + m.CallChain.Size() + binaryutil.SizeofBytes(m.Path) + m.Args.Size()
+	return binaryutil.SizeofVarint(m.CorrId) + binaryutil.SizeofVarint(m.Deadline) + binaryutil.SizeofVarint(m.Window) + m.Trace.Size() + m.Baggage.Size() + m.CallChain.Size() + binaryutil.SizeofBytes(m.Path) + m.Args.Size()
 }
 
 // MsgId 消息Id
 func (MsgRPCRequest) MsgId() MsgId {
 	return MsgId_RPC_Request
 }
+
+// ReadRPCRequestDeadline 从RPC请求消息数据中读取截止时间，无需解析整个消息
+func ReadRPCRequestDeadline(data []byte) (int64, error) {
+	bs := binaryutil.NewBigEndianStream(data)
+
+	if _, err := bs.ReadVarint(); err != nil {
+		return 0, err
+	}
+
+	return bs.ReadVarint()
+}
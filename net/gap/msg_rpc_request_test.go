@@ -0,0 +1,79 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package gap
+
+import (
+	"bytes"
+	"errors"
+	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/utils/tracing"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestMsgRPCRequestDeadline(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+
+	args, err := variant.MakeReadonlyArray([]any{1, "a"})
+	if err != nil {
+		t.Fatalf("make args failed, %s", err)
+	}
+
+	msg := &MsgRPCRequest{
+		CorrId:   42,
+		Deadline: deadline.UnixMilli(),
+		Trace:    tracing.SpanContext{TraceId: tracing.NewTraceId(), SpanId: tracing.NewSpanId(), Flags: tracing.TraceFlags_Sampled},
+		CallChain: variant.CallChain{
+			{Svc: "svc", Addr: "addr", Timestamp: time.Now()},
+		},
+		Path: []byte("path"),
+		Args: args,
+	}
+
+	buf := make([]byte, msg.Size())
+	if n, err := msg.Read(buf); !errors.Is(err, io.EOF) || n != len(buf) {
+		t.Fatalf("encode returned %d, %v, want %d, EOF", n, err, len(buf))
+	}
+
+	// 无需解析整个消息即可读取截止时间
+	if got, err := ReadRPCRequestDeadline(buf); err != nil || got != msg.Deadline {
+		t.Fatalf("read deadline returned %d, %v, want %d", got, err, msg.Deadline)
+	}
+
+	decoded := &MsgRPCRequest{}
+	if _, err := decoded.Write(buf); err != nil {
+		t.Fatalf("decode failed, %s", err)
+	}
+	if decoded.CorrId != msg.CorrId || decoded.Deadline != msg.Deadline || !bytes.Equal(decoded.Path, msg.Path) || len(decoded.Args) != len(msg.Args) {
+		t.Fatalf("got %+v, want %+v", decoded, msg)
+	}
+	// 截止时间与链路追踪上下文只在请求中携带一份
+	if decoded.Trace != msg.Trace {
+		t.Fatalf("got trace %s, want %s", decoded.Trace, msg.Trace)
+	}
+	if len(decoded.CallChain) != 1 || decoded.CallChain.Last().Addr != "addr" {
+		t.Fatalf("got call chain %+v, want %+v", decoded.CallChain, msg.CallChain)
+	}
+
+	if _, err := ReadRPCRequestDeadline(buf[:1]); err == nil {
+		t.Fatal("read deadline from truncated data succeeded, want error")
+	}
+}
@@ -0,0 +1,80 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package gap
+
+import (
+	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/utils/binaryutil"
+	"git.golaxy.org/framework/utils/tracing"
+	"io"
+)
+
+// MsgRPCStreamEnd RPC流式答复结束
+type MsgRPCStreamEnd struct {
+	CorrId int64               // 关联Id，与RPC请求一致
+	Trace  tracing.SpanContext // 被调用方的链路追踪上下文
+	Error  variant.Error       // 结束原因，为空表示正常结束
+}
+
+// Read implements io.Reader
+func (m MsgRPCStreamEnd) Read(p []byte) (int, error) {
+	bs := binaryutil.NewBigEndianStream(p)
+	if err := bs.WriteVarint(m.CorrId); err != nil {
+		return bs.BytesWritten(), err
+	}
+	if _, err := binaryutil.CopyToByteStream(&bs, m.Trace); err != nil {
+		return bs.BytesWritten(), err
+	}
+	if _, err := binaryutil.CopyToByteStream(&bs, m.Error); err != nil {
+		return bs.BytesWritten(), err
+	}
+	return bs.BytesWritten(), io.EOF
+}
+
+// Write implements io.Writer
+func (m *MsgRPCStreamEnd) Write(p []byte) (int, error) {
+	bs := binaryutil.NewBigEndianStream(p)
+	var err error
+
+	m.CorrId, err = bs.ReadVarint()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+
+	if _, err = bs.WriteTo(&m.Trace); err != nil {
+		return bs.BytesRead(), err
+	}
+
+	if _, err = bs.WriteTo(&m.Error); err != nil {
+		return bs.BytesRead(), err
+	}
+
+	return bs.BytesRead(), nil
+}
+
+// Size 大小
+func (m MsgRPCStreamEnd) Size() int {
+	return binaryutil.SizeofVarint(m.CorrId) + m.Trace.Size() + m.Error.Size()
+}
+
+// MsgId 消息Id
+func (MsgRPCStreamEnd) MsgId() MsgId {
+	return MsgId_RPC_StreamEnd
+}
@@ -54,6 +54,11 @@ func init() {
 	DefaultMsgCreator().Declare(&MsgRPCReply{})
 	DefaultMsgCreator().Declare(&MsgOnewayRPC{})
 	DefaultMsgCreator().Declare(&MsgForward{})
+	DefaultMsgCreator().Declare(&MsgRPCCancel{})
+	DefaultMsgCreator().Declare(&MsgRPCStreamItem{})
+	DefaultMsgCreator().Declare(&MsgRPCStreamEnd{})
+	DefaultMsgCreator().Declare(&MsgRPCStreamAck{})
+	DefaultMsgCreator().Declare(&MsgChunk{})
 }
 
 // NewMsgCreator 创建消息对象构建器
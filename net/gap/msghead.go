@@ -74,10 +74,52 @@ func (o Origin) Size() int {
 	return binaryutil.SizeofString(o.Svc) + binaryutil.SizeofString(o.Addr) + binaryutil.SizeofInt64()
 }
 
+// Flags 所有标志位
+type Flags uint8
+
+// Is 判断标志位
+func (f Flags) Is(b Flag) bool {
+	return f&Flags(b) != 0
+}
+
+// Set 设置标志位
+func (f *Flags) Set(b Flag, v bool) *Flags {
+	if v {
+		*f |= Flags(b)
+	} else {
+		*f &= ^Flags(b)
+	}
+	return f
+}
+
+// Setd 拷贝并设置标志位
+func (f Flags) Setd(b Flag, v bool) Flags {
+	if v {
+		f |= Flags(b)
+	} else {
+		f &= ^Flags(b)
+	}
+	return f
+}
+
+func Flags_None() Flags {
+	return 0
+}
+
+// Flag 标志位
+type Flag = uint8
+
+// 固定标志位
+const (
+	Flag_Compressed Flag = 1 << iota // 已压缩
+	Flag_Customize       = iota      // 自定义标志位起点
+)
+
 // MsgHead 消息头
 type MsgHead struct {
 	Len   uint32 // 消息长度
 	MsgId MsgId  // 消息Id
+	Flags Flags  // 标志位
 	Src   Origin // 源信息
 	Seq   int64  // 序号
 }
@@ -91,6 +133,9 @@ func (m MsgHead) Read(p []byte) (int, error) {
 	if err := bs.WriteUint32(m.MsgId); err != nil {
 		return bs.BytesWritten(), err
 	}
+	if err := bs.WriteUint8(uint8(m.Flags)); err != nil {
+		return bs.BytesWritten(), err
+	}
 	if _, err := binaryutil.CopyToByteStream(&bs, m.Src); err != nil {
 		return bs.BytesWritten(), err
 	}
@@ -115,6 +160,12 @@ func (m *MsgHead) Write(p []byte) (int, error) {
 		return bs.BytesRead(), err
 	}
 
+	flags, err := bs.ReadUint8()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+	m.Flags = Flags(flags)
+
 	_, err = bs.WriteTo(&m.Src)
 	if err != nil {
 		return bs.BytesRead(), err
@@ -130,5 +181,5 @@ func (m *MsgHead) Write(p []byte) (int, error) {
 
 // Size 大小
 func (m MsgHead) Size() int {
-	return binaryutil.SizeofUint32() + binaryutil.SizeofUint32() + m.Src.Size() + binaryutil.SizeofInt64()
+	return binaryutil.SizeofUint32() + binaryutil.SizeofUint32() + binaryutil.SizeofUint8() + m.Src.Size() + binaryutil.SizeofInt64()
 }
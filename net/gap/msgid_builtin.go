@@ -20,10 +20,15 @@
 package gap
 
 const (
-	MsgId_None        MsgId = iota // 未设置
-	MsgId_RPC_Request              // RPC请求
-	MsgId_RPC_Reply                // RPC答复
-	MsgId_OnewayRPC                // 单程RPC请求
-	MsgId_Forward                  // 转发
-	MsgId_Customize   = 32         // 自定义消息起点
+	MsgId_None           MsgId = iota // 未设置
+	MsgId_RPC_Request                 // RPC请求
+	MsgId_RPC_Reply                   // RPC答复
+	MsgId_OnewayRPC                   // 单程RPC请求
+	MsgId_Forward                     // 转发
+	MsgId_RPC_Cancel                  // RPC取消
+	MsgId_RPC_StreamItem              // RPC流式答复数据项
+	MsgId_RPC_StreamEnd               // RPC流式答复结束
+	MsgId_RPC_StreamAck               // RPC流式答复确认
+	MsgId_Chunk                       // 分块传输
+	MsgId_Customize      = 32         // 自定义消息起点
 )
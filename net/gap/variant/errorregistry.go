@@ -0,0 +1,132 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package variant
+
+import (
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/framework/utils/concurrent"
+	"reflect"
+	"slices"
+)
+
+// IErrorRegistry 错误码注册表接口，错误跨服务传递后，使用错误码还原哨兵错误，支持errors.Is()与errors.As()
+type IErrorRegistry interface {
+	// Declare 注册错误码对应的哨兵错误，一个错误码可以对应多个哨兵错误
+	Declare(code int32, sentinel error)
+	// Undeclare 取消注册错误码
+	Undeclare(code int32)
+	// Sentinels 查询错误码对应的哨兵错误
+	Sentinels(code int32) []error
+	// Code 查询错误链中第一个已注册的哨兵错误的错误码
+	Code(err error) (int32, bool)
+}
+
+var errorRegistry = _NewErrorRegistry()
+
+// ErrorRegistry 错误码注册表
+func ErrorRegistry() IErrorRegistry {
+	return errorRegistry
+}
+
+type _ErrorTab struct {
+	sentinels map[int32][]error
+	codes     map[error]int32
+}
+
+// _NewErrorRegistry 创建错误码注册表
+func _NewErrorRegistry() IErrorRegistry {
+	return &_ErrorRegistry{
+		tab: concurrent.MakeRWLocked(_ErrorTab{
+			sentinels: map[int32][]error{},
+			codes:     map[error]int32{},
+		}),
+	}
+}
+
+// _ErrorRegistry 错误码注册表
+type _ErrorRegistry struct {
+	tab concurrent.RWLocked[_ErrorTab]
+}
+
+// Declare 注册错误码对应的哨兵错误，一个错误码可以对应多个哨兵错误
+func (r *_ErrorRegistry) Declare(code int32, sentinel error) {
+	if sentinel == nil || !reflect.TypeOf(sentinel).Comparable() {
+		exception.Panicf("%w: sentinel error must be comparable", ErrVariant)
+	}
+
+	if _, ok := sentinel.(*Error); ok {
+		exception.Panicf("%w: sentinel error can't be variant error", ErrVariant)
+	}
+
+	r.tab.AutoLock(func(tab *_ErrorTab) {
+		if declared, ok := tab.codes[sentinel]; ok {
+			exception.Panicf("%w: sentinel error %q has already been declared by code(%d)", ErrVariant, sentinel, declared)
+		}
+		tab.sentinels[code] = append(tab.sentinels[code], sentinel)
+		tab.codes[sentinel] = code
+	})
+}
+
+// Undeclare 取消注册错误码
+func (r *_ErrorRegistry) Undeclare(code int32) {
+	r.tab.AutoLock(func(tab *_ErrorTab) {
+		for _, sentinel := range tab.sentinels[code] {
+			delete(tab.codes, sentinel)
+		}
+		delete(tab.sentinels, code)
+	})
+}
+
+// Sentinels 查询错误码对应的哨兵错误
+func (r *_ErrorRegistry) Sentinels(code int32) (sentinels []error) {
+	r.tab.AutoRLock(func(tab *_ErrorTab) {
+		sentinels = slices.Clone(tab.sentinels[code])
+	})
+	return
+}
+
+// Code 查询错误链中第一个已注册的哨兵错误的错误码
+func (r *_ErrorRegistry) Code(err error) (code int32, ok bool) {
+	r.tab.AutoRLock(func(tab *_ErrorTab) {
+		for ; err != nil; err = unwrapError(err) {
+			if !reflect.TypeOf(err).Comparable() {
+				continue
+			}
+			if code, ok = tab.codes[err]; ok {
+				return
+			}
+		}
+	})
+	return
+}
+
+// unwrapError 获取错误链中的下一个错误，包装多个错误时，使用第一个错误
+func unwrapError(err error) error {
+	switch v := err.(type) {
+	case interface{ Unwrap() error }:
+		return v.Unwrap()
+	case interface{ Unwrap() []error }:
+		errs := v.Unwrap()
+		if len(errs) > 0 {
+			return errs[0]
+		}
+	}
+	return nil
+}
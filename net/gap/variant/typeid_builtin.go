@@ -42,5 +42,8 @@ const (
 	TypeId_Map
 	TypeId_Error
 	TypeId_CallChain
+	TypeId_Time
+	TypeId_Duration
+	TypeId_Uid
 	TypeId_Customize = 32 // 自定义类型起点
 )
@@ -0,0 +1,59 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package variant
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestCallChainReadWrite(t *testing.T) {
+	now := time.Now()
+
+	cc := CallChain{
+		{Svc: "svc1", Addr: "addr1", Timestamp: now},
+		{Svc: "svc2", Addr: "addr2", Timestamp: now, Transit: true},
+	}
+
+	buf := make([]byte, cc.Size())
+	if n, err := cc.Read(buf); !errors.Is(err, io.EOF) || n != len(buf) {
+		t.Fatalf("encode returned %d, %v, want %d, EOF", n, err, len(buf))
+	}
+
+	var decoded CallChain
+	if _, err := decoded.Write(buf); err != nil {
+		t.Fatalf("decode failed, %s", err)
+	}
+	if len(decoded) != len(cc) {
+		t.Fatalf("got %d calls, want %d", len(decoded), len(cc))
+	}
+	for i := range cc {
+		if decoded[i].Svc != cc[i].Svc || decoded[i].Addr != cc[i].Addr || decoded[i].Transit != cc[i].Transit ||
+			decoded[i].Timestamp.UnixMilli() != cc[i].Timestamp.UnixMilli() {
+			t.Fatalf("got call %+v, want %+v", decoded[i], cc[i])
+		}
+	}
+
+	if decoded.First().Addr != "addr1" || decoded.Last().Addr != "addr2" {
+		t.Fatalf("got first %q, last %q, want addr1, addr2", decoded.First().Addr, decoded.Last().Addr)
+	}
+}
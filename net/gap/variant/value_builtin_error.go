@@ -22,18 +22,47 @@ package variant
 import (
 	"errors"
 	"fmt"
+	"git.golaxy.org/core/utils/generic"
 	"git.golaxy.org/framework/utils/binaryutil"
 	"io"
 )
 
+var (
+	ErrErrorCauseTooDeep = fmt.Errorf("%w: error cause nested too deep", ErrVariant)       // 错误原因的嵌套深度超过上限
+	ErrErrorInDetails    = fmt.Errorf("%w: error details can't contain error", ErrVariant) // 错误详情中包含错误
+)
+
+// maxErrorCauseDepth 错误原因的最大深度，编码时截断超过深度的错误原因，解码时返回错误
+const maxErrorCauseDepth = 8
+
+// MakeError 创建错误，错误码使用错误链中已注册的哨兵错误的错误码，没有时为-1，错误链转换为错误原因
 func MakeError(err error) *Error {
 	if err == nil {
 		return &Error{}
 	}
+	return makeError(err, 0)
+}
+
+func makeError(err error, depth int) *Error {
+	if varErr, ok := err.(*Error); ok {
+		return varErr
+	}
+
+	varErr := &Error{
+		Code:    -1,
+		Message: err.Error(),
+	}
+
+	if depth < maxErrorCauseDepth {
+		if cause := unwrapError(err); cause != nil {
+			varErr.Cause = makeError(cause, depth+1)
+		}
+	}
 
-	var varErr *Error
-	if !errors.As(err, &varErr) {
-		return Errorln(-1, err.Error())
+	if code, ok := errorRegistry.Code(err); ok {
+		varErr.Code = code
+	} else if varErr.Cause != nil {
+		varErr.Code = varErr.Cause.Code
 	}
 
 	return varErr
@@ -55,12 +84,20 @@ func Errorln(code int32, message string) *Error {
 
 // Error builtin error
 type Error struct {
-	Code    int32
-	Message string
+	Code    int32  // 错误码
+	Message string // 错误信息
+	Details Map    // 错误详情，不能包含错误，解码时返回错误
+	Cause   *Error // 错误原因
 }
 
 // Read implements io.Reader
 func (v Error) Read(p []byte) (int, error) {
+	return v.read(p, 0)
+}
+
+func (v Error) read(p []byte, depth int) (int, error) {
+	hasCause := v.Cause != nil && depth < maxErrorCauseDepth
+
 	bs := binaryutil.NewBigEndianStream(p)
 	if err := bs.WriteInt32(v.Code); err != nil {
 		return bs.BytesWritten(), err
@@ -68,11 +105,26 @@ func (v Error) Read(p []byte) (int, error) {
 	if err := bs.WriteString(v.Message); err != nil {
 		return bs.BytesWritten(), err
 	}
+	if _, err := binaryutil.CopyToByteStream(&bs, v.Details); err != nil {
+		return bs.BytesWritten(), err
+	}
+	if err := bs.WriteBool(hasCause); err != nil {
+		return bs.BytesWritten(), err
+	}
+	if hasCause {
+		if _, err := binaryutil.CopyToByteStream(&bs, _ErrorCause{err: v.Cause, depth: depth + 1}); err != nil {
+			return bs.BytesWritten(), err
+		}
+	}
 	return bs.BytesWritten(), io.EOF
 }
 
 // Write implements io.Writer
 func (v *Error) Write(p []byte) (int, error) {
+	return v.write(p, 0)
+}
+
+func (v *Error) write(p []byte, depth int) (int, error) {
 	bs := binaryutil.NewBigEndianStream(p)
 	var err error
 
@@ -86,12 +138,42 @@ func (v *Error) Write(p []byte) (int, error) {
 		return bs.BytesRead(), err
 	}
 
+	// 错误详情中的错误不受错误原因的深度限制，解码时拒绝
+	if _, err := bs.WriteTo(_ErrorDetailsMap{m: &v.Details}); err != nil {
+		return bs.BytesRead(), err
+	}
+
+	hasCause, err := bs.ReadBool()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+
+	v.Cause = nil
+	if hasCause {
+		// 错误原因的嵌套深度超过上限时返回错误，避免恶意数据导致栈溢出
+		if depth >= maxErrorCauseDepth {
+			return bs.BytesRead(), ErrErrorCauseTooDeep
+		}
+		v.Cause = &Error{}
+		if _, err := bs.WriteTo(_ErrorCause{err: v.Cause, depth: depth + 1}); err != nil {
+			return bs.BytesRead(), err
+		}
+	}
+
 	return bs.BytesRead(), nil
 }
 
 // Size 大小
 func (v Error) Size() int {
-	return binaryutil.SizeofInt32() + binaryutil.SizeofString(v.Message)
+	return v.size(0)
+}
+
+func (v Error) size(depth int) int {
+	n := binaryutil.SizeofInt32() + binaryutil.SizeofString(v.Message) + v.Details.Size() + binaryutil.SizeofBool()
+	if v.Cause != nil && depth < maxErrorCauseDepth {
+		n += v.Cause.size(depth + 1)
+	}
+	return n
 }
 
 // TypeId 类型
@@ -111,3 +193,149 @@ func (v Error) Error() string {
 func (v Error) OK() bool {
 	return v.Code == 0
 }
+
+// Unwrap 错误原因
+func (v *Error) Unwrap() error {
+	if v.Cause == nil {
+		return nil
+	}
+	return v.Cause
+}
+
+// Is 判断错误码对应的哨兵错误是否匹配目标错误
+func (v *Error) Is(target error) bool {
+	for _, sentinel := range errorRegistry.Sentinels(v.Code) {
+		if errors.Is(sentinel, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As 使用错误码对应的哨兵错误匹配目标类型
+func (v *Error) As(target any) bool {
+	for _, sentinel := range errorRegistry.Sentinels(v.Code) {
+		if errors.As(sentinel, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// _ErrorCause 错误原因，编解码时记录嵌套深度
+type _ErrorCause struct {
+	err   *Error
+	depth int
+}
+
+// Read implements io.Reader
+func (c _ErrorCause) Read(p []byte) (int, error) {
+	return c.err.read(p, c.depth)
+}
+
+// Write implements io.Writer
+func (c _ErrorCause) Write(p []byte) (int, error) {
+	return c.err.write(p, c.depth)
+}
+
+// _ErrorDetailsMap 错误详情，解码时检查其中不包含错误
+type _ErrorDetailsMap struct {
+	m *Map
+}
+
+// Write implements io.Writer
+func (d _ErrorDetailsMap) Write(p []byte) (int, error) {
+	bs := binaryutil.NewBigEndianStream(p)
+
+	l, err := bs.ReadUvarint()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+
+	*d.m = make([]generic.UnorderedKV[Variant, Variant], l)
+
+	for i := uint64(0); i < l; i++ {
+		kv := &(*d.m)[i]
+
+		if _, err := bs.WriteTo(_ErrorDetailsVariant{v: &kv.K}); err != nil {
+			return bs.BytesRead(), err
+		}
+
+		if _, err := bs.WriteTo(_ErrorDetailsVariant{v: &kv.V}); err != nil {
+			return bs.BytesRead(), err
+		}
+	}
+
+	return bs.BytesRead(), nil
+}
+
+// _ErrorDetailsArray 错误详情中的数组，解码时检查其中不包含错误
+type _ErrorDetailsArray struct {
+	arr *Array
+}
+
+// Write implements io.Writer
+func (d _ErrorDetailsArray) Write(p []byte) (int, error) {
+	bs := binaryutil.NewBigEndianStream(p)
+
+	l, err := bs.ReadUvarint()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+
+	*d.arr = make([]Variant, l)
+
+	for i := uint64(0); i < l; i++ {
+		if _, err := bs.WriteTo(_ErrorDetailsVariant{v: &(*d.arr)[i]}); err != nil {
+			return bs.BytesRead(), err
+		}
+	}
+
+	return bs.BytesRead(), nil
+}
+
+// _ErrorDetailsVariant 错误详情中的可变类型，类型为错误时返回错误，数组与map逐个检查其中的元素
+type _ErrorDetailsVariant struct {
+	v *Variant
+}
+
+// Write implements io.Writer
+func (d _ErrorDetailsVariant) Write(p []byte) (int, error) {
+	bs := binaryutil.NewBigEndianStream(p)
+
+	var typeId TypeId
+	if _, err := bs.WriteTo(&typeId); err != nil {
+		return bs.BytesRead(), err
+	}
+
+	if typeId == TypeId_Error {
+		return bs.BytesRead(), ErrErrorInDetails
+	}
+
+	reflected, err := typeId.NewReflected()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+
+	value := reflected.Interface().(Value)
+
+	switch v := value.(type) {
+	case *Map:
+		_, err = bs.WriteTo(_ErrorDetailsMap{m: v})
+	case *Array:
+		_, err = bs.WriteTo(_ErrorDetailsArray{arr: v})
+	default:
+		_, err = bs.WriteTo(value)
+	}
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+
+	*d.v = Variant{
+		TypeId:    typeId,
+		Value:     value,
+		Reflected: reflected,
+	}
+
+	return bs.BytesRead(), nil
+}
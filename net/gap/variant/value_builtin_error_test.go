@@ -0,0 +1,182 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package variant
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// encodeError 编码错误
+func encodeError(t *testing.T, v *Error) []byte {
+	t.Helper()
+
+	buf := make([]byte, v.Size())
+	if n, err := v.Read(buf); !errors.Is(err, io.EOF) || n != len(buf) {
+		t.Fatalf("encode returned %d, %v, want %d, EOF", n, err, len(buf))
+	}
+	return buf
+}
+
+func TestErrorRoundTrip(t *testing.T) {
+	k, err := CastReadonlyVariant("key")
+	if err != nil {
+		t.Fatalf("cast failed, %s", err)
+	}
+	v, err := CastReadonlyVariant("value")
+	if err != nil {
+		t.Fatalf("cast failed, %s", err)
+	}
+
+	details := Map{}
+	details.ToUnorderedSliceMap().Add(k, v)
+
+	src := &Error{
+		Code:    1,
+		Message: "outer",
+		Details: details,
+		Cause:   &Error{Code: 2, Message: "inner"},
+	}
+
+	var decoded Error
+	if _, err := decoded.Write(encodeError(t, src)); err != nil {
+		t.Fatalf("decode failed, %s", err)
+	}
+
+	if decoded.Code != src.Code || decoded.Message != src.Message || len(decoded.Details) != 1 {
+		t.Fatalf("got %+v, want %+v", decoded, src)
+	}
+	if decoded.Cause == nil || decoded.Cause.Code != 2 || decoded.Cause.Message != "inner" || decoded.Cause.Cause != nil {
+		t.Fatalf("got cause %+v, want %+v", decoded.Cause, src.Cause)
+	}
+}
+
+func TestErrorSentinel(t *testing.T) {
+	sentinel := errors.New("sentinel")
+
+	ErrorRegistry().Declare(100, sentinel)
+	defer ErrorRegistry().Undeclare(100)
+
+	var decoded Error
+	if _, err := decoded.Write(encodeError(t, MakeError(fmt.Errorf("wrapped: %w", sentinel)))); err != nil {
+		t.Fatalf("decode failed, %s", err)
+	}
+
+	// 解码后使用错误码还原哨兵错误
+	if decoded.Code != 100 || !errors.Is(&decoded, sentinel) {
+		t.Fatalf("got %+v, want code 100 matching sentinel", decoded)
+	}
+}
+
+func TestErrorCauseTruncated(t *testing.T) {
+	// 本地构造的错误原因超过深度上限时，编码时截断
+	src := &Error{Code: 0, Message: "0"}
+	for i := 1; i <= maxErrorCauseDepth*2; i++ {
+		src = &Error{Code: int32(i), Message: fmt.Sprint(i), Cause: src}
+	}
+
+	var decoded Error
+	if _, err := decoded.Write(encodeError(t, src)); err != nil {
+		t.Fatalf("decode failed, %s", err)
+	}
+
+	depth := 0
+	for cause := decoded.Cause; cause != nil; cause = cause.Cause {
+		depth++
+	}
+	if depth != maxErrorCauseDepth {
+		t.Fatalf("got cause depth %d, want %d", depth, maxErrorCauseDepth)
+	}
+}
+
+func TestErrorCauseTooDeep(t *testing.T) {
+	leaf := encodeError(t, &Error{Code: 1, Message: "x"})
+
+	// 构造错误原因嵌套过深的恶意数据，将末尾的无错误原因标记改为有错误原因
+	prefix := bytes.Clone(leaf)
+	prefix[len(prefix)-1] = 1
+
+	for _, depth := range []int{maxErrorCauseDepth, maxErrorCauseDepth + 1, 10000} {
+		data := append(bytes.Repeat(prefix, depth), leaf...)
+
+		var decoded Error
+		_, err := decoded.Write(data)
+
+		if depth <= maxErrorCauseDepth {
+			if err != nil {
+				t.Fatalf("depth %d decode failed, %s", depth, err)
+			}
+		} else if !errors.Is(err, ErrErrorCauseTooDeep) {
+			t.Fatalf("depth %d got decode error %v, want %v", depth, err, ErrErrorCauseTooDeep)
+		}
+	}
+}
+
+func TestErrorDetailsNested(t *testing.T) {
+	makeDetails := func(value any) Map {
+		k, err := CastReadonlyVariant("key")
+		if err != nil {
+			t.Fatalf("cast failed, %s", err)
+		}
+		v, err := CastReadonlyVariant(value)
+		if err != nil {
+			t.Fatalf("cast failed, %s", err)
+		}
+		details := Map{}
+		details.ToUnorderedSliceMap().Add(k, v)
+		return details
+	}
+
+	// 错误详情中的数组与map不包含错误时正常解码
+	src := &Error{Code: 1, Message: "outer", Details: makeDetails([]any{"a", map[string]int{"b": 1}})}
+
+	var decoded Error
+	if _, err := decoded.Write(encodeError(t, src)); err != nil {
+		t.Fatalf("decode failed, %s", err)
+	}
+	if len(decoded.Details) != 1 || decoded.Details[0].V.TypeId != TypeId_Array {
+		t.Fatalf("got details %+v, want nested array", decoded.Details)
+	}
+
+	// 错误详情中直接或在数组、map中嵌套错误时，解码返回错误
+	inner := &Error{Code: 2, Message: "inner", Cause: &Error{Code: 3, Message: "cause"}}
+
+	for _, value := range []any{inner, []any{"a", inner}, map[string]any{"b": []any{inner}}} {
+		src := &Error{Code: 1, Message: "outer", Details: makeDetails(value)}
+
+		var decoded Error
+		if _, err := decoded.Write(encodeError(t, src)); !errors.Is(err, ErrErrorInDetails) {
+			t.Fatalf("details %v got decode error %v, want %v", value, err, ErrErrorInDetails)
+		}
+	}
+
+	// 构造错误详情中反复嵌套错误的恶意数据，在第一层返回错误，不会重新从深度0开始解码
+	nested := &Error{Code: 1, Message: "x"}
+	for range 64 {
+		nested = &Error{Code: 1, Message: "x", Details: makeDetails(nested)}
+	}
+
+	if _, err := decoded.Write(encodeError(t, nested)); !errors.Is(err, ErrErrorInDetails) {
+		t.Fatalf("got decode error %v, want %v", err, ErrErrorInDetails)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package variant
+
+import (
+	"git.golaxy.org/framework/utils/binaryutil"
+	"io"
+	"time"
+)
+
+// Time builtin time.Time
+type Time time.Time
+
+// Read implements io.Reader
+func (v Time) Read(p []byte) (int, error) {
+	t := time.Time(v)
+	bs := binaryutil.NewBigEndianStream(p)
+	if err := bs.WriteVarint(t.Unix()); err != nil {
+		return bs.BytesWritten(), err
+	}
+	if err := bs.WriteUvarint(uint64(t.Nanosecond())); err != nil {
+		return bs.BytesWritten(), err
+	}
+	return bs.BytesWritten(), io.EOF
+}
+
+// Write implements io.Writer
+func (v *Time) Write(p []byte) (int, error) {
+	bs := binaryutil.NewBigEndianStream(p)
+	sec, err := bs.ReadVarint()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+	nsec, err := bs.ReadUvarint()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+	*v = Time(time.Unix(sec, int64(nsec)))
+	return bs.BytesRead(), nil
+}
+
+// Size 大小
+func (v Time) Size() int {
+	t := time.Time(v)
+	return binaryutil.SizeofVarint(t.Unix()) + binaryutil.SizeofUvarint(uint64(t.Nanosecond()))
+}
+
+// TypeId 类型
+func (Time) TypeId() TypeId {
+	return TypeId_Time
+}
+
+// Indirect 原始值
+func (v Time) Indirect() any {
+	return time.Time(v)
+}
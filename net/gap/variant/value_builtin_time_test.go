@@ -0,0 +1,120 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package variant
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimeRoundTrip(t *testing.T) {
+	times := []time.Time{
+		time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC),
+		time.Date(1960, 1, 2, 3, 4, 5, 6, time.UTC),
+		{},
+	}
+
+	for _, tm := range times {
+		for _, cast := range []func(a any) (Variant, error){CastReadonlyVariant, CastSerializedVariant} {
+			v, err := cast(tm)
+			if err != nil {
+				t.Fatalf("cast %v failed, %s", tm, err)
+			}
+
+			decoded := roundTrip(t, v)
+			if decoded.TypeId != TypeId_Time {
+				t.Fatalf("got type id %d, want %d", decoded.TypeId, TypeId_Time)
+			}
+
+			// 时间精确到纳秒，时区不参与编码
+			if got := decoded.Value.Indirect().(time.Time); !got.Equal(tm) {
+				t.Fatalf("got %v, want %v", got, tm)
+			}
+
+			rv, err := decoded.Convert(reflect.TypeFor[time.Time]())
+			if err != nil {
+				t.Fatalf("convert failed, %s", err)
+			}
+			if got := rv.Interface().(time.Time); !got.Equal(tm) {
+				t.Fatalf("got converted %v, want %v", got, tm)
+			}
+		}
+	}
+}
+
+func TestDurationRoundTrip(t *testing.T) {
+	durations := []time.Duration{0, time.Nanosecond, 90 * time.Minute, -time.Second, time.Duration(1<<63 - 1)}
+
+	for _, d := range durations {
+		for _, cast := range []func(a any) (Variant, error){CastReadonlyVariant, CastSerializedVariant} {
+			v, err := cast(d)
+			if err != nil {
+				t.Fatalf("cast %v failed, %s", d, err)
+			}
+
+			decoded := roundTrip(t, v)
+			if decoded.TypeId != TypeId_Duration {
+				t.Fatalf("got type id %d, want %d", decoded.TypeId, TypeId_Duration)
+			}
+			if got := decoded.Value.Indirect().(time.Duration); got != d {
+				t.Fatalf("got %v, want %v", got, d)
+			}
+
+			rv, err := decoded.Convert(reflect.TypeFor[time.Duration]())
+			if err != nil {
+				t.Fatalf("convert failed, %s", err)
+			}
+			if got := rv.Interface().(time.Duration); got != d {
+				t.Fatalf("got converted %v, want %v", got, d)
+			}
+		}
+	}
+}
+
+func TestTimeConvertFromString(t *testing.T) {
+	tm := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	v, err := CastReadonlyVariant(tm.Format(time.RFC3339Nano))
+	if err != nil {
+		t.Fatalf("cast failed, %s", err)
+	}
+
+	rv, err := roundTrip(t, v).Convert(reflect.TypeFor[time.Time]())
+	if err != nil {
+		t.Fatalf("convert failed, %s", err)
+	}
+	if got := rv.Interface().(time.Time); !got.Equal(tm) {
+		t.Fatalf("got %v, want %v", got, tm)
+	}
+
+	v, err = CastReadonlyVariant("1m30s")
+	if err != nil {
+		t.Fatalf("cast failed, %s", err)
+	}
+
+	rv, err = roundTrip(t, v).Convert(reflect.TypeFor[time.Duration]())
+	if err != nil {
+		t.Fatalf("convert failed, %s", err)
+	}
+	if got := rv.Interface().(time.Duration); got != 90*time.Second {
+		t.Fatalf("got %v, want %v", got, 90*time.Second)
+	}
+}
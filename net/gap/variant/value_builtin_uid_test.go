@@ -0,0 +1,53 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package variant
+
+import (
+	"git.golaxy.org/core/utils/uid"
+	"reflect"
+	"testing"
+)
+
+func TestUidRoundTrip(t *testing.T) {
+	for _, id := range []uid.Id{"", "c8r0ds9qgbfsbvbvdh40"} {
+		for _, cast := range []func(a any) (Variant, error){CastReadonlyVariant, CastSerializedVariant} {
+			v, err := cast(id)
+			if err != nil {
+				t.Fatalf("cast %q failed, %s", id, err)
+			}
+
+			decoded := roundTrip(t, v)
+			if decoded.TypeId != TypeId_Uid {
+				t.Fatalf("got type id %d, want %d", decoded.TypeId, TypeId_Uid)
+			}
+			if got := decoded.Value.Indirect().(uid.Id); got != id {
+				t.Fatalf("got %q, want %q", got, id)
+			}
+
+			rv, err := decoded.Convert(reflect.TypeFor[uid.Id]())
+			if err != nil {
+				t.Fatalf("convert failed, %s", err)
+			}
+			if got := rv.Interface().(uid.Id); got != id {
+				t.Fatalf("got converted %q, want %q", got, id)
+			}
+		}
+	}
+}
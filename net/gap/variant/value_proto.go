@@ -0,0 +1,121 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package variant
+
+import (
+	"git.golaxy.org/core/utils/types"
+	"git.golaxy.org/framework/utils/binaryutil"
+	"google.golang.org/protobuf/proto"
+	"hash/fnv"
+	"io"
+	"math"
+)
+
+// TypeId_ProtoBegin protobuf消息类型Id起点，之前的区间保留给手动指定的自定义类型Id
+const TypeId_ProtoBegin TypeId = TypeId_Customize + 1<<16
+
+// MakeProtoTypeId 使用protobuf消息全名创建类型Id，类型Id位于TypeId_ProtoBegin开始的区间
+func MakeProtoTypeId(msg proto.Message) TypeId {
+	hash := fnv.New32a()
+	hash.Write([]byte(msg.ProtoReflect().Descriptor().FullName()))
+	return TypeId_ProtoBegin + TypeId(hash.Sum32()%uint32(math.MaxUint32-TypeId_ProtoBegin+1))
+}
+
+// Proto protobuf消息，T需要为生成的消息指针类型，使用前需要调用VariantCreator().Declare(&Proto[T]{})注册，类型Id冲突时注册返回错误
+type Proto[T proto.Message] struct {
+	Message T
+}
+
+// Read implements io.Reader
+func (v Proto[T]) Read(p []byte) (int, error) {
+	data, err := proto.Marshal(v.Message)
+	if err != nil {
+		return 0, err
+	}
+
+	bs := binaryutil.NewBigEndianStream(p)
+	if err := bs.WriteBytes(data); err != nil {
+		return bs.BytesWritten(), err
+	}
+
+	return bs.BytesWritten(), io.EOF
+}
+
+// Write implements io.Writer
+func (v *Proto[T]) Write(p []byte) (int, error) {
+	bs := binaryutil.NewBigEndianStream(p)
+
+	data, err := bs.ReadBytesRef()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+
+	msg := v.Message.ProtoReflect().Type().New().Interface().(T)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return bs.BytesRead(), err
+	}
+	v.Message = msg
+
+	return bs.BytesRead(), nil
+}
+
+// Size 大小
+func (v Proto[T]) Size() int {
+	n := proto.Size(v.Message)
+	return binaryutil.SizeofUvarint(uint64(n)) + n
+}
+
+// TypeId 类型
+func (Proto[T]) TypeId() TypeId {
+	return MakeProtoTypeId(types.ZeroT[T]())
+}
+
+// Indirect 原始值
+func (v Proto[T]) Indirect() any {
+	return v.Message
+}
+
+func (v *Proto[T]) setMessage(msg proto.Message) bool {
+	m, ok := msg.(T)
+	if ok {
+		v.Message = m
+	}
+	return ok
+}
+
+type iProtoValue interface {
+	Value
+	setMessage(msg proto.Message) bool
+}
+
+// castProto 使用已注册的Proto类型包装protobuf消息
+func castProto(msg proto.Message) (ValueReader, error) {
+	v, err := variantCreator.New(MakeProtoTypeId(msg))
+	if err != nil {
+		return nil, err
+	}
+
+	pv, ok := v.(iProtoValue)
+	if !ok || !pv.setMessage(msg) {
+		return nil, ErrInvalidCast
+	}
+
+	return pv, nil
+}
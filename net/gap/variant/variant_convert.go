@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"git.golaxy.org/core/utils/generic"
 	"reflect"
+	"time"
 )
 
 var (
@@ -37,9 +38,17 @@ var (
 	unorderedSliceMapStringAnyRT = reflect.TypeFor[generic.UnorderedSliceMap[string, any]]()
 	rvRT                         = reflect.TypeFor[reflect.Value]()
 	variantRT                    = reflect.TypeFor[Variant]()
+	timeRT                       = reflect.TypeFor[time.Time]()
+	durationRT                   = reflect.TypeFor[time.Duration]()
 )
 
+// Convert 转换为指定类型
 func (v Variant) Convert(valueRT reflect.Type) (reflect.Value, error) {
+	return v.convert(valueRT, 0)
+}
+
+// convert 转换为指定类型，depth为反射转换的嵌套深度
+func (v Variant) convert(valueRT reflect.Type, depth int) (reflect.Value, error) {
 	if !v.Reflected.IsValid() {
 		return reflect.Value{}, ErrInvalidCast
 	}
@@ -67,6 +76,14 @@ func (v Variant) Convert(valueRT reflect.Type) (reflect.Value, error) {
 		}
 	}
 
+	// 原始值可以直接赋值时，使用原始值，例如protobuf消息
+	if v.Value != nil {
+		indirectRV := reflect.ValueOf(v.Value.Indirect())
+		if indirectRV.IsValid() && indirectRV.Type().AssignableTo(valueRT) {
+			return indirectRV, nil
+		}
+	}
+
 	switch valueRT.Kind() {
 	case reflect.Array, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice:
 		if v.TypeId == TypeId_Null {
@@ -186,7 +203,37 @@ func (v Variant) Convert(valueRT reflect.Type) (reflect.Value, error) {
 		} else {
 			return rv, nil
 		}
+
+	case timeRT, reflect.PointerTo(timeRT):
+		switch v.TypeId {
+		case TypeId_String:
+			rv, err := time.Parse(time.RFC3339Nano, v.Value.Indirect().(string))
+			if err != nil {
+				return reflect.Value{}, ErrInvalidCast
+			}
+
+			if valueRT.Kind() == reflect.Pointer {
+				return reflect.ValueOf(&rv), nil
+			} else {
+				return reflect.ValueOf(rv), nil
+			}
+		}
+
+	case durationRT, reflect.PointerTo(durationRT):
+		switch v.TypeId {
+		case TypeId_String:
+			rv, err := time.ParseDuration(v.Value.Indirect().(string))
+			if err != nil {
+				return reflect.Value{}, ErrInvalidCast
+			}
+
+			if valueRT.Kind() == reflect.Pointer {
+				return reflect.ValueOf(&rv), nil
+			} else {
+				return reflect.ValueOf(rv), nil
+			}
+		}
 	}
 
-	return reflect.Value{}, ErrInvalidCast
+	return v.convertReflected(valueRT, depth)
 }
@@ -22,11 +22,18 @@ package variant
 import (
 	"git.golaxy.org/core/utils/generic"
 	"git.golaxy.org/core/utils/uid"
+	"google.golang.org/protobuf/proto"
 	"reflect"
+	"time"
 )
 
 // CastReadonlyVariant 转换只读可变类型
 func CastReadonlyVariant(a any) (Variant, error) {
+	return castReadonlyVariant(a, 0)
+}
+
+// castReadonlyVariant 转换只读可变类型，depth为反射转换的嵌套深度
+func castReadonlyVariant(a any, depth int) (Variant, error) {
 retry:
 	switch v := a.(type) {
 	case int:
@@ -90,9 +97,17 @@ retry:
 	case *string:
 		return MakeReadonlyVariant((*String)(v))
 	case uid.Id:
-		return MakeReadonlyVariant(String(v))
+		return MakeReadonlyVariant(Uid(v))
 	case *uid.Id:
-		return MakeReadonlyVariant((*String)(v))
+		return MakeReadonlyVariant((*Uid)(v))
+	case time.Time:
+		return MakeReadonlyVariant(Time(v))
+	case *time.Time:
+		return MakeReadonlyVariant((*Time)(v))
+	case time.Duration:
+		return MakeReadonlyVariant(Duration(v))
+	case *time.Duration:
+		return MakeReadonlyVariant((*Duration)(v))
 	case nil:
 		return MakeReadonlyVariant(Null{})
 	case Array:
@@ -173,6 +188,12 @@ retry:
 		return MakeReadonlyVariant(v)
 	case *CallChain:
 		return MakeReadonlyVariant(*v)
+	case proto.Message:
+		pv, err := castProto(v)
+		if err != nil {
+			return Variant{}, err
+		}
+		return MakeReadonlyVariant(pv)
 	case reflect.Value:
 		if !v.CanInterface() {
 			return Variant{}, ErrInvalidCast
@@ -192,6 +213,6 @@ retry:
 	case ValueReader:
 		return MakeReadonlyVariant(v)
 	default:
-		return Variant{}, ErrInvalidCast
+		return castReflected(reflect.ValueOf(a), depth, castReadonlyVariant, MakeReadonlyVariant)
 	}
 }
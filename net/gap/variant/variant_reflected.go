@@ -0,0 +1,362 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package variant
+
+import (
+	"fmt"
+	"git.golaxy.org/framework/utils/concurrent"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrReflectedTooDeep = fmt.Errorf("%w: reflected value nested too deep", ErrInvalidCast) // 反射转换的嵌套深度超过上限
+)
+
+// maxReflectedDepth 反射转换的最大嵌套深度，避免循环引用的结构体导致栈溢出
+const maxReflectedDepth = 32
+
+// structCodecs 结构体编解码信息缓存
+var structCodecs = concurrent.MakeLockedMap[reflect.Type, *_StructCodec](0)
+
+type _StructField struct {
+	index     []int
+	name      string
+	rtype     reflect.Type
+	omitEmpty bool
+}
+
+// _StructCodec 结构体编解码信息，结构体编码为以字段名为key的map，字段名可以使用`variant:"name,omitempty"`标签修改，使用`variant:"-"`忽略字段
+type _StructCodec struct {
+	fields []_StructField
+	names  map[string]int
+}
+
+func structCodecOf(rt reflect.Type) *_StructCodec {
+	if codec, ok := structCodecs.Get(rt); ok {
+		return codec
+	}
+
+	codec := &_StructCodec{
+		names: map[string]int{},
+	}
+
+	for _, field := range reflect.VisibleFields(rt) {
+		if !field.IsExported() || throughPointer(rt, field.Index) {
+			continue
+		}
+
+		tag, tagged := field.Tag.Lookup("variant")
+		if tag == "-" {
+			continue
+		}
+
+		// 未设置标签的嵌入结构体，字段已经提升，不需要处理
+		if field.Anonymous && !tagged {
+			fieldRT := field.Type
+			if fieldRT.Kind() == reflect.Pointer {
+				fieldRT = fieldRT.Elem()
+			}
+			if fieldRT.Kind() == reflect.Struct {
+				continue
+			}
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		if _, ok := codec.names[name]; ok {
+			continue
+		}
+
+		codec.names[name] = len(codec.fields)
+		codec.fields = append(codec.fields, _StructField{
+			index:     field.Index,
+			name:      name,
+			rtype:     field.Type,
+			omitEmpty: strings.Contains(opts, "omitempty"),
+		})
+	}
+
+	structCodecs.Add(rt, codec)
+	return codec
+}
+
+// throughPointer 字段是否提升自嵌入的结构体指针
+func throughPointer(rt reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		rt = rt.Field(i).Type
+		if rt.Kind() == reflect.Pointer {
+			return true
+		}
+	}
+	return false
+}
+
+// castReflected 使用反射转换自定义类型，结构体转换为map，切片与数组转换为array，map转换为map，嵌套深度超过上限时返回错误
+func castReflected(rv reflect.Value, depth int, cast func(a any, depth int) (Variant, error), makeVariant func(ValueReader) (Variant, error)) (Variant, error) {
+	if depth >= maxReflectedDepth {
+		return Variant{}, ErrReflectedTooDeep
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		return makeVariant(Bool(rv.Bool()))
+	case reflect.Int:
+		return makeVariant(Int(rv.Int()))
+	case reflect.Int8:
+		return makeVariant(Int8(rv.Int()))
+	case reflect.Int16:
+		return makeVariant(Int16(rv.Int()))
+	case reflect.Int32:
+		return makeVariant(Int32(rv.Int()))
+	case reflect.Int64:
+		return makeVariant(Int64(rv.Int()))
+	case reflect.Uint:
+		return makeVariant(Uint(rv.Uint()))
+	case reflect.Uint8:
+		return makeVariant(Uint8(rv.Uint()))
+	case reflect.Uint16:
+		return makeVariant(Uint16(rv.Uint()))
+	case reflect.Uint32:
+		return makeVariant(Uint32(rv.Uint()))
+	case reflect.Uint64:
+		return makeVariant(Uint64(rv.Uint()))
+	case reflect.Float32:
+		return makeVariant(Float(rv.Float()))
+	case reflect.Float64:
+		return makeVariant(Double(rv.Float()))
+	case reflect.String:
+		return makeVariant(String(rv.String()))
+
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return makeVariant(Null{})
+		}
+		return cast(rv.Elem().Interface(), depth+1)
+
+	case reflect.Struct:
+		codec := structCodecOf(rv.Type())
+
+		m := make(Map, 0, len(codec.fields))
+		for i := range codec.fields {
+			field := &codec.fields[i]
+
+			fieldRV := rv.FieldByIndex(field.index)
+			if field.omitEmpty && fieldRV.IsZero() {
+				continue
+			}
+
+			k, err := cast(field.name, depth+1)
+			if err != nil {
+				m.Release()
+				return Variant{}, err
+			}
+
+			v, err := cast(fieldRV.Interface(), depth+1)
+			if err != nil {
+				m.Release()
+				return Variant{}, err
+			}
+
+			m.ToUnorderedSliceMap().Add(k, v)
+		}
+
+		ret, err := makeVariant(m)
+		if err != nil {
+			m.Release()
+		}
+		return ret, err
+
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice {
+			if rv.IsNil() {
+				return makeVariant(Null{})
+			}
+			if rv.Type().Elem().Kind() == reflect.Uint8 {
+				return makeVariant(Bytes(rv.Bytes()))
+			}
+		}
+
+		arr := make(Array, 0, rv.Len())
+		for i := range rv.Len() {
+			v, err := cast(rv.Index(i).Interface(), depth+1)
+			if err != nil {
+				arr.Release()
+				return Variant{}, err
+			}
+			arr = append(arr, v)
+		}
+
+		ret, err := makeVariant(arr)
+		if err != nil {
+			arr.Release()
+		}
+		return ret, err
+
+	case reflect.Map:
+		if rv.IsNil() {
+			return makeVariant(Null{})
+		}
+
+		m := make(Map, 0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			k, err := cast(iter.Key().Interface(), depth+1)
+			if err != nil {
+				m.Release()
+				return Variant{}, err
+			}
+
+			v, err := cast(iter.Value().Interface(), depth+1)
+			if err != nil {
+				m.Release()
+				return Variant{}, err
+			}
+
+			m.ToUnorderedSliceMap().Add(k, v)
+		}
+
+		ret, err := makeVariant(m)
+		if err != nil {
+			m.Release()
+		}
+		return ret, err
+	}
+
+	return Variant{}, ErrInvalidCast
+}
+
+// convertReflected 使用反射转换自定义类型，map转换为结构体或map，array转换为切片或数组，嵌套深度超过上限时返回错误
+func (v Variant) convertReflected(valueRT reflect.Type, depth int) (reflect.Value, error) {
+	if depth >= maxReflectedDepth {
+		return reflect.Value{}, ErrReflectedTooDeep
+	}
+
+	switch valueRT.Kind() {
+	case reflect.Pointer:
+		elemRV, err := v.convert(valueRT.Elem(), depth+1)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptrRV := reflect.New(valueRT.Elem())
+		ptrRV.Elem().Set(elemRV)
+		return ptrRV, nil
+
+	case reflect.Struct:
+		if v.TypeId != TypeId_Map {
+			return reflect.Value{}, ErrInvalidCast
+		}
+
+		codec := structCodecOf(valueRT)
+		structRV := reflect.New(valueRT).Elem()
+
+		for _, kv := range *v.Value.(*Map).ToUnorderedSliceMap() {
+			if kv.K.TypeId != TypeId_String {
+				return reflect.Value{}, ErrInvalidCast
+			}
+
+			// 忽略不存在的字段，兼容结构体的版本差异
+			idx, ok := codec.names[kv.K.Value.Indirect().(string)]
+			if !ok {
+				continue
+			}
+			field := &codec.fields[idx]
+
+			fieldRV, err := convertElem(kv.V, field.rtype, depth+1)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			structRV.FieldByIndex(field.index).Set(fieldRV)
+		}
+
+		return structRV, nil
+
+	case reflect.Slice, reflect.Array:
+		if v.TypeId != TypeId_Array {
+			return reflect.Value{}, ErrInvalidCast
+		}
+
+		arr := *v.Value.(*Array)
+
+		var arrRV reflect.Value
+		if valueRT.Kind() == reflect.Slice {
+			arrRV = reflect.MakeSlice(valueRT, len(arr), len(arr))
+		} else {
+			if len(arr) > valueRT.Len() {
+				return reflect.Value{}, ErrInvalidCast
+			}
+			arrRV = reflect.New(valueRT).Elem()
+		}
+
+		for i := range arr {
+			elemRV, err := convertElem(arr[i], valueRT.Elem(), depth+1)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			arrRV.Index(i).Set(elemRV)
+		}
+
+		return arrRV, nil
+
+	case reflect.Map:
+		if v.TypeId != TypeId_Map {
+			return reflect.Value{}, ErrInvalidCast
+		}
+
+		m := *v.Value.(*Map).ToUnorderedSliceMap()
+		mapRV := reflect.MakeMapWithSize(valueRT, len(m))
+
+		for _, kv := range m {
+			keyRV, err := convertElem(kv.K, valueRT.Key(), depth+1)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+
+			elemRV, err := convertElem(kv.V, valueRT.Elem(), depth+1)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+
+			mapRV.SetMapIndex(keyRV, elemRV)
+		}
+
+		return mapRV, nil
+	}
+
+	return reflect.Value{}, ErrInvalidCast
+}
+
+// convertElem 转换元素，元素类型为接口时，使用原始值
+func convertElem(v Variant, valueRT reflect.Type, depth int) (reflect.Value, error) {
+	if valueRT.Kind() == reflect.Interface && v.Value != nil {
+		indirect := v.Value.Indirect()
+		if indirect == nil {
+			return reflect.Zero(valueRT), nil
+		}
+		indirectRV := reflect.ValueOf(indirect)
+		if indirectRV.Type().AssignableTo(valueRT) {
+			return indirectRV, nil
+		}
+	}
+	return v.convert(valueRT, depth)
+}
@@ -0,0 +1,173 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package variant
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type _Inner struct {
+	Name string
+	Tags []string
+}
+
+type _Outer struct {
+	Id      int64
+	Title   string `variant:"title"`
+	Hidden  string `variant:"-"`
+	Empty   string `variant:",omitempty"`
+	Inner   *_Inner
+	Scores  map[string]int32
+	private int
+}
+
+type _Node struct {
+	Name string
+	Next *_Node
+}
+
+// roundTrip 编码后解码可变类型
+func roundTrip(t *testing.T, v Variant) Variant {
+	t.Helper()
+
+	buf := make([]byte, v.Size())
+	if n, err := v.Read(buf); !errors.Is(err, io.EOF) || n != len(buf) {
+		t.Fatalf("encode returned %d, %v, want %d, EOF", n, err, len(buf))
+	}
+
+	var decoded Variant
+	if _, err := decoded.Write(buf); err != nil {
+		t.Fatalf("decode failed, %s", err)
+	}
+	return decoded
+}
+
+func TestReflectedRoundTrip(t *testing.T) {
+	src := _Outer{
+		Id:      1,
+		Title:   "title",
+		Hidden:  "hidden",
+		Inner:   &_Inner{Name: "inner", Tags: []string{"a", "b"}},
+		Scores:  map[string]int32{"x": 1, "y": 2},
+		private: 1,
+	}
+
+	casts := map[string]func(a any) (Variant, error){
+		"readonly":   CastReadonlyVariant,
+		"serialized": CastSerializedVariant,
+	}
+
+	for name, cast := range casts {
+		v, err := cast(src)
+		if err != nil {
+			t.Fatalf("%s cast failed, %s", name, err)
+		}
+
+		rv, err := roundTrip(t, v).Convert(reflect.TypeFor[_Outer]())
+		if err != nil {
+			t.Fatalf("%s convert failed, %s", name, err)
+		}
+		dst := rv.Interface().(_Outer)
+
+		// 忽略的字段与未导出字段不参与编解码
+		if dst.Id != src.Id || dst.Title != src.Title || dst.Hidden != "" || dst.Empty != "" || dst.private != 0 {
+			t.Fatalf("%s got %+v, want %+v", name, dst, src)
+		}
+		if dst.Inner == nil || dst.Inner.Name != src.Inner.Name || !slices.Equal(dst.Inner.Tags, src.Inner.Tags) {
+			t.Fatalf("%s got inner %+v, want %+v", name, dst.Inner, src.Inner)
+		}
+		if !reflect.DeepEqual(dst.Scores, src.Scores) {
+			t.Fatalf("%s got scores %v, want %v", name, dst.Scores, src.Scores)
+		}
+	}
+}
+
+func TestReflectedOmitEmpty(t *testing.T) {
+	v, err := CastReadonlyVariant(_Outer{Empty: "empty"})
+	if err != nil {
+		t.Fatalf("cast failed, %s", err)
+	}
+	withEmpty := v.Size()
+
+	v, err = CastReadonlyVariant(_Outer{})
+	if err != nil {
+		t.Fatalf("cast failed, %s", err)
+	}
+	if v.Size() >= withEmpty {
+		t.Fatalf("got size %d, want less than %d", v.Size(), withEmpty)
+	}
+}
+
+func TestReflectedCycle(t *testing.T) {
+	n := &_Node{Name: "loop"}
+	n.Next = n
+
+	// 循环引用的结构体返回错误，不会导致栈溢出
+	if _, err := CastReadonlyVariant(n); !errors.Is(err, ErrReflectedTooDeep) {
+		t.Fatalf("got readonly cast error %v, want %v", err, ErrReflectedTooDeep)
+	}
+	if _, err := CastSerializedVariant(n); !errors.Is(err, ErrReflectedTooDeep) {
+		t.Fatalf("got serialized cast error %v, want %v", err, ErrReflectedTooDeep)
+	}
+
+	// 未超过深度上限的链表正常转换
+	var head *_Node
+	for range 4 {
+		head = &_Node{Name: "node", Next: head}
+	}
+
+	v, err := CastSerializedVariant(head)
+	if err != nil {
+		t.Fatalf("cast failed, %s", err)
+	}
+
+	rv, err := roundTrip(t, v).Convert(reflect.TypeFor[*_Node]())
+	if err != nil {
+		t.Fatalf("convert failed, %s", err)
+	}
+
+	count := 0
+	for node := rv.Interface().(*_Node); node != nil; node = node.Next {
+		count++
+	}
+	if count != 4 {
+		t.Fatalf("got %d nodes, want 4", count)
+	}
+}
+
+func TestReflectedConvertTooDeep(t *testing.T) {
+	name := String("Next")
+
+	// 构造嵌套层数超过上限的map，模拟恶意数据
+	v := Variant{TypeId: TypeId_Null, Value: &Null{}}
+	for range maxReflectedDepth {
+		m := Map{}
+		m.ToUnorderedSliceMap().Add(Variant{TypeId: TypeId_String, Value: &name}, v)
+		v = Variant{TypeId: TypeId_Map, Value: &m}
+	}
+
+	if _, err := roundTrip(t, v).Convert(reflect.TypeFor[*_Node]()); !errors.Is(err, ErrReflectedTooDeep) {
+		t.Fatalf("got convert error %v, want %v", err, ErrReflectedTooDeep)
+	}
+}
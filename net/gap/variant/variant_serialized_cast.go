@@ -22,11 +22,18 @@ package variant
 import (
 	"git.golaxy.org/core/utils/generic"
 	"git.golaxy.org/core/utils/uid"
+	"google.golang.org/protobuf/proto"
 	"reflect"
+	"time"
 )
 
 // CastSerializedVariant 转换已序列化可变类型
-func CastSerializedVariant(a any) (ret Variant, err error) {
+func CastSerializedVariant(a any) (Variant, error) {
+	return castSerializedVariant(a, 0)
+}
+
+// castSerializedVariant 转换已序列化可变类型，depth为反射转换的嵌套深度
+func castSerializedVariant(a any, depth int) (ret Variant, err error) {
 retry:
 	switch v := a.(type) {
 	case int:
@@ -90,9 +97,17 @@ retry:
 	case *string:
 		return MakeSerializedVariant((*String)(v))
 	case uid.Id:
-		return MakeSerializedVariant(String(v))
+		return MakeSerializedVariant(Uid(v))
 	case *uid.Id:
-		return MakeSerializedVariant((*String)(v))
+		return MakeSerializedVariant((*Uid)(v))
+	case time.Time:
+		return MakeSerializedVariant(Time(v))
+	case *time.Time:
+		return MakeSerializedVariant((*Time)(v))
+	case time.Duration:
+		return MakeSerializedVariant(Duration(v))
+	case *time.Duration:
+		return MakeSerializedVariant((*Duration)(v))
 	case nil:
 		return MakeSerializedVariant(Null{})
 	case Array:
@@ -223,6 +238,12 @@ retry:
 		return MakeSerializedVariant(v)
 	case *CallChain:
 		return MakeSerializedVariant(*v)
+	case proto.Message:
+		pv, err := castProto(v)
+		if err != nil {
+			return Variant{}, err
+		}
+		return MakeSerializedVariant(pv)
 	case reflect.Value:
 		if !v.CanInterface() {
 			return Variant{}, ErrInvalidCast
@@ -242,6 +263,6 @@ retry:
 	case ValueReader:
 		return MakeSerializedVariant(v)
 	default:
-		return Variant{}, ErrInvalidCast
+		return castReflected(reflect.ValueOf(a), depth, castSerializedVariant, MakeSerializedVariant)
 	}
 }
@@ -28,13 +28,14 @@ import (
 )
 
 var (
-	ErrNotDeclared = fmt.Errorf("%w: variant not declared", ErrVariant) // 类型未注册
+	ErrNotDeclared     = fmt.Errorf("%w: variant not declared", ErrVariant)      // 类型未注册
+	ErrTypeIdConflicts = fmt.Errorf("%w: variant type id conflicts", ErrVariant) // 类型Id与已注册的其他类型冲突
 )
 
 // IVariantCreator 可变类型对象构建器接口
 type IVariantCreator interface {
-	// Declare 注册类型
-	Declare(v Value)
+	// Declare 注册类型，重复注册相同类型时忽略，类型Id与已注册的其他类型冲突时返回错误
+	Declare(v Value) error
 	// Undeclare 取消注册类型
 	Undeclare(typeId TypeId)
 	// New 创建对象指针
@@ -51,27 +52,37 @@ func VariantCreator() IVariantCreator {
 }
 
 func init() {
-	VariantCreator().Declare(new(Int))
-	VariantCreator().Declare(new(Int8))
-	VariantCreator().Declare(new(Int16))
-	VariantCreator().Declare(new(Int32))
-	VariantCreator().Declare(new(Int64))
-	VariantCreator().Declare(new(Uint))
-	VariantCreator().Declare(new(Uint8))
-	VariantCreator().Declare(new(Uint16))
-	VariantCreator().Declare(new(Uint32))
-	VariantCreator().Declare(new(Uint64))
-	VariantCreator().Declare(new(Float))
-	VariantCreator().Declare(new(Double))
-	VariantCreator().Declare(new(Byte))
-	VariantCreator().Declare(new(Bool))
-	VariantCreator().Declare(new(Bytes))
-	VariantCreator().Declare(new(String))
-	VariantCreator().Declare(&Null{})
-	VariantCreator().Declare(&Map{})
-	VariantCreator().Declare(&Array{})
-	VariantCreator().Declare(&Error{})
-	VariantCreator().Declare(&CallChain{})
+	builtin := []Value{
+		new(Int),
+		new(Int8),
+		new(Int16),
+		new(Int32),
+		new(Int64),
+		new(Uint),
+		new(Uint8),
+		new(Uint16),
+		new(Uint32),
+		new(Uint64),
+		new(Float),
+		new(Double),
+		new(Byte),
+		new(Bool),
+		new(Bytes),
+		new(String),
+		&Null{},
+		&Map{},
+		&Array{},
+		&Error{},
+		&CallChain{},
+		&Time{},
+		new(Duration),
+		new(Uid),
+	}
+	for _, v := range builtin {
+		if err := VariantCreator().Declare(v); err != nil {
+			exception.Panic(err)
+		}
+	}
 }
 
 // _NewVariantCreator 创建可变类型对象构建器
@@ -86,14 +97,21 @@ type _VariantCreator struct {
 	variantTypeMap concurrent.LockedMap[TypeId, reflect.Type]
 }
 
-// Declare 注册类型
-func (c *_VariantCreator) Declare(v Value) {
+// Declare 注册类型，重复注册相同类型时忽略，类型Id与已注册的其他类型冲突时返回错误
+func (c *_VariantCreator) Declare(v Value) (err error) {
+	rtype := reflect.TypeOf(v).Elem()
+
 	c.variantTypeMap.AutoLock(func(m *map[TypeId]reflect.Type) {
-		if rtype, ok := (*m)[v.TypeId()]; ok {
-			exception.Panicf("%w: variant type(%d) has already been declared by %q", ErrVariant, v.TypeId(), types.FullNameRT(rtype))
+		if declared, ok := (*m)[v.TypeId()]; ok {
+			if declared != rtype {
+				err = fmt.Errorf("%w: type %q id(%d) has already been declared by %q", ErrTypeIdConflicts, types.FullNameRT(rtype), v.TypeId(), types.FullNameRT(declared))
+			}
+			return
 		}
-		(*m)[v.TypeId()] = reflect.TypeOf(v).Elem()
+		(*m)[v.TypeId()] = rtype
 	})
+
+	return err
 }
 
 // Undeclare 取消注册类型
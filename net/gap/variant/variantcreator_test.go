@@ -0,0 +1,98 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package variant
+
+import (
+	"errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"reflect"
+	"testing"
+)
+
+type _ConflictValue struct {
+	Int
+}
+
+func TestDeclare(t *testing.T) {
+	// 重复注册相同类型时忽略
+	if err := VariantCreator().Declare(new(Int)); err != nil {
+		t.Fatalf("redeclare failed, %s", err)
+	}
+
+	// 类型Id与已注册的其他类型冲突
+	if err := VariantCreator().Declare(&_ConflictValue{}); !errors.Is(err, ErrTypeIdConflicts) {
+		t.Fatalf("got declare error %v, want %v", err, ErrTypeIdConflicts)
+	}
+
+	v, err := VariantCreator().New(TypeId_Int)
+	if err != nil {
+		t.Fatalf("new failed, %s", err)
+	}
+	if _, ok := v.(*Int); !ok {
+		t.Fatalf("got %T, want *Int", v)
+	}
+}
+
+func TestProto(t *testing.T) {
+	typeId := MakeProtoTypeId(&wrapperspb.StringValue{})
+	if typeId < TypeId_ProtoBegin {
+		t.Fatalf("got type id %d, want at least %d", typeId, TypeId_ProtoBegin)
+	}
+	if typeId == MakeProtoTypeId(&wrapperspb.Int64Value{}) {
+		t.Fatalf("got same type id %d for different messages", typeId)
+	}
+
+	if err := VariantCreator().Declare(&Proto[*wrapperspb.StringValue]{}); err != nil {
+		t.Fatalf("declare failed, %s", err)
+	}
+	defer VariantCreator().Undeclare(typeId)
+
+	if err := VariantCreator().Declare(&Proto[*wrapperspb.StringValue]{}); err != nil {
+		t.Fatalf("redeclare failed, %s", err)
+	}
+
+	msg := wrapperspb.String("hello")
+
+	casts := map[string]func(a any) (Variant, error){
+		"readonly":   CastReadonlyVariant,
+		"serialized": CastSerializedVariant,
+	}
+
+	for name, cast := range casts {
+		v, err := cast(msg)
+		if err != nil {
+			t.Fatalf("%s cast failed, %s", name, err)
+		}
+
+		decoded := roundTrip(t, v)
+		if decoded.TypeId != typeId {
+			t.Fatalf("%s got type id %d, want %d", name, decoded.TypeId, typeId)
+		}
+
+		rv, err := decoded.Convert(reflect.TypeFor[*wrapperspb.StringValue]())
+		if err != nil {
+			t.Fatalf("%s convert failed, %s", name, err)
+		}
+		if !proto.Equal(rv.Interface().(*wrapperspb.StringValue), msg) {
+			t.Fatalf("%s got %v, want %v", name, rv.Interface(), msg)
+		}
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"git.golaxy.org/framework/net/gtp"
 	"git.golaxy.org/framework/net/gtp/method"
 	"git.golaxy.org/framework/utils/binaryutil"
+	"io"
 	"math"
 )
 
@@ -57,6 +58,7 @@ func NewCompressionModule(cs method.CompressionStream) ICompressionModule {
 // CompressionModule 压缩模块
 type CompressionModule struct {
 	CompressionStream method.CompressionStream // 压缩流
+	MaxOriginalSize   int                      // 解压缩后的最大长度（字节），<=0表示不限制
 }
 
 // Compress 压缩数据
@@ -137,10 +139,14 @@ func (m *CompressionModule) Uncompress(src []byte) (dst binaryutil.RecycleBytes,
 		return binaryutil.NilRecycleBytes, fmt.Errorf("%w: %w", ErrCompress, err)
 	}
 
-	if msgCompressed.OriginalSize >= math.MaxInt32 {
+	if msgCompressed.OriginalSize < 0 || msgCompressed.OriginalSize >= math.MaxInt32 {
 		return binaryutil.NilRecycleBytes, fmt.Errorf("%w: original size too large", ErrCompress)
 	}
 
+	if m.MaxOriginalSize > 0 && msgCompressed.OriginalSize > int64(m.MaxOriginalSize) {
+		return binaryutil.NilRecycleBytes, fmt.Errorf("%w: original size %d exceeds limit %d", ErrCompress, msgCompressed.OriginalSize, m.MaxOriginalSize)
+	}
+
 	buf := binaryutil.MakeRecycleBytes(int(msgCompressed.OriginalSize))
 	defer func() {
 		if !buf.Equal(dst) {
@@ -153,7 +159,7 @@ func (m *CompressionModule) Uncompress(src []byte) (dst binaryutil.RecycleBytes,
 		return binaryutil.NilRecycleBytes, fmt.Errorf("%w: %w", ErrCompress, err)
 	}
 
-	if _, err = binaryutil.CopyToBuff(buf.Data(), r); err != nil {
+	if _, err = io.ReadFull(r, buf.Data()); err != nil {
 		return binaryutil.NilRecycleBytes, fmt.Errorf("%w: %w", ErrCompress, err)
 	}
 
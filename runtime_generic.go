@@ -34,10 +34,12 @@ import (
 	"git.golaxy.org/framework/addins/dentr"
 	"git.golaxy.org/framework/addins/log"
 	"git.golaxy.org/framework/addins/log/zap_log"
+	"git.golaxy.org/framework/addins/metrics"
 	"git.golaxy.org/framework/addins/rpcstack"
 	etcdv3 "go.etcd.io/etcd/client/v3"
 	"go.uber.org/zap"
 	"sync"
+	"time"
 )
 
 type _RuntimeSettings struct {
@@ -101,6 +103,9 @@ func (r *RuntimeGeneric) generate(settings _RuntimeSettings) core.Runtime {
 	runGCBeginCB, _ := r.instance.(LifecycleRuntimeRunGCBegin)
 	runGCEndCB, _ := r.instance.(LifecycleRuntimeRunGCEnd)
 
+	// 帧更新开始时间，用于统计帧更新耗时
+	var frameUpdateBegin time.Time
+
 	rtCtx := runtime.NewContext(r.GetService(),
 		runtime.With.Context.InstanceFace(rtInstFace),
 		runtime.With.Context.Name(settings.Name),
@@ -139,6 +144,7 @@ func (r *RuntimeGeneric) generate(settings _RuntimeSettings) core.Runtime {
 					cb.FrameLoopBegin(rtInst)
 				}
 			case runtime.RunningStatus_FrameUpdateBegin:
+				frameUpdateBegin = time.Now()
 				if cb := frameUpdateBeginCB; cb != nil {
 					cb.FrameUpdateBegin(rtInst)
 				}
@@ -152,6 +158,9 @@ func (r *RuntimeGeneric) generate(settings _RuntimeSettings) core.Runtime {
 				if cb := rtInstFrameUpdateEndCB; cb != nil {
 					cb.FrameUpdateEnd(rtInst)
 				}
+				if m, ok := metrics.Lookup(r.svcInst); ok {
+					m.ObserveFrame(settings.Name, time.Since(frameUpdateBegin))
+				}
 			case runtime.RunningStatus_FrameLoopEnd:
 				if cb := frameLoopEndCB; cb != nil {
 					cb.FrameLoopEnd(rtInst)
@@ -246,6 +255,8 @@ func (r *RuntimeGeneric) generate(settings _RuntimeSettings) core.Runtime {
 		return ok
 	}
 
+	installer, _ := r.svcInst.GetMemKV().Load("startup.installer")
+
 	// 安装日志插件
 	if !installed(log.Name) {
 		if cb, ok := rtInst.(InstallRuntimeLogger); ok {
@@ -257,6 +268,11 @@ func (r *RuntimeGeneric) generate(settings _RuntimeSettings) core.Runtime {
 			cb.InstallLogger(rtInst)
 		}
 	}
+	if !installed(log.Name) {
+		if cb, ok := installer.(InstallRuntimeLogger); ok {
+			cb.InstallLogger(rtInst)
+		}
+	}
 	if !installed(log.Name) {
 		if v, _ := r.svcInst.GetMemKV().Load("zap.logger"); v != nil {
 			zap_log.Install(rtInst,
@@ -279,7 +295,16 @@ func (r *RuntimeGeneric) generate(settings _RuntimeSettings) core.Runtime {
 		}
 	}
 	if !installed(rpcstack.Name) {
-		rpcstack.Install(rtInst)
+		if cb, ok := installer.(InstallRuntimeRPCStack); ok {
+			cb.InstallRPCStack(rtInst)
+		}
+	}
+	if !installed(rpcstack.Name) {
+		rpcstack.Install(rtInst,
+			rpcstack.With.BaggageKeys(wholeConf.GetStringSlice("rpcstack.baggage_keys")...),
+			rpcstack.With.BaggageMaxEntries(wholeConf.GetInt("rpcstack.baggage_max_entries")),
+			rpcstack.With.BaggageMaxSize(wholeConf.GetInt("rpcstack.baggage_max_size")),
+		)
 	}
 
 	// 安装分布式实体支持插件
@@ -293,6 +318,11 @@ func (r *RuntimeGeneric) generate(settings _RuntimeSettings) core.Runtime {
 			cb.InstallDistEntityRegistry(rtInst)
 		}
 	}
+	if !installed(dentr.Name) {
+		if cb, ok := installer.(InstallRuntimeDistEntityRegistry); ok {
+			cb.InstallDistEntityRegistry(rtInst)
+		}
+	}
 	if !installed(dentr.Name) {
 		v, _ := r.GetService().GetMemKV().Load("etcd.lazy_conn")
 		fun, _ := v.(func() *etcdv3.Client)
@@ -314,6 +344,9 @@ func (r *RuntimeGeneric) generate(settings _RuntimeSettings) core.Runtime {
 	if cb, ok := rtInst.(LifecycleRuntimeBuilt); ok {
 		cb.Built(rtInst)
 	}
+	if cb, ok := installer.(LifecycleRuntimeBuilt); ok {
+		cb.Built(rtInst)
+	}
 
 	// 订阅实体管理器的相关事件，用于缓存实体动态添加的组件的调用路径
 	runtime.BindEventEntityManagerAddEntity(rtInst.GetEntityManager(), r.handleEntityManagerAddEntity, -10)
@@ -41,7 +41,10 @@ import (
 	"git.golaxy.org/framework/addins/dsync/etcd_dsync"
 	"git.golaxy.org/framework/addins/log"
 	"git.golaxy.org/framework/addins/log/zap_log"
+	"git.golaxy.org/framework/addins/metrics"
 	"git.golaxy.org/framework/addins/rpc"
+	"git.golaxy.org/framework/addins/tracer"
+	"git.golaxy.org/framework/utils/tracing"
 	"github.com/spf13/viper"
 	etcdv3 "go.etcd.io/etcd/client/v3"
 	"go.uber.org/zap"
@@ -53,7 +56,7 @@ import (
 )
 
 type iServiceGeneric interface {
-	init(startupConf *viper.Viper, name string, instance any)
+	init(app *App, name string, instance any)
 	generate(ctx context.Context, no int) core.Service
 }
 
@@ -61,13 +64,15 @@ type iServiceGeneric interface {
 type ServiceGeneric struct {
 	once        sync.Once
 	startupConf *viper.Viper
+	installer   any
 	name        string
 	instance    any
 }
 
-func (s *ServiceGeneric) init(startupConf *viper.Viper, name string, instance any) {
+func (s *ServiceGeneric) init(app *App, name string, instance any) {
 	s.once.Do(func() {
-		s.startupConf = startupConf
+		s.startupConf = app.startupConf
+		s.installer = app.installer
 		s.name = name
 		s.instance = instance
 	})
@@ -79,6 +84,9 @@ func (s *ServiceGeneric) generate(ctx context.Context, no int) core.Service {
 	memKV := &sync.Map{}
 	memKV.Store("startup.no", no)
 	memKV.Store("startup.conf", startupConf)
+	if s.installer != nil {
+		memKV.Store("startup.installer", s.installer)
+	}
 
 	ctx = context.WithValue(ctx, "mem_kv", memKV)
 
@@ -239,6 +247,11 @@ func (s *ServiceGeneric) generate(ctx context.Context, no int) core.Service {
 			cb.InstallLogger(svcInst)
 		}
 	}
+	if !installed(log.Name) {
+		if cb, ok := s.installer.(InstallServiceLogger); ok {
+			cb.InstallLogger(svcInst)
+		}
+	}
 	if !installed(log.Name) {
 		level, err := zapcore.ParseLevel(startupConf.GetString("log.level"))
 		if err != nil {
@@ -294,6 +307,11 @@ func (s *ServiceGeneric) generate(ctx context.Context, no int) core.Service {
 			cb.InstallConfig(svcInst)
 		}
 	}
+	if !installed(conf.Name) {
+		if cb, ok := s.installer.(InstallServiceConfig); ok {
+			cb.InstallConfig(svcInst)
+		}
+	}
 	if !installed(conf.Name) {
 		conf.Install(svcInst,
 			conf.With.Format(startupConf.GetString("conf.format")),
@@ -308,6 +326,26 @@ func (s *ServiceGeneric) generate(ctx context.Context, no int) core.Service {
 		)
 	}
 
+	// 安装指标插件，需要先于其他插件安装，其他插件初始化时查找指标插件
+	if !installed(metrics.Name) {
+		if cb, ok := svcInst.(InstallServiceMetrics); ok {
+			cb.InstallMetrics(svcInst)
+		}
+	}
+	if !installed(metrics.Name) {
+		if cb, ok := s.instance.(InstallServiceMetrics); ok {
+			cb.InstallMetrics(svcInst)
+		}
+	}
+	if !installed(metrics.Name) {
+		if cb, ok := s.installer.(InstallServiceMetrics); ok {
+			cb.InstallMetrics(svcInst)
+		}
+	}
+	if !installed(metrics.Name) && startupConf.GetBool("metrics.enable") {
+		metrics.Install(svcInst)
+	}
+
 	// 安装消息队列中间件插件
 	if !installed(broker.Name) {
 		if cb, ok := svcInst.(InstallServiceBroker); ok {
@@ -319,6 +357,11 @@ func (s *ServiceGeneric) generate(ctx context.Context, no int) core.Service {
 			cb.InstallBroker(svcInst)
 		}
 	}
+	if !installed(broker.Name) {
+		if cb, ok := s.installer.(InstallServiceBroker); ok {
+			cb.InstallBroker(svcInst)
+		}
+	}
 	if !installed(broker.Name) {
 		nats_broker.Install(svcInst,
 			nats_broker.With.CustomAddresses(startupConf.GetString("nats.address")),
@@ -326,6 +369,9 @@ func (s *ServiceGeneric) generate(ctx context.Context, no int) core.Service {
 				startupConf.GetString("nats.username"),
 				startupConf.GetString("nats.password"),
 			),
+			nats_broker.With.JetStream(nats_broker.JetStreamOptions{
+				Enabled: startupConf.GetBool("nats.jetstream"),
+			}),
 		)
 	}
 
@@ -340,6 +386,11 @@ func (s *ServiceGeneric) generate(ctx context.Context, no int) core.Service {
 			cb.InstallRegistry(svcInst)
 		}
 	}
+	if !installed(discovery.Name) {
+		if cb, ok := s.installer.(InstallServiceRegistry); ok {
+			cb.InstallRegistry(svcInst)
+		}
+	}
 	if !installed(discovery.Name) {
 		etcd_discovery.Install(svcInst,
 			etcd_discovery.With.TTL(startupConf.GetDuration("service.ttl"), true),
@@ -362,6 +413,11 @@ func (s *ServiceGeneric) generate(ctx context.Context, no int) core.Service {
 			cb.InstallDistSync(svcInst)
 		}
 	}
+	if !installed(dsync.Name) {
+		if cb, ok := s.installer.(InstallServiceDistSync); ok {
+			cb.InstallDistSync(svcInst)
+		}
+	}
 	if !installed(dsync.Name) {
 		etcd_dsync.Install(svcInst,
 			etcd_dsync.With.CustomAddresses(startupConf.GetString("etcd.address")),
@@ -383,6 +439,11 @@ func (s *ServiceGeneric) generate(ctx context.Context, no int) core.Service {
 			cb.InstallDistService(svcInst)
 		}
 	}
+	if !installed(dsvc.Name) {
+		if cb, ok := s.installer.(InstallServiceDistService); ok {
+			cb.InstallDistService(svcInst)
+		}
+	}
 	if !installed(dsvc.Name) {
 		dsvc.Install(svcInst,
 			dsvc.With.Version(startupConf.GetString("service.version")),
@@ -402,6 +463,11 @@ func (s *ServiceGeneric) generate(ctx context.Context, no int) core.Service {
 			cb.InstallDistEntityQuerier(svcInst)
 		}
 	}
+	if !installed(dentq.Name) {
+		if cb, ok := s.installer.(InstallServiceDistEntityQuerier); ok {
+			cb.InstallDistEntityQuerier(svcInst)
+		}
+	}
 	if !installed(dentq.Name) {
 		dentq.Install(svcInst,
 			dentq.With.CustomAddresses(startupConf.GetString("etcd.address")),
@@ -412,6 +478,36 @@ func (s *ServiceGeneric) generate(ctx context.Context, no int) core.Service {
 		)
 	}
 
+	// 安装链路追踪插件
+	if !installed(tracer.Name) {
+		if cb, ok := svcInst.(InstallServiceTracer); ok {
+			cb.InstallTracer(svcInst)
+		}
+	}
+	if !installed(tracer.Name) {
+		if cb, ok := s.instance.(InstallServiceTracer); ok {
+			cb.InstallTracer(svcInst)
+		}
+	}
+	if !installed(tracer.Name) {
+		if cb, ok := s.installer.(InstallServiceTracer); ok {
+			cb.InstallTracer(svcInst)
+		}
+	}
+	if !installed(tracer.Name) && startupConf.GetString("tracing.dir") != "" {
+		filePath := filepath.Join(startupConf.GetString("tracing.dir"), fmt.Sprintf("%s-%s-%d.trace.jsonl", strings.TrimSuffix(filepath.Base(os.Args[0]), filepath.Ext(os.Args[0])), s.GetName(), no))
+
+		exporter, err := tracing.NewJSONLinesExporter(filePath)
+		if err != nil {
+			exception.Panicf("%w: new tracing exporter %q failed, %s", ErrFramework, filePath, err)
+		}
+
+		tracer.Install(svcInst,
+			tracer.With.Exporter(exporter),
+			tracer.With.SampleRatio(startupConf.GetFloat64("tracing.sample_ratio")),
+		)
+	}
+
 	// 安装RPC支持插件
 	if !installed(rpc.Name) {
 		if cb, ok := svcInst.(InstallServiceRPC); ok {
@@ -423,6 +519,11 @@ func (s *ServiceGeneric) generate(ctx context.Context, no int) core.Service {
 			cb.InstallRPC(svcInst)
 		}
 	}
+	if !installed(rpc.Name) {
+		if cb, ok := s.installer.(InstallServiceRPC); ok {
+			cb.InstallRPC(svcInst)
+		}
+	}
 	if !installed(rpc.Name) {
 		rpc.Install(svcInst)
 	}
@@ -434,6 +535,9 @@ func (s *ServiceGeneric) generate(ctx context.Context, no int) core.Service {
 	if cb, ok := svcInst.(LifecycleServiceBuilt); ok {
 		cb.Built(svcInst)
 	}
+	if cb, ok := s.installer.(LifecycleServiceBuilt); ok {
+		cb.Built(svcInst)
+	}
 
 	// 延迟连接etcd
 	memKV.Store("etcd.lazy_conn", sync.OnceValue(func() *etcdv3.Client {
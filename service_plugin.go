@@ -27,6 +27,10 @@ type InstallServiceConfig interface {
 	InstallConfig(inst IServiceInstance)
 }
 
+type InstallServiceMetrics interface {
+	InstallMetrics(inst IServiceInstance)
+}
+
 type InstallServiceBroker interface {
 	InstallBroker(inst IServiceInstance)
 }
@@ -43,6 +47,10 @@ type InstallServiceDistService interface {
 	InstallDistService(inst IServiceInstance)
 }
 
+type InstallServiceTracer interface {
+	InstallTracer(inst IServiceInstance)
+}
+
 type InstallServiceRPC interface {
 	InstallRPC(inst IServiceInstance)
 }
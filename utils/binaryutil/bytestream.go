@@ -504,6 +504,7 @@ func (s *ByteStream) ReadBytes16() ([16]byte, error) {
 		return v, io.ErrUnexpectedEOF
 	}
 	copy(v[:], s.rp[:SizeofBytes16()])
+	s.rp = s.rp[SizeofBytes16():]
 	return v, nil
 }
 
@@ -513,6 +514,7 @@ func (s *ByteStream) ReadBytes32() ([32]byte, error) {
 		return v, io.ErrUnexpectedEOF
 	}
 	copy(v[:], s.rp[:SizeofBytes32()])
+	s.rp = s.rp[SizeofBytes32():]
 	return v, nil
 }
 
@@ -522,6 +524,7 @@ func (s *ByteStream) ReadBytes64() ([64]byte, error) {
 		return v, io.ErrUnexpectedEOF
 	}
 	copy(v[:], s.rp[:SizeofBytes64()])
+	s.rp = s.rp[SizeofBytes64():]
 	return v, nil
 }
 
@@ -531,6 +534,7 @@ func (s *ByteStream) ReadBytes128() ([128]byte, error) {
 		return v, io.ErrUnexpectedEOF
 	}
 	copy(v[:], s.rp[:SizeofBytes128()])
+	s.rp = s.rp[SizeofBytes128():]
 	return v, nil
 }
 
@@ -540,6 +544,7 @@ func (s *ByteStream) ReadBytes160() ([160]byte, error) {
 		return v, io.ErrUnexpectedEOF
 	}
 	copy(v[:], s.rp[:SizeofBytes160()])
+	s.rp = s.rp[SizeofBytes160():]
 	return v, nil
 }
 
@@ -549,6 +554,7 @@ func (s *ByteStream) ReadBytes256() ([256]byte, error) {
 		return v, io.ErrUnexpectedEOF
 	}
 	copy(v[:], s.rp[:SizeofBytes256()])
+	s.rp = s.rp[SizeofBytes256():]
 	return v, nil
 }
 
@@ -558,6 +564,7 @@ func (s *ByteStream) ReadBytes512() ([512]byte, error) {
 		return v, io.ErrUnexpectedEOF
 	}
 	copy(v[:], s.rp[:SizeofBytes512()])
+	s.rp = s.rp[SizeofBytes512():]
 	return v, nil
 }
 
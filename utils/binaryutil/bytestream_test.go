@@ -0,0 +1,64 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package binaryutil
+
+import (
+	"testing"
+)
+
+func TestByteStreamFixedBytes(t *testing.T) {
+	var a [16]byte
+	var b [32]byte
+	for i := range a {
+		a[i] = byte(i + 1)
+	}
+	for i := range b {
+		b[i] = byte(i + 100)
+	}
+
+	buf := make([]byte, SizeofBytes16()+SizeofBytes32()+SizeofUint8())
+
+	ws := NewBigEndianStream(buf)
+	ws.WriteBytes16(a[:])
+	ws.WriteBytes32(b[:])
+	ws.WriteUint8(0xff)
+
+	// 读取定长字节数组后继续读取后续字段
+	rs := NewBigEndianStream(buf)
+
+	gotA, err := rs.ReadBytes16()
+	if err != nil || gotA != a {
+		t.Fatalf("read bytes16 returned %v, %v, want %v", gotA, err, a)
+	}
+	gotB, err := rs.ReadBytes32()
+	if err != nil || gotB != b {
+		t.Fatalf("read bytes32 returned %v, %v, want %v", gotB, err, b)
+	}
+	if v, err := rs.ReadUint8(); err != nil || v != 0xff {
+		t.Fatalf("read uint8 returned %d, %v, want 255", v, err)
+	}
+	if n := rs.BytesRead(); n != len(buf) {
+		t.Fatalf("got %d bytes read, want %d", n, len(buf))
+	}
+
+	if _, err := rs.ReadBytes16(); err == nil {
+		t.Fatal("read bytes16 past end succeeded, want error")
+	}
+}
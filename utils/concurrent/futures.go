@@ -25,6 +25,7 @@ import (
 	"git.golaxy.org/core/utils/async"
 	"git.golaxy.org/core/utils/generic"
 	"github.com/elliotchance/pie/v2"
+	"math"
 	"math/rand"
 	"sync"
 	"sync/atomic"
@@ -43,6 +44,9 @@ type (
 	RequestHandler = generic.Action1[Future] // Future请求处理器
 )
 
+// FutureNoTimeout Future不超时，截止时间为零值，需要由调用方取消或解决
+const FutureNoTimeout time.Duration = math.MinInt64
+
 // NewFutures 创建Future控制器
 func NewFutures(ctx context.Context, timeout time.Duration) *Futures {
 	if ctx == nil {
@@ -61,20 +65,18 @@ type Futures struct {
 	id      int64           // 请求id生成器
 	timeout time.Duration   // 请求超时时间
 	tasks   sync.Map
+	count   atomic.Int64 // 未解决的Future数量
 }
 
-// Make 创建Future
+// Make 创建Future，timeout为FutureNoTimeout时不超时，小于等于0时使用默认的超时时间
 func (fs *Futures) Make(ctx context.Context, resp Resp, timeout ...time.Duration) Future {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	_timeout := pie.First(timeout)
-	if _timeout <= 0 {
-		_timeout = fs.timeout
-	}
+	_timeout, deadline := fs.timing(pie.First(timeout))
 
-	task := newTask(fs, resp)
+	task := newTask(fs, resp, deadline)
 	go task.Run(ctx, _timeout)
 
 	return task.Future()
@@ -94,11 +96,32 @@ func (fs *Futures) Request(ctx context.Context, handler RequestHandler, timeout
 
 // Resolve 解决
 func (fs *Futures) Resolve(id int64, ret async.Ret) error {
+	return fs.resolve(id, ret, nil)
+}
+
+// Count 未解决的Future数量
+func (fs *Futures) Count() int64 {
+	return fs.count.Load()
+}
+
+func (fs *Futures) resolve(id int64, ret async.Ret, cause error) error {
 	v, ok := fs.tasks.LoadAndDelete(id)
 	if !ok {
 		return ErrFutureNotFound
 	}
-	return v.(iTask).Resolve(ret)
+	fs.count.Add(-1)
+	return v.(iTask).Resolve(ret, cause)
+}
+
+// timing 计算超时时间与截止时间，timeout为FutureNoTimeout时不超时，小于等于0时使用默认的超时时间
+func (fs *Futures) timing(timeout time.Duration) (time.Duration, time.Time) {
+	if timeout == FutureNoTimeout {
+		return timeout, time.Time{}
+	}
+	if timeout <= 0 {
+		timeout = fs.timeout
+	}
+	return timeout, time.Now().Add(timeout)
 }
 
 func (fs *Futures) makeId() int64 {
@@ -21,23 +21,21 @@ package concurrent
 
 import (
 	"context"
+	"errors"
 	"git.golaxy.org/core/utils/async"
 	"github.com/elliotchance/pie/v2"
 	"time"
 )
 
-// MakeFuture 创建Future
+// MakeFuture 创建Future，timeout为FutureNoTimeout时不超时，小于等于0时使用默认的超时时间
 func MakeFuture[T Resp](fs *Futures, ctx context.Context, resp T, timeout ...time.Duration) Future {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	_timeout := pie.First(timeout)
-	if _timeout <= 0 {
-		_timeout = fs.timeout
-	}
+	_timeout, deadline := fs.timing(pie.First(timeout))
 
-	task := newTask(fs, resp)
+	task := newTask(fs, resp, deadline)
 	go task.Run(ctx, _timeout)
 
 	return task.Future()
@@ -45,14 +43,21 @@ func MakeFuture[T Resp](fs *Futures, ctx context.Context, resp T, timeout ...tim
 
 // Future 异步模型Future
 type Future struct {
-	Finish  context.Context // 上下文
-	Id      int64           // Id
-	futures *Futures
+	Finish   context.Context // 上下文
+	Id       int64           // Id
+	Deadline time.Time       // 截止时间，不超时时为零值
+	futures  *Futures
 }
 
 // Cancel 取消
 func (f Future) Cancel(err error) {
-	f.futures.Resolve(f.Id, async.MakeRet(nil, err))
+	f.futures.resolve(f.Id, async.MakeRet(nil, err), ErrFutureCanceled)
+}
+
+// Abandoned 是否已放弃，超时、取消或Future控制器关闭时，调用方不再等待结果，可以通知对端停止处理
+func (f Future) Abandoned() bool {
+	cause := context.Cause(f.Finish)
+	return cause != nil && !errors.Is(cause, context.Canceled)
 }
 
 // Wait 等待
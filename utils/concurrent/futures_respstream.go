@@ -0,0 +1,208 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package concurrent
+
+import (
+	"context"
+	"git.golaxy.org/core/utils/async"
+	"sync"
+	"time"
+)
+
+// MakeRespStream 创建接收流式响应的异步调用结果，window为流控窗口，每消费半个窗口的数据项，回调ack通知已消费的数据项数量，
+// idle为等待下一个数据项的空闲超时时间，超时后产出ErrFutureTimeout错误并停止产出，小于等于0时不限制，ctx取消或调用Close时停止产出
+func MakeRespStream(ctx context.Context, window int64, idle time.Duration, ack func(consumed int64)) *RespStream {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	resp := &RespStream{
+		ctx:    ctx,
+		window: max(window, 1),
+		idle:   idle,
+		ack:    ack,
+		out:    make(chan async.Ret),
+		signal: make(chan struct{}, 1),
+		closed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go resp.run()
+
+	return resp
+}
+
+// RespStream 接收流式响应的异步调用结果，使用Yield依次填入数据项，使用Push结束，异步调用结果依次产出数据项，结束时关闭
+type RespStream struct {
+	ctx       context.Context
+	window    int64
+	idle      time.Duration
+	ack       func(consumed int64)
+	out       chan async.Ret
+	mutex     sync.Mutex
+	queue     []async.Ret
+	ended     bool
+	err       error
+	signal    chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// Yield 填入数据项
+func (resp *RespStream) Yield(ret async.Ret) bool {
+	resp.mutex.Lock()
+	if resp.ended {
+		resp.mutex.Unlock()
+		return false
+	}
+	resp.queue = append(resp.queue, ret)
+	resp.mutex.Unlock()
+
+	resp.notify()
+	return true
+}
+
+// Push 填入返回结果，结束流式响应，返回结果有值或有错误时，作为最后一个数据项产出
+func (resp *RespStream) Push(ret async.Ret) error {
+	resp.mutex.Lock()
+	if resp.ended {
+		resp.mutex.Unlock()
+		return nil
+	}
+	if !ret.OK() || ret.Value != nil {
+		resp.queue = append(resp.queue, ret)
+	}
+	resp.ended = true
+	resp.mutex.Unlock()
+
+	resp.notify()
+	return nil
+}
+
+// ToAsyncRet 转换为异步调用结果
+func (resp *RespStream) ToAsyncRet() async.AsyncRet {
+	return resp.out
+}
+
+// Close 放弃接收，停止产出并丢弃未产出的数据项
+func (resp *RespStream) Close() {
+	resp.closeOnce.Do(func() { close(resp.closed) })
+}
+
+// Done 停止产出时关闭
+func (resp *RespStream) Done() <-chan struct{} {
+	return resp.done
+}
+
+// Err 提前停止产出的原因，空闲超时时返回ErrFutureTimeout，ctx取消或调用Close时返回ErrFutureCanceled，正常结束时返回nil，需要在Done关闭后调用
+func (resp *RespStream) Err() error {
+	resp.mutex.Lock()
+	defer resp.mutex.Unlock()
+	return resp.err
+}
+
+func (resp *RespStream) notify() {
+	select {
+	case resp.signal <- struct{}{}:
+	default:
+	}
+}
+
+// stop 提前停止产出，丢弃未产出的数据项，不再接收新的数据项
+func (resp *RespStream) stop(err error) {
+	resp.mutex.Lock()
+	defer resp.mutex.Unlock()
+	resp.queue = nil
+	resp.ended = true
+	resp.err = err
+}
+
+func (resp *RespStream) run() {
+	defer close(resp.out)
+	defer close(resp.done)
+
+	step := max(resp.window/2, 1)
+	var consumed, acked int64
+
+	var idle *time.Timer
+	if resp.idle > 0 {
+		idle = time.NewTimer(resp.idle)
+		defer idle.Stop()
+	}
+
+	for {
+		resp.mutex.Lock()
+		items := resp.queue
+		ended := resp.ended
+		resp.queue = nil
+		resp.mutex.Unlock()
+
+		if len(items) <= 0 {
+			if ended {
+				return
+			}
+
+			// 等待下一个数据项，超过空闲超时时间时产出超时错误
+			var idleC <-chan time.Time
+			if idle != nil {
+				idle.Reset(resp.idle)
+				idleC = idle.C
+			}
+
+			select {
+			case <-resp.signal:
+				continue
+			case <-idleC:
+				resp.stop(ErrFutureTimeout)
+				select {
+				case resp.out <- async.MakeRet(nil, ErrFutureTimeout):
+				case <-resp.closed:
+				case <-resp.ctx.Done():
+				}
+				return
+			case <-resp.closed:
+				resp.stop(ErrFutureCanceled)
+				return
+			case <-resp.ctx.Done():
+				resp.stop(ErrFutureCanceled)
+				return
+			}
+		}
+
+		for i := range items {
+			select {
+			case resp.out <- items[i]:
+			case <-resp.closed:
+				resp.stop(ErrFutureCanceled)
+				return
+			case <-resp.ctx.Done():
+				resp.stop(ErrFutureCanceled)
+				return
+			}
+
+			consumed++
+
+			if !ended && resp.ack != nil && consumed-acked >= step {
+				acked = consumed
+				resp.ack(consumed)
+			}
+		}
+	}
+}
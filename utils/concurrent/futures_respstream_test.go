@@ -0,0 +1,169 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package concurrent
+
+import (
+	"context"
+	"errors"
+	"git.golaxy.org/core/utils/async"
+	"sync"
+	"testing"
+	"time"
+)
+
+func waitDone(t *testing.T, resp *RespStream) {
+	t.Helper()
+	select {
+	case <-resp.Done():
+	case <-time.After(time.Second):
+		t.Fatal("stream not stopped")
+	}
+}
+
+func TestRespStream(t *testing.T) {
+	var mutex sync.Mutex
+	var acks []int64
+
+	resp := MakeRespStream(context.Background(), 4, 0, func(consumed int64) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		acks = append(acks, consumed)
+	})
+
+	// 按顺序产出数据项
+	for i := 1; i <= 5; i++ {
+		if !resp.Yield(async.MakeRet(i, nil)) {
+			t.Fatalf("yield %d failed", i)
+		}
+		if ret := <-resp.ToAsyncRet(); !ret.OK() || ret.Value != i {
+			t.Fatalf("got ret %+v, want %d", ret, i)
+		}
+	}
+	resp.Push(async.MakeRet(6, nil))
+
+	// 结束后不再接收数据项
+	if resp.Yield(async.MakeRet(7, nil)) {
+		t.Fatal("yield after push succeeded")
+	}
+
+	// 返回结果作为最后一个数据项产出，结束时关闭
+	var rets []async.Ret
+	for ret := range resp.ToAsyncRet() {
+		rets = append(rets, ret)
+	}
+	if len(rets) != 1 || rets[0].Value != 6 {
+		t.Fatalf("got rets %+v, want 6", rets)
+	}
+
+	waitDone(t, resp)
+	if err := resp.Err(); err != nil {
+		t.Fatalf("got error %v, want nil", err)
+	}
+
+	// 每消费半个窗口的数据项确认一次
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(acks) != 2 || acks[0] != 2 || acks[1] != 4 {
+		t.Fatalf("got acks %v, want [2 4]", acks)
+	}
+}
+
+func TestRespStreamPushError(t *testing.T) {
+	resp := MakeRespStream(context.Background(), 1, 0, nil)
+
+	resp.Yield(async.MakeRet(1, nil))
+	resp.Push(async.MakeRet(nil, ErrFutureTimeout))
+
+	var rets []async.Ret
+	for ret := range resp.ToAsyncRet() {
+		rets = append(rets, ret)
+	}
+	if len(rets) != 2 || rets[0].Value != 1 || !errors.Is(rets[1].Error, ErrFutureTimeout) {
+		t.Fatalf("got rets %+v, want item and error", rets)
+	}
+}
+
+func TestRespStreamClose(t *testing.T) {
+	resp := MakeRespStream(context.Background(), 1, 0, nil)
+
+	// 调用方不再接收时，产出协程阻塞在发送数据项上，Close后退出
+	resp.Yield(async.MakeRet(1, nil))
+	resp.Yield(async.MakeRet(2, nil))
+	<-resp.ToAsyncRet()
+
+	resp.Close()
+	resp.Close()
+	waitDone(t, resp)
+
+	if err := resp.Err(); !errors.Is(err, ErrFutureCanceled) {
+		t.Fatalf("got error %v, want %v", err, ErrFutureCanceled)
+	}
+	if resp.Yield(async.MakeRet(3, nil)) {
+		t.Fatal("yield after close succeeded")
+	}
+	if _, ok := <-resp.ToAsyncRet(); ok {
+		t.Fatal("got item after close")
+	}
+}
+
+func TestRespStreamContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	resp := MakeRespStream(ctx, 1, 0, nil)
+
+	cancel()
+	waitDone(t, resp)
+
+	if err := resp.Err(); !errors.Is(err, ErrFutureCanceled) {
+		t.Fatalf("got error %v, want %v", err, ErrFutureCanceled)
+	}
+}
+
+func TestRespStreamIdleTimeout(t *testing.T) {
+	resp := MakeRespStream(context.Background(), 1, 30*time.Millisecond, nil)
+
+	// 持续产出数据项时不超时，总时长可以超过空闲超时时间
+	go func() {
+		for i := 0; i < 5; i++ {
+			resp.Yield(async.MakeRet(i, nil))
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	var items int
+	var last async.Ret
+	for ret := range resp.ToAsyncRet() {
+		if ret.OK() {
+			items++
+		}
+		last = ret
+	}
+
+	if items != 5 {
+		t.Fatalf("got %d items, want 5", items)
+	}
+	if !errors.Is(last.Error, ErrFutureTimeout) {
+		t.Fatalf("got last error %v, want %v", last.Error, ErrFutureTimeout)
+	}
+
+	waitDone(t, resp)
+	if err := resp.Err(); !errors.Is(err, ErrFutureTimeout) {
+		t.Fatalf("got error %v, want %v", err, ErrFutureTimeout)
+	}
+}
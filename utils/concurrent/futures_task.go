@@ -26,19 +26,21 @@ import (
 	"time"
 )
 
-func newTask[T Resp](fs *Futures, resp T) iTask {
-	ctx, cancel := context.WithCancel(context.Background())
+func newTask[T Resp](fs *Futures, resp T, deadline time.Time) iTask {
+	ctx, cancel := context.WithCancelCause(context.Background())
 
 	task := &_Task[T]{
 		future: Future{
-			Finish:  ctx,
-			Id:      fs.makeId(),
-			futures: fs,
+			Finish:   ctx,
+			Id:       fs.makeId(),
+			Deadline: deadline,
+			futures:  fs,
 		},
 		resp:      resp,
 		terminate: cancel,
 	}
 	fs.tasks.Store(task.future.Id, task)
+	fs.count.Add(1)
 
 	return task
 }
@@ -46,13 +48,13 @@ func newTask[T Resp](fs *Futures, resp T) iTask {
 type iTask interface {
 	Future() Future
 	Run(ctx context.Context, timeout time.Duration)
-	Resolve(ret async.Ret) error
+	Resolve(ret async.Ret, cause error) error
 }
 
 type _Task[T Resp] struct {
 	future    Future
 	resp      T
-	terminate context.CancelFunc
+	terminate context.CancelCauseFunc
 }
 
 func (t *_Task[T]) Future() Future {
@@ -60,23 +62,28 @@ func (t *_Task[T]) Future() Future {
 }
 
 func (t *_Task[T]) Run(ctx context.Context, timeout time.Duration) {
-	timer := time.NewTimer(timeout)
-	defer timer.Stop()
+	// 不超时时不创建定时器
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
 
 	select {
 	case <-t.future.futures.ctx.Done():
-		t.future.futures.Resolve(t.future.Id, async.RetT[any]{Error: ErrFuturesClosed})
+		t.future.futures.resolve(t.future.Id, async.RetT[any]{Error: ErrFuturesClosed}, ErrFuturesClosed)
 	case <-ctx.Done():
-		t.future.futures.Resolve(t.future.Id, async.RetT[any]{Error: ErrFutureCanceled})
-	case <-timer.C:
-		t.future.futures.Resolve(t.future.Id, async.RetT[any]{Error: ErrFutureTimeout})
+		t.future.futures.resolve(t.future.Id, async.RetT[any]{Error: ErrFutureCanceled}, ErrFutureCanceled)
+	case <-timeoutC:
+		t.future.futures.resolve(t.future.Id, async.RetT[any]{Error: ErrFutureTimeout}, ErrFutureTimeout)
 	case <-t.future.Finish.Done():
 		return
 	}
 }
 
-func (t *_Task[T]) Resolve(ret async.Ret) (retErr error) {
-	t.terminate()
+func (t *_Task[T]) Resolve(ret async.Ret, cause error) (retErr error) {
+	t.terminate(cause)
 
 	defer func() {
 		if err := types.Panic2Err(recover()); err != nil {
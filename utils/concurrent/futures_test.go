@@ -0,0 +1,116 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package concurrent
+
+import (
+	"context"
+	"errors"
+	"git.golaxy.org/core/utils/async"
+	"testing"
+	"time"
+)
+
+func TestFutureResolve(t *testing.T) {
+	fs := NewFutures(context.Background(), time.Minute)
+
+	resp := MakeRespAsyncRet()
+	future := MakeFuture(fs, nil, resp)
+	if n := fs.Count(); n != 1 {
+		t.Fatalf("got %d unresolved futures, want 1", n)
+	}
+
+	if err := fs.Resolve(future.Id, async.MakeRet(1, nil)); err != nil {
+		t.Fatalf("resolve failed, %s", err)
+	}
+	if ret := resp.ToAsyncRet().Wait(context.Background()); !ret.OK() || ret.Value != 1 {
+		t.Fatalf("got ret %+v, want 1", ret)
+	}
+
+	// 正常完成时调用方未放弃
+	<-future.Finish.Done()
+	if future.Abandoned() {
+		t.Fatal("resolved future abandoned")
+	}
+	if err := fs.Resolve(future.Id, async.VoidRet); !errors.Is(err, ErrFutureNotFound) {
+		t.Fatalf("resolve twice returned %v, want %v", err, ErrFutureNotFound)
+	}
+	if n := fs.Count(); n != 0 {
+		t.Fatalf("got %d unresolved futures, want 0", n)
+	}
+}
+
+func TestFutureAbandoned(t *testing.T) {
+	fs := NewFutures(context.Background(), time.Minute)
+
+	// 超时
+	resp := MakeRespAsyncRet()
+	future := MakeFuture(fs, nil, resp, 10*time.Millisecond)
+	if ret := resp.ToAsyncRet().Wait(context.Background()); !errors.Is(ret.Error, ErrFutureTimeout) {
+		t.Fatalf("got error %v, want %v", ret.Error, ErrFutureTimeout)
+	}
+	<-future.Finish.Done()
+	if !future.Abandoned() {
+		t.Fatal("timeout future not abandoned")
+	}
+
+	// 主动取消
+	resp = MakeRespAsyncRet()
+	future = MakeFuture(fs, nil, resp)
+	future.Cancel(context.Canceled)
+	<-future.Finish.Done()
+	if !future.Abandoned() {
+		t.Fatal("canceled future not abandoned")
+	}
+
+	// 调用方上下文取消
+	ctx, cancel := context.WithCancel(context.Background())
+	resp = MakeRespAsyncRet()
+	future = MakeFuture(fs, ctx, resp)
+	cancel()
+	if ret := resp.ToAsyncRet().Wait(context.Background()); !errors.Is(ret.Error, ErrFutureCanceled) {
+		t.Fatalf("got error %v, want %v", ret.Error, ErrFutureCanceled)
+	}
+	<-future.Finish.Done()
+	if !future.Abandoned() {
+		t.Fatal("future with canceled ctx not abandoned")
+	}
+}
+
+func TestFutureNoTimeout(t *testing.T) {
+	fs := NewFutures(context.Background(), 10*time.Millisecond)
+
+	// 不超时的Future截止时间为零值，超过默认超时时间后仍未解决
+	resp := MakeRespAsyncRet()
+	future := MakeFuture(fs, nil, resp, FutureNoTimeout)
+	if !future.Deadline.IsZero() {
+		t.Fatalf("got deadline %v, want zero", future.Deadline)
+	}
+
+	select {
+	case <-future.Finish.Done():
+		t.Fatal("future without timeout finished")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	future.Cancel(ErrFutureCanceled)
+	if ret := resp.ToAsyncRet().Wait(context.Background()); !errors.Is(ret.Error, ErrFutureCanceled) {
+		t.Fatalf("got error %v, want %v", ret.Error, ErrFutureCanceled)
+	}
+}
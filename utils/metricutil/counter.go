@@ -0,0 +1,74 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package metricutil
+
+import (
+	"io"
+)
+
+// CounterVec 计数器，按标签值区分序列
+type CounterVec struct {
+	*_Family[Counter]
+}
+
+// With 获取标签值对应的计数器序列，不存在时创建
+func (v *CounterVec) With(labelValues ...string) *Counter {
+	return v.with(labelValues...)
+}
+
+// Delete 删除标签值对应的计数器序列
+func (v *CounterVec) Delete(labelValues ...string) bool {
+	return v.delete(labelValues...)
+}
+
+func (v *CounterVec) writeText(w io.Writer) error {
+	if err := v.writeHeader(w); err != nil {
+		return err
+	}
+	for _, series := range v.snapshot() {
+		if err := writeSample(w, v.metricName, v.labels, series.labelValues, "", "", series.metric.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Counter 计数器序列，只增不减
+type Counter struct {
+	value _Float64
+}
+
+// Inc 加1
+func (c *Counter) Inc() {
+	c.value.add(1)
+}
+
+// Add 增加，delta小于0时忽略
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		return
+	}
+	c.value.add(delta)
+}
+
+// Value 当前值
+func (c *Counter) Value() float64 {
+	return c.value.load()
+}
@@ -0,0 +1,103 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package metricutil
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ContentType Prometheus文本格式的内容类型
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WriteText 使用Prometheus文本格式导出所有指标，导出前调用采集回调
+func (r *Registry) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	for _, family := range r.collect() {
+		if err := family.writeText(bw); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// ServeHTTP implements http.Handler
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	var buf bytes.Buffer
+
+	if err := r.WriteText(&buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Write(buf.Bytes())
+}
+
+func writeSample(w io.Writer, name string, labelNames, labelValues []string, extraName, extraValue string, value float64) error {
+	var sb strings.Builder
+
+	sb.WriteString(name)
+
+	if len(labelNames) > 0 || extraName != "" {
+		sb.WriteByte('{')
+		for i := range labelNames {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeLabel(&sb, labelNames[i], labelValues[i])
+		}
+		if extraName != "" {
+			if len(labelNames) > 0 {
+				sb.WriteByte(',')
+			}
+			writeLabel(&sb, extraName, extraValue)
+		}
+		sb.WriteByte('}')
+	}
+
+	sb.WriteByte(' ')
+	sb.WriteString(formatFloat(value))
+	sb.WriteByte('\n')
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeLabel(sb *strings.Builder, name, value string) {
+	sb.WriteString(name)
+	sb.WriteString(`="`)
+	sb.WriteString(labelValueEscaper.Replace(value))
+	sb.WriteByte('"')
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
@@ -0,0 +1,153 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package metricutil
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type iFamily interface {
+	name() string
+	labelNames() []string
+	deleteMatching(labels map[string]string)
+	writeText(w io.Writer) error
+}
+
+type _Series[T any] struct {
+	labelValues []string
+	metric      *T
+}
+
+func newFamily[T any](name, help, typ string, labelNames []string, create func() *T) *_Family[T] {
+	return &_Family[T]{
+		metricName: name,
+		help:       help,
+		typ:        typ,
+		labels:     slices.Clone(labelNames),
+		create:     create,
+		series:     map[string]*_Series[T]{},
+	}
+}
+
+type _Family[T any] struct {
+	metricName string
+	help       string
+	typ        string
+	labels     []string
+	create     func() *T
+	mutex      sync.RWMutex
+	series     map[string]*_Series[T]
+}
+
+func (f *_Family[T]) name() string {
+	return f.metricName
+}
+
+func (f *_Family[T]) labelNames() []string {
+	return f.labels
+}
+
+func (f *_Family[T]) with(labelValues ...string) *T {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Errorf("metricutil: metric %q expects %d label values, got %d", f.metricName, len(f.labels), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	f.mutex.RLock()
+	series, ok := f.series[key]
+	f.mutex.RUnlock()
+
+	if ok {
+		return series.metric
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	series, ok = f.series[key]
+	if !ok {
+		series = &_Series[T]{
+			labelValues: slices.Clone(labelValues),
+			metric:      f.create(),
+		}
+		f.series[key] = series
+	}
+
+	return series.metric
+}
+
+func (f *_Family[T]) delete(labelValues ...string) bool {
+	key := strings.Join(labelValues, "\xff")
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, ok := f.series[key]; !ok {
+		return false
+	}
+	delete(f.series, key)
+	return true
+}
+
+func (f *_Family[T]) deleteMatching(labels map[string]string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+loop:
+	for key, series := range f.series {
+		for name, value := range labels {
+			idx := slices.Index(f.labels, name)
+			if idx < 0 || series.labelValues[idx] != value {
+				continue loop
+			}
+		}
+		delete(f.series, key)
+	}
+}
+
+func (f *_Family[T]) snapshot() []*_Series[T] {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	snapshot := make([]*_Series[T], 0, len(f.series))
+	for _, series := range f.series {
+		snapshot = append(snapshot, series)
+	}
+	slices.SortFunc(snapshot, func(a, b *_Series[T]) int {
+		return slices.Compare(a.labelValues, b.labelValues)
+	})
+
+	return snapshot
+}
+
+func (f *_Family[T]) writeHeader(w io.Writer) error {
+	if f.help != "" {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n", f.metricName, escapeHelp(f.help)); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "# TYPE %s %s\n", f.metricName, f.typ)
+	return err
+}
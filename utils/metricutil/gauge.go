@@ -0,0 +1,81 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package metricutil
+
+import (
+	"io"
+)
+
+// GaugeVec 仪表，按标签值区分序列
+type GaugeVec struct {
+	*_Family[Gauge]
+}
+
+// With 获取标签值对应的仪表序列，不存在时创建
+func (v *GaugeVec) With(labelValues ...string) *Gauge {
+	return v.with(labelValues...)
+}
+
+// Delete 删除标签值对应的仪表序列
+func (v *GaugeVec) Delete(labelValues ...string) bool {
+	return v.delete(labelValues...)
+}
+
+func (v *GaugeVec) writeText(w io.Writer) error {
+	if err := v.writeHeader(w); err != nil {
+		return err
+	}
+	for _, series := range v.snapshot() {
+		if err := writeSample(w, v.metricName, v.labels, series.labelValues, "", "", series.metric.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Gauge 仪表序列，可增可减
+type Gauge struct {
+	value _Float64
+}
+
+// Set 设置
+func (g *Gauge) Set(v float64) {
+	g.value.store(v)
+}
+
+// Inc 加1
+func (g *Gauge) Inc() {
+	g.value.add(1)
+}
+
+// Dec 减1
+func (g *Gauge) Dec() {
+	g.value.add(-1)
+}
+
+// Add 增加，delta可以为负数
+func (g *Gauge) Add(delta float64) {
+	g.value.add(delta)
+}
+
+// Value 当前值
+func (g *Gauge) Value() float64 {
+	return g.value.load()
+}
@@ -0,0 +1,131 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package metricutil
+
+import (
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets 默认的直方图桶上界，单位秒，适用于统计耗时
+var DefaultBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// HistogramVec 直方图，按标签值区分序列
+type HistogramVec struct {
+	*_Family[Histogram]
+}
+
+// With 获取标签值对应的直方图序列，不存在时创建
+func (v *HistogramVec) With(labelValues ...string) *Histogram {
+	return v.with(labelValues...)
+}
+
+// Delete 删除标签值对应的直方图序列
+func (v *HistogramVec) Delete(labelValues ...string) bool {
+	return v.delete(labelValues...)
+}
+
+func (v *HistogramVec) writeText(w io.Writer) error {
+	if err := v.writeHeader(w); err != nil {
+		return err
+	}
+	for _, series := range v.snapshot() {
+		buckets, counts, sum, count := series.metric.snapshot()
+
+		var cumulative uint64
+		for i, upper := range buckets {
+			cumulative += counts[i]
+			if err := writeSample(w, v.metricName+"_bucket", v.labels, series.labelValues, "le", formatFloat(upper), float64(cumulative)); err != nil {
+				return err
+			}
+		}
+		if err := writeSample(w, v.metricName+"_bucket", v.labels, series.labelValues, "le", "+Inf", float64(count)); err != nil {
+			return err
+		}
+		if err := writeSample(w, v.metricName+"_sum", v.labels, series.labelValues, "", "", sum); err != nil {
+			return err
+		}
+		if err := writeSample(w, v.metricName+"_count", v.labels, series.labelValues, "", "", float64(count)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+// Histogram 直方图序列
+type Histogram struct {
+	mutex   sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// Observe 记录观测值
+func (h *Histogram) Observe(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+
+	idx, _ := slices.BinarySearch(h.buckets, v)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if idx < len(h.counts) {
+		h.counts[idx]++
+	}
+	h.sum += v
+	h.count++
+}
+
+// ObserveDuration 记录耗时，单位秒
+func (h *Histogram) ObserveDuration(d time.Duration) {
+	h.Observe(d.Seconds())
+}
+
+func (h *Histogram) snapshot() (buckets []float64, counts []uint64, sum float64, count uint64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.buckets, slices.Clone(h.counts), h.sum, h.count
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,151 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package metricutil
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// DefaultRegistry 默认指标注册表
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		families:   map[string]iFamily{},
+		collectors: map[int64]func(){},
+	}
+}
+
+// Registry 指标注册表，线程安全
+type Registry struct {
+	mutex       sync.RWMutex
+	families    map[string]iFamily
+	collectorId int64
+	collectors  map[int64]func()
+}
+
+// Counter 注册计数器，已注册同名计数器且标签相同时返回已注册的计数器
+func (r *Registry) Counter(name, help string, labelNames ...string) *CounterVec {
+	return register(r, name, func() *CounterVec {
+		return &CounterVec{_Family: newFamily(name, help, "counter", labelNames, func() *Counter { return &Counter{} })}
+	})
+}
+
+// Gauge 注册仪表，已注册同名仪表且标签相同时返回已注册的仪表
+func (r *Registry) Gauge(name, help string, labelNames ...string) *GaugeVec {
+	return register(r, name, func() *GaugeVec {
+		return &GaugeVec{_Family: newFamily(name, help, "gauge", labelNames, func() *Gauge { return &Gauge{} })}
+	})
+}
+
+// Histogram 注册直方图，buckets为各个桶的上界，为空时使用DefaultBuckets，已注册同名直方图且标签相同时返回已注册的直方图
+func (r *Registry) Histogram(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if len(buckets) <= 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	return register(r, name, func() *HistogramVec {
+		return &HistogramVec{_Family: newFamily(name, help, "histogram", labelNames, func() *Histogram { return newHistogram(buckets) })}
+	})
+}
+
+// OnCollect 添加采集回调，导出指标前调用，用于更新瞬时值，返回取消回调的函数
+func (r *Registry) OnCollect(fun func()) (cancel func()) {
+	if fun == nil {
+		return func() {}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.collectorId++
+	id := r.collectorId
+	r.collectors[id] = fun
+
+	return func() {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		delete(r.collectors, id)
+	}
+}
+
+// DeleteMatching 删除所有包含指定标签值的指标序列，用于服务关闭时清理指标
+func (r *Registry) DeleteMatching(labels map[string]string) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, family := range r.families {
+		family.deleteMatching(labels)
+	}
+}
+
+func register[T iFamily](r *Registry, name string, create func() T) T {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	created := create()
+
+	if registered, ok := r.families[name]; ok {
+		if v, ok := registered.(T); ok && slices.Equal(v.labelNames(), created.labelNames()) {
+			return v
+		}
+		panic(fmt.Errorf("metricutil: metric %q already registered with a different type or labels", name))
+	}
+
+	r.families[name] = created
+	return created
+}
+
+func (r *Registry) collect() []iFamily {
+	r.mutex.RLock()
+	collectors := make([]func(), 0, len(r.collectors))
+	for _, fun := range r.collectors {
+		collectors = append(collectors, fun)
+	}
+	r.mutex.RUnlock()
+
+	for _, fun := range collectors {
+		fun()
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	families := make([]iFamily, 0, len(r.families))
+	for _, family := range r.families {
+		families = append(families, family)
+	}
+	slices.SortFunc(families, func(a, b iFamily) int {
+		switch {
+		case a.name() < b.name():
+			return -1
+		case a.name() > b.name():
+			return 1
+		}
+		return 0
+	})
+
+	return families
+}
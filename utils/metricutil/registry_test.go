@@ -0,0 +1,188 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package metricutil
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func writeText(t *testing.T, r *Registry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText failed, %s", err)
+	}
+	return buf.String()
+}
+
+func TestRegistryWriteText(t *testing.T) {
+	r := NewRegistry()
+
+	calls := r.Counter("rpc_calls_total", "RPC calls.\nBy path.", "path")
+	calls.With("a").Inc()
+	calls.With("a").Add(2)
+	calls.With("a").Add(-1) // 计数器只增不减
+	calls.With(`b"\` + "\n").Inc()
+
+	sessions := r.Gauge("gate_sessions", "", "state")
+	sessions.With("active").Inc()
+	sessions.With("active").Inc()
+	sessions.With("active").Dec()
+
+	want := `# TYPE gate_sessions gauge
+gate_sessions{state="active"} 1
+# HELP rpc_calls_total RPC calls.\nBy path.
+# TYPE rpc_calls_total counter
+rpc_calls_total{path="a"} 3
+rpc_calls_total{path="b\"\\\n"} 1
+`
+	if got := writeText(t, r); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	r := NewRegistry()
+
+	h := r.Histogram("latency_seconds", "", []float64{1, 0.1}, "path")
+	h.With("a").Observe(0.05)
+	h.With("a").Observe(0.1) // 等于上界时计入该桶
+	h.With("a").Observe(0.5)
+	h.With("a").Observe(5)
+
+	want := `# TYPE latency_seconds histogram
+latency_seconds_bucket{path="a",le="0.1"} 2
+latency_seconds_bucket{path="a",le="1"} 3
+latency_seconds_bucket{path="a",le="+Inf"} 4
+latency_seconds_sum{path="a"} 5.65
+latency_seconds_count{path="a"} 4
+`
+	if got := writeText(t, r); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	r := NewRegistry()
+
+	// 同名且标签相同时返回已注册的指标
+	a := r.Counter("c", "", "x")
+	if b := r.Counter("c", "", "x"); b != a {
+		t.Fatal("register same counter twice returned a different vec")
+	}
+
+	mustPanic := func(name string, fun func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("%s did not panic", name)
+			}
+		}()
+		fun()
+	}
+
+	mustPanic("different labels", func() { r.Counter("c", "", "y") })
+	mustPanic("different type", func() { r.Gauge("c", "", "x") })
+	mustPanic("label count mismatch", func() { a.With("1", "2") })
+}
+
+func TestRegistryDelete(t *testing.T) {
+	r := NewRegistry()
+
+	c := r.Counter("c", "", "service", "node")
+	g := r.Gauge("g", "", "node")
+	c.With("s", "n1").Inc()
+	c.With("s", "n2").Inc()
+	g.With("n1").Set(3)
+
+	if !c.Delete("s", "n2") || c.Delete("s", "n2") {
+		t.Fatal("delete series failed")
+	}
+
+	// 删除所有包含指定标签值的序列，不包含该标签的指标不受影响
+	r.DeleteMatching(map[string]string{"node": "n1"})
+	r.DeleteMatching(map[string]string{"missing": "n1"})
+
+	want := `# TYPE c counter
+# TYPE g gauge
+`
+	if got := writeText(t, r); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRegistryOnCollect(t *testing.T) {
+	r := NewRegistry()
+
+	g := r.Gauge("futures", "")
+	var n float64
+	cancel := r.OnCollect(func() {
+		n++
+		g.With().Set(n)
+	})
+
+	if got := writeText(t, r); !strings.Contains(got, "futures 1\n") {
+		t.Fatalf("collect hook not called before export, got\n%s", got)
+	}
+
+	cancel()
+	if got := writeText(t, r); !strings.Contains(got, "futures 1\n") {
+		t.Fatalf("collect hook called after cancel, got\n%s", got)
+	}
+}
+
+func TestRegistryServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("c", "").With().Inc()
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Fatalf("got content type %q, want %q", ct, ContentType)
+	}
+	if body := rec.Body.String(); body != "# TYPE c counter\nc 1\n" {
+		t.Fatalf("got body %q", body)
+	}
+}
+
+func TestCounterConcurrent(t *testing.T) {
+	c := NewRegistry().Counter("c", "", "x")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.With("a").Inc()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v := c.With("a").Value(); v != 8000 {
+		t.Fatalf("got %v, want 8000", v)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	ErrExporterClosed = errors.New("tracing: exporter closed") // 导出器已关闭
+)
+
+// IExporter Span导出器接口
+type IExporter interface {
+	// Export 导出已结束的Span，需要支持多线程调用
+	Export(span *Span) error
+	// Close 关闭导出器
+	Close() error
+}
+
+// NewJSONLinesExporter 创建JSON Lines文件导出器，每个Span写入一行JSON，追加写入文件
+func NewJSONLinesExporter(path string) (*JSONLinesExporter, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONLinesExporter{
+		file:    file,
+		encoder: json.NewEncoder(file),
+	}, nil
+}
+
+// JSONLinesExporter JSON Lines文件导出器
+type JSONLinesExporter struct {
+	mutex   sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// Export 导出已结束的Span
+func (e *JSONLinesExporter) Export(span *Span) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.file == nil {
+		return ErrExporterClosed
+	}
+
+	return e.encoder.Encode(span)
+}
+
+// Close 关闭导出器
+func (e *JSONLinesExporter) Close() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.file == nil {
+		return nil
+	}
+
+	err := e.file.Close()
+	e.file = nil
+	e.encoder = nil
+	return err
+}
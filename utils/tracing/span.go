@@ -0,0 +1,130 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package tracing
+
+import (
+	"maps"
+	"sync"
+	"time"
+)
+
+// SpanKind Span类型
+type SpanKind string
+
+const (
+	SpanKind_Client SpanKind = "client" // 客户端发起调用
+	SpanKind_Server SpanKind = "server" // 服务端处理调用
+	SpanKind_Relay  SpanKind = "relay"  // 中转调用
+)
+
+// Span 链路中的一跳，为nil时所有方法均为空操作，未启用链路追踪时可以直接使用
+type Span struct {
+	TraceId      TraceId           `json:"trace_id"`             // 链路Id
+	SpanId       SpanId            `json:"span_id"`              // Span Id
+	ParentSpanId SpanId            `json:"parent_span_id"`       // 父Span Id，为空表示根Span
+	Name         string            `json:"name"`                 // 名称
+	Kind         SpanKind          `json:"kind"`                 // 类型
+	Service      string            `json:"service"`              // 服务名称
+	Node         string            `json:"node"`                 // 服务节点
+	StartTime    time.Time         `json:"start_time"`           // 开始时间
+	EndTime      time.Time         `json:"end_time"`             // 结束时间
+	Error        string            `json:"error,omitempty"`      // 错误信息
+	Attributes   map[string]string `json:"attributes,omitempty"` // 属性
+
+	tracer *Tracer
+	flags  TraceFlags
+	mutex  sync.Mutex
+	ended  bool
+}
+
+// Context 链路追踪上下文，用于传播至下一跳
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return SpanContext{TraceId: s.TraceId, SpanId: s.SpanId, Flags: s.flags}
+}
+
+// ContextOr 链路追踪上下文，Span为nil时返回指定的上下文
+func (s *Span) ContextOr(sc SpanContext) SpanContext {
+	if s == nil {
+		return sc
+	}
+	return s.Context()
+}
+
+// SetAttribute 设置属性
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	if s.Attributes == nil {
+		s.Attributes = map[string]string{}
+	}
+	s.Attributes[key] = value
+}
+
+// End 结束Span，已采样时导出，多次调用只有第一次生效
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+
+	s.EndTime = time.Now()
+	if err != nil {
+		s.Error = err.Error()
+	}
+
+	s.mutex.Unlock()
+
+	if !s.flags.IsSampled() || s.tracer == nil || s.tracer.exporter == nil {
+		return
+	}
+
+	s.tracer.export(&Span{
+		TraceId:      s.TraceId,
+		SpanId:       s.SpanId,
+		ParentSpanId: s.ParentSpanId,
+		Name:         s.Name,
+		Kind:         s.Kind,
+		Service:      s.Service,
+		Node:         s.Node,
+		StartTime:    s.StartTime,
+		EndTime:      s.EndTime,
+		Error:        s.Error,
+		Attributes:   maps.Clone(s.Attributes),
+	})
+}
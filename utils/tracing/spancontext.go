@@ -0,0 +1,235 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package tracing
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"git.golaxy.org/framework/utils/binaryutil"
+	"io"
+	"math/rand/v2"
+	"strings"
+)
+
+var (
+	ErrInvalidTraceParent = errors.New("tracing: invalid traceparent") // 无效的traceparent
+)
+
+// TraceId 链路Id
+type TraceId [16]byte
+
+// IsValid 是否有效
+func (id TraceId) IsValid() bool {
+	return id != TraceId{}
+}
+
+// String implements fmt.Stringer
+func (id TraceId) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (id TraceId) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// SpanId Span Id
+type SpanId [8]byte
+
+// IsValid 是否有效
+func (id SpanId) IsValid() bool {
+	return id != SpanId{}
+}
+
+// String implements fmt.Stringer
+func (id SpanId) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (id SpanId) MarshalText() ([]byte, error) {
+	if !id.IsValid() {
+		return nil, nil
+	}
+	return []byte(id.String()), nil
+}
+
+// TraceFlags 链路标志位
+type TraceFlags uint8
+
+const (
+	TraceFlags_Sampled TraceFlags = 1 << iota // 已采样
+)
+
+// IsSampled 是否已采样
+func (f TraceFlags) IsSampled() bool {
+	return f&TraceFlags_Sampled != 0
+}
+
+// NewTraceId 生成链路Id
+func NewTraceId() TraceId {
+	var id TraceId
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(id[8:], rand.Uint64())
+	}
+	return id
+}
+
+// NewSpanId 生成Span Id
+func NewSpanId() SpanId {
+	var id SpanId
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:], rand.Uint64())
+	}
+	return id
+}
+
+// SpanContext 链路追踪上下文，兼容W3C Trace Context的traceparent
+type SpanContext struct {
+	TraceId TraceId    // 链路Id
+	SpanId  SpanId     // Span Id
+	Flags   TraceFlags // 标志位
+}
+
+// IsValid 是否有效
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceId.IsValid() && sc.SpanId.IsValid()
+}
+
+// IsSampled 是否已采样
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags.IsSampled()
+}
+
+// TraceParent 格式化为W3C traceparent，无效时返回空字符串
+func (sc SpanContext) TraceParent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceId, sc.SpanId, uint8(sc.Flags))
+}
+
+// String implements fmt.Stringer
+func (sc SpanContext) String() string {
+	return sc.TraceParent()
+}
+
+// ParseTraceParent 解析W3C traceparent
+func ParseTraceParent(traceParent string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+
+	// 版本00不允许有多余字段，版本ff无效
+	if (parts[0] == "00" && len(parts) != 4) || parts[0] == "ff" {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+
+	var sc SpanContext
+	var version, flags [1]byte
+
+	if _, err := hex.Decode(version[:], []byte(parts[0])); err != nil {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(sc.TraceId[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(sc.SpanId[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+	sc.Flags = TraceFlags(flags[0])
+
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+
+	return sc, nil
+}
+
+// Read implements io.Reader
+func (sc SpanContext) Read(p []byte) (int, error) {
+	bs := binaryutil.NewBigEndianStream(p)
+	if err := bs.WriteBool(sc.IsValid()); err != nil {
+		return bs.BytesWritten(), err
+	}
+	if !sc.IsValid() {
+		return bs.BytesWritten(), io.EOF
+	}
+	if err := bs.WriteBytes16(sc.TraceId[:]); err != nil {
+		return bs.BytesWritten(), err
+	}
+	if err := bs.WriteUint64(binary.BigEndian.Uint64(sc.SpanId[:])); err != nil {
+		return bs.BytesWritten(), err
+	}
+	if err := bs.WriteUint8(uint8(sc.Flags)); err != nil {
+		return bs.BytesWritten(), err
+	}
+	return bs.BytesWritten(), io.EOF
+}
+
+// Write implements io.Writer
+func (sc *SpanContext) Write(p []byte) (int, error) {
+	bs := binaryutil.NewBigEndianStream(p)
+
+	valid, err := bs.ReadBool()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+
+	if !valid {
+		*sc = SpanContext{}
+		return bs.BytesRead(), nil
+	}
+
+	traceId, err := bs.ReadBytes16()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+	sc.TraceId = traceId
+
+	spanId, err := bs.ReadUint64()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+	binary.BigEndian.PutUint64(sc.SpanId[:], spanId)
+
+	flags, err := bs.ReadUint8()
+	if err != nil {
+		return bs.BytesRead(), err
+	}
+	sc.Flags = TraceFlags(flags)
+
+	return bs.BytesRead(), nil
+}
+
+// Size 大小
+func (sc SpanContext) Size() int {
+	if !sc.IsValid() {
+		return binaryutil.SizeofBool()
+	}
+	return binaryutil.SizeofBool() + binaryutil.SizeofBytes16() + binaryutil.SizeofUint64() + binaryutil.SizeofUint8()
+}
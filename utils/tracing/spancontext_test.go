@@ -0,0 +1,85 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package tracing
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	sc, err := ParseTraceParent(traceParent)
+	if err != nil {
+		t.Fatalf("parse failed, %s", err)
+	}
+	if !sc.IsValid() || !sc.IsSampled() {
+		t.Fatalf("got %+v, want valid and sampled", sc)
+	}
+	if got := sc.TraceParent(); got != traceParent {
+		t.Fatalf("got traceparent %q, want %q", got, traceParent)
+	}
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+	}
+	for _, s := range invalid {
+		if _, err := ParseTraceParent(s); !errors.Is(err, ErrInvalidTraceParent) {
+			t.Errorf("parse %q returned %v, want %v", s, err, ErrInvalidTraceParent)
+		}
+	}
+
+	// 未来版本允许有多余字段
+	if _, err := ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); err != nil {
+		t.Errorf("parse future version failed, %s", err)
+	}
+
+	if got := (SpanContext{}).TraceParent(); got != "" {
+		t.Errorf("got traceparent %q for invalid context, want empty", got)
+	}
+}
+
+func TestSpanContextReadWrite(t *testing.T) {
+	for _, sc := range []SpanContext{
+		{TraceId: NewTraceId(), SpanId: NewSpanId(), Flags: TraceFlags_Sampled},
+		{},
+	} {
+		buf := make([]byte, sc.Size())
+		if n, err := sc.Read(buf); !errors.Is(err, io.EOF) || n != len(buf) {
+			t.Fatalf("encode returned %d, %v, want %d, EOF", n, err, len(buf))
+		}
+
+		decoded := SpanContext{TraceId: NewTraceId()}
+		if _, err := decoded.Write(buf); err != nil {
+			t.Fatalf("decode failed, %s", err)
+		}
+		if decoded != sc {
+			t.Fatalf("got %+v, want %+v", decoded, sc)
+		}
+	}
+}
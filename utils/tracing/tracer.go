@@ -0,0 +1,81 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package tracing
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// NewTracer 创建链路追踪器，sampleRatio为开始新链路时的采样率，取值范围[0,1]，延续已有链路时沿用上游的采样决策
+func NewTracer(service, node string, exporter IExporter, sampleRatio float64, onExportFailed func(err error)) *Tracer {
+	return &Tracer{
+		service:        service,
+		node:           node,
+		exporter:       exporter,
+		sampleRatio:    min(max(sampleRatio, 0), 1),
+		onExportFailed: onExportFailed,
+	}
+}
+
+// Tracer 链路追踪器，为nil时开始的Span均为nil
+type Tracer struct {
+	service        string
+	node           string
+	exporter       IExporter
+	sampleRatio    float64
+	onExportFailed func(err error)
+}
+
+// StartSpan 开始Span，parent有效时作为其子Span，否则开始新的链路
+func (t *Tracer) StartSpan(parent SpanContext, name string, kind SpanKind) *Span {
+	if t == nil {
+		return nil
+	}
+
+	span := &Span{
+		SpanId:    NewSpanId(),
+		Name:      name,
+		Kind:      kind,
+		Service:   t.service,
+		Node:      t.node,
+		StartTime: time.Now(),
+		tracer:    t,
+	}
+
+	if parent.IsValid() {
+		span.TraceId = parent.TraceId
+		span.ParentSpanId = parent.SpanId
+		span.flags = parent.Flags
+	} else {
+		span.TraceId = NewTraceId()
+		if t.sampleRatio >= 1 || (t.sampleRatio > 0 && rand.Float64() < t.sampleRatio) {
+			span.flags |= TraceFlags_Sampled
+		}
+	}
+
+	return span
+}
+
+func (t *Tracer) export(span *Span) {
+	if err := t.exporter.Export(span); err != nil && t.onExportFailed != nil {
+		t.onExportFailed(err)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package tracing
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTracerStartSpan(t *testing.T) {
+	tracer := NewTracer("svc", "node1", nil, 1, nil)
+
+	root := tracer.StartSpan(SpanContext{}, "root", SpanKind_Client)
+	if !root.Context().IsValid() || !root.Context().IsSampled() || root.ParentSpanId.IsValid() {
+		t.Fatalf("got root span %+v, want new sampled trace", root)
+	}
+
+	// 子Span延续链路与上游的采样决策
+	child := tracer.StartSpan(root.Context(), "child", SpanKind_Server)
+	if child.TraceId != root.TraceId || child.ParentSpanId != root.SpanId || child.SpanId == root.SpanId {
+		t.Fatalf("got child span %+v, want child of %+v", child, root)
+	}
+
+	unsampled := NewTracer("svc", "node1", nil, 0, nil).StartSpan(SpanContext{}, "root", SpanKind_Client)
+	if unsampled.Context().IsSampled() {
+		t.Fatal("got sampled span with sample ratio 0")
+	}
+	if child := tracer.StartSpan(unsampled.Context(), "child", SpanKind_Server); child.Context().IsSampled() {
+		t.Fatal("got sampled child of unsampled parent")
+	}
+
+	// 未启用链路追踪时Span为nil，所有方法均为空操作
+	var nilTracer *Tracer
+	span := nilTracer.StartSpan(root.Context(), "noop", SpanKind_Relay)
+	span.SetAttribute("k", "v")
+	span.End(nil)
+	if got := span.ContextOr(root.Context()); got != root.Context() {
+		t.Fatalf("got context %+v from nil span, want %+v", got, root.Context())
+	}
+}
+
+func TestJSONLinesExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "spans.jsonl")
+
+	exporter, err := NewJSONLinesExporter(path)
+	if err != nil {
+		t.Fatalf("new exporter failed, %s", err)
+	}
+
+	tracer := NewTracer("svc", "node1", exporter, 1, func(err error) {
+		t.Errorf("export failed, %s", err)
+	})
+
+	root := tracer.StartSpan(SpanContext{}, "root", SpanKind_Client)
+	child := tracer.StartSpan(root.Context(), "child", SpanKind_Server)
+	child.SetAttribute("rpc.src", "addr")
+	child.End(errors.New("failed"))
+	child.End(nil)
+	root.End(nil)
+
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("close failed, %s", err)
+	}
+	if err := exporter.Export(root); !errors.Is(err, ErrExporterClosed) {
+		t.Fatalf("export after close returned %v, want %v", err, ErrExporterClosed)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open failed, %s", err)
+	}
+	defer file.Close()
+
+	// 每个Span一行，多次结束只导出一次
+	var lines []map[string]any
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("unmarshal line failed, %s", err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+
+	if lines[0]["name"] != "child" || lines[0]["error"] != "failed" || lines[0]["parent_span_id"] != root.SpanId.String() {
+		t.Fatalf("got child line %v, want child of root with error", lines[0])
+	}
+	if attrs, _ := lines[0]["attributes"].(map[string]any); attrs["rpc.src"] != "addr" {
+		t.Fatalf("got attributes %v, want rpc.src", lines[0]["attributes"])
+	}
+	if lines[1]["name"] != "root" || lines[1]["trace_id"] != root.TraceId.String() {
+		t.Fatalf("got root line %v, want root", lines[1])
+	}
+}